@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/abhayk/filesorter/pkg/sorter"
+)
+
+// cancelOnInterrupt returns a context which is cancelled on Ctrl-C or SIGTERM. The run then
+// removes the copy in progress and prints the report before exiting. A second Ctrl-C gets the
+// default handling and quits right away.
+func cancelOnInterrupt() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		signal.Stop(signals)
+		sorter.Printf("Stopping the run. Press Ctrl-C again to quit right away\n")
+		cancel()
+	}()
+	return ctx
+}
@@ -1,200 +1,405 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
-	"io"
 	"os"
-	"path/filepath"
-	"strconv"
+	"sort"
 	"strings"
 
-	"github.com/karrick/godirwalk"
+	"github.com/abhayk/filesorter/pkg/sorter"
 )
 
-type processedCount struct {
-	visitedDirectories int
-	copiedFiles        int
-	skippedFiles       int
-	erroredFiles       int
-	totalBytesCopied   int64
-}
-
-func main() {
-
-	sourcePath := flag.String("source", "", "The source directory path,")
-	destPathBase := flag.String("destination", "", "The destination to which the files should be copied and sorted.")
-	fileTypeFilter := flag.String("types", "", `Optional. Provide the list of file types that should be included from
-	the source directory separated by a ':'. For eg: jpg:jpeg:mp4`)
-	flag.Parse()
-
-	// check for mandatory arguments
-	if strings.Compare(*sourcePath, "") == 0 || strings.Compare(*destPathBase, "") == 0 {
-		fmt.Println("Usage: filesorter <source path> <destination path> [file types]")
-		flag.PrintDefaults()
-		os.Exit(1)
-	}
-
-	if !isPathValid(*sourcePath) || !isPathValid(*destPathBase) {
-		os.Exit(1)
-	}
-
-	filterTypes := make(map[string]struct{})
-
-	if strings.Compare(*fileTypeFilter, "") != 0 {
-		var empty struct{}
-		for _, v := range strings.Split(*fileTypeFilter, ":") {
-			filterTypes[v] = empty
-		}
-	}
-
-	var counts processedCount
+// stringList collects a repeatable flag like -destination.
+type stringList []string
 
-	godirwalk.Walk(*sourcePath, &godirwalk.Options{
-		Callback: func(path string, dirent *godirwalk.Dirent) error {
-			visitErr := visitFile(path, dirent, *destPathBase, filterTypes, &counts)
-			if visitErr != nil {
-				counts.erroredFiles++
-			}
-			return visitErr
-		},
-		PostChildrenCallback: func(path string, dirent *godirwalk.Dirent) error {
-			return postVisitDir(path, dirent, &counts)
-		},
-		ErrorCallback: func(string, error) godirwalk.ErrorAction {
-			// try processing all files even if one of the files errored.
-			return godirwalk.SkipNode
-		},
-	})
-
-	printReport(&counts)
+func (list *stringList) String() string {
+	return strings.Join(*list, ", ")
 }
 
-func visitFile(path string, dirent *godirwalk.Dirent, destPathBase string, filterTypes map[string]struct{}, counts *processedCount) error {
-
-	// walk returns directories also. skip those
-	if dirent.IsDir() {
-		return nil
-	}
+func (list *stringList) Set(value string) error {
+	*list = append(*list, value)
+	return nil
+}
 
-	sourceFileStat, err := os.Stat(path)
+// applyProfile sets the options of the profile which were not passed explicitly and adds them to
+// explicit.
+func applyProfile(configFile string, name string, explicit map[string]bool) error {
+	profile, err := sorter.ReadProfile(configFile, name)
 	if err != nil {
-		fmt.Printf("An error occurred while trying to stat the source path %s", path)
 		return err
 	}
-
-	if !sourceFileStat.Mode().IsRegular() {
-		return fmt.Errorf("The file %s is not a regular file", path)
+	options := make([]string, 0, len(profile))
+	for option := range profile {
+		options = append(options, option)
 	}
-
-	// if file type filter were passed apply those
-	if len(filterTypes) > 0 {
-		if _, ok := filterTypes[filepath.Ext(path)[1:]]; !ok {
-			counts.skippedFiles++
-			return nil
+	sort.Strings(options)
+	for _, option := range options {
+		if flag.Lookup(option) == nil || strings.Compare(option, "profile") == 0 || strings.Compare(option, "config") == 0 {
+			return sorter.Errorf("The option %s of the profile %s is not supported\n", option, name)
+		}
+		if explicit[option] {
+			continue
 		}
+		for _, value := range profile[option] {
+			if err := flag.Set(option, value); err != nil {
+				return sorter.Errorf("The option %s of the profile %s is not valid: %v\n", option, name, err)
+			}
+		}
+		explicit[option] = true
 	}
+	return nil
+}
 
-	destFilePath := getDestFilePath(destPathBase, sourceFileStat)
+func main() {
 
-	destFileStat, err := os.Stat(destFilePath)
-	if err != nil {
-		// stat returns an error if the file does not exist.
-		// we can ignore that but if the error is of some other type then skip processing this file
-		if !os.IsNotExist(err) {
-			fmt.Printf("An error occurred while trying to stat the file %s", destFilePath)
-			return err
+	// plan and diff walk the source like sort but do not copy anything
+	command := "sort"
+	if len(os.Args) > 1 {
+		if run := sorter.Command(os.Args[1]); run != nil {
+			os.Exit(run(os.Args[2:]))
 		}
-	} else {
-		// we assume the file in the destination is the same as the source file if their sizes match
-		// this might be useful in cases where cop file fails and an empty is created at the destination
-		if sourceFileStat.Size() == destFileStat.Size() {
-			counts.skippedFiles++
-			return nil
+		switch os.Args[1] {
+		case "plan", "diff":
+			command = os.Args[1]
+			os.Args = append(os.Args[:1], os.Args[2:]...)
+		case "sort":
+			// sort is the default command and can be omitted
+			os.Args = append(os.Args[:1], os.Args[2:]...)
 		}
 	}
 
-	err = os.MkdirAll(filepath.Dir(destFilePath), os.ModePerm)
-	if err != nil {
-		fmt.Printf("An error occurred while trying to create directories for the file %s", destFilePath)
-		return err
-	}
+	sourcePath := flag.String("source", "", "The source directory path,")
+	var destPaths stringList
+	var tiers stringList
+	flag.Var(&destPaths, "destination", `The destination to which the files should be copied and sorted. Repeat it to
+	copy to several destinations while reading the source only once`)
+	fileTypeFilter := flag.String("types", "", `Optional. Provide the list of file types that should be included from
+	the source directory separated by a ':'. For eg: jpg:jpeg:mp4. The categories images, videos,
+	audio and documents stand for their common extensions and can be combined with others like images:psd`)
+	excludeTypeFilter := flag.String("exclude-types", "", `Optional. The list of file types that should be skipped separated by a ':'.
+	For eg: iso:vmdk:tmp. Takes the same categories as -types`)
+	var excludes stringList
+	flag.Var(&excludes, "exclude", `Optional. A glob pattern like *.tmp or .git of the names of the files and directories
+	to leave out. Repeat it for several patterns`)
+	globalIgnore := flag.String("global-ignore", "", `Optional. A file of exclude patterns, one per line, which applies to every run. By
+	default filesorter/ignore in the config directory of the user, like ~/.config/filesorter/ignore,
+	is read if it exists. none turns it off`)
+	noExtension := flag.String("no-extension", "", `Optional. Whether the files without an extension are sorted, either include or
+	skip. By default they are skipped when -types is passed and sorted otherwise`)
+	catalogPath := flag.String("catalog", "", `Optional. A catalog database in which every copied file is recorded
+	so that the archive can be verified later using 'filesorter verify'`)
+	dateSource := flag.String("date-source", "mtime", `Optional. The sources from which the date used for sorting is read, separated
+	by a ','. The first source which has a date for a file is used and the modified time
+	is used when none of them have one. Supported sources: exif, video, pdf, office, email, filename, mtime`)
+	schemeName := flag.String("scheme", "date", `Optional. How the files are organized at the destination. Either 'date',
+	'music' which sorts audio files by their tags or 'ebook' which sorts epub and
+	pdf files by their author and title. Files without the metadata are sorted by date`)
+	layout := flag.String("layout", "", `Optional. The destination path of the files as a template. The date fields are
+	.Year, .MonthNum, .Month, .Day, .Hour, .Minute, .Second and .File like {{.Year}}/{{printf "%02d" .MonthNum}}/{{.File}}.
+	The music scheme has .Artist, .AlbumArtist, .Album, .Title, .Year, .Track and the ebook scheme
+	.Author, .Title, .Year. All of them have .Name of the file, .Stem without its extension and .Ext`)
+	screenshots := flag.Bool("screenshots", false, `Optional. Detect screenshots and sort them into a separate Screenshots folder
+	at the destination so that they do not get mixed with the photos`)
+	presetName := flag.String("preset", "", `Optional. Use the types, date sources and excludes tuned for a common folder
+	structure. One of android (the DCIM folder), whatsapp (the WhatsApp/Media folder) or
+	iphone-backup (an unencrypted iTunes/Finder backup). Explicitly passed options take precedence`)
+	errorReport := flag.String("error-report", "", `Optional. Write the files which could not be processed along with the errors
+	to this json file`)
+	retryFrom := flag.String("retry-from", "", `Optional. Process only the files listed in the error report of a previous run
+	instead of walking the source. The source is not required when this is used`)
+	resumeRun := flag.Bool("resume", false, `Optional. Continue an interrupted run. The files which the catalog records as
+	copied unchanged to the destinations by earlier runs are skipped without looking at the
+	destinations. Needs -catalog`)
+	resume := flag.Bool("resume-partial", false, `Optional. Keep the temporary file of an interrupted copy and when it, or a smaller
+	file at the destination, matches the start of the source, copy only the rest of the source`)
+	delta := flag.Bool("delta", false, `Optional. When an older version of a file exists at the destination, update it in
+	place writing only the blocks which changed instead of copying the whole file`)
+	watch := flag.Bool("watch", false, `Optional. After sorting the source keep watching it and sort the files added to it
+	until the process is stopped with Ctrl-C`)
+	watchSettle := flag.Duration("watch-settle", 0, `Optional. How long a new file has to stay unchanged before -watch sorts it, so that
+	files still being written are not copied halfway. The default is 5s`)
+	watchQueue := flag.String("watch-queue", "", `Optional. Keep the files -watch waits to sort in this json file, so that the ones
+	noticed just before a crash or a reboot are sorted when it runs again`)
+	filesPerSec := flag.Float64("files-per-sec", 0, `Optional. Copy at most this many files per second, like 5 or 0.5, for cloud backends
+	with a limit on the requests or SMB servers which slow down under many metadata operations. It
+	can be combined with -bandwidth-schedule`)
+	onConflict := flag.String("on-conflict", "overwrite", `Optional. What to do with a file when a different file of the same name is at the
+	destination, either overwrite, skip, rename which appends -1, -2... to the name of the copy or
+	hash-suffix which appends the first 8 characters of its sha256. Files resumed or updated with
+	-resume-partial or -delta are not conflicts`)
+	conflicts := flag.String("conflicts", "", `Optional. With -on-conflict skip add the skipped files to this json file so that
+	they can be resolved later using 'filesorter resolve'`)
+	output := flag.String("output", "text", `Optional. text to print messages or json to print a line of json with the source,
+	destination, action, bytes and error of every processed file and one with the summary at the end,
+	for scripts. The messages are printed to the standard error then`)
+	progress := flag.Bool("progress", false, `Optional. Count the files of the source first and then show a progress bar with the
+	percentage, the throughput and the time left while sorting them. Only shown when the output is a terminal`)
+	manifest := flag.String("manifest", "", `Optional. Append every copy and move to this file of json lines so that the run
+	can be reversed using 'filesorter undo'`)
+	profileName := flag.String("profile", "", `Optional. Read the options of a recurring job from this profile of the config file,
+	like photos. The options passed on the command line take precedence`)
+	configFile := flag.String("config", "", `Optional. The yaml config file with the profiles for -profile. By default
+	filesorter/config.yaml in the config directory of the user, like ~/.config/filesorter/config.yaml`)
+	clamd := flag.String("clamd", "", `Optional. Scan every file with ClamAV before it is copied, through the unix socket
+	of clamd like /var/run/clamav/clamd.ctl or its host:port. Infected files are not copied`)
+	quarantine := flag.String("quarantine", "", `Optional. With -clamd copy the infected files into this directory, or move them
+	with -move, instead of leaving them in the source`)
+	after := flag.String("after", "", `Optional. Only sort the files with a date on or after this one, like 2023-01-01. The
+	files sorted by their tags are limited by their modification time`)
+	before := flag.String("before", "", `Optional. Only sort the files with a date before this one, like 2024-01-01`)
+	dirMode := flag.String("dir-mode", "", `Optional. The octal mode like 0750 or 2775 of the directories created in the destinations,
+	set regardless of the umask. By default they are created with 0777 less the umask`)
+	preserveDirOwner := flag.Bool("preserve-dir-owner", false, `Optional. Give the directories created in the destinations the owner and group of
+	the directory they are created in, like when sorting as root into a shared archive`)
+	minSize := flag.String("min-size", "", `Optional. Skip the files smaller than this size, like 500KB to leave out thumbnails or
+	1 to leave out the empty files`)
+	maxSize := flag.String("max-size", "", `Optional. Skip the files larger than this size, like 2GB`)
+	minDate := flag.String("min-date", "", `Optional. The earliest valid date, like 1990-01-01. The files with an earlier date are sorted
+	into the -unsorted folder. By default 1980-01-01`)
+	maxFuture := flag.Duration("max-future", 0, `Optional. How far in the future a date may be, like 2h. The files with a later date are sorted
+	into the -unsorted folder. By default 24h`)
+	fileTimeout := flag.Duration("file-timeout", 0, `Optional. Give up on a file after this long, like 10m, when reading its date or copying it
+	hangs on a dying disk or a stuck network mount. The file is counted as errored and the run goes on`)
+	salvage := flag.Bool("salvage", false, `Optional. Copy the files of failing media even when parts of them can not be read. The
+	failed reads are retried in smaller blocks and what still can not be read is filled with zeros. The
+	salvaged files are listed in the report and are not removed from the source by -move`)
+	verify := flag.Bool("verify", false, `Optional. Read every copy back from the destination and compare its checksum with the
+	source before counting it as copied. A copy which does not match is removed and reported as corrupted`)
+	unsorted := flag.String("unsorted", "", `Optional. The folder of the destination the files with an invalid date, before 1980 or
+	in the future, are sorted into instead of a folder like 1970/January/1. _unsorted by default and none sorts
+	them by their date anyway`)
+	minFreeInodes := flag.Int64("min-free-inodes", 1000, `Optional. Stop the run before a destination has fewer free inodes than this, so
+	that millions of small files do not use them all up. The run fails right away when a destination
+	already has fewer. -1 turns the check off`)
+	order := flag.String("order", "", `Optional. Walk the whole source first and then process the files in this order
+	so that an interrupted run has copied the files which matter most. One of newest,
+	oldest, smallest, largest (by modified time and size) or path`)
+	protect := flag.Bool("protect", false, `Optional. Make the copied files read-only once their content is verified so that
+	they are not modified by accident`)
+	immutable := flag.Bool("immutable", false, `Optional. Like -protect and also set the immutable flag (chattr +i on Linux which
+	needs root, chflags uchg on macOS and FreeBSD) so that not even the owner can modify them`)
+	attributes := flag.Bool("windows-attributes", false, `Optional. Carry over the read-only, hidden, system and archive attributes of the
+	files to the copies. Only supported on Windows`)
+	htmlReport := flag.String("html-report", "", `Optional. Write a report of the run as a single html file, with the summary, the copied
+	files per day, the errors and the duplicates. Pass a directory, like the one of the logs, to get a
+	new report named by the time of every run`)
+	sshCommand := flag.String("ssh-command", "", `Optional. The ssh command with its options, like "ssh -i ~/.ssh/backup", which
+	the sftp://user@host/path destinations are reached with. By default ssh`)
+	maxFileSize := flag.String("max-file-size", "", `Optional. The largest file the destinations can hold, like 4GB. By default it is read
+	from the file system of each destination, which only knows the limit of FAT. The larger files are not copied`)
+	splitLarge := flag.Bool("split-large", false, `Optional. Copy the files larger than a destination can hold in parts like IMG_0001.MOV.001
+	along with a manifest IMG_0001.MOV.parts.json to join and check them again`)
+	compressTransit := flag.Bool("compress-transit", false, `Optional. Compress the files on their way to the sftp:// destinations with the
+	compression of ssh, which speeds up document archives over a slow uplink. The stored files are the same`)
+	alerts := sorter.AddAlertFlags(flag.CommandLine)
+	s3Config := sorter.AddS3Flags(flag.CommandLine)
+	planOut := flag.String("out", "", `Only for 'filesorter plan'. The json file to which the planned copies are
+	written so that they can be reviewed and executed later using 'filesorter apply'`)
+	planDiff := flag.String("diff", "", `Only for 'filesorter plan'. A plan written by an earlier plan to compare the new one
+	with. The files added, removed and sorted into other paths are listed, to see the effect of
+	changing the layout or the filters`)
+	bandwidthSchedule := flag.String("bandwidth-schedule", "", `Optional. Limit the rate at which the files are copied, for destinations on a
+	network share. Either a rate like 10MB for the whole day or time of day windows like
+	08:00-23:00=10MB,23:00-08:00=50MB. Outside of the windows the rate is not limited`)
+	flag.Var(&tiers, "tier", `Optional. Route the files by their age to one of several destinations, like
+	-tier 6m=/mnt/ssd/photos -tier /mnt/hdd/photos. Each file goes to the first tier it is newer
+	than, and a tier without an age takes the rest. Files older than all tiers are skipped`)
+	duplicateNames := flag.String("duplicate-names", "copy", `Optional. What to do with a file when a file with the same name and content was
+	copied under another date before, like after exporting the same photos again. Either copy
+	it again or alias it, which records the path in the catalog instead. alias needs -catalog`)
+	move := flag.Bool("move", false, `Optional. Move the files instead of copying them. A file is renamed when the
+	destination is on the same file system and otherwise removed from the source once it is
+	copied to every destination. A file already at a destination is removed from the source
+	only when the one there has the same content, which is read to compare them unless
+	-compare hash did. Uploaded files are only compared with -compare hash`)
+	stagingDir := flag.String("staging", "", `Optional. Write the copies to this directory first and rename them into the
+	destination once complete, so that partially copied files never appear in the archive. It
+	has to be on the same file system as the destinations`)
+	dryRun := flag.Bool("dry-run", false, `Optional. Only print the files which would be copied, overwritten, resumed or
+	skipped and where, without touching the destination`)
+	incremental := flag.Bool("incremental", false, `Optional. Skip the source directories whose modified time has not changed since
+	an earlier -incremental run sorted all of their files. Needs -catalog. Files changed in place
+	are only noticed by a run without it`)
+	sample := flag.String("sample", "", `Optional. Process only a random subset of the files of the types being sorted,
+	to try out the settings on real data before a long run. Either a count like 100 or a
+	percentage like 1%`)
+	maxFiles := flag.Int("max-files", 0, `Optional. Stop the run after copying this many files so that a large import can be
+	split over several runs. The next run continues with the files which were not copied`)
+	maxBytes := flag.String("max-bytes", "", `Optional. Stop the run before copying more than this much, like 100GB. The next
+	run continues with the files which were not copied`)
+	maxFilesPerDir := flag.Int("max-files-per-dir", 0, `Optional. Split the folders the files are sorted into in numbered sub folders
+	001, 002 and so on of at most this many files, for file systems and tools which are slow
+	with very large folders`)
+	bursts := flag.Bool("bursts", false, `Optional. Sort the photos taken in a burst, at least 3 from the same source folder
+	each within 2 seconds of the previous one, into a sub folder of the day like burst-153059`)
+	volume := flag.String("volume", "", `Optional. Span the archive over several volumes of a fixed size like discs or
+	disks. The label of the volume at the destination, which is recorded in the catalog for
+	every file copied onto it. Needs -catalog and -volume-size`)
+	volumeSize := flag.String("volume-size", "", `Optional. The size of the volumes for -volume like 25GB. The run stops when the
+	next file does not fit and the run onto the next volume continues with the rest`)
+	var postProcess stringList
+	flag.Var(&postProcess, "post-process", `Optional. Run a command for every copied file, like generating thumbnails or
+	proxies of videos. Either thumbnail or proxy which use ffmpeg, or a command with the placeholders
+	{file}, {dir}, {name} and {source}. Limit it to some types with a prefix like videos=thumbnail.
+	Repeat it to run several commands one after the other`)
+	postProcessJobs := flag.Int("post-process-jobs", 2, "Optional. The number of files post-processed at the same time")
+	compare := flag.String("compare", "size", `Optional. How a file already at the destination is found to be the same as the
+	source. Either size, which is fast but takes two different files of the same size as the same,
+	or hash which reads both and compares their contents`)
+	readOnlySource := flag.Bool("assert-readonly-source", false, `Optional. For forensic copies. Refuse the options which write to the source like
+	-move or a catalog inside it, and read the source files without updating their access time (Linux
+	only, needs the owner of the files or root)`)
+	workers := flag.Int("workers", 1, `Optional. The number of files copied at the same time, for destinations like a NAS
+	where a single copy does not use the available bandwidth. The source is still walked in order`)
+	controlSocket := flag.String("control-socket", "", `Optional. Serve the progress and accept pause, resume and cancel commands as
+	newline delimited json on a unix socket at this path, for GUI frontends`)
+	lang := sorter.AddLanguageFlag(flag.CommandLine)
+	flag.Parse()
 
-	written, err := copyFile(path, destFilePath)
-	if err != nil {
-		fmt.Printf("An error occurred while trying to copy the file %s to %s", path, destFilePath)
-		return err
+	// the options passed take precedence over the environment, and both over the profile
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	// the errors of the environment are shown in its language, before -lang is applied
+	sorter.SetLanguage("")
+	if err := sorter.ApplyEnvironment(flag.CommandLine, explicit); err != nil {
+		sorter.Println(err)
+		os.Exit(1)
 	}
 
-	// maintain the access and modified time of the file so that the correct time can be
-	// used if the file again needs to be sorted and copied somewhere else
-	err = os.Chtimes(destFilePath, sourceFileStat.ModTime(), sourceFileStat.ModTime())
-	if err != nil {
-		fmt.Printf("An error occurred while trying to set the access time of the copied file %s", destFilePath)
-		return err
+	if err := sorter.SetLanguage(*lang); err != nil {
+		sorter.Println(err)
+		os.Exit(1)
 	}
 
-	fmt.Printf("Copied %s --> %s\n", path, destFilePath)
-	counts.copiedFiles++
-	counts.totalBytesCopied += written
-
-	return nil
-}
-
-func postVisitDir(path string, dirent *godirwalk.Dirent, counts *processedCount) error {
-	counts.visitedDirectories++
-	return nil
-}
-
-func getDestFilePath(destPathBase string, fileInfo os.FileInfo) string {
-	modTime := fileInfo.ModTime()
-
-	// a file with the name abc.txt which was last modified at May 2 2020 will end up with the path -
-	// <destination directory>/2020/May/2/abc.txt
-	return filepath.Join(destPathBase,
-		strconv.Itoa(modTime.Year()),
-		modTime.Month().String(),
-		strconv.Itoa(modTime.Day()),
-		fileInfo.Name())
-}
-
-func isPathValid(path string) bool {
-
-	fileInfo, err := os.Stat(path)
-	if os.IsNotExist(err) {
-		fmt.Printf("The path %s does not exist.", path)
-		return false
+	// the settings of a profile apply to the flags which are not passed explicitly
+	if strings.Compare(*profileName, "") != 0 {
+		if err := applyProfile(*configFile, *profileName, explicit); err != nil {
+			sorter.Println(err)
+			os.Exit(1)
+		}
 	}
-	if !fileInfo.IsDir() {
-		fmt.Printf("The path %s is not a directory.", path)
-		return false
+	// the date source of a preset is used unless one is passed explicitly
+	if !explicit["date-source"] && strings.Compare(*presetName, "") != 0 {
+		*dateSource = ""
 	}
-	return true
-}
-
-func copyFile(source string, destination string) (int64, error) {
 
-	sourceFile, err := os.Open(source)
+	s, err := sorter.New(sorter.Options{
+		Command:           command,
+		PlanOut:           *planOut,
+		PlanDiff:          *planDiff,
+		Source:            *sourcePath,
+		Destinations:      destPaths,
+		Tiers:             tiers,
+		Types:             *fileTypeFilter,
+		ExcludeTypes:      *excludeTypeFilter,
+		Excludes:          excludes,
+		GlobalIgnore:      *globalIgnore,
+		NoExtension:       *noExtension,
+		Preset:            *presetName,
+		DateSource:        *dateSource,
+		Scheme:            *schemeName,
+		Layout:            *layout,
+		Screenshots:       *screenshots,
+		Catalog:           *catalogPath,
+		ErrorReport:       *errorReport,
+		RetryFrom:         *retryFrom,
+		ResumePartial:     *resume,
+		Delta:             *delta,
+		Order:             *order,
+		Protect:           *protect,
+		Immutable:         *immutable,
+		WindowsAttributes: *attributes,
+		BandwidthSchedule: *bandwidthSchedule,
+		DuplicateNames:    *duplicateNames,
+		Move:              *move,
+		Staging:           *stagingDir,
+		DryRun:            *dryRun,
+		Incremental:       *incremental,
+		Sample:            *sample,
+		MaxFiles:          *maxFiles,
+		MaxBytes:          *maxBytes,
+		MaxFilesPerDir:    *maxFilesPerDir,
+		ControlSocket:     *controlSocket,
+		Alerts:            *alerts,
+		Volume:            *volume,
+		VolumeSize:        *volumeSize,
+		Bursts:            *bursts,
+		PostProcess:       postProcess,
+		PostProcessJobs:   *postProcessJobs,
+		Workers:           *workers,
+		Compare:           *compare,
+
+		AssertReadOnlySource: *readOnlySource,
+		Resume:               *resumeRun,
+		Watch:                *watch,
+		WatchSettle:          *watchSettle,
+		WatchQueue:           *watchQueue,
+		MinFreeInodes:        *minFreeInodes,
+		FilesPerSec:          *filesPerSec,
+		OnConflict:           *onConflict,
+		Conflicts:            *conflicts,
+		Output:               *output,
+		Progress:             *progress,
+		Manifest:             *manifest,
+		Clamd:                *clamd,
+		Quarantine:           *quarantine,
+		After:                *after,
+		Before:               *before,
+		DirMode:              *dirMode,
+		PreserveDirOwner:     *preserveDirOwner,
+		MinSize:              *minSize,
+		MaxSize:              *maxSize,
+		Unsorted:             *unsorted,
+		Verify:               *verify,
+		MinDate:              *minDate,
+		MaxFuture:            *maxFuture,
+		FileTimeout:          *fileTimeout,
+		Salvage:              *salvage,
+		S3:                   *s3Config,
+		HTMLReport:           *htmlReport,
+		SSHCommand:           *sshCommand,
+		CompressTransit:      *compressTransit,
+		MaxFileSize:          *maxFileSize,
+		SplitLarge:           *splitLarge,
+	})
+	if errors.Is(err, sorter.ErrUsage) {
+		sorter.Printf("Usage: filesorter [sort] <source path> <destination path> [file types]\n")
+		sorter.Printf("       filesorter plan -out <plan path> <source path> <destination path> [file types]\n")
+		sorter.Printf("       filesorter diff <source path> <destination path> [file types]\n")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
 	if err != nil {
-		return 0, err
+		sorter.Println(err)
+		os.Exit(1)
 	}
-	defer sourceFile.Close()
 
-	destFile, err := os.Create(destination)
+	handleControlSignals(s)
+	ctx := cancelOnInterrupt()
+	report, err := s.Run(ctx)
 	if err != nil {
-		return 0, err
+		// the standard output is left to the json
+		if strings.Compare(*output, "json") == 0 {
+			fmt.Fprintln(os.Stderr, err)
+		} else {
+			sorter.Println(err)
+		}
+		os.Exit(1)
 	}
-	defer destFile.Close()
-
-	return io.Copy(destFile, sourceFile)
-}
 
-func printReport(counts *processedCount) {
-	fmt.Println("Completed !")
-	fmt.Printf("Copied %d files from %d directories. Skipped %d, Errored %d, Bytes copied %d\n",
-		counts.copiedFiles,
-		counts.visitedDirectories,
-		counts.skippedFiles,
-		counts.erroredFiles,
-		counts.totalBytesCopied)
+	if report.CorruptedCopies > 0 {
+		os.Exit(sorter.ExitCorruption)
+	}
+	// like a shell exit with 130 when the run was stopped with Ctrl-C
+	if ctx.Err() != nil {
+		os.Exit(130)
+	}
+	// like diff(1) exit with 1 when there are differences
+	if report.Differences > 0 {
+		os.Exit(1)
+	}
 }
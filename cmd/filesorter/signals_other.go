@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "github.com/abhayk/filesorter/pkg/sorter"
+
+// handleControlSignals does nothing since there are no user signals. The run can be paused
+// through -control-socket instead.
+func handleControlSignals(s *sorter.Sorter) {}
@@ -0,0 +1,29 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/abhayk/filesorter/pkg/sorter"
+)
+
+// handleControlSignals pauses the run on SIGUSR1 and resumes it on SIGUSR2, for example to free up
+// the disks for a while without aborting a long run.
+func handleControlSignals(s *sorter.Sorter) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for sig := range signals {
+			if sig == syscall.SIGUSR1 {
+				sorter.Printf("Pausing after the current file. Send SIGUSR2 to resume (kill -USR2 %d)\n", os.Getpid())
+				s.Pause()
+			} else {
+				sorter.Printf("Resuming\n")
+				s.Resume()
+			}
+		}
+	}()
+}
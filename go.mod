@@ -0,0 +1,11 @@
+module github.com/abhayk/filesorter
+
+go 1.26.0
+
+require (
+	github.com/fsnotify/fsnotify v1.10.1
+	github.com/karrick/godirwalk v1.17.0
+	github.com/mattn/go-sqlite3 v1.14.33
+	golang.org/x/sys v0.48.0
+	golang.org/x/text v0.42.0
+)
@@ -0,0 +1,123 @@
+package sorter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// ExitCorruption is the exit code when files are found corrupted so that scripts running periodic
+// verifies can tell bit rot apart from other errors which exit with 1.
+const ExitCorruption = 2
+
+// errCopyMismatch is returned when a copied file is read back and does not match the hash of the source.
+var errCopyMismatch = errors.New("the copied file does not match the source")
+
+// AlertConfig holds the channels through which corruption and infected files are reported besides the exit code. The
+// SMTP user and password are read from FILESORTER_SMTP_USER and FILESORTER_SMTP_PASSWORD.
+type AlertConfig struct {
+	Webhook  string
+	Email    string
+	SMTPAddr string
+	SMTPFrom string
+}
+
+// alert is posted as json to the webhook and sent as text in the email.
+type alert struct {
+	Kind    string      `json:"kind"`
+	Host    string      `json:"host"`
+	Time    time.Time   `json:"time"`
+	Summary string      `json:"summary"`
+	Files   []alertFile `json:"files"`
+}
+
+type alertFile struct {
+	Path   string `json:"path"`
+	Detail string `json:"detail"`
+}
+
+// AddAlertFlags adds the flags of the alert channels.
+func AddAlertFlags(flags *flag.FlagSet) *AlertConfig {
+	config := &AlertConfig{}
+	flags.StringVar(&config.Webhook, "alert-webhook", "", `Optional. Post the details as json to this URL when corrupted or infected files are found`)
+	flags.StringVar(&config.Email, "alert-email", "", `Optional. Email the details to this address when corrupted or infected files are found.
+	Needs -smtp. The SMTP user and password are read from FILESORTER_SMTP_USER and
+	FILESORTER_SMTP_PASSWORD`)
+	flags.StringVar(&config.SMTPAddr, "smtp", "", "Optional. The host:port of the SMTP server used for -alert-email")
+	flags.StringVar(&config.SMTPFrom, "smtp-from", "", "Optional. The sender of the alert emails. Defaults to -alert-email")
+	return config
+}
+
+func (config *AlertConfig) validate(printer *outputPrinter) error {
+	if strings.Compare(config.Email, "") != 0 && strings.Compare(config.SMTPAddr, "") == 0 {
+		return printer.errorf("The -alert-email option needs the SMTP server passed with -smtp\n")
+	}
+	return nil
+}
+
+// sendAlert reports the corrupted files through every configured channel. A channel which fails
+// is reported and does not stop the others.
+func sendAlert(config *AlertConfig, kind string, summary string, files []alertFile, p *outputPrinter) {
+	host, _ := os.Hostname()
+	a := alert{Kind: kind, Host: host, Time: time.Now(), Summary: summary, Files: files}
+
+	if strings.Compare(config.Webhook, "") != 0 {
+		if err := postWebhook(config.Webhook, a); err != nil {
+			p.Printf("An error occurred while trying to post the alert to %s: %v\n", config.Webhook, err)
+		}
+	}
+	if strings.Compare(config.Email, "") != 0 {
+		if err := sendAlertEmail(config, a); err != nil {
+			p.Printf("An error occurred while trying to email the alert to %s: %v\n", config.Email, err)
+		}
+	}
+}
+
+func postWebhook(url string, a alert) error {
+	content, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Post(url, "application/json", bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("the webhook responded with %s", response.Status)
+	}
+	return nil
+}
+
+func sendAlertEmail(config *AlertConfig, a alert) error {
+	from := config.SMTPFrom
+	if strings.Compare(from, "") == 0 {
+		from = config.Email
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\nTo: %s\r\nSubject: filesorter: %s on %s\r\n\r\n", from, config.Email, a.Summary, a.Host)
+	fmt.Fprintf(&body, "%s on %s at %s\r\n\r\n", a.Summary, a.Host, a.Time.Format("2006-01-02 15:04:05"))
+	for _, file := range a.Files {
+		fmt.Fprintf(&body, "%s: %s\r\n", file.Path, file.Detail)
+	}
+
+	var auth smtp.Auth
+	if user := os.Getenv("FILESORTER_SMTP_USER"); strings.Compare(user, "") != 0 {
+		host, _, err := net.SplitHostPort(config.SMTPAddr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", user, os.Getenv("FILESORTER_SMTP_PASSWORD"), host)
+	}
+	return smtp.SendMail(config.SMTPAddr, auth, from, []string{config.Email}, []byte(body.String()))
+}
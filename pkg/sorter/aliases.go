@@ -0,0 +1,47 @@
+package sorter
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// aliasDuplicate checks whether a file with the same name and content was copied into the
+// destination before under another date, like after the same photos were exported again with a
+// new modified time. If so the copy is skipped and its path is recorded in the catalog as an alias
+// of the earlier copy. The source is hashed at most once for all the destinations.
+func aliasDuplicate(path string, sourceFileStat os.FileInfo, c *fileCopy, sourceHash *string, opts *sortOptions) (bool, error) {
+
+	name := filepath.Base(c.destFilePath)
+	candidates, err := opts.catalog.sameNameCopies(c.dest.path, name, sourceFileStat.Size())
+	if err != nil {
+		opts.printer.Printf("An error occurred while trying to search the catalog for copies of %s", name)
+		return false, err
+	}
+
+	for _, candidate := range candidates {
+		// the earlier copy may have been removed since
+		if _, err := os.Stat(candidate.destPath); err != nil {
+			continue
+		}
+		if *sourceHash == "" {
+			if *sourceHash, err = opts.runIO.hashFile(path); err != nil {
+				opts.printer.Printf("An error occurred while trying to hash the file %s", path)
+				return false, err
+			}
+		}
+		if candidate.hash != *sourceHash {
+			continue
+		}
+
+		// nothing is recorded while planning
+		if opts.plan == nil {
+			if err := opts.catalog.recordAlias(c.destFilePath, candidate.destPath, path); err != nil {
+				opts.printer.Printf("An error occurred while trying to record the alias %s in the catalog", c.destFilePath)
+				return false, err
+			}
+		}
+		opts.printer.Printf("Already copied %s to %s, recorded %s as an alias\n", path, candidate.destPath, c.destFilePath)
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package sorter
+
+const fileAttributesSupported = false
+
+// copyFileAttributes does nothing since the attributes only exist on Windows.
+func copyFileAttributes(source string, destination string) error {
+	return nil
+}
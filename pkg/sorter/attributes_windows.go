@@ -0,0 +1,36 @@
+package sorter
+
+import "syscall"
+
+const fileAttributesSupported = true
+
+// copiedFileAttributes are the attributes which are carried over to the copy.
+const copiedFileAttributes = syscall.FILE_ATTRIBUTE_READONLY | syscall.FILE_ATTRIBUTE_HIDDEN |
+	syscall.FILE_ATTRIBUTE_SYSTEM | syscall.FILE_ATTRIBUTE_ARCHIVE
+
+// copyFileAttributes sets the read-only, hidden, system and archive attributes of the source on
+// the destination. It has to be done last since a read-only file can not be changed any more.
+func copyFileAttributes(source string, destination string) error {
+	sourcePath, err := syscall.UTF16PtrFromString(source)
+	if err != nil {
+		return err
+	}
+	destPath, err := syscall.UTF16PtrFromString(destination)
+	if err != nil {
+		return err
+	}
+
+	sourceAttributes, err := syscall.GetFileAttributes(sourcePath)
+	if err != nil {
+		return err
+	}
+	destAttributes, err := syscall.GetFileAttributes(destPath)
+	if err != nil {
+		return err
+	}
+	attributes := destAttributes&^copiedFileAttributes | sourceAttributes&copiedFileAttributes
+	if attributes == destAttributes {
+		return nil
+	}
+	return syscall.SetFileAttributes(destPath, attributes)
+}
@@ -0,0 +1,124 @@
+package sorter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bandwidthSchedule is a list of time of day windows with the rate allowed in each. Outside of
+// the windows the rate is not limited. The rate is looked up on every read so a long run picks up
+// the window it is in.
+type bandwidthSchedule struct {
+	windows []bandwidthWindow
+
+	mu sync.Mutex
+	// next is the earliest time at which the next byte may be copied
+	next time.Time
+}
+
+type bandwidthWindow struct {
+	// start and end are minutes since midnight. A window which ends before it starts wraps
+	// around midnight and a window which ends where it starts covers the whole day.
+	start int
+	end   int
+	// rate is in bytes per second
+	rate int64
+}
+
+// parseBandwidthSchedule parses a schedule like 08:00-23:00=10MB,23:00-08:00=50MB. A window
+// without the times like 10MB applies to the whole day.
+func parseBandwidthSchedule(schedule string, printer *outputPrinter) (*bandwidthSchedule, error) {
+	s := &bandwidthSchedule{}
+	for _, entry := range strings.Split(schedule, ",") {
+		entry = strings.TrimSpace(entry)
+		window := bandwidthWindow{}
+		rate := entry
+		if i := strings.Index(entry, "="); i >= 0 {
+			var startHour, startMinute, endHour, endMinute int
+			_, err := fmt.Sscanf(entry[:i], "%d:%d-%d:%d", &startHour, &startMinute, &endHour, &endMinute)
+			var startOK, endOK bool
+			window.start, startOK = minuteOfDay(startHour, startMinute)
+			window.end, endOK = minuteOfDay(endHour, endMinute)
+			if err != nil || !startOK || !endOK {
+				return nil, printer.errorf("The bandwidth window %s is not valid. Use the form 08:00-23:00=10MB\n", entry)
+			}
+			rate = entry[i+1:]
+		}
+		var err error
+		window.rate, err = parseSize(strings.TrimSuffix(rate, "/s"), printer)
+		if err != nil || window.rate <= 0 {
+			return nil, printer.errorf("The bandwidth %s is not valid\n", rate)
+		}
+		s.windows = append(s.windows, window)
+	}
+	return s, nil
+}
+
+// minuteOfDay converts a time of the day to minutes since midnight. 24:00 is the only time with the
+// hour 24, which ends a window at midnight.
+func minuteOfDay(hour int, minute int) (int, bool) {
+	if hour < 0 || minute < 0 || minute > 59 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, false
+	}
+	return hour*60 + minute, true
+}
+
+// rateAt returns the rate allowed at the time of the day or 0 if it is not limited.
+func (s *bandwidthSchedule) rateAt(t time.Time) int64 {
+	minute := t.Hour()*60 + t.Minute()
+	for _, window := range s.windows {
+		switch {
+		case window.start == window.end:
+			return window.rate
+		case window.start < window.end && minute >= window.start && minute < window.end:
+			return window.rate
+		case window.start > window.end && (minute >= window.start || minute < window.end):
+			return window.rate
+		}
+	}
+	return 0
+}
+
+// wait blocks until n more bytes may be copied at the rate.
+func (s *bandwidthSchedule) wait(n int, rate int64) {
+	s.mu.Lock()
+	now := time.Now()
+	if s.next.Before(now) {
+		s.next = now
+	}
+	s.next = s.next.Add(time.Duration(int64(n) * int64(time.Second) / rate))
+	delay := s.next.Sub(now)
+	s.mu.Unlock()
+
+	time.Sleep(delay)
+}
+
+// throttle limits the reads from the reader to the bandwidth schedule.
+func (r *runIO) throttle(reader io.Reader) io.Reader {
+	if r == nil || r.bandwidth == nil {
+		return reader
+	}
+	return &throttledReader{reader: reader, schedule: r.bandwidth}
+}
+
+type throttledReader struct {
+	reader   io.Reader
+	schedule *bandwidthSchedule
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	rate := t.schedule.rateAt(time.Now())
+	if rate == 0 {
+		return t.reader.Read(p)
+	}
+	// read at most a tenth of a second worth so that the rate stays smooth
+	if chunk := int(rate / 10); chunk > 0 && len(p) > chunk {
+		p = p[:chunk]
+	}
+	n, err := t.reader.Read(p)
+	t.schedule.wait(n, rate)
+	return n, err
+}
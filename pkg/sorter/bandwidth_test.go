@@ -0,0 +1,37 @@
+package sorter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBandwidthSchedule(t *testing.T) {
+	tests := []struct {
+		schedule string
+		valid    bool
+		// rate is the rate at 23:30 of a valid schedule
+		rate int64
+	}{
+		{"08:00-23:00=10MB,23:00-08:00=50MB", true, 50 * 1024 * 1024},
+		{"20:00-24:00=1MB", true, 1024 * 1024},
+		{"00:00-24:00=2MB", true, 2 * 1024 * 1024},
+		{"10MB", true, 10 * 1024 * 1024},
+		{"23:00-24:59=1MB", false, 0},
+		{"24:30-08:00=1MB", false, 0},
+		{"25:00-08:00=1MB", false, 0},
+		{"-1:00-08:00=1MB", false, 0},
+		{"08:-5-09:00=1MB", false, 0},
+		{"08:00-09:60=1MB", false, 0},
+	}
+	at := time.Date(2023, time.July, 5, 23, 30, 0, 0, time.Local)
+	for _, test := range tests {
+		s, err := parseBandwidthSchedule(test.schedule, printer)
+		if (err == nil) != test.valid {
+			t.Errorf("parseBandwidthSchedule(%q) returned %v, want valid %v", test.schedule, err, test.valid)
+			continue
+		}
+		if err == nil && s.rateAt(at) != test.rate {
+			t.Errorf("the rate of %q at 23:30 is %d, want %d", test.schedule, s.rateAt(at), test.rate)
+		}
+	}
+}
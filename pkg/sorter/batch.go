@@ -0,0 +1,37 @@
+package sorter
+
+import "errors"
+
+// errBatchLimit stops the walk once the run copied as much as -max-files or -max-bytes allow. The
+// next run skips the files which were copied and continues with the rest.
+var errBatchLimit = errors.New("the limit of the run was reached")
+
+// batchLimit caps the source files copied in one run. Zero values do not limit.
+type batchLimit struct {
+	maxFiles int
+	maxBytes int64
+	// files and bytes are the source files taken so far, counted once however many destinations
+	// they are copied to
+	files int
+	bytes int64
+	// reached is set once a file was not copied because of the limit
+	reached bool
+}
+
+// take reports whether a source file of the size may still be copied in this run and counts it
+// if it may. A file larger than -max-bytes is copied when it is the first one so that it does not
+// stop every run.
+func (b *batchLimit) take(size int64) bool {
+	if (b.maxFiles > 0 && b.files >= b.maxFiles) || (b.maxBytes > 0 && b.files > 0 && b.bytes+size > b.maxBytes) {
+		b.reached = true
+		return false
+	}
+	b.files++
+	b.bytes += size
+	return true
+}
+
+// stopsRun reports whether the error returned for a file ends the walk.
+func stopsRun(err error) bool {
+	return err == errCancelled || err == errBatchLimit || err == errVolumeFull || err == errOutOfInodes
+}
@@ -0,0 +1,176 @@
+package sorter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unicode/utf16"
+)
+
+// bplistUID is a reference to another object of a keyed archive.
+type bplistUID uint64
+
+// parseBinaryPlist decodes a binary property list into maps, slices, strings, int64, float64,
+// []byte, bool and bplistUID values. It is only meant for the small plists embedded in backup manifests.
+func parseBinaryPlist(content []byte) (interface{}, error) {
+	if len(content) < 40 || !bytes.Equal(content[:8], []byte("bplist00")) {
+		return nil, fmt.Errorf("The content is not a binary plist")
+	}
+
+	trailer := content[len(content)-32:]
+	offsetSize := int(trailer[6])
+	refSize := int(trailer[7])
+	count := binary.BigEndian.Uint64(trailer[8:])
+	top := binary.BigEndian.Uint64(trailer[16:])
+	tableOffset := binary.BigEndian.Uint64(trailer[24:])
+	// the checks are written so that the values of a corrupted trailer can not overflow them
+	size := uint64(len(content))
+	if offsetSize < 1 || offsetSize > 8 || refSize < 1 || refSize > 8 || count > size || tableOffset > size ||
+		count*uint64(offsetSize) > size-tableOffset {
+		return nil, fmt.Errorf("The binary plist has a corrupted trailer")
+	}
+
+	offsets := make([]uint64, count)
+	for i := range offsets {
+		offsets[i] = readSizedInt(content[tableOffset+uint64(i*offsetSize):], offsetSize)
+	}
+
+	parser := &bplistParser{content: content, offsets: offsets, refSize: refSize}
+	return parser.object(top, 0)
+}
+
+type bplistParser struct {
+	content []byte
+	offsets []uint64
+	refSize int
+}
+
+func (p *bplistParser) object(ref uint64, depth int) (interface{}, error) {
+	// nested containers are never deep in practice so this only guards against reference cycles
+	if ref >= uint64(len(p.offsets)) || depth > 32 || p.offsets[ref] >= uint64(len(p.content)) {
+		return nil, fmt.Errorf("The binary plist has an invalid object reference")
+	}
+	data := p.content[p.offsets[ref]:]
+	marker, info := data[0]>>4, int(data[0]&0x0F)
+
+	switch marker {
+	case 0x0:
+		return info == 0x9, nil
+	case 0x1:
+		size := 1 << info
+		if size > 8 || len(data) < 1+size {
+			return nil, fmt.Errorf("The binary plist has an invalid integer")
+		}
+		return int64(readSizedInt(data[1:], size)), nil
+	case 0x2, 0x3:
+		size := 1 << info
+		if (size != 4 && size != 8) || len(data) < 1+size {
+			return nil, fmt.Errorf("The binary plist has an invalid real")
+		}
+		if size == 4 {
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(data[1:]))), nil
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(data[1:])), nil
+	case 0x8:
+		if info > 7 || len(data) < 2+info {
+			return nil, fmt.Errorf("The binary plist has an invalid uid")
+		}
+		return bplistUID(readSizedInt(data[1:], info+1)), nil
+	}
+
+	length, start, err := p.length(data, info)
+	if err != nil {
+		return nil, err
+	}
+
+	switch marker {
+	case 0x4, 0x5:
+		if length > uint64(len(data))-start {
+			return nil, fmt.Errorf("The binary plist has an invalid string")
+		}
+		if marker == 0x4 {
+			return data[start : start+length], nil
+		}
+		return string(data[start : start+length]), nil
+	case 0x6:
+		if length > (uint64(len(data))-start)/2 {
+			return nil, fmt.Errorf("The binary plist has an invalid string")
+		}
+		units := make([]uint16, length)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(data[start+uint64(2*i):])
+		}
+		return string(utf16.Decode(units)), nil
+	case 0xA:
+		refs, err := p.refs(data[start:], length)
+		if err != nil {
+			return nil, err
+		}
+		array := make([]interface{}, len(refs))
+		for i, ref := range refs {
+			if array[i], err = p.object(ref, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return array, nil
+	case 0xD:
+		refs, err := p.refs(data[start:], 2*length)
+		if err != nil {
+			return nil, err
+		}
+		dict := make(map[string]interface{}, length)
+		for i := uint64(0); i < length; i++ {
+			key, err := p.object(refs[i], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			value, err := p.object(refs[length+i], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if name, ok := key.(string); ok {
+				dict[name] = value
+			}
+		}
+		return dict, nil
+	}
+	return nil, fmt.Errorf("The binary plist has an unsupported object type %x", marker)
+}
+
+// length returns the length of a data, string or container object and the offset at which its content
+// starts. Lengths of 15 and more are stored as a separate integer after the marker.
+func (p *bplistParser) length(data []byte, info int) (uint64, uint64, error) {
+	if info != 0x0F {
+		return uint64(info), 1, nil
+	}
+	if len(data) < 2 || data[1]>>4 != 0x1 || len(data) < 2+(1<<(data[1]&0x0F)) || 1<<(data[1]&0x0F) > 8 {
+		return 0, 0, fmt.Errorf("The binary plist has an invalid length")
+	}
+	size := 1 << (data[1] & 0x0F)
+	length := readSizedInt(data[2:], size)
+	// no object can be longer than the whole plist, which keeps the sums of the callers small
+	if length > uint64(len(p.content)) {
+		return 0, 0, fmt.Errorf("The binary plist has an invalid length")
+	}
+	return length, uint64(2 + size), nil
+}
+
+func (p *bplistParser) refs(data []byte, count uint64) ([]uint64, error) {
+	if count > uint64(len(data))/uint64(p.refSize) {
+		return nil, fmt.Errorf("The binary plist has an invalid container")
+	}
+	refs := make([]uint64, count)
+	for i := range refs {
+		refs[i] = readSizedInt(data[i*p.refSize:], p.refSize)
+	}
+	return refs, nil
+}
+
+func readSizedInt(data []byte, size int) uint64 {
+	var value uint64
+	for _, b := range data[:size] {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
@@ -0,0 +1,86 @@
+package sorter
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// buildBinaryPlist puts the objects into a binary plist with one byte offsets and references.
+func buildBinaryPlist(objects ...[]byte) []byte {
+	content := []byte("bplist00")
+	var offsets []byte
+	for _, object := range objects {
+		offsets = append(offsets, byte(len(content)))
+		content = append(content, object...)
+	}
+	tableOffset := len(content)
+	content = append(content, offsets...)
+	trailer := make([]byte, 32)
+	trailer[6], trailer[7] = 1, 1
+	binary.BigEndian.PutUint64(trailer[8:], uint64(len(objects)))
+	binary.BigEndian.PutUint64(trailer[24:], uint64(tableOffset))
+	return append(content, trailer...)
+}
+
+func TestParseBinaryPlist(t *testing.T) {
+	content := buildBinaryPlist([]byte{0xD1, 1, 2}, []byte{0x51, 'a'}, []byte{0x51, 'b'})
+	value, err := parseBinaryPlist(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]interface{}{"a": "b"}; !reflect.DeepEqual(value, want) {
+		t.Errorf("got %v, want %v", value, want)
+	}
+}
+
+func TestParseBinaryPlistCorrupted(t *testing.T) {
+	// a length of 15 or more follows the marker as an integer, here the largest one of 8 bytes
+	huge := []byte{0x13, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+	trailer := func(offsetSize byte, count uint64, tableOffset uint64) []byte {
+		content := make([]byte, 40)
+		copy(content, "bplist00")
+		content[8+6], content[8+7] = offsetSize, 1
+		binary.BigEndian.PutUint64(content[8+8:], count)
+		binary.BigEndian.PutUint64(content[8+24:], tableOffset)
+		return content
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{"fuzzed trailer", []byte("bplist00000000\x01\x01\x00\x00\x00\x00\x00\x00\x00 00000000\xff\xff\xff\xff\xff\xff\xff\xf2")},
+		{"table offset wrapping around", trailer(1, 1, 0xFFFFFFFFFFFFFFF2)},
+		{"table offset past the end", trailer(1, 1, 41)},
+		{"offset table past the end", trailer(8, 4, 8)},
+		{"offset size 0", trailer(0, 0, 8)},
+		{"object count wrapping around", trailer(8, 0x2000000000000001, 8)},
+		{"data length wrapping around", buildBinaryPlist(append([]byte{0x4F}, huge...))},
+		{"string length wrapping around", buildBinaryPlist(append([]byte{0x5F}, huge...))},
+		{"utf16 length wrapping around", buildBinaryPlist(append([]byte{0x6F}, huge...))},
+		{"array length wrapping around", buildBinaryPlist(append([]byte{0xAF}, huge...))},
+		{"dict length wrapping around", buildBinaryPlist(append([]byte{0xDF}, huge...))},
+		{"real of 1 byte", buildBinaryPlist([]byte{0x20, 0, 0, 0, 0, 0, 0, 0, 0})},
+		{"real of 2 bytes", buildBinaryPlist([]byte{0x21, 0, 0, 0, 0, 0, 0, 0, 0})},
+		{"uid of 16 bytes", buildBinaryPlist(append([]byte{0x8F}, make([]byte, 16)...))},
+		{"reference past the objects", buildBinaryPlist([]byte{0xA1, 5})},
+		{"reference cycle", buildBinaryPlist([]byte{0xA1, 0})},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := parseBinaryPlist(test.content); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func FuzzParseBinaryPlist(f *testing.F) {
+	f.Add(buildBinaryPlist([]byte{0xD1, 1, 2}, []byte{0x51, 'a'}, []byte{0x51, 'b'}))
+	f.Add([]byte("bplist00000000\x01\x01\x00\x00\x00\x00\x00\x00\x00 00000000\xff\xff\xff\xff\xff\xff\xff\xf2"))
+	f.Fuzz(func(t *testing.T, content []byte) {
+		// corrupted plists have to fail with an error instead of a panic
+		parseBinaryPlist(content)
+	})
+}
@@ -0,0 +1,67 @@
+package sorter
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+)
+
+// bucketFolder matches the folders of -max-files-per-dir like 001. A folder past 999 can not be
+// told apart from a year and is not recognized.
+var bucketFolder = regexp.MustCompile(`^\d{3}$`)
+
+// dirBuckets splits the folders the files are sorted into in numbered sub folders of at most max
+// files each, like 2020/May/2/001 and 2020/May/2/002. A file goes to the first bucket which already
+// has a file with its name or else to the first one with room left, so that a second run over the
+// same source puts every file where the first run did.
+type dirBuckets struct {
+	max int
+	// dirs holds the entries of the folders which were looked at, including the files placed
+	// during this run which may not have been copied yet when planning
+	dirs *dirEntryCache
+}
+
+func newDirBuckets(max int, dirs *dirEntryCache) *dirBuckets {
+	return &dirBuckets{max: max, dirs: dirs}
+}
+
+// place returns the path in a bucket for the destination file path.
+func (b *dirBuckets) place(destFilePath string) (string, error) {
+	dir, name := filepath.Split(destFilePath)
+
+	// files copied into the folder itself before the buckets were used are left where they are
+	names, err := b.dirs.entries(dir)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := names[name]; ok {
+		return destFilePath, nil
+	}
+
+	for i := 1; ; i++ {
+		bucket := filepath.Join(dir, fmt.Sprintf("%03d", i))
+		names, err := b.dirs.entries(bucket)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := names[name]; ok {
+			return filepath.Join(bucket, name), nil
+		}
+		if len(names) < b.max {
+			names[name] = struct{}{}
+			return filepath.Join(bucket, name), nil
+		}
+	}
+}
+
+// trimSubFolders removes the bucket and the burst folder, which are put below the folders of the
+// layout, from the end of the folders of a path. It reports whether there was a burst folder.
+func trimSubFolders(folders []string) ([]string, bool) {
+	if len(folders) > 0 && bucketFolder.MatchString(folders[len(folders)-1]) {
+		folders = folders[:len(folders)-1]
+	}
+	if len(folders) > 0 && burstFolder.MatchString(folders[len(folders)-1]) {
+		return folders[:len(folders)-1], true
+	}
+	return folders, false
+}
@@ -0,0 +1,95 @@
+package sorter
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/karrick/godirwalk"
+)
+
+// burstGap is the most time between two frames of a burst and burstFrames the fewest frames which
+// make one. The files of a source directory are taken to be from the same camera.
+const (
+	burstGap    = 2 * time.Second
+	burstFrames = 3
+)
+
+var imageTypes = parseTypes("images")
+
+// burstIndex groups the photos of a source directory which were taken in a burst so that they are
+// sorted into a sub folder of the day instead of flooding it.
+type burstIndex struct {
+	// dirs maps the source directories looked at so far to the burst folder of each of their files
+	// which are part of a burst
+	dirs map[string]map[string]string
+}
+
+func newBurstIndex() *burstIndex {
+	return &burstIndex{dirs: make(map[string]map[string]string)}
+}
+
+// folder returns the burst folder like burst-153059 the file is sorted into, named after the time
+// of the first frame, or an empty string when the file is not part of a burst. The directory of
+// the file is looked at when the first of its files is sorted.
+func (b *burstIndex) folder(path string, opts *sortOptions) string {
+	dir := filepath.Dir(path)
+	bursts, ok := b.dirs[dir]
+	if !ok {
+		bursts = findBursts(dir, opts)
+		b.dirs[dir] = bursts
+	}
+	return bursts[filepath.Base(path)]
+}
+
+type burstFrame struct {
+	name string
+	time time.Time
+}
+
+func findBursts(dir string, opts *sortOptions) map[string]string {
+
+	bursts := make(map[string]string)
+	dirents, err := godirwalk.ReadDirents(dir, nil)
+	if err != nil {
+		return bursts
+	}
+
+	var frames []burstFrame
+	for _, dirent := range dirents {
+		if dirent.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, dirent.Name())
+		fileInfo, err := os.Stat(path)
+		if err != nil || !fileInfo.Mode().IsRegular() {
+			continue
+		}
+		if file, ok := opts.backupFiles[dirent.Name()]; ok {
+			fileInfo = backupFileInfo{FileInfo: fileInfo, file: file}
+		}
+		if _, ok := imageTypes[fileType(fileInfo.Name())]; !ok || !matchesTypes(fileInfo.Name(), opts) {
+			continue
+		}
+		frames = append(frames, burstFrame{name: dirent.Name(), time: opts.runIO.getSortTime(path, fileInfo, opts.dateSources)})
+	}
+	sort.Slice(frames, func(i, j int) bool {
+		return frames[i].time.Before(frames[j].time)
+	})
+
+	for start := 0; start < len(frames); {
+		end := start + 1
+		for end < len(frames) && frames[end].time.Sub(frames[end-1].time) <= burstGap {
+			end++
+		}
+		if end-start >= burstFrames {
+			folder := "burst-" + frames[start].time.Format("150405")
+			for _, frame := range frames[start:end] {
+				bursts[frame.name] = folder
+			}
+		}
+		start = end
+	}
+	return bursts
+}
@@ -0,0 +1,567 @@
+package sorter
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// catalog is a sqlite database which records every file copied into the destination
+// so that the archive can be verified and searched later without rescanning the source.
+type catalog struct {
+	db *sql.DB
+	// run is the id of the run the recorded files are attributed to
+	run int64
+	// volume is the label of the volume the recorded files are copied onto
+	volume string
+}
+
+type catalogEntry struct {
+	sourcePath string
+	destPath   string
+	size       int64
+	modTime    time.Time
+	hash       string
+	copiedAt   time.Time
+	run        int64
+	// sortTime is the date the file was sorted by. It is zero for files sorted by their tags.
+	sortTime time.Time
+	// volume is the label of the volume holding the copy when the archive spans several
+	volume string
+}
+
+// catalogRun is a sort or apply run which copied files into the archive.
+type catalogRun struct {
+	id      int64
+	started time.Time
+	command string
+	source  string
+	// finished is zero for runs which are still going or crashed before the summary was recorded
+	finished     time.Time
+	copiedFiles  int
+	skippedFiles int
+	erroredFiles int
+	resumedFiles int
+	bytesCopied  int64
+}
+
+const catalogSchema = `
+CREATE TABLE IF NOT EXISTS files (
+	dest_path   TEXT PRIMARY KEY,
+	source_path TEXT NOT NULL,
+	size        INTEGER NOT NULL,
+	mod_time    INTEGER NOT NULL,
+	sha256      TEXT NOT NULL,
+	copied_at   INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS runs (
+	id      INTEGER PRIMARY KEY AUTOINCREMENT,
+	started INTEGER NOT NULL,
+	command TEXT NOT NULL,
+	source  TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS scanned_dirs (
+	path         TEXT NOT NULL,
+	destinations TEXT NOT NULL,
+	mod_time     INTEGER NOT NULL,
+	subdirs      TEXT NOT NULL,
+	PRIMARY KEY (path, destinations)
+);
+CREATE TABLE IF NOT EXISTS aliases (
+	alias_path  TEXT PRIMARY KEY,
+	dest_path   TEXT NOT NULL,
+	source_path TEXT NOT NULL,
+	run_id      INTEGER NOT NULL,
+	created     INTEGER NOT NULL
+)`
+
+// catalogMigrations are the columns added to the tables after they were first released. They
+// are added to older catalogs when those are opened.
+var catalogMigrations = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"files", "run_id", "INTEGER NOT NULL DEFAULT 0"},
+	{"files", "sort_time", "INTEGER NOT NULL DEFAULT 0"},
+	{"runs", "finished", "INTEGER NOT NULL DEFAULT 0"},
+	{"runs", "copied_files", "INTEGER NOT NULL DEFAULT 0"},
+	{"runs", "skipped_files", "INTEGER NOT NULL DEFAULT 0"},
+	{"runs", "errored_files", "INTEGER NOT NULL DEFAULT 0"},
+	{"runs", "resumed_files", "INTEGER NOT NULL DEFAULT 0"},
+	{"runs", "bytes_copied", "INTEGER NOT NULL DEFAULT 0"},
+	{"files", "volume", "TEXT NOT NULL DEFAULT ''"},
+}
+
+const catalogRunColumns = `id, started, command, source, finished, copied_files, skipped_files, errored_files,
+	resumed_files, bytes_copied`
+
+const catalogEntryColumns = `dest_path, source_path, size, mod_time, sha256, copied_at, run_id, sort_time, volume`
+
+func openCatalog(path string) (*catalog, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(catalogSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := migrateCatalog(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &catalog{db: db}, nil
+}
+
+func migrateCatalog(db *sql.DB) error {
+	rows, err := db.Query(`SELECT m.name, p.name FROM sqlite_master m, pragma_table_info(m.name) p WHERE m.type = 'table'`)
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var table, name string
+		if err := rows.Scan(&table, &name); err != nil {
+			rows.Close()
+			return err
+		}
+		columns[table+"."+name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, migration := range catalogMigrations {
+		if columns[migration.table+"."+migration.column] {
+			continue
+		}
+		_, err := db.Exec(`ALTER TABLE ` + migration.table + ` ADD COLUMN ` + migration.column + ` ` + migration.definition)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startRun records a new run to which the files recorded from now on are attributed.
+func (c *catalog) startRun(command string, source string) error {
+	result, err := c.db.Exec(`INSERT INTO runs (started, command, source) VALUES (?, ?, ?)`,
+		time.Now().UnixNano(), command, source)
+	if err != nil {
+		return err
+	}
+	c.run, err = result.LastInsertId()
+	return err
+}
+
+// finishRun records the summary of the current run. It does nothing when no run was started.
+func (c *catalog) finishRun(counts *processedCount) error {
+	if c.run == 0 {
+		return nil
+	}
+	_, err := c.db.Exec(`UPDATE runs SET finished = ?, copied_files = ?, skipped_files = ?, errored_files = ?,
+		resumed_files = ?, bytes_copied = ? WHERE id = ?`,
+		time.Now().UnixNano(), counts.copiedFiles, counts.skippedFiles, counts.erroredFiles,
+		counts.resumedFiles, counts.totalBytesCopied, c.run)
+	return err
+}
+
+// getRun returns the run with the id. The files recorded before runs were tracked have the id 0
+// for which the returned run is not found.
+func (c *catalog) getRun(id int64) (catalogRun, bool, error) {
+	rows, err := c.db.Query(`SELECT `+catalogRunColumns+` FROM runs WHERE id = ?`, id)
+	if err != nil {
+		return catalogRun{id: id}, false, err
+	}
+	runs, err := scanRuns(rows)
+	if err != nil || len(runs) == 0 {
+		return catalogRun{id: id}, false, err
+	}
+	return runs[0], true, nil
+}
+
+// previousRun returns the latest run of the command from the source before the current one.
+func (c *catalog) previousRun(command string, source string) (catalogRun, bool, error) {
+	rows, err := c.db.Query(`SELECT `+catalogRunColumns+` FROM runs WHERE command = ? AND source = ? AND id != ?
+		ORDER BY id DESC LIMIT 1`, command, source, c.run)
+	if err != nil {
+		return catalogRun{}, false, err
+	}
+	runs, err := scanRuns(rows)
+	if err != nil || len(runs) == 0 {
+		return catalogRun{}, false, err
+	}
+	return runs[0], true, nil
+}
+
+// runFiles returns the number of files recorded by the run.
+func (c *catalog) runFiles(id int64) (int, error) {
+	var n int
+	err := c.db.QueryRow(`SELECT COUNT(*) FROM files WHERE run_id = ?`, id).Scan(&n)
+	return n, err
+}
+
+// recentRuns returns up to limit of the latest runs, the oldest first.
+func (c *catalog) recentRuns(limit int) ([]catalogRun, error) {
+	rows, err := c.db.Query(`SELECT * FROM (SELECT `+catalogRunColumns+` FROM runs ORDER BY id DESC LIMIT ?)
+		ORDER BY id`, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanRuns(rows)
+}
+
+func scanRuns(rows *sql.Rows) ([]catalogRun, error) {
+	defer rows.Close()
+
+	var runs []catalogRun
+	for rows.Next() {
+		var run catalogRun
+		var started, finished int64
+		err := rows.Scan(&run.id, &started, &run.command, &run.source, &finished, &run.copiedFiles,
+			&run.skippedFiles, &run.erroredFiles, &run.resumedFiles, &run.bytesCopied)
+		if err != nil {
+			return nil, err
+		}
+		run.started = time.Unix(0, started)
+		if finished != 0 {
+			run.finished = time.Unix(0, finished)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+func (c *catalog) Close() error {
+	return c.db.Close()
+}
+
+// record adds the entry to the catalog replacing any older entry for the same destination path.
+func (c *catalog) record(entry catalogEntry) error {
+	// store absolute paths so that the catalog can be used from any working directory
+	// the source of the files added by index is not known and stays empty
+	sourcePath := entry.sourcePath
+	if sourcePath != "" {
+		var err error
+		if sourcePath, err = filepath.Abs(sourcePath); err != nil {
+			return err
+		}
+	}
+	destPath, err := filepath.Abs(entry.destPath)
+	if err != nil {
+		return err
+	}
+	var sortTime int64
+	if !entry.sortTime.IsZero() {
+		sortTime = entry.sortTime.UnixNano()
+	}
+	_, err = c.db.Exec(`INSERT OR REPLACE INTO files (`+catalogEntryColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		destPath, sourcePath, entry.size, entry.modTime.UnixNano(), entry.hash, entry.copiedAt.UnixNano(), c.run, sortTime, c.volume)
+	return err
+}
+
+// merge adds an entry from another catalog. An existing entry for the same destination path is
+// replaced only if the merged one was copied later. It returns whether the catalog changed.
+func (c *catalog) merge(entry catalogEntry) (bool, error) {
+	var sortTime int64
+	if !entry.sortTime.IsZero() {
+		sortTime = entry.sortTime.UnixNano()
+	}
+	result, err := c.db.Exec(`INSERT INTO files (`+catalogEntryColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (dest_path) DO UPDATE SET
+			source_path = excluded.source_path, size = excluded.size, mod_time = excluded.mod_time,
+			sha256 = excluded.sha256, copied_at = excluded.copied_at, run_id = excluded.run_id,
+			sort_time = excluded.sort_time, volume = excluded.volume
+		WHERE excluded.copied_at > files.copied_at`,
+		entry.destPath, entry.sourcePath, entry.size, entry.modTime.UnixNano(), entry.hash, entry.copiedAt.UnixNano(), c.run, sortTime,
+		entry.volume)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// get returns the entry for the destination path.
+func (c *catalog) get(destPath string) (catalogEntry, bool, error) {
+	rows, err := c.db.Query(`SELECT `+catalogEntryColumns+` FROM files WHERE dest_path = ?`, destPath)
+	if err != nil {
+		return catalogEntry{}, false, err
+	}
+	entries, err := scanEntries(rows)
+	if err != nil || len(entries) == 0 {
+		return catalogEntry{}, false, err
+	}
+	return entries[0], true, nil
+}
+
+// sourceHash returns the sha256 recorded for a copy of the source file if the file had the same
+// size and modification time when it was copied.
+func (c *catalog) sourceHash(sourcePath string, size int64, modTime time.Time) (string, bool, error) {
+	var hash string
+	err := c.db.QueryRow(`SELECT sha256 FROM files WHERE source_path = ? AND size = ? AND mod_time = ? LIMIT 1`,
+		sourcePath, size, modTime.UnixNano()).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	return hash, err == nil, err
+}
+
+// copiedSources calls copied for every file recorded with its source.
+func (c *catalog) copiedSources(copied func(sourcePath string, size int64, modTime int64, destPath string)) error {
+	rows, err := c.db.Query(`SELECT source_path, size, mod_time, dest_path FROM files WHERE source_path != ''`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sourcePath, destPath string
+		var size, modTime int64
+		if err := rows.Scan(&sourcePath, &size, &modTime, &destPath); err != nil {
+			return err
+		}
+		copied(sourcePath, size, modTime, destPath)
+	}
+	return rows.Err()
+}
+
+// sourceVolume returns the volume a copy of the source file was recorded on if the file had the
+// same size and modification time when it was copied.
+func (c *catalog) sourceVolume(sourcePath string, size int64, modTime time.Time) (string, bool, error) {
+	var volume string
+	err := c.db.QueryRow(`SELECT volume FROM files WHERE source_path = ? AND size = ? AND mod_time = ? AND volume != '' LIMIT 1`,
+		sourcePath, size, modTime.UnixNano()).Scan(&volume)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	return volume, err == nil, err
+}
+
+// volumeBytes returns the size of the files recorded on the volume.
+func (c *catalog) volumeBytes(volume string) (int64, error) {
+	var size int64
+	err := c.db.QueryRow(`SELECT COALESCE(SUM(size), 0) FROM files WHERE volume = ?`, volume).Scan(&size)
+	return size, err
+}
+
+// sameNameCopies returns the entries under the destination whose file has the name and the size.
+func (c *catalog) sameNameCopies(destination string, name string, size int64) ([]catalogEntry, error) {
+	root, err := filepath.Abs(destination)
+	if err != nil {
+		return nil, err
+	}
+	separator := string(filepath.Separator)
+	rows, err := c.db.Query(`SELECT `+catalogEntryColumns+` FROM files WHERE size = ? AND dest_path LIKE ? ESCAPE '\'`,
+		size, escapeLike(root+separator)+"%"+escapeLike(separator+name))
+	if err != nil {
+		return nil, err
+	}
+	entries, err := scanEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// LIKE ignores the case of the names
+	var copies []catalogEntry
+	for _, entry := range entries {
+		if filepath.Base(entry.destPath) == name {
+			copies = append(copies, entry)
+		}
+	}
+	return copies, nil
+}
+
+// recordAlias records a path at which a file was not copied since the same file had been copied
+// to the destination path before.
+func (c *catalog) recordAlias(aliasPath string, destPath string, sourcePath string) error {
+	aliasPath, err := filepath.Abs(aliasPath)
+	if err != nil {
+		return err
+	}
+	if sourcePath, err = filepath.Abs(sourcePath); err != nil {
+		return err
+	}
+	_, err = c.db.Exec(`INSERT OR REPLACE INTO aliases (alias_path, dest_path, source_path, run_id, created) VALUES (?, ?, ?, ?, ?)`,
+		aliasPath, destPath, sourcePath, c.run, time.Now().UnixNano())
+	return err
+}
+
+// scannedDir returns the summary of the source directory recorded by the last -incremental run
+// into the destinations.
+func (c *catalog) scannedDir(path string, destinations string) (*scannedDir, bool, error) {
+	var modTime int64
+	var subdirs string
+	err := c.db.QueryRow(`SELECT mod_time, subdirs FROM scanned_dirs WHERE path = ? AND destinations = ?`,
+		path, destinations).Scan(&modTime, &subdirs)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	dir := &scannedDir{modTime: modTime}
+	if subdirs != "" {
+		dir.subdirs = strings.Split(subdirs, "\n")
+	}
+	return dir, true, nil
+}
+
+func (c *catalog) recordScannedDir(path string, destinations string, dir *scannedDir) error {
+	_, err := c.db.Exec(`INSERT OR REPLACE INTO scanned_dirs (path, destinations, mod_time, subdirs) VALUES (?, ?, ?, ?)`,
+		path, destinations, dir.modTime, strings.Join(dir.subdirs, "\n"))
+	return err
+}
+
+// remove deletes the entry for the destination path.
+func (c *catalog) remove(destPath string) error {
+	_, err := c.db.Exec(`DELETE FROM files WHERE dest_path = ?`, destPath)
+	return err
+}
+
+// has reports whether there is an entry for the destination path.
+func (c *catalog) has(destPath string) (bool, error) {
+	var n int
+	err := c.db.QueryRow(`SELECT COUNT(*) FROM files WHERE dest_path = ?`, destPath).Scan(&n)
+	return n > 0, err
+}
+
+func (c *catalog) count() (int, error) {
+	var n int
+	err := c.db.QueryRow(`SELECT COUNT(*) FROM files`).Scan(&n)
+	return n, err
+}
+
+// randomEntries returns up to limit entries picked at random. A negative limit returns all the entries.
+func (c *catalog) randomEntries(limit int) ([]catalogEntry, error) {
+	rows, err := c.db.Query(`SELECT `+catalogEntryColumns+` FROM files ORDER BY RANDOM() LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanEntries(rows)
+}
+
+func scanEntries(rows *sql.Rows) ([]catalogEntry, error) {
+	defer rows.Close()
+
+	var entries []catalogEntry
+	for rows.Next() {
+		var entry catalogEntry
+		var modTime, copiedAt, sortTime int64
+		err := rows.Scan(&entry.destPath, &entry.sourcePath, &entry.size, &modTime, &entry.hash, &copiedAt,
+			&entry.run, &sortTime, &entry.volume)
+		if err != nil {
+			return nil, err
+		}
+		entry.modTime = time.Unix(0, modTime)
+		entry.copiedAt = time.Unix(0, copiedAt)
+		if sortTime != 0 {
+			entry.sortTime = time.Unix(0, sortTime)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// catalogFilter narrows down the entries returned by query. Zero values do not filter.
+type catalogFilter struct {
+	ext     string
+	year    int
+	minSize int64
+	maxSize int64
+	// name is matched as a substring of the source and the destination path
+	name string
+}
+
+// query returns the entries which match all the set filters ordered by their destination path.
+func (c *catalog) query(filter catalogFilter) ([]catalogEntry, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.ext != "" {
+		conditions = append(conditions, `LOWER(dest_path) LIKE ? ESCAPE '\'`)
+		args = append(args, "%."+escapeLike(strings.ToLower(strings.TrimPrefix(filter.ext, "."))))
+	}
+	if filter.year != 0 {
+		// the year is that of the date the file was sorted by, which is only the modified time for the
+		// entries recorded before the sort time was stored
+		start := time.Date(filter.year, time.January, 1, 0, 0, 0, 0, time.Local)
+		conditions = append(conditions, `(CASE WHEN sort_time != 0 THEN sort_time ELSE mod_time END) BETWEEN ? AND ?`)
+		args = append(args, start.UnixNano(), start.AddDate(1, 0, 0).UnixNano()-1)
+	}
+	if filter.minSize > 0 {
+		conditions = append(conditions, `size >= ?`)
+		args = append(args, filter.minSize)
+	}
+	if filter.maxSize > 0 {
+		conditions = append(conditions, `size <= ?`)
+		args = append(args, filter.maxSize)
+	}
+	if filter.name != "" {
+		conditions = append(conditions, `(source_path LIKE ? ESCAPE '\' OR dest_path LIKE ? ESCAPE '\')`)
+		pattern := "%" + escapeLike(filter.name) + "%"
+		args = append(args, pattern, pattern)
+	}
+
+	query := `SELECT ` + catalogEntryColumns + ` FROM files`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	rows, err := c.db.Query(query+` ORDER BY dest_path`, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanEntries(rows)
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern so that the value is matched literally.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// find returns the entries whose source or destination file name matches the glob pattern or
+// whose sha256 starts with the pattern.
+func (c *catalog) find(pattern string) ([]catalogEntry, error) {
+	// GLOB narrows the entries down in the database and the names are then matched exactly
+	query := `SELECT ` + catalogEntryColumns + ` FROM files WHERE source_path GLOB ? OR dest_path GLOB ?`
+	args := []interface{}{"*" + pattern, "*" + pattern}
+	if isHashPrefix(pattern) {
+		query += ` OR sha256 LIKE ?`
+		args = append(args, strings.ToLower(pattern)+"%")
+	}
+	rows, err := c.db.Query(query+` ORDER BY dest_path`, args...)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := scanEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []catalogEntry
+	for _, entry := range entries {
+		if matchesName(pattern, entry.sourcePath) || matchesName(pattern, entry.destPath) ||
+			(isHashPrefix(pattern) && strings.HasPrefix(entry.hash, strings.ToLower(pattern))) {
+			found = append(found, entry)
+		}
+	}
+	return found, nil
+}
+
+// isHashPrefix reports whether the pattern can be the start of a sha256. Fewer than 8 digits
+// would match too many files by chance.
+func isHashPrefix(pattern string) bool {
+	if len(pattern) < 8 || len(pattern) > 64 {
+		return false
+	}
+	return strings.Trim(strings.ToLower(pattern), "0123456789abcdef") == ""
+}
+
+func matchesName(pattern string, path string) bool {
+	matched, _ := filepath.Match(pattern, filepath.Base(path))
+	return matched
+}
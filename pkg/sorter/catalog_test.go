@@ -0,0 +1,61 @@
+package sorter
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQueryYear(t *testing.T) {
+	dir := t.TempDir()
+	cat, err := openCatalog(filepath.Join(dir, "catalog.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cat.Close()
+
+	// the photos were taken in 2019 and copied off the camera in 2021, which is their modified time
+	taken := time.Date(2019, time.August, 10, 0, 0, 0, 0, time.Local)
+	copied := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.Local)
+	entries := []catalogEntry{
+		{destPath: filepath.Join(dir, "2019", "August", "10", "IMG_0001.jpg"), modTime: copied, sortTime: taken},
+		{destPath: filepath.Join(dir, "2021", "March", "1", "IMG_0002.jpg"), modTime: copied, sortTime: copied},
+		// recorded before the sort time was stored
+		{destPath: filepath.Join(dir, "2019", "May", "2", "IMG_0003.jpg"), modTime: time.Date(2019, time.May, 2, 0, 0, 0, 0, time.Local)},
+		{destPath: filepath.Join(dir, "2019", "December", "31", "IMG_0004.jpg"), modTime: copied, sortTime: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.Local)},
+	}
+	for _, entry := range entries {
+		if err := cat.record(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		year int
+		want []string
+	}{
+		{2019, []string{"IMG_0001.jpg", "IMG_0003.jpg"}},
+		{2020, []string{"IMG_0004.jpg"}},
+		{2021, []string{"IMG_0002.jpg"}},
+	}
+	for _, test := range tests {
+		found, err := cat.query(catalogFilter{year: test.year})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, entry := range found {
+			got = append(got, filepath.Base(entry.destPath))
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("the query for %d found %v, want %v", test.year, got, test.want)
+			continue
+		}
+		for i := range test.want {
+			if got[i] != test.want[i] {
+				t.Errorf("the query for %d found %v, want %v", test.year, got, test.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package sorter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf16"
+)
+
+// cfbFile is a minimal reader for the compound file binary format used by the legacy office
+// formats (.doc, .xls, .ppt) and outlook messages (.msg). It only supports finding and reading
+// streams by name which is all that is needed to get to their metadata.
+type cfbFile struct {
+	file             *os.File
+	sectorSize       int64
+	miniSectorSize   int64
+	miniStreamCutoff int64
+	fat              []uint32
+	miniFAT          []uint32
+	entries          []cfbEntry
+}
+
+type cfbEntry struct {
+	name        string
+	entryType   byte
+	left        uint32
+	right       uint32
+	child       uint32
+	startSector uint32
+	size        int64
+}
+
+const (
+	cfbEndOfChain  = 0xFFFFFFFE
+	cfbStreamEntry = 2
+)
+
+var cfbSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+func (r *runIO) openCFB(path string) (*cfbFile, error) {
+
+	file, err := r.openSource(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfb, err := readCFB(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return cfb, nil
+}
+
+func readCFB(file *os.File) (*cfbFile, error) {
+
+	header := make([]byte, 512)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header[:8], cfbSignature) {
+		return nil, fmt.Errorf("The file %s is not a compound file", file.Name())
+	}
+
+	sectorShift := binary.LittleEndian.Uint16(header[0x1E:])
+	miniSectorShift := binary.LittleEndian.Uint16(header[0x20:])
+	if (sectorShift != 9 && sectorShift != 12) || miniSectorShift != 6 {
+		return nil, fmt.Errorf("The compound file %s has an unsupported sector size", file.Name())
+	}
+
+	cfb := &cfbFile{
+		file:             file,
+		sectorSize:       1 << sectorShift,
+		miniSectorSize:   1 << miniSectorShift,
+		miniStreamCutoff: int64(binary.LittleEndian.Uint32(header[0x38:])),
+	}
+
+	// the first 109 fat sectors are listed in the header and the rest in a chain of difat sectors
+	var fatSectors []uint32
+	for i := 0; i < 109; i++ {
+		fatSectors = append(fatSectors, binary.LittleEndian.Uint32(header[0x4C+i*4:]))
+	}
+	difatSector := binary.LittleEndian.Uint32(header[0x44:])
+	for n := binary.LittleEndian.Uint32(header[0x48:]); n > 0 && difatSector < cfbEndOfChain; n-- {
+		sector, err := cfb.readSector(difatSector)
+		if err != nil {
+			return nil, err
+		}
+		last := len(sector)/4 - 1
+		for i := 0; i < last; i++ {
+			fatSectors = append(fatSectors, binary.LittleEndian.Uint32(sector[i*4:]))
+		}
+		difatSector = binary.LittleEndian.Uint32(sector[last*4:])
+	}
+
+	fatSectorCount := int(binary.LittleEndian.Uint32(header[0x2C:]))
+	if fatSectorCount > len(fatSectors) {
+		return nil, fmt.Errorf("The compound file %s has a corrupted header", file.Name())
+	}
+	for _, fatSector := range fatSectors[:fatSectorCount] {
+		sector, err := cfb.readSector(fatSector)
+		if err != nil {
+			return nil, err
+		}
+		cfb.fat = append(cfb.fat, toUint32s(sector)...)
+	}
+
+	miniFAT, err := cfb.readChain(binary.LittleEndian.Uint32(header[0x3C:]), -1)
+	if err != nil {
+		return nil, err
+	}
+	cfb.miniFAT = toUint32s(miniFAT)
+
+	directory, err := cfb.readChain(binary.LittleEndian.Uint32(header[0x30:]), -1)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i+128 <= len(directory); i += 128 {
+		entry := directory[i : i+128]
+		nameLength := int(binary.LittleEndian.Uint16(entry[0x40:]))
+		if nameLength < 2 || nameLength > 64 {
+			cfb.entries = append(cfb.entries, cfbEntry{})
+			continue
+		}
+		name := make([]uint16, nameLength/2-1)
+		for j := range name {
+			name[j] = binary.LittleEndian.Uint16(entry[j*2:])
+		}
+		cfb.entries = append(cfb.entries, cfbEntry{
+			name:        string(utf16.Decode(name)),
+			entryType:   entry[0x42],
+			left:        binary.LittleEndian.Uint32(entry[0x44:]),
+			right:       binary.LittleEndian.Uint32(entry[0x48:]),
+			child:       binary.LittleEndian.Uint32(entry[0x4C:]),
+			startSector: binary.LittleEndian.Uint32(entry[0x74:]),
+			size:        int64(binary.LittleEndian.Uint32(entry[0x78:])),
+		})
+	}
+	if len(cfb.entries) == 0 {
+		return nil, fmt.Errorf("The compound file %s does not have a root entry", file.Name())
+	}
+
+	return cfb, nil
+}
+
+func (cfb *cfbFile) Close() error {
+	return cfb.file.Close()
+}
+
+// readStream returns the content of the stream with the given name from the root storage. Streams
+// with the same name in nested storages (like the attachments of a message) are not considered.
+func (cfb *cfbFile) readStream(name string) ([]byte, bool, error) {
+
+	// the children of a storage are kept in a tree linked through the left and right siblings
+	visited := make(map[uint32]bool)
+	pending := []uint32{cfb.entries[0].child}
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if int(id) >= len(cfb.entries) || visited[id] {
+			continue
+		}
+		visited[id] = true
+
+		entry := cfb.entries[id]
+		pending = append(pending, entry.left, entry.right)
+		if entry.entryType != cfbStreamEntry || entry.name != name {
+			continue
+		}
+		if entry.size >= cfb.miniStreamCutoff {
+			content, err := cfb.readChain(entry.startSector, entry.size)
+			return content, err == nil, err
+		}
+		content, err := cfb.readMiniChain(entry.startSector, entry.size)
+		return content, err == nil, err
+	}
+	return nil, false, nil
+}
+
+func (cfb *cfbFile) readSector(sector uint32) ([]byte, error) {
+	content := make([]byte, cfb.sectorSize)
+	_, err := cfb.file.ReadAt(content, (int64(sector)+1)*cfb.sectorSize)
+	return content, err
+}
+
+// readChain follows the fat from the start sector and returns size bytes. A negative size reads the whole chain.
+func (cfb *cfbFile) readChain(sector uint32, size int64) ([]byte, error) {
+	var content []byte
+	for sector < cfbEndOfChain && (size < 0 || int64(len(content)) < size) {
+		if int(sector) >= len(cfb.fat) || int64(len(content)) > int64(len(cfb.fat))*cfb.sectorSize {
+			return nil, fmt.Errorf("The compound file %s has a corrupted sector chain", cfb.file.Name())
+		}
+		data, err := cfb.readSector(sector)
+		if err != nil {
+			return nil, err
+		}
+		content = append(content, data...)
+		sector = cfb.fat[sector]
+	}
+	if size >= 0 && int64(len(content)) >= size {
+		content = content[:size]
+	}
+	return content, nil
+}
+
+// readMiniChain reads a stream smaller than the cutoff size from the mini stream held by the root entry.
+func (cfb *cfbFile) readMiniChain(sector uint32, size int64) ([]byte, error) {
+	root := cfb.entries[0]
+	miniStream, err := cfb.readChain(root.startSector, root.size)
+	if err != nil {
+		return nil, err
+	}
+
+	var content []byte
+	for sector < cfbEndOfChain && int64(len(content)) < size {
+		offset := int64(sector) * cfb.miniSectorSize
+		if int(sector) >= len(cfb.miniFAT) || offset+cfb.miniSectorSize > int64(len(miniStream)) {
+			return nil, fmt.Errorf("The compound file %s has a corrupted mini sector chain", cfb.file.Name())
+		}
+		content = append(content, miniStream[offset:offset+cfb.miniSectorSize]...)
+		sector = cfb.miniFAT[sector]
+	}
+	if int64(len(content)) >= size {
+		content = content[:size]
+	}
+	return content, nil
+}
+
+func toUint32s(content []byte) []uint32 {
+	values := make([]uint32, len(content)/4)
+	for i := range values {
+		values[i] = binary.LittleEndian.Uint32(content[i*4:])
+	}
+	return values
+}
@@ -0,0 +1,173 @@
+package sorter
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// clamdChunkSize is the size of the chunks a file is streamed to clamd in.
+const clamdChunkSize = 64 * 1024
+
+// clamdScanner scans the files with the clamd daemon of ClamAV before they are copied, over its
+// unix socket or a host:port. The files are streamed with the INSTREAM command so that clamd does
+// not need access to the source.
+type clamdScanner struct {
+	address    string
+	quarantine string
+	runIO      *runIO
+}
+
+// newClamdScanner checks that clamd answers before the run starts.
+func newClamdScanner(address string, quarantine string, runIO *runIO) (*clamdScanner, error) {
+	s := &clamdScanner{address: address, quarantine: quarantine, runIO: runIO}
+	conn, err := s.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("zPING\x00")); err != nil {
+		return nil, err
+	}
+	reply, err := readClamdReply(conn)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Compare(reply, "PONG") != 0 {
+		return nil, fmt.Errorf("clamd replied %s to PING", reply)
+	}
+	return s, nil
+}
+
+// dial connects to a path as a unix socket and to anything else with a : as a host:port.
+func (s *clamdScanner) dial() (net.Conn, error) {
+	network := "unix"
+	if !strings.Contains(s.address, string(filepath.Separator)) && strings.Contains(s.address, ":") {
+		network = "tcp"
+	}
+	return net.DialTimeout(network, s.address, 10*time.Second)
+}
+
+// scan streams the file to clamd and returns the name of the signature it matched or "" when it
+// is clean.
+func (s *clamdScanner) scan(path string) (string, error) {
+	file, err := s.runIO.openSource(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	conn, err := s.dial()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	writer := bufio.NewWriterSize(conn, clamdChunkSize+4)
+	if _, err := writer.WriteString("zINSTREAM\x00"); err != nil {
+		return "", err
+	}
+	reader := s.runIO.cancellable(file)
+	chunk := make([]byte, clamdChunkSize)
+	for {
+		n, err := reader.Read(chunk)
+		if n > 0 {
+			binary.Write(writer, binary.BigEndian, uint32(n))
+			if _, err := writer.Write(chunk[:n]); err != nil {
+				return "", err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	// a chunk of length 0 ends the stream
+	binary.Write(writer, binary.BigEndian, uint32(0))
+	if err := writer.Flush(); err != nil {
+		// clamd closes the connection when the file is larger than its StreamMaxLength
+		if reply, replyErr := readClamdReply(conn); replyErr == nil {
+			return "", fmt.Errorf("clamd replied %s", reply)
+		}
+		return "", err
+	}
+
+	reply, err := readClamdReply(conn)
+	if err != nil {
+		return "", err
+	}
+	// the replies are like "stream: OK" and "stream: Eicar-Signature FOUND"
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case strings.Compare(reply, "OK") == 0:
+		return "", nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return strings.TrimSuffix(reply, " FOUND"), nil
+	}
+	return "", fmt.Errorf("clamd replied %s", reply)
+}
+
+// readClamdReply reads a reply terminated by a null byte as asked for by the z prefix of the commands.
+func readClamdReply(conn net.Conn) (string, error) {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Minute))
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && len(reply) == 0 {
+		return "", err
+	}
+	return strings.TrimSpace(strings.TrimSuffix(reply, "\x00")), nil
+}
+
+// quarantineFile moves an infected file into the quarantine directory with -move, and copies it
+// there otherwise so that the source is left as it is. A file of the same name already there is
+// kept and the file gets a -1, -2... suffix.
+func (s *clamdScanner) quarantineFile(path string, move bool) (string, error) {
+	quarantined := filepath.Join(s.quarantine, filepath.Base(path))
+	if _, err := os.Lstat(quarantined); err == nil {
+		quarantined = freeName(quarantined)
+	}
+	if move {
+		return quarantined, moveFile(path, quarantined)
+	}
+	if _, _, err := s.runIO.copyFile(path, quarantined); err != nil {
+		return "", err
+	}
+	return quarantined, nil
+}
+
+// scanFile scans the file before it is copied. An infected file is not copied and is moved or
+// copied into the quarantine directory when there is one.
+func scanFile(path string, opts *sortOptions, counts *processedCount) (bool, error) {
+	signature, err := opts.virusScan.scan(path)
+	if err != nil {
+		opts.printer.Printf("An error occurred while trying to scan the file %s for viruses", path)
+		return false, err
+	}
+	if strings.Compare(signature, "") == 0 {
+		return false, nil
+	}
+	counts.infected = append(counts.infected, alertFile{Path: path, Detail: signature})
+	if opts.output != nil {
+		opts.output.file(path, "", "infected", 0, nil)
+	}
+	if strings.Compare(opts.virusScan.quarantine, "") == 0 {
+		opts.printer.Printf("Skipped %s, it is infected with %s\n", path, signature)
+		counts.skippedFiles++
+		return true, nil
+	}
+	quarantined, err := opts.virusScan.quarantineFile(path, opts.move)
+	if err != nil {
+		opts.printer.Printf("An error occurred while trying to quarantine the infected file %s", path)
+		return true, err
+	}
+	opts.printer.Printf("Quarantined %s --> %s, it is infected with %s\n", path, quarantined, signature)
+	counts.skippedFiles++
+	return true, nil
+}
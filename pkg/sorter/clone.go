@@ -0,0 +1,37 @@
+package sorter
+
+import "os"
+
+// copyMethod is how the data of a copy got to the destination.
+type copyMethod int
+
+const (
+	// copyWritten is a copy read and written by filesorter
+	copyWritten copyMethod = iota
+	// copyCloned is a clone which shares the data of the source on a copy on write file system
+	// like Btrfs, XFS or APFS until either one is modified
+	copyCloned
+	// copyInKernel is a copy made by the kernel or the file server without going through filesorter
+	copyInKernel
+)
+
+// cloneToSameDevice clones the source into the destination when both are on the same file system.
+// The destination file is replaced when the clone had to recreate it. The data is written as usual
+// when copyWritten is returned.
+func cloneToSameDevice(sourceFile *os.File, destFile *os.File) (*os.File, copyMethod) {
+	// an empty file has nothing to clone
+	sourceStat, err := sourceFile.Stat()
+	if err != nil || sourceStat.Size() == 0 {
+		return destFile, copyWritten
+	}
+	destStat, err := destFile.Stat()
+	if err != nil {
+		return destFile, copyWritten
+	}
+	sourceDevice, ok := deviceOf(sourceStat)
+	destDevice, destOk := deviceOf(destStat)
+	if !ok || !destOk || sourceDevice != destDevice {
+		return destFile, copyWritten
+	}
+	return cloneFile(sourceFile, destFile, sourceStat.Size())
+}
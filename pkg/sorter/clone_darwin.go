@@ -0,0 +1,28 @@
+//go:build darwin
+
+package sorter
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile clones the file with clonefile of APFS. clonefile creates the file itself, so the
+// empty destination file is removed first and created again when the clone is not supported.
+func cloneFile(sourceFile *os.File, destFile *os.File, size int64) (*os.File, copyMethod) {
+	path := destFile.Name()
+	if err := os.Remove(path); err != nil {
+		return destFile, copyWritten
+	}
+	if err := unix.Clonefile(sourceFile.Name(), path, unix.CLONE_NOFOLLOW); err == nil {
+		// the copy is closed and renamed by its path so the handle of the removed file still does
+		return destFile, copyCloned
+	}
+	destFile.Close()
+	recreated, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return destFile, copyWritten
+	}
+	return recreated, copyWritten
+}
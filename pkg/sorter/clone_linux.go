@@ -0,0 +1,29 @@
+//go:build linux
+
+package sorter
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile clones the file with the FICLONE ioctl of Btrfs and XFS. Where it is not supported
+// copy_file_range copies it in the kernel, which NFS and SMB servers can do on their own side.
+// A copy_file_range which fails part way is truncated again for the usual copy.
+func cloneFile(sourceFile *os.File, destFile *os.File, size int64) (*os.File, copyMethod) {
+	if unix.IoctlFileClone(int(destFile.Fd()), int(sourceFile.Fd())) == nil {
+		return destFile, copyCloned
+	}
+
+	// the offsets are passed so that the source is still read from its start for the checksum
+	var sourceOffset, destOffset int64
+	for sourceOffset < size {
+		n, err := unix.CopyFileRange(int(sourceFile.Fd()), &sourceOffset, int(destFile.Fd()), &destOffset, int(size-sourceOffset), 0)
+		if err != nil || n == 0 {
+			destFile.Truncate(0)
+			return destFile, copyWritten
+		}
+	}
+	return destFile, copyInKernel
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package sorter
+
+import "os"
+
+// cloneFile is not supported here so the copies are always written.
+func cloneFile(sourceFile *os.File, destFile *os.File, size int64) (*os.File, copyMethod) {
+	return destFile, copyWritten
+}
@@ -0,0 +1,47 @@
+package sorter
+
+import (
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+)
+
+// compareModes are the -compare values. By default a file of the same size at the destination is
+// taken to be the same file, while hash reads both files and compares their xxHash64.
+var compareModes = map[string]bool{"": true, "size": true, "hash": true}
+
+// sameContent tells whether the file at the destination has the same content as the source. The
+// sizes are compared first and the contents only with -compare hash.
+func sameContent(path string, sourceFileStat os.FileInfo, destFilePath string, destFileStat os.FileInfo, opts *sortOptions) (bool, error) {
+	if sourceFileStat.Size() != destFileStat.Size() {
+		return false, nil
+	}
+	if !opts.compareHash {
+		return true, nil
+	}
+
+	sourceHash, err := opts.runIO.hashFileWith(path, newXXH64())
+	if err != nil {
+		return false, err
+	}
+	destHash, err := opts.runIO.hashFileWith(destFilePath, newXXH64())
+	if err != nil {
+		return false, err
+	}
+	return sourceHash == destHash, nil
+}
+
+func (r *runIO) hashFileWith(path string, sum hash.Hash) (string, error) {
+
+	file, err := r.openSource(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(sum, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
@@ -0,0 +1,380 @@
+package sorter
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// conflictPolicies are the supported -on-conflict values. A conflict is a different file of the
+// same name at the destination which is neither resumed nor updated.
+var conflictPolicies = map[string]bool{"": true, "overwrite": true, "skip": true, "rename": true, "hash-suffix": true}
+
+// conflict is a file which was not copied by -on-conflict skip since a different file of the same
+// name was at its destination. It is kept in the conflicts file until 'filesorter resolve' decides
+// which of the two to keep.
+type conflict struct {
+	Source             string    `json:"source"`
+	SourceSize         int64     `json:"sourceSize"`
+	SourceModTime      time.Time `json:"sourceModTime"`
+	Destination        string    `json:"destination"`
+	DestinationSize    int64     `json:"destinationSize"`
+	DestinationModTime time.Time `json:"destinationModTime"`
+	// SortTime is the date the file was sorted by, which a resolved copy is recorded with
+	SortTime time.Time `json:"sortTime"`
+	Found    time.Time `json:"found"`
+}
+
+func newConflict(path string, sourceFileStat os.FileInfo, c *fileCopy) conflict {
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
+	destFilePath := c.destFilePath
+	if absPath, err := filepath.Abs(destFilePath); err == nil && c.dest.remote == nil {
+		destFilePath = absPath
+	}
+	return conflict{
+		Source:             path,
+		SourceSize:         sourceFileStat.Size(),
+		SourceModTime:      sourceFileStat.ModTime(),
+		Destination:        destFilePath,
+		DestinationSize:    c.destFileStat.Size(),
+		DestinationModTime: c.destFileStat.ModTime(),
+		SortTime:           c.sortTime,
+		Found:              time.Now(),
+	}
+}
+
+// writeConflicts adds the conflicts of the run to the ones of the earlier runs in the file. A
+// conflict found again replaces the earlier entry of the same source and destination.
+func writeConflicts(path string, found []conflict) error {
+	conflicts, err := readConflicts(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	index := make(map[string]int)
+	for i, c := range conflicts {
+		index[c.Source+"\x00"+c.Destination] = i
+	}
+	for _, c := range found {
+		if i, ok := index[c.Source+"\x00"+c.Destination]; ok {
+			conflicts[i] = c
+			continue
+		}
+		conflicts = append(conflicts, c)
+	}
+	return saveConflicts(path, conflicts)
+}
+
+func saveConflicts(path string, conflicts []conflict) error {
+	if conflicts == nil {
+		conflicts = []conflict{}
+	}
+	content, err := json.MarshalIndent(conflicts, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
+
+func readConflicts(path string) ([]conflict, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var conflicts []conflict
+	if err := json.Unmarshal(content, &conflicts); err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
+
+// resolveRules are the -rule values of resolve. Each one returns the resolution of a conflict.
+var resolveRules = map[string]func(c conflict, dest os.FileInfo) string{
+	"keep-destination": func(c conflict, dest os.FileInfo) string { return "destination" },
+	"keep-source":      func(c conflict, dest os.FileInfo) string { return "source" },
+	"keep-both":        func(c conflict, dest os.FileInfo) string { return "both" },
+	"newer": func(c conflict, dest os.FileInfo) string {
+		if c.SourceModTime.After(dest.ModTime()) {
+			return "source"
+		}
+		return "destination"
+	},
+	"larger": func(c conflict, dest os.FileInfo) string {
+		if c.SourceSize > dest.Size() {
+			return "source"
+		}
+		return "destination"
+	},
+}
+
+type resolveCount struct {
+	resolved  int
+	left      int
+	errored   int
+	settled   int
+	keptBoth  int
+	replaced  int
+	unchanged int
+}
+
+// runResolve goes through the conflicts left by -on-conflict skip. Each one is decided either by
+// asking on the terminal or by a rule, and the undecided ones stay in the file for later.
+func runResolve(args []string) int {
+
+	flags := flag.NewFlagSet("resolve", flag.ExitOnError)
+	rule := flags.String("rule", "", `Optional. Decide all the conflicts by a rule instead of asking for each one. One
+	of keep-destination, keep-source, keep-both (copy the source next to the destination file with
+	a -1, -2... suffix), newer or larger (keep the newer or the larger file)`)
+	catalogPath := flags.String("catalog", "", `Optional. The catalog of the destination in which the copies
+	of the sources are recorded`)
+	dryRun := flags.Bool("dry-run", false, "Optional. Only print how the conflicts would be resolved with -rule")
+	lang := AddLanguageFlag(flags)
+	parseFlags(flags, args)
+
+	if err := SetLanguage(*lang); err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	if flags.NArg() != 1 {
+		printer.Printf("Usage: filesorter resolve [-rule <rule>] [-catalog <catalog path>] [-dry-run] <conflicts path>\n")
+		flags.PrintDefaults()
+		return 1
+	}
+	decide, ok := resolveRules[*rule]
+	if strings.Compare(*rule, "") != 0 && !ok {
+		printer.Printf("The rule %s is not supported\n", *rule)
+		return 1
+	}
+	if *dryRun && decide == nil {
+		printer.Printf("The -dry-run option needs -rule\n")
+		return 1
+	}
+
+	conflictsPath := flags.Arg(0)
+	conflicts, err := readConflicts(conflictsPath)
+	if err != nil {
+		printer.Printf("An error occurred while trying to read the conflicts %s: %v\n", conflictsPath, err)
+		return 1
+	}
+
+	var cat *catalog
+	if strings.Compare(*catalogPath, "") != 0 && !*dryRun {
+		cat, err = openCatalog(*catalogPath)
+		if err != nil {
+			printer.Printf("An error occurred while trying to open the catalog %s: %v\n", *catalogPath, err)
+			return 1
+		}
+		defer cat.Close()
+	}
+
+	var counts resolveCount
+	var left []conflict
+	input := bufio.NewReader(os.Stdin)
+	quit := false
+	for _, c := range conflicts {
+		if quit {
+			left = append(left, c)
+			continue
+		}
+		resolved, err := resolveConflict(c, decide, input, cat, *dryRun, &counts)
+		if err == errCancelled {
+			quit = true
+		} else if err != nil {
+			printer.Printf("An error occurred while trying to resolve the conflict of %s and %s: %v\n", c.Source, c.Destination, err)
+			counts.errored++
+		}
+		if !resolved {
+			left = append(left, c)
+		}
+	}
+	counts.left = len(left)
+
+	if !*dryRun {
+		if err := saveConflicts(conflictsPath, left); err != nil {
+			printer.Printf("An error occurred while trying to write the conflicts %s: %v\n", conflictsPath, err)
+			return 1
+		}
+	}
+	printer.Printf("Resolved %d conflicts. Kept both %d, replaced %d destination files, kept %d. %d were gone or the same and %d are left\n",
+		counts.resolved, counts.keptBoth, counts.replaced, counts.unchanged, counts.settled, counts.left)
+	if counts.errored > 0 {
+		return 1
+	}
+	return 0
+}
+
+// resolveConflict decides the conflict and carries it out. A conflict whose source is gone or
+// whose destination now matches the source is resolved without asking. errCancelled stops resolve.
+func resolveConflict(c conflict, decide func(c conflict, dest os.FileInfo) string, input *bufio.Reader, cat *catalog, dryRun bool, counts *resolveCount) (bool, error) {
+
+	sourceStat, err := os.Stat(c.Source)
+	if os.IsNotExist(err) {
+		printer.Printf("The source %s is gone\n", c.Source)
+		counts.settled++
+		counts.resolved++
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	destStat, err := os.Stat(c.Destination)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	if destStat != nil && destStat.Size() == sourceStat.Size() {
+		same, err := sameContent(c.Source, sourceStat, c.Destination, destStat, &sortOptions{compareHash: true})
+		if err != nil {
+			return false, err
+		}
+		if same {
+			printer.Printf("The destination %s is the same as the source %s now\n", c.Destination, c.Source)
+			counts.settled++
+			counts.resolved++
+			return true, nil
+		}
+	}
+
+	var resolution string
+	switch {
+	case destStat == nil:
+		// the destination file was removed meanwhile so the source takes its place
+		resolution = "source"
+	case decide != nil:
+		resolution = decide(c, destStat)
+	default:
+		resolution, err = askResolution(c, sourceStat, destStat, input)
+		if err != nil || strings.Compare(resolution, "") == 0 {
+			return false, err
+		}
+	}
+
+	target := c.Destination
+	switch resolution {
+	case "destination":
+		if dryRun {
+			printer.Printf("Would keep %s and leave out %s\n", c.Destination, c.Source)
+		} else {
+			printer.Printf("Kept %s and left out %s\n", c.Destination, c.Source)
+		}
+		counts.unchanged++
+		counts.resolved++
+		return true, nil
+	case "both":
+		target = freeName(c.Destination)
+		counts.keptBoth++
+	default:
+		if destStat != nil {
+			counts.replaced++
+		}
+	}
+
+	if dryRun {
+		printer.Printf("Would copy %s --> %s\n", c.Source, target)
+		counts.resolved++
+		return true, nil
+	}
+	// the copy is renamed over the destination file once complete and gets the modified time of the source
+	written, hash, err := defaultIO.copyFile(c.Source, target)
+	if err != nil {
+		return false, err
+	}
+	if cat != nil {
+		err = cat.record(catalogEntry{
+			sourcePath: c.Source,
+			destPath:   target,
+			size:       written,
+			modTime:    sourceStat.ModTime(),
+			hash:       hash,
+			copiedAt:   time.Now(),
+			sortTime:   c.SortTime,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	printer.Printf("Copied %s --> %s\n", c.Source, target)
+	counts.resolved++
+	return true, nil
+}
+
+// askResolution shows both files and asks which to keep. It returns an empty resolution to leave
+// the conflict for later and errCancelled to stop.
+func askResolution(c conflict, sourceStat os.FileInfo, destStat os.FileInfo, input *bufio.Reader) (string, error) {
+	printer.Printf("\n%s\n", c.Destination)
+	printer.Printf("  destination: %d bytes, modified %s\n", destStat.Size(), destStat.ModTime().Format(time.RFC3339))
+	printer.Printf("  source %s: %d bytes, modified %s\n", c.Source, sourceStat.Size(), sourceStat.ModTime().Format(time.RFC3339))
+	for {
+		printer.Printf("Keep the [d]estination, replace it with the [s]ource, keep [b]oth, decide [l]ater or [q]uit? ")
+		answer, err := input.ReadString('\n')
+		if err != nil && strings.Compare(answer, "") == 0 {
+			// the input ended, like when stdin is not a terminal
+			return "", errCancelled
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "d":
+			return "destination", nil
+		case "s":
+			return "source", nil
+		case "b":
+			return "both", nil
+		case "l":
+			return "", nil
+		case "q":
+			return "", errCancelled
+		}
+	}
+}
+
+// freeName returns the path with a -1, -2... suffix before the extension which is not taken yet.
+// conflictName returns the name a file is copied under by -on-conflict rename or hash-suffix when a
+// different file is at destFilePath. rename appends -1, -2... to the name and hash-suffix the first
+// 8 characters of the sha256 of the file, followed by -1, -2... in the unlikely case that is taken
+// too. same is set when the file was copied under the name by an earlier run already.
+func conflictName(path string, sourceFileStat os.FileInfo, destFilePath string, opts *sortOptions) (string, bool, error) {
+
+	ext := filepath.Ext(destFilePath)
+	base := strings.TrimSuffix(destFilePath, ext)
+	first := 1
+	if strings.Compare(opts.onConflict, "hash-suffix") == 0 {
+		hash, err := opts.runIO.hashFile(path)
+		if err != nil {
+			return "", false, err
+		}
+		base, first = base+"-"+hash[:8], 0
+	}
+	for i := first; ; i++ {
+		candidate := base + ext
+		if i > 0 {
+			candidate = base + "-" + strconv.Itoa(i) + ext
+		}
+		candidateStat, comparePath, err := statPlanned(candidate, opts)
+		if os.IsNotExist(err) {
+			return candidate, false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		same, err := sameContent(path, sourceFileStat, comparePath, candidateStat, opts)
+		if err != nil || same {
+			return candidate, same, err
+		}
+	}
+}
+
+func freeName(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := base + "-" + strconv.Itoa(i) + ext
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
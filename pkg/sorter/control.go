@@ -0,0 +1,100 @@
+package sorter
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// errCancelled stops the walk when the run was cancelled.
+var errCancelled = errors.New("the run was cancelled")
+
+// runControl pauses and cancels a run between files. It is driven from other goroutines like the
+// ones serving the control socket.
+type runControl struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	paused    bool
+	cancelled bool
+	// onChange is called with paused, resumed or cancelled whenever the state changes. It must
+	// not call back into the control.
+	onChange func(state string)
+}
+
+func newRunControl() *runControl {
+	c := &runControl{}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *runControl) pause() {
+	c.set(func() bool {
+		changed := !c.paused && !c.cancelled
+		c.paused = true
+		return changed
+	}, "paused")
+}
+
+func (c *runControl) resume() {
+	c.set(func() bool {
+		changed := c.paused && !c.cancelled
+		c.paused = false
+		return changed
+	}, "resumed")
+}
+
+func (c *runControl) cancel() {
+	c.set(func() bool {
+		changed := !c.cancelled
+		c.cancelled = true
+		return changed
+	}, "cancelled")
+}
+
+// set updates the state and notifies about the change before the run can continue, so that for
+// example the cancelled event is sent before the run completes.
+func (c *runControl) set(update func() bool, state string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if update() && c.onChange != nil {
+		c.onChange(state)
+	}
+	c.cond.Broadcast()
+}
+
+// isCancelled returns whether the run was cancelled without waiting while it is paused.
+func (c *runControl) isCancelled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cancelled
+}
+
+// cancellable stops reading with errCancelled once the run is cancelled.
+func (r *runIO) cancellable(reader io.Reader) io.Reader {
+	if r == nil || r.control == nil {
+		return reader
+	}
+	return &cancellableReader{reader: reader, control: r.control}
+}
+
+type cancellableReader struct {
+	reader  io.Reader
+	control *runControl
+}
+
+func (r *cancellableReader) Read(p []byte) (int, error) {
+	if r.control.isCancelled() {
+		return 0, errCancelled
+	}
+	return r.reader.Read(p)
+}
+
+// wait blocks while the run is paused and returns whether it was cancelled.
+func (c *runControl) wait() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.paused && !c.cancelled {
+		c.cond.Wait()
+	}
+	return c.cancelled
+}
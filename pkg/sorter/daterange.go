@@ -0,0 +1,45 @@
+package sorter
+
+import (
+	"strings"
+	"time"
+)
+
+// dateRange limits a run to the files with a sort date inside a window, like to sort a single year
+// of an archive. after is inclusive and before exclusive so that -after 2023-01-01 -before
+// 2024-01-01 is the whole of 2023. Either one can be zero.
+type dateRange struct {
+	after  time.Time
+	before time.Time
+}
+
+// newDateRange parses the bounds, which are dates like 2023-01-01 or 2023-01 in the local time
+// zone. It returns nil when neither is passed.
+func newDateRange(after string, before string, printer *outputPrinter) (*dateRange, error) {
+	if strings.Compare(after, "") == 0 && strings.Compare(before, "") == 0 {
+		return nil, nil
+	}
+	var r dateRange
+	var err error
+	if strings.Compare(after, "") != 0 {
+		if r.after, err = parseISODate(after); err != nil {
+			return nil, printer.errorf("The date %s of -after is not valid. Use a date like 2023-01-01\n", after)
+		}
+	}
+	if strings.Compare(before, "") != 0 {
+		if r.before, err = parseISODate(before); err != nil {
+			return nil, printer.errorf("The date %s of -before is not valid. Use a date like 2023-01-01\n", before)
+		}
+	}
+	if !r.after.IsZero() && !r.before.IsZero() && !r.after.Before(r.before) {
+		return nil, printer.errorf("The date %s of -after is not before the date %s of -before\n", after, before)
+	}
+	return &r, nil
+}
+
+func (r *dateRange) contains(date time.Time) bool {
+	if !r.after.IsZero() && date.Before(r.after) {
+		return false
+	}
+	return r.before.IsZero() || date.Before(r.before)
+}
@@ -0,0 +1,134 @@
+package sorter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateExtractor reads the date at which a file was created from its content. ok is false
+// if the file is not of a type which the extractor understands or it does not carry a date.
+type dateExtractor func(r *runIO, path string) (date time.Time, ok bool, err error)
+
+var dateExtractors = map[string]dateExtractor{
+	"exif":     (*runIO).exifDate,
+	"pdf":      (*runIO).pdfDate,
+	"office":   (*runIO).officeDate,
+	"email":    (*runIO).emailDate,
+	"filename": (*runIO).fileNameDate,
+	"video":    (*runIO).videoDate,
+}
+
+// dates embedded in the names given by cameras and apps like IMG_20200502_101112.jpg,
+// IMG-20200502-WA0001.jpg or Screenshot 2020-05-02 at 10.11.12.png
+var fileNameDatePattern = regexp.MustCompile(`(?:^|\D)((?:19|20)\d{2})-?(0[1-9]|1[0-2])-?(0[1-9]|[12]\d|3[01])(?:[_\- ]?(?:at )?([01]\d|2[0-3])[.\-:]?([0-5]\d)[.\-:]?([0-5]\d))?(?:\D|$)`)
+
+// fileNameDate reads the date from the file name. The time is used too if it follows the date.
+func (r *runIO) fileNameDate(path string) (time.Time, bool, error) {
+	match := fileNameDatePattern.FindStringSubmatch(filepath.Base(path))
+	if match == nil {
+		return time.Time{}, false, nil
+	}
+
+	fields := make([]int, 6)
+	for i := range fields {
+		fields[i], _ = strconv.Atoi(match[i+1])
+	}
+	date := time.Date(fields[0], time.Month(fields[1]), fields[2], fields[3], fields[4], fields[5], 0, time.Local)
+
+	// reject dates like February 31 which time.Date would silently normalize
+	if date.Day() != fields[2] {
+		return time.Time{}, false, nil
+	}
+	return date, true, nil
+}
+
+// parseDateSources validates the comma separated -date-source value.
+func parseDateSources(value string, printer *outputPrinter) ([]string, error) {
+	var sources []string
+	for _, source := range strings.Split(value, ",") {
+		source = strings.TrimSpace(source)
+		if _, ok := dateExtractors[source]; !ok && strings.Compare(source, "mtime") != 0 {
+			return nil, printer.errorf("The date source %s is not supported\n", source)
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// getSortTime returns the date from the first source which has one for the file. The modified
+// time is used if none of the sources have a date.
+func (r *runIO) getSortTime(path string, fileInfo os.FileInfo, sources []string) time.Time {
+	for _, source := range sources {
+		if strings.Compare(source, "mtime") == 0 {
+			break
+		}
+		date, ok, err := dateExtractors[source](r, path)
+		if err != nil {
+			r.printer.Printf("An error occurred while trying to read the %s date of the file %s: %v\n", source, path, err)
+			continue
+		}
+		if ok {
+			return date
+		}
+	}
+	return fileInfo.ModTime()
+}
+
+// readHeadAndTail returns up to size bytes from the start and the end of the file. Most formats keep
+// their metadata at one of the ends so this avoids reading huge files completely.
+func (r *runIO) readHeadAndTail(path string, size int64) ([]byte, []byte, error) {
+
+	file, err := r.openSource(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if fileInfo.Size() <= 2*size {
+		head, err := io.ReadAll(file)
+		return head, nil, err
+	}
+
+	head := make([]byte, size)
+	if _, err := io.ReadFull(file, head); err != nil {
+		return nil, nil, err
+	}
+	tail := make([]byte, size)
+	if _, err := file.ReadAt(tail, fileInfo.Size()-size); err != nil {
+		return nil, nil, err
+	}
+	return head, tail, nil
+}
+
+// isoDateLayouts are the variations of ISO 8601 used in XMP and other xml based metadata.
+var isoDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// parseISODate parses the date using the local time zone when the value does not specify one.
+func parseISODate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range isoDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("The date %s is not in a known format", value)
+}
@@ -0,0 +1,171 @@
+package sorter
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/karrick/godirwalk"
+)
+
+// dedupGroup is a set of source files with the same content.
+type dedupGroup struct {
+	size  int64
+	paths []string
+}
+
+// runEstimateDedup reports how much space would be saved if the files of the source with the same
+// content were stored once, by hardlinks or content addressed storage, before those are enabled.
+func runEstimateDedup(args []string) int {
+
+	flags := flag.NewFlagSet("estimate-dedup", flag.ExitOnError)
+	sourcePath := flags.String("source", "", "The source directory path,")
+	catalogPath := flags.String("catalog", "", `Optional. A catalog database written by previous sort runs. The hashes of
+	the source files which were copied before and have not changed since are read from it instead of
+	hashing the files again`)
+	top := flags.Int("top", 10, "Optional. The number of groups of duplicates saving the most space to list")
+	lang := AddLanguageFlag(flags)
+	parseFlags(flags, args)
+
+	if err := SetLanguage(*lang); err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	if strings.Compare(*sourcePath, "") == 0 {
+		printer.Printf("Usage: filesorter estimate-dedup -source <source path> [-catalog <catalog path>] [-top <groups>]\n")
+		flags.PrintDefaults()
+		return 1
+	}
+	if !isPathValid(sourcePath, printer) {
+		return 1
+	}
+
+	var cat *catalog
+	if strings.Compare(*catalogPath, "") != 0 {
+		var err error
+		cat, err = openCatalog(*catalogPath)
+		if err != nil {
+			printer.Printf("An error occurred while trying to open the catalog %s: %v\n", *catalogPath, err)
+			return 1
+		}
+		defer cat.Close()
+	}
+
+	// only the files sharing their size with another file can be duplicates so the others are not hashed
+	bySize := make(map[int64][]string)
+	var scannedFiles, erroredFiles int
+	var scannedBytes int64
+	godirwalk.Walk(*sourcePath, &godirwalk.Options{
+		Unsorted: false,
+		Callback: func(path string, dirent *godirwalk.Dirent) error {
+			if !dirent.IsRegular() {
+				return nil
+			}
+			stat, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			scannedFiles++
+			scannedBytes += stat.Size()
+			bySize[stat.Size()] = append(bySize[stat.Size()], path)
+			return nil
+		},
+		ErrorCallback: func(path string, err error) godirwalk.ErrorAction {
+			printer.Printf("An error occurred while trying to read %s: %v\n", path, err)
+			erroredFiles++
+			return godirwalk.SkipNode
+		},
+	})
+
+	var groups []dedupGroup
+	var hashedFiles, cachedFiles int
+	for size, paths := range bySize {
+		// empty files take no space to begin with
+		if len(paths) < 2 || size == 0 {
+			continue
+		}
+		byHash := make(map[string][]string)
+		for _, path := range paths {
+			hash, cached, err := sourceHash(cat, path)
+			if err != nil {
+				printer.Printf("An error occurred while trying to hash the file %s: %v\n", path, err)
+				erroredFiles++
+				continue
+			}
+			if cached {
+				cachedFiles++
+			} else {
+				hashedFiles++
+			}
+			byHash[hash] = append(byHash[hash], path)
+		}
+		for _, same := range byHash {
+			if len(same) > 1 {
+				groups = append(groups, dedupGroup{size: size, paths: same})
+			}
+		}
+	}
+
+	var duplicateFiles int
+	var savedBytes int64
+	for _, group := range groups {
+		duplicateFiles += len(group.paths) - 1
+		savedBytes += group.size * int64(len(group.paths)-1)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].size*int64(len(groups[i].paths)-1) > groups[j].size*int64(len(groups[j].paths)-1)
+	})
+
+	for i, group := range groups {
+		if i >= *top {
+			break
+		}
+		printer.Printf("%d copies of %d bytes:\n", len(group.paths), group.size)
+		sort.Strings(group.paths)
+		for _, path := range group.paths {
+			printer.Printf("  %s\n", path)
+		}
+	}
+
+	printer.Printf("Completed !\n")
+	printer.Printf("Scanned %d files of %d bytes. Hashed %d, Read from the catalog %d, Errored %d\n",
+		scannedFiles, scannedBytes, hashedFiles, cachedFiles, erroredFiles)
+	percent := 0.0
+	if scannedBytes > 0 {
+		percent = float64(savedBytes) * 100 / float64(scannedBytes)
+	}
+	printer.Printf("%d files are duplicates in %d groups. Deduplicating them would save %d bytes (%.1f%%)\n",
+		duplicateFiles, len(groups), savedBytes, percent)
+
+	if erroredFiles > 0 {
+		return 1
+	}
+	return 0
+}
+
+// sourceHash returns the sha256 of the source file. It is read from the catalog when the file was
+// copied before and its size and modification time are unchanged, otherwise the file is hashed.
+func sourceHash(cat *catalog, path string) (string, bool, error) {
+	if cat != nil {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return "", false, err
+		}
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return "", false, err
+		}
+		hash, ok, err := cat.sourceHash(absPath, stat.Size(), stat.ModTime())
+		if err != nil {
+			return "", false, err
+		}
+		if ok {
+			return hash, true, nil
+		}
+	}
+	hash, err := defaultIO.hashFile(path)
+	return hash, false, err
+}
@@ -0,0 +1,213 @@
+package sorter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"math"
+	"os"
+)
+
+const (
+	minDeltaBlockSize = 2 * 1024
+	maxDeltaBlockSize = 128 * 1024
+	// pending literal data is written out once it grows beyond this so that memory use stays bounded
+	maxDeltaLiteral = 4 * 1024 * 1024
+)
+
+type blockSignature struct {
+	offset int64
+	strong [sha256.Size]byte
+}
+
+// deltaCopy updates an older version of the file at the destination in place so that it matches the
+// source, similar to rsync --inplace. The destination is split into blocks which are indexed by a
+// rolling checksum. The source is then scanned with the same checksum and the blocks found in it are
+// reused from the destination. A block which is already at the right offset is not written at all,
+// so files which were appended to or modified in a few places are updated with very few writes.
+// Like copyFile it returns the number of bytes written and the sha256 of the source.
+func (r *runIO) deltaCopy(source string, destination string) (int64, string, error) {
+
+	sourceFile, err := r.openSource(source)
+	if err != nil {
+		return 0, "", err
+	}
+	defer sourceFile.Close()
+
+	destFile, err := openForRewrite(destination)
+	if err != nil {
+		return 0, "", err
+	}
+	defer destFile.Close()
+
+	blockSize, signatures, err := readBlockSignatures(destFile)
+	if err != nil {
+		return 0, "", err
+	}
+
+	hash := sha256.New()
+	reader := io.TeeReader(sourceFile, hash)
+
+	// buf holds the source data which is not written yet. buf[0] belongs at offset out of the
+	// destination and the rolling checksum window starts at buf[pos].
+	var buf []byte
+	var out, written int64
+	pos := 0
+	eof := false
+	rolling := false
+	var a, b uint32
+
+	writeLiteral := func(n int) error {
+		if n == 0 {
+			return nil
+		}
+		if _, err := destFile.WriteAt(buf[:n], out); err != nil {
+			return err
+		}
+		written += int64(n)
+		out += int64(n)
+		buf = buf[n:]
+		pos -= n
+		return nil
+	}
+
+	chunk := make([]byte, maxDeltaBlockSize)
+	for {
+		// the window and the byte after it are needed to roll the checksum
+		for !eof && len(buf)-pos <= blockSize {
+			n, err := reader.Read(chunk)
+			buf = append(buf, chunk[:n]...)
+			if err == io.EOF {
+				eof = true
+			} else if err != nil {
+				return written, "", err
+			}
+		}
+		if len(buf)-pos < blockSize {
+			break
+		}
+
+		window := buf[pos : pos+blockSize]
+		if !rolling {
+			a, b = weakChecksum(window)
+			rolling = true
+		}
+
+		if offset, ok := findBlock(signatures, a|b<<16, window, out+int64(pos)); ok {
+			if err := writeLiteral(pos); err != nil {
+				return written, "", err
+			}
+			if offset != out {
+				// the block moved so it is read from its old offset before it gets overwritten
+				block := make([]byte, blockSize)
+				if _, err := destFile.ReadAt(block, offset); err != nil {
+					return written, "", err
+				}
+				if _, err := destFile.WriteAt(block, out); err != nil {
+					return written, "", err
+				}
+				written += int64(blockSize)
+			}
+			out += int64(blockSize)
+			buf = buf[blockSize:]
+			pos = 0
+			rolling = false
+			continue
+		}
+
+		if len(buf)-pos == blockSize {
+			break
+		}
+		a, b = rollChecksum(a, b, buf[pos], buf[pos+blockSize], blockSize)
+		pos++
+
+		if pos >= maxDeltaLiteral {
+			if err := writeLiteral(pos); err != nil {
+				return written, "", err
+			}
+		}
+	}
+
+	if err := writeLiteral(len(buf)); err != nil {
+		return written, "", err
+	}
+	if err := destFile.Truncate(out); err != nil {
+		return written, "", err
+	}
+	return written, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// readBlockSignatures splits the file into blocks and indexes them by their weak checksum.
+func readBlockSignatures(file *os.File) (int, map[uint32][]blockSignature, error) {
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// like rsync the block size grows with the square root of the file size
+	blockSize := int(math.Sqrt(float64(fileInfo.Size())))
+	if blockSize < minDeltaBlockSize {
+		blockSize = minDeltaBlockSize
+	}
+	if blockSize > maxDeltaBlockSize {
+		blockSize = maxDeltaBlockSize
+	}
+
+	signatures := make(map[uint32][]blockSignature)
+	block := make([]byte, blockSize)
+	for offset := int64(0); ; offset += int64(blockSize) {
+		// a partial block at the end is never matched
+		if _, err := io.ReadFull(file, block); err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return 0, nil, err
+		}
+		a, b := weakChecksum(block)
+		weak := a | b<<16
+		signatures[weak] = append(signatures[weak], blockSignature{offset: offset, strong: sha256.Sum256(block)})
+	}
+	return blockSize, signatures, nil
+}
+
+// findBlock returns the offset of a destination block with the same content as the window. Only
+// blocks at or after the output offset are considered since the ones before it may have already
+// been overwritten. A block already at the output offset is preferred since it needs no write.
+func findBlock(signatures map[uint32][]blockSignature, weak uint32, window []byte, out int64) (int64, bool) {
+	candidates, ok := signatures[weak]
+	if !ok {
+		return 0, false
+	}
+
+	strong := sha256.Sum256(window)
+	found := int64(-1)
+	for _, candidate := range candidates {
+		if candidate.offset < out || candidate.strong != strong {
+			continue
+		}
+		if candidate.offset == out {
+			return out, true
+		}
+		if found < 0 {
+			found = candidate.offset
+		}
+	}
+	return found, found >= 0
+}
+
+// weakChecksum is the rsync rolling checksum of the block split into its two 16 bit halves.
+func weakChecksum(block []byte) (uint32, uint32) {
+	var a, b uint32
+	for i, c := range block {
+		a += uint32(c)
+		b += uint32(len(block)-i) * uint32(c)
+	}
+	return a & 0xFFFF, b & 0xFFFF
+}
+
+// rollChecksum moves the checksum window one byte forward by removing out and adding in.
+func rollChecksum(a uint32, b uint32, out byte, in byte, blockSize int) (uint32, uint32) {
+	a = (a - uint32(out) + uint32(in)) & 0xFFFF
+	b = (b - uint32(blockSize)*uint32(out) + a) & 0xFFFF
+	return a, b
+}
@@ -0,0 +1,205 @@
+package sorter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// destination is one of the directories the files are copied and sorted into. Every destination
+// decides on its own whether a file needs to be copied and keeps its own counts.
+type destination struct {
+	path   string
+	counts destinationCount
+	// tiered is set for the destinations passed with -tier. A file is copied to only one of those,
+	// the first whose newerThan it is sorted after. A zero newerThan takes the files of any age.
+	tiered    bool
+	newerThan time.Time
+	// remote is set for the s3:// and sftp:// destinations, whose path is their url
+	remote remoteTarget
+	// maxFileSize is the largest file the destination can hold, like 4 GB on FAT32, and 0 when
+	// there is no limit. fileSystem is the name of the file system with the limit
+	maxFileSize int64
+	fileSystem  string
+}
+
+type destinationCount struct {
+	copiedFiles      int
+	skippedFiles     int
+	erroredFiles     int
+	resumedFiles     int
+	totalBytesCopied int64
+}
+
+// parseTier parses a tier like 6m=/mnt/ssd/photos into a destination. A tier without an age like
+// /mnt/hdd/photos takes the files of any age.
+func parseTier(tier string, now time.Time, printer *outputPrinter) (*destination, error) {
+	dest := &destination{path: tier, tiered: true}
+	if i := strings.Index(tier, "="); i >= 0 {
+		newerThan, err := parseAge(tier[:i], now, printer)
+		if err != nil {
+			return nil, err
+		}
+		dest.path, dest.newerThan = tier[i+1:], newerThan
+	}
+	return dest, nil
+}
+
+// routeDestinations returns the destinations a file sorted by the time is copied to. It is every
+// destination passed with -destination and the first matching tier.
+func routeDestinations(destinations []*destination, sortTime time.Time) []*destination {
+	var routed []*destination
+	tiered := false
+	for _, dest := range destinations {
+		if dest.tiered {
+			if tiered || (!dest.newerThan.IsZero() && !sortTime.After(dest.newerThan)) {
+				continue
+			}
+			tiered = true
+		}
+		routed = append(routed, dest)
+	}
+	return routed
+}
+
+// destinationsInSource returns the destinations which are inside the source, as the paths under
+// which the walk of the source reaches them, so that an archive is not sorted into itself again.
+func destinationsInSource(sourcePath string, destinations []*destination) map[string]struct{} {
+	inSource := make(map[string]struct{})
+	source, err := resolvePath(sourcePath)
+	if err != nil {
+		return inSource
+	}
+	for _, dest := range destinations {
+		destPath, err := resolvePath(dest.path)
+		if err != nil {
+			continue
+		}
+		relativePath, err := filepath.Rel(source, destPath)
+		if err != nil || relativePath == "." || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+			continue
+		}
+		inSource[filepath.Join(sourcePath, relativePath)] = struct{}{}
+	}
+	return inSource
+}
+
+// resolvePath returns the absolute path with the symlinks resolved.
+func resolvePath(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(absPath)
+}
+
+// errSameFile is returned instead of truncating the source when the destination file is the source
+// itself, like through a symlink, a hardlink or a bind mount.
+var errSameFile = errors.New("the destination is the source file itself")
+
+var errAllWritesFailed = errors.New("the writes to all the destinations failed")
+
+// fanoutWriter writes to all of its writers. Unlike io.MultiWriter a writer which fails is dropped
+// and the rest continue so that one failing destination does not stop the copy to the others.
+type fanoutWriter struct {
+	writers []io.Writer
+	errs    []error
+}
+
+func (w *fanoutWriter) Write(p []byte) (int, error) {
+	ok := false
+	for i, writer := range w.writers {
+		if w.errs[i] != nil {
+			continue
+		}
+		if _, err := writer.Write(p); err != nil {
+			w.errs[i] = err
+			continue
+		}
+		ok = true
+	}
+	if !ok {
+		return 0, errAllWritesFailed
+	}
+	return len(p), nil
+}
+
+// copyFileToAll copies the source to all the destinations reading the source only once. It returns
+// the number of bytes written, the sha256 of the content and how and with which error every
+// destination was copied. err is set only if the source itself could not be read. A destination
+// on the file system of the source is cloned where supported and the source is then only read
+// for the checksum. With -salvage unreadable is the number of bytes of the source which could not be
+// read and were written as zeros.
+func (r *runIO) copyFileToAll(source string, destinations []string) (written int64, hash string, unreadable int64, methods []copyMethod, errs []error, err error) {
+
+	errs = make([]error, len(destinations))
+	methods = make([]copyMethod, len(destinations))
+	salvage := r != nil && r.salvage
+
+	sourceFile, err := r.openSource(source)
+	if err != nil {
+		return 0, "", 0, methods, errs, err
+	}
+	defer sourceFile.Close()
+	sourceStat, err := sourceFile.Stat()
+	if err != nil {
+		return 0, "", 0, methods, errs, err
+	}
+
+	fanout := &fanoutWriter{writers: make([]io.Writer, len(destinations)), errs: errs}
+	destFiles := make([]*os.File, len(destinations))
+	for i, destination := range destinations {
+		destFile, err := r.stageDestination(sourceFile, destination)
+		if err != nil {
+			errs[i] = err
+			fanout.writers[i] = io.Discard
+			continue
+		}
+		destFiles[i], fanout.writers[i] = destFile, destFile
+		// a clone of a salvaged file would not have the zeros in place of what could not be read
+		if !salvage {
+			destFiles[i], methods[i] = cloneToSameDevice(sourceFile, destFile)
+			fanout.writers[i] = destFiles[i]
+		}
+		if methods[i] != copyWritten {
+			fanout.writers[i] = io.Discard
+		}
+	}
+
+	var reader io.Reader = sourceFile
+	var salvaged *salvageReader
+	if salvage {
+		salvaged = newSalvageReader(sourceFile, sourceStat.Size(), r.printer)
+		reader = salvaged
+	}
+	sha := sha256.New()
+	written, err = io.Copy(io.MultiWriter(fanout, sha), r.cancellable(r.throttle(reader)))
+	if salvaged != nil {
+		unreadable = salvaged.unreadable
+	}
+	// a copy is renamed into place only if the whole source was read
+	for i, destFile := range destFiles {
+		if destFile == nil {
+			continue
+		}
+		copyErr := errs[i]
+		if copyErr == nil && err != nil && err != errAllWritesFailed {
+			copyErr = err
+		}
+		if copyErr = r.unstage(destFile, destinations[i], sourceStat.ModTime(), copyErr); errs[i] == nil && err == nil {
+			errs[i] = copyErr
+		}
+	}
+	if err == errAllWritesFailed {
+		return written, "", unreadable, methods, errs, nil
+	}
+	if err != nil {
+		return written, "", unreadable, methods, errs, err
+	}
+	return written, hex.EncodeToString(sha.Sum(nil)), unreadable, methods, errs, nil
+}
@@ -0,0 +1,458 @@
+package sorter
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karrick/godirwalk"
+)
+
+// burstFolder matches the folders of -bursts like burst-153059.
+var burstFolder = regexp.MustCompile(`^burst-\d{6}$`)
+
+// layoutToken is a part of a folder name, either a date field like Year or the literal text
+// between them. padded tells whether a month or day number was written with a leading zero, and
+// is unknown for numbers of two digits like 11.
+type layoutToken struct {
+	field  string
+	text   string
+	padded int
+}
+
+const (
+	paddingUnknown = iota
+	paddingYes
+	paddingNo
+)
+
+// detectedLayout is the layout shared by some of the files of the archive.
+type detectedLayout struct {
+	// folders are the tokens of every folder above the files
+	folders [][]layoutToken
+	files   int
+	example string
+	// freeText is set when a folder has text like an event name which a layout can not produce
+	freeText    bool
+	screenshots bool
+	bursts      bool
+	// padded and unpadded count the numbers written with and without a leading zero by the
+	// position of the token, like 1/0 for the first token of the second folder
+	padded   map[string]int
+	unpadded map[string]int
+}
+
+// runDetectLayout looks at the folders of an existing archive and suggests the -layout which
+// sorts new files the same way, to continue a tree sorted by hand or by another tool.
+func runDetectLayout(args []string) int {
+
+	flags := flag.NewFlagSet("detect-layout", flag.ExitOnError)
+	destPath := flags.String("destination", "", "The archive whose layout is detected")
+	sample := flags.Int("sample", 10000, "Optional. The number of files looked at")
+	lang := AddLanguageFlag(flags)
+	parseFlags(flags, args)
+
+	if err := SetLanguage(*lang); err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	if strings.Compare(*destPath, "") == 0 || flags.NArg() != 0 {
+		printer.Printf("Usage: filesorter detect-layout -destination <destination path> [-sample <files>]\n")
+		flags.PrintDefaults()
+		return 1
+	}
+	if !isPathValid(destPath, printer) {
+		return 1
+	}
+
+	layouts := make(map[string]*detectedLayout)
+	files := 0
+	audio, ebooks := 0, 0
+	godirwalk.Walk(*destPath, &godirwalk.Options{
+		Unsorted: false,
+		Callback: func(path string, dirent *godirwalk.Dirent) error {
+			// hidden files, like a staging directory or the catalog next to the files, are left out
+			if strings.HasPrefix(dirent.Name(), ".") && filepath.Clean(path) != *destPath {
+				if dirent.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			// the files with an invalid date are not sorted by any layout
+			if dirent.IsDir() && strings.Compare(filepath.Dir(path), *destPath) == 0 && strings.Compare(dirent.Name(), defaultUnsortedFolder) == 0 {
+				return filepath.SkipDir
+			}
+			if dirent.IsDir() || strings.HasSuffix(dirent.Name(), tempSuffix) {
+				return nil
+			}
+			if files >= *sample {
+				return filepath.SkipDir
+			}
+			relativePath, err := filepath.Rel(*destPath, path)
+			if err != nil {
+				return nil
+			}
+			files++
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".mp3", ".flac", ".m4a", ".m4b", ".ogg", ".oga", ".opus":
+				audio++
+			case ".epub", ".pdf":
+				ebooks++
+			}
+			addLayoutSample(layouts, relativePath)
+			return nil
+		},
+		ErrorCallback: func(path string, err error) godirwalk.ErrorAction {
+			printer.Printf("An error occurred while trying to read %s: %v\n", path, err)
+			return godirwalk.SkipNode
+		},
+	})
+
+	if files == 0 {
+		printer.Printf("There are no files in %s to detect the layout from\n", *destPath)
+		return 1
+	}
+	printer.Printf("Looked at %d files in %s\n", files, *destPath)
+
+	var found []*detectedLayout
+	for _, l := range layouts {
+		found = append(found, l)
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].files != found[j].files {
+			return found[i].files > found[j].files
+		}
+		return found[i].example < found[j].example
+	})
+
+	best := found[0]
+	share := best.files * 100 / files
+	if !hasDateField(best) {
+		// an archive of music or ebooks is sorted by the tags which are not in the path
+		switch {
+		case audio*2 > files:
+			printer.Printf("The folders of %d%% of the files have no dates and most files are music, like %s\n", share, best.example)
+			printer.Printf("Suggested options: -scheme music\n")
+		case ebooks*2 > files:
+			printer.Printf("The folders of %d%% of the files have no dates and most files are ebooks, like %s\n", share, best.example)
+			printer.Printf("Suggested options: -scheme ebook\n")
+		default:
+			printer.Printf("No dates were found in the folders of %d%% of the files, like %s. The layout could not be detected\n", share, best.example)
+			return 1
+		}
+		return 0
+	}
+
+	layout := best.template()
+	printer.Printf("%d%% of the files are sorted like %s\n", share, best.example)
+	if best.freeText {
+		printer.Printf("The folders have text besides the date, like the name of an event, which a layout can not produce. The files would be sorted into the folders of the date without it\n")
+	}
+	var suggested []string
+	if strings.Compare(layout, defaultDateLayout) != 0 {
+		suggested = append(suggested, "-layout '"+layout+"'")
+	}
+	if best.screenshots {
+		suggested = append(suggested, "-screenshots")
+	}
+	if best.bursts {
+		suggested = append(suggested, "-bursts")
+	}
+	if len(suggested) == 0 {
+		printer.Printf("This is the default layout of filesorter and needs no options\n")
+	} else {
+		printer.Printf("Suggested options: %s\n", strings.Join(suggested, " "))
+		if strings.Compare(layout, defaultDateLayout) != 0 {
+			printer.Printf("In a profile of the config file:\n    layout: '%s'\n", layout)
+		}
+	}
+	if !best.freeText && !layoutReproduces(layout, best.example) {
+		printer.Printf("The layout does not reproduce the path of %s exactly, check it with -dry-run first\n", best.example)
+	}
+
+	// the other layouts point at files sorted by hand or by an older layout
+	for _, l := range found[1:] {
+		if l.files*100/files < 1 {
+			break
+		}
+		printer.Printf("  %d%% of the files are sorted differently, like %s\n", l.files*100/files, l.example)
+	}
+	return 0
+}
+
+// defaultDateLayout is the template which produces the folders of the files sorted without -layout.
+const defaultDateLayout = "{{.Year}}/{{.Month}}/{{.Day}}/{{.File}}"
+
+// addLayoutSample adds a file of the archive to the layout its folders follow.
+func addLayoutSample(layouts map[string]*detectedLayout, relativePath string) {
+	folders := strings.Split(filepath.Dir(relativePath), string(filepath.Separator))
+	if len(folders) == 1 && strings.Compare(folders[0], ".") == 0 {
+		folders = nil
+	}
+
+	sample := &detectedLayout{example: relativePath}
+	if len(folders) > 0 && strings.Compare(folders[0], screenshotsFolder) == 0 {
+		sample.screenshots = true
+		folders = folders[1:]
+	}
+	folders, sample.bursts = trimSubFolders(folders)
+
+	var key []string
+	seen := make(map[string]bool)
+	for _, folder := range folders {
+		tokens, freeText := parseLayoutFolder(folder, seen)
+		sample.folders = append(sample.folders, tokens)
+		sample.freeText = sample.freeText || freeText
+		key = append(key, layoutKey(tokens))
+	}
+
+	l, ok := layouts[strings.Join(key, "/")]
+	if !ok {
+		l = sample
+		l.padded, l.unpadded = make(map[string]int), make(map[string]int)
+		layouts[strings.Join(key, "/")] = l
+	}
+	// a layout with and without screenshots or bursts is the same one
+	l.screenshots = l.screenshots || sample.screenshots
+	l.bursts = l.bursts || sample.bursts
+	l.files++
+	for i, tokens := range sample.folders {
+		for j, token := range tokens {
+			position := fmt.Sprintf("%d/%d", i, j)
+			switch token.padded {
+			case paddingYes:
+				l.padded[position]++
+			case paddingNo:
+				l.unpadded[position]++
+			}
+		}
+	}
+}
+
+// parseLayoutFolder splits a folder name into the date fields and the text between them. The
+// fields are expected in the order year, month and day, and seen tracks them across the folders
+// of a path. A year starts the order again. freeText is set for words in the name which are not a month.
+func parseLayoutFolder(folder string, seen map[string]bool) ([]layoutToken, bool) {
+	var tokens []layoutToken
+	freeText := false
+	for _, run := range splitRuns(folder) {
+		token := layoutToken{text: run}
+		switch {
+		case isDigits(run):
+			token = dateNumberToken(run, seen)
+			if strings.Compare(token.field, "") == 0 && len(run) == 8 {
+				// a date like 20200502
+				if _, err := time.Parse("20060102", run); err == nil {
+					seen["Year"], seen["MonthNum"], seen["Day"] = true, true, true
+					tokens = append(tokens,
+						layoutToken{field: "Year", text: run[:4]},
+						layoutToken{field: "MonthNum", text: run[4:6], padded: paddingYes},
+						layoutToken{field: "Day", text: run[6:], padded: paddingYes})
+					continue
+				}
+			}
+			if strings.Compare(token.field, "") == 0 {
+				freeText = true
+			}
+		case isLetters(run):
+			if month, short := monthName(run); month && seen["Year"] && !seen["Month"] {
+				seen["Month"] = true
+				token.field = "Month"
+				if short {
+					token.field = "MonthShort"
+				}
+			} else {
+				freeText = true
+			}
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, freeText
+}
+
+// dateNumberToken reads a number of a folder name as the next date field it can be.
+func dateNumberToken(run string, seen map[string]bool) layoutToken {
+	token := layoutToken{text: run}
+	n, err := strconv.Atoi(run)
+	if err != nil {
+		return token
+	}
+	padded := paddingUnknown
+	if len(run) == 1 {
+		padded = paddingNo
+	} else if len(run) == 2 && run[0] == '0' {
+		padded = paddingYes
+	}
+	switch {
+	case len(run) == 4 && n >= 1900 && n <= 2100:
+		// the year may be repeated like in 2020/2020-05-02 and the month and day follow it again
+		token.field = "Year"
+		seen["Month"], seen["Day"] = false, false
+	case len(run) <= 2 && n >= 1 && n <= 12 && seen["Year"] && !seen["Month"]:
+		token.field, token.padded = "MonthNum", padded
+		seen["Month"] = true
+	case len(run) <= 2 && n >= 1 && n <= 31 && seen["Month"] && !seen["Day"]:
+		token.field, token.padded = "Day", padded
+	}
+	if strings.Compare(token.field, "") != 0 {
+		seen[token.field] = true
+	}
+	return token
+}
+
+// layoutKey identifies the fields of a folder and the separators between them. Free text is
+// left out so that folders like 2020-05-02 Birthday and 2020-05-03 Beach have the same key.
+func layoutKey(tokens []layoutToken) string {
+	var key strings.Builder
+	for _, token := range tokens {
+		switch {
+		case strings.Compare(token.field, "") != 0:
+			key.WriteString("{" + token.field + "}")
+		case !isDigits(token.text) && !isLetters(token.text):
+			key.WriteString(token.text)
+		default:
+			key.WriteString("*")
+		}
+	}
+	return key.String()
+}
+
+// template returns the -layout of the files with this layout. Free text is left out since it
+// differs from folder to folder.
+func (l *detectedLayout) template() string {
+	var folders []string
+	for i, tokens := range l.folders {
+		var folder strings.Builder
+		for j, token := range tokens {
+			position := fmt.Sprintf("%d/%d", i, j)
+			switch token.field {
+			case "Year":
+				folder.WriteString("{{.Year}}")
+			case "Month":
+				folder.WriteString("{{.Month}}")
+			case "MonthShort":
+				folder.WriteString(`{{printf "%.3s" .Month}}`)
+			case "MonthNum", "Day":
+				if l.padded[position] >= l.unpadded[position] {
+					folder.WriteString(`{{printf "%02d" .` + token.field + `}}`)
+				} else {
+					folder.WriteString("{{." + token.field + "}}")
+				}
+			default:
+				if !isDigits(token.text) && !isLetters(token.text) {
+					folder.WriteString(token.text)
+				}
+			}
+		}
+		text := strings.TrimSpace(folder.String())
+		if strings.Compare(text, "") != 0 {
+			folders = append(folders, text)
+		}
+	}
+	return strings.Join(append(folders, "{{.File}}"), "/")
+}
+
+func hasDateField(l *detectedLayout) bool {
+	for _, tokens := range l.folders {
+		for _, token := range tokens {
+			if strings.Compare(token.field, "") != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// layoutReproduces renders the layout with the date read from the folders of the example and
+// compares it with the path of the example.
+func layoutReproduces(layout string, example string) bool {
+	tmpl, err := parseLayout(layout, dateLayoutFields{}, printer)
+	if err != nil {
+		return false
+	}
+	folders := strings.Split(filepath.Dir(example), string(filepath.Separator))
+	if len(folders) > 0 && strings.Compare(folders[0], screenshotsFolder) == 0 {
+		folders = folders[1:]
+	}
+	folders, _ = trimSubFolders(folders)
+	year, month, day := 0, 1, 1
+	seen := make(map[string]bool)
+	for _, folder := range folders {
+		tokens, _ := parseLayoutFolder(folder, seen)
+		for _, token := range tokens {
+			switch token.field {
+			case "Year":
+				year, _ = strconv.Atoi(token.text)
+			case "MonthNum":
+				month, _ = strconv.Atoi(token.text)
+			case "Day":
+				day, _ = strconv.Atoi(token.text)
+			case "Month", "MonthShort":
+				for m := time.January; m <= time.December; m++ {
+					if strings.HasPrefix(m.String(), token.text) {
+						month = int(m)
+					}
+				}
+			}
+		}
+	}
+	sortTime := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
+	relativePath, err := renderLayout(tmpl, dateFields(filepath.Base(example), sortTime))
+	if err != nil {
+		return false
+	}
+	return strings.Compare(relativePath, strings.Join(append(folders, filepath.Base(example)), string(filepath.Separator))) == 0
+}
+
+// splitRuns splits a name into runs of digits, of letters and of the other characters.
+func splitRuns(name string) []string {
+	var runs []string
+	kind := func(r rune) int {
+		switch {
+		case r >= '0' && r <= '9':
+			return 1
+		case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+			return 2
+		}
+		return 3
+	}
+	start := 0
+	for i, r := range name {
+		if i > start && kind(r) != kind(rune(name[start])) {
+			runs = append(runs, name[start:i])
+			start = i
+		}
+	}
+	if start < len(name) {
+		runs = append(runs, name[start:])
+	}
+	return runs
+}
+
+func isDigits(s string) bool {
+	return len(s) > 0 && strings.Trim(s, "0123456789") == ""
+}
+
+func isLetters(s string) bool {
+	return len(s) > 0 && strings.IndexFunc(s, func(r rune) bool { return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') }) < 0
+}
+
+// monthName reports whether the word is the English name of a month as written by the layouts,
+// like May, or its first three letters like Jan.
+func monthName(word string) (bool, bool) {
+	for m := time.January; m <= time.December; m++ {
+		if strings.Compare(word, m.String()) == 0 {
+			return true, false
+		}
+		if strings.Compare(word, m.String()[:3]) == 0 {
+			return true, true
+		}
+	}
+	return false, false
+}
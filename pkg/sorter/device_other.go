@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd
+
+package sorter
+
+import "os"
+
+// deviceOf is not supported here so a move always tries the rename first.
+func deviceOf(fileInfo os.FileInfo) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,17 @@
+//go:build linux || darwin || freebsd
+
+package sorter
+
+import (
+	"os"
+	"syscall"
+)
+
+// deviceOf returns the id of the device holding the file.
+func deviceOf(fileInfo os.FileInfo) (uint64, bool) {
+	stat, ok := fileInfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Dev), true
+}
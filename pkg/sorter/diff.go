@@ -0,0 +1,101 @@
+package sorter
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/karrick/godirwalk"
+)
+
+// diff collects the differences between the source and the destinations for 'filesorter diff'.
+type diff struct {
+	// mapped holds the destination paths which the source files are sorted into
+	mapped            map[string]struct{}
+	onlyInSource      int
+	onlyInDestination int
+	differentFiles    int
+	sameFiles         int
+}
+
+// diffFile compares the source file with the file it would be copied to at every destination it is
+// routed to. Like
+// sort it considers the files to be the same if their sizes match.
+func diffFile(path string, sourceFileStat os.FileInfo, relativePath string, destinations []*destination, opts *sortOptions) error {
+
+	for _, dest := range destinations {
+		destFilePath := filepath.Join(dest.path, relativePath)
+		if opts.buckets != nil {
+			var err error
+			if destFilePath, err = opts.buckets.place(destFilePath); err != nil {
+				opts.printer.Printf("An error occurred while trying to read the destination folder of the file %s", path)
+				return err
+			}
+		}
+		opts.diff.mapped[destFilePath] = struct{}{}
+
+		destFileStat, err := opts.destEntries.stat(destFilePath)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				opts.printer.Printf("An error occurred while trying to stat the file %s", destFilePath)
+				return err
+			}
+			opts.printer.Printf("Only in source: %s --> %s\n", path, destFilePath)
+			opts.diff.onlyInSource++
+			continue
+		}
+
+		if sourceFileStat.Size() != destFileStat.Size() {
+			opts.printer.Printf("Differs: %s --> %s (%d and %d bytes)\n", path, destFilePath, sourceFileStat.Size(), destFileStat.Size())
+			opts.diff.differentFiles++
+			continue
+		}
+		same, err := sameContent(path, sourceFileStat, destFilePath, destFileStat, opts)
+		if err != nil {
+			opts.printer.Printf("An error occurred while trying to compare the file %s with %s", path, destFilePath)
+			return err
+		}
+		if !same {
+			opts.printer.Printf("Differs: %s --> %s (same size, different content)\n", path, destFilePath)
+			opts.diff.differentFiles++
+			continue
+		}
+		opts.diff.sameFiles++
+	}
+	return nil
+}
+
+// diffDestinations reports the files at the destinations which no source file is sorted into.
+func diffDestinations(opts *sortOptions, counts *processedCount) {
+	for _, dest := range opts.destinations {
+		godirwalk.Walk(dest.path, &godirwalk.Options{
+			Unsorted: false,
+			Callback: func(path string, dirent *godirwalk.Dirent) error {
+				if dirent.IsDir() {
+					return nil
+				}
+				if _, ok := opts.diff.mapped[filepath.Clean(path)]; !ok {
+					opts.printer.Printf("Only in destination: %s\n", path)
+					opts.diff.onlyInDestination++
+				}
+				return nil
+			},
+			ErrorCallback: func(path string, err error) godirwalk.ErrorAction {
+				counts.erroredFiles++
+				counts.errors = append(counts.errors, newFileError(path, err))
+				return godirwalk.SkipNode
+			},
+		})
+	}
+}
+
+func printDiffReport(opts *sortOptions, counts *processedCount) {
+	d := opts.diff
+	opts.printer.Printf("Completed !\n")
+	opts.printer.Printf("Only in source %d, Only in destination %d, Differ %d, Same %d. Skipped %d, Errored %d\n",
+		d.onlyInSource,
+		d.onlyInDestination,
+		d.differentFiles,
+		d.sameFiles,
+		counts.skippedFiles,
+		counts.erroredFiles)
+}
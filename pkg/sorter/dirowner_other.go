@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !freebsd
+
+package sorter
+
+import (
+	"fmt"
+	"os"
+)
+
+const dirOwnerSupported = false
+
+func chownLike(path string, like os.FileInfo) error {
+	return fmt.Errorf("preserving the owner is not supported on this platform")
+}
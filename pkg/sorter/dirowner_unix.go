@@ -0,0 +1,19 @@
+//go:build linux || darwin || freebsd
+
+package sorter
+
+import (
+	"os"
+	"syscall"
+)
+
+const dirOwnerSupported = true
+
+// chownLike gives the file the owner and group of another one.
+func chownLike(path string, like os.FileInfo) error {
+	stat, ok := like.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	return os.Lchown(path, int(stat.Uid), int(stat.Gid))
+}
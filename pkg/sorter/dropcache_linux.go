@@ -0,0 +1,17 @@
+//go:build linux && (amd64 || arm64)
+
+package sorter
+
+import (
+	"os"
+	"syscall"
+)
+
+// posixFadvDontNeed is POSIX_FADV_DONTNEED, which the syscall package does not define.
+const posixFadvDontNeed = 4
+
+// dropPageCache asks the kernel to drop the cached pages of the file. The file has to be synced
+// before since dirty pages are not dropped.
+func dropPageCache(file *os.File) {
+	syscall.Syscall6(syscall.SYS_FADVISE64, file.Fd(), 0, 0, posixFadvDontNeed, 0, 0)
+}
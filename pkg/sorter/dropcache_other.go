@@ -0,0 +1,9 @@
+//go:build !linux || !(amd64 || arm64)
+
+package sorter
+
+import "os"
+
+// dropPageCache is not supported here so the copy may be read back from the page cache.
+func dropPageCache(file *os.File) {
+}
@@ -0,0 +1,93 @@
+package sorter
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ebookMetadata is the metadata read from the package document of an epub or the metadata of a pdf.
+type ebookMetadata struct {
+	author string
+	title  string
+	year   int
+}
+
+// readEbookMetadata reads the metadata of epub and pdf files. ok is false if the file is not one of those.
+func (r *runIO) readEbookMetadata(path string) (ebookMetadata, bool, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".epub":
+		metadata, err := r.readEPUBMetadata(path)
+		return metadata, err == nil, err
+	case ".pdf":
+		metadata, err := r.readPDFMetadata(path)
+		return metadata, err == nil, err
+	}
+	return ebookMetadata{}, false, nil
+}
+
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type epubPackage struct {
+	Creators []string `xml:"metadata>creator"`
+	Titles   []string `xml:"metadata>title"`
+	Dates    []string `xml:"metadata>date"`
+}
+
+// readEPUBMetadata reads the dublin core metadata from the package document which the container points to.
+func (r *runIO) readEPUBMetadata(epubPath string) (ebookMetadata, error) {
+
+	archive, source, err := r.openSourceZip(epubPath)
+	if err != nil {
+		return ebookMetadata{}, err
+	}
+	defer source.Close()
+
+	var container epubContainer
+	if err := decodeZipXML(archive, "META-INF/container.xml", &container); err != nil {
+		return ebookMetadata{}, err
+	}
+	if len(container.Rootfiles) == 0 {
+		return ebookMetadata{}, fmt.Errorf("The epub %s does not have a package document", epubPath)
+	}
+
+	var pkg epubPackage
+	if err := decodeZipXML(archive, path.Clean(container.Rootfiles[0].FullPath), &pkg); err != nil {
+		return ebookMetadata{}, err
+	}
+
+	var metadata ebookMetadata
+	if len(pkg.Creators) > 0 {
+		metadata.author = strings.TrimSpace(pkg.Creators[0])
+	}
+	if len(pkg.Titles) > 0 {
+		metadata.title = strings.TrimSpace(pkg.Titles[0])
+	}
+	if len(pkg.Dates) > 0 {
+		metadata.year = leadingNumber(strings.TrimSpace(pkg.Dates[0]))
+	}
+	return metadata, nil
+}
+
+func decodeZipXML(archive *zip.Reader, name string, value interface{}) error {
+	for _, file := range archive.File {
+		if file.Name != name {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		return xml.NewDecoder(io.LimitReader(reader, 4*1024*1024)).Decode(value)
+	}
+	return fmt.Errorf("The file %s was not found in the archive", name)
+}
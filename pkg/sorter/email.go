@@ -0,0 +1,89 @@
+package sorter
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net/mail"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// property ids of the sent and received times of an outlook message
+	prClientSubmitTime    = 0x0039
+	prMessageDeliveryTime = 0x0E06
+	ptSysTime             = 0x0040
+
+	// the top level property stream of a message has a 32 byte header before the 16 byte property entries
+	msgPropertiesHeaderSize = 32
+	msgPropertyEntrySize    = 16
+)
+
+// emailDate reads the date at which a message was sent from exported .eml and outlook .msg files
+// so that mail archives are sorted by the message date rather than the export date.
+func (r *runIO) emailDate(path string) (time.Time, bool, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".eml":
+		return r.emlDate(path)
+	case ".msg":
+		return r.msgDate(path)
+	}
+	return time.Time{}, false, nil
+}
+
+func (r *runIO) emlDate(path string) (time.Time, bool, error) {
+
+	file, err := r.openSource(path)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	defer file.Close()
+
+	// only the header is parsed, the body is never read
+	message, err := mail.ReadMessage(bufio.NewReader(file))
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if strings.Compare(message.Header.Get("Date"), "") == 0 {
+		return time.Time{}, false, nil
+	}
+
+	date, err := message.Header.Date()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return date, true, nil
+}
+
+func (r *runIO) msgDate(path string) (time.Time, bool, error) {
+
+	cfb, err := r.openCFB(path)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	defer cfb.Close()
+
+	stream, ok, err := cfb.readStream("__properties_version1.0")
+	if err != nil || !ok {
+		return time.Time{}, false, err
+	}
+
+	dates := make(map[uint16]time.Time)
+	for offset := msgPropertiesHeaderSize; offset+msgPropertyEntrySize <= len(stream); offset += msgPropertyEntrySize {
+		tag := binary.LittleEndian.Uint32(stream[offset:])
+		if tag&0xFFFF != ptSysTime {
+			continue
+		}
+		if date, ok := fileTimeToTime(binary.LittleEndian.Uint64(stream[offset+8:])); ok {
+			dates[uint16(tag>>16)] = date
+		}
+	}
+
+	for _, id := range []uint16{prClientSubmitTime, prMessageDeliveryTime} {
+		if date, ok := dates[id]; ok {
+			return date, true, nil
+		}
+	}
+	return time.Time{}, false, nil
+}
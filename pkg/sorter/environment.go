@@ -0,0 +1,52 @@
+package sorter
+
+import (
+	"flag"
+	"os"
+	"strings"
+)
+
+// envPrefix is the prefix of the environment variables which set the options, like
+// FILESORTER_WATCH_SETTLE for -watch-settle.
+const envPrefix = "FILESORTER_"
+
+// ApplyEnvironment sets the options of the flag set which were not passed explicitly from their
+// FILESORTER_ environment variables and adds them to explicit. A value with several lines sets a
+// repeatable option like -destination once for every line.
+func ApplyEnvironment(flags *flag.FlagSet, explicit map[string]bool) error {
+	var err error
+	flags.VisitAll(func(f *flag.Flag) {
+		if err != nil || explicit[f.Name] {
+			return
+		}
+		name := envPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return
+		}
+		for _, line := range strings.Split(strings.TrimRight(value, "\n"), "\n") {
+			if setErr := flags.Set(f.Name, strings.TrimSpace(line)); setErr != nil {
+				err = errorf("The value of %s is not valid: %v\n", name, setErr)
+				return
+			}
+		}
+		explicit[f.Name] = true
+	})
+	return err
+}
+
+// parseFlags parses the arguments of a command and then applies the environment to the options
+// which were not passed.
+func parseFlags(flags *flag.FlagSet, args []string) {
+	flags.Parse(args)
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	// the errors of the environment are shown in its language, before -lang is applied
+	SetLanguage("")
+	if err := ApplyEnvironment(flags, explicit); err != nil {
+		printer.Println(err)
+		os.Exit(2)
+	}
+}
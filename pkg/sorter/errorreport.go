@@ -0,0 +1,45 @@
+package sorter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// fileError is an entry of the error report. The report lists the files which could not be
+// processed so that a later run can retry just those using -retry-from.
+type fileError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+func newFileError(path string, err error) fileError {
+	// the report may be used from a different working directory
+	if absPath, absErr := filepath.Abs(path); absErr == nil {
+		path = absPath
+	}
+	return fileError{Path: path, Error: err.Error()}
+}
+
+func writeErrorReport(path string, errors []fileError) error {
+	if errors == nil {
+		errors = []fileError{}
+	}
+	content, err := json.MarshalIndent(errors, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
+
+func readErrorReport(path string) ([]fileError, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var errors []fileError
+	if err := json.Unmarshal(content, &errors); err != nil {
+		return nil, err
+	}
+	return errors, nil
+}
@@ -0,0 +1,384 @@
+package sorter
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	jpegTypes = map[string]struct{}{
+		".jpg": {}, ".jpeg": {}, ".jpe": {},
+	}
+	// the raw formats of most cameras are tiff files, some of them with their own magic number
+	tiffTypes = map[string]struct{}{
+		".tif": {}, ".tiff": {}, ".dng": {}, ".nef": {}, ".nrw": {}, ".arw": {}, ".srf": {}, ".sr2": {},
+		".cr2": {}, ".orf": {}, ".rw2": {}, ".pef": {}, ".srw": {}, ".3fr": {}, ".iiq": {},
+	}
+	heifTypes = map[string]struct{}{
+		".heic": {}, ".heif": {}, ".avif": {},
+	}
+)
+
+const (
+	// tags of the exif metadata
+	tagExifIFD            = 0x8769
+	tagDateTimeOriginal   = 0x9003
+	tagSubSecTimeOriginal = 0x9291
+	exifDateLayout        = "2006:01:02 15:04:05"
+	// limits against corrupt files
+	maxIFDEntries   = 1000
+	maxHEIFMetaSize = 16 << 20
+)
+
+var errInvalidExif = errors.New("the exif metadata is not valid")
+
+var errInvalidBox = errors.New("the boxes of the file are not valid")
+
+// exifDate reads the DateTimeOriginal of photos, the time the shutter was pressed. The modified
+// time of photos is often that of a download or a restore from a backup. Like the dates in the
+// file names it is the time of the camera's clock without a time zone.
+func (r *runIO) exifDate(path string) (time.Time, bool, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	_, isJPEG := jpegTypes[ext]
+	_, isTIFF := tiffTypes[ext]
+	_, isHEIF := heifTypes[ext]
+	if !isJPEG && !isTIFF && !isHEIF {
+		return time.Time{}, false, nil
+	}
+
+	file, err := r.openSource(path)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	defer file.Close()
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	var tiff *io.SectionReader
+	switch {
+	case isJPEG:
+		tiff, err = jpegExif(file)
+	case isTIFF:
+		tiff = io.NewSectionReader(file, 0, fileInfo.Size())
+	case isHEIF:
+		tiff, err = heifExif(file, fileInfo.Size())
+	}
+	if err != nil || tiff == nil {
+		return time.Time{}, false, err
+	}
+	return tiffDate(tiff)
+}
+
+// jpegExif finds the exif metadata in the APP1 segment before the image data. It returns nil when
+// there is none.
+func jpegExif(r io.ReaderAt) (*io.SectionReader, error) {
+	var marker [4]byte
+	if _, err := r.ReadAt(marker[:2], 0); err != nil || marker[0] != 0xFF || marker[1] != 0xD8 {
+		return nil, errInvalidExif
+	}
+
+	offset := int64(2)
+	for {
+		if _, err := r.ReadAt(marker[:], offset); err != nil {
+			return nil, nil
+		}
+		if marker[0] != 0xFF {
+			return nil, errInvalidExif
+		}
+		switch marker[1] {
+		case 0xFF:
+			// padding before a marker
+			offset++
+			continue
+		case 0xDA, 0xD9:
+			// the image data starts and no metadata follows it
+			return nil, nil
+		}
+		length := int64(binary.BigEndian.Uint16(marker[2:]))
+		if marker[1] == 0xE1 && length > 8 {
+			var id [6]byte
+			if _, err := r.ReadAt(id[:], offset+4); err == nil && string(id[:]) == "Exif\x00\x00" {
+				return io.NewSectionReader(r, offset+10, length-8), nil
+			}
+		}
+		offset += 2 + length
+	}
+}
+
+// heifExif finds the exif item of a HEIF image through the item info and the item locations of
+// the meta box. It returns nil when there is none.
+func heifExif(r io.ReaderAt, size int64) (*io.SectionReader, error) {
+	meta, err := topLevelBox(r, size, "meta")
+	if err != nil || meta == nil {
+		return nil, err
+	}
+	// meta is a full box with a version and flags
+	if len(meta) < 4 {
+		return nil, errInvalidExif
+	}
+	meta = meta[4:]
+
+	iinf := &boxReader{data: childBox(meta, "iinf")}
+	version := iinf.uint(1)
+	iinf.uint(3)
+	if version == 0 {
+		iinf.uint(2)
+	} else {
+		iinf.uint(4)
+	}
+	exifItem, found := uint64(0), false
+	for _, infe := range childBoxes(iinf.data[iinf.pos:], "infe") {
+		entry := &boxReader{data: infe}
+		version := entry.uint(1)
+		entry.uint(3)
+		if version < 2 {
+			continue
+		}
+		id := entry.uint(2)
+		if version >= 3 {
+			id = id<<16 | entry.uint(2)
+		}
+		entry.uint(2)
+		if !entry.failed && entry.pos+4 <= len(infe) && string(infe[entry.pos:entry.pos+4]) == "Exif" {
+			exifItem, found = id, true
+			break
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+
+	iloc := &boxReader{data: childBox(meta, "iloc")}
+	version = iloc.uint(1)
+	iloc.uint(3)
+	sizes := iloc.uint(2)
+	offsetSize, lengthSize, baseOffsetSize, indexSize := int(sizes>>12), int(sizes>>8&0xF), int(sizes>>4&0xF), int(sizes&0xF)
+	idSize := 2
+	if version == 2 {
+		idSize = 4
+	}
+	itemCount := iloc.uint(idSize)
+	for i := uint64(0); i < itemCount && !iloc.failed; i++ {
+		id := iloc.uint(idSize)
+		if version == 1 || version == 2 {
+			iloc.uint(2)
+		}
+		iloc.uint(2)
+		baseOffset := iloc.uint(baseOffsetSize)
+		extentCount := iloc.uint(2)
+		for j := uint64(0); j < extentCount && !iloc.failed; j++ {
+			if (version == 1 || version == 2) && indexSize > 0 {
+				iloc.uint(indexSize)
+			}
+			extentOffset := iloc.uint(offsetSize)
+			extentLength := iloc.uint(lengthSize)
+			if id != exifItem || j > 0 {
+				continue
+			}
+			// the exif item starts with the offset of the tiff header after it
+			start := int64(baseOffset + extentOffset)
+			var header [4]byte
+			if _, err := r.ReadAt(header[:], start); err != nil {
+				return nil, errInvalidExif
+			}
+			skip := 4 + int64(binary.BigEndian.Uint32(header[:]))
+			if int64(extentLength) <= skip {
+				return nil, errInvalidExif
+			}
+			return io.NewSectionReader(r, start+skip, int64(extentLength)-skip), nil
+		}
+	}
+	if iloc.failed {
+		return nil, errInvalidExif
+	}
+	return nil, nil
+}
+
+// topLevelBox returns the content of the first box of the type in an ISO base media file.
+func topLevelBox(r io.ReaderAt, size int64, boxType string) ([]byte, error) {
+	start, end, err := findBox(r, 0, size, boxType)
+	if err != nil || start < 0 {
+		return nil, err
+	}
+	if end-start > maxHEIFMetaSize {
+		return nil, errInvalidExif
+	}
+	content := make([]byte, end-start)
+	if _, err := r.ReadAt(content, start); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// findBox returns where the content of the first box of the type between offset and end starts
+// and ends without reading it, or -1 if there is none. The boxes of videos can be far too large to
+// be read just to get to the ones inside.
+func findBox(r io.ReaderAt, offset int64, end int64, boxType string) (int64, int64, error) {
+	var header [16]byte
+	for offset+8 <= end {
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return -1, -1, err
+		}
+		boxSize, headerSize := int64(binary.BigEndian.Uint32(header[:4])), int64(8)
+		switch boxSize {
+		case 0:
+			boxSize = end - offset
+		case 1:
+			if _, err := r.ReadAt(header[8:], offset+8); err != nil {
+				return -1, -1, err
+			}
+			boxSize, headerSize = int64(binary.BigEndian.Uint64(header[8:])), 16
+		}
+		if boxSize < headerSize || boxSize > end-offset {
+			return -1, -1, errInvalidBox
+		}
+		if string(header[4:8]) == boxType {
+			return offset + headerSize, offset + boxSize, nil
+		}
+		offset += boxSize
+	}
+	return -1, -1, nil
+}
+
+// childBoxes returns the contents of the boxes of the type among the boxes in data.
+func childBoxes(data []byte, boxType string) [][]byte {
+	var boxes [][]byte
+	for len(data) >= 8 {
+		boxSize := int(binary.BigEndian.Uint32(data[:4]))
+		if boxSize < 8 || boxSize > len(data) {
+			break
+		}
+		if string(data[4:8]) == boxType {
+			boxes = append(boxes, data[8:boxSize])
+		}
+		data = data[boxSize:]
+	}
+	return boxes
+}
+
+func childBox(data []byte, boxType string) []byte {
+	if boxes := childBoxes(data, boxType); len(boxes) > 0 {
+		return boxes[0]
+	}
+	return nil
+}
+
+// boxReader reads the big endian fields of a box. Reading past the end sets failed and returns 0.
+type boxReader struct {
+	data   []byte
+	pos    int
+	failed bool
+}
+
+func (b *boxReader) uint(size int) uint64 {
+	if b.pos+size > len(b.data) {
+		b.failed = true
+		return 0
+	}
+	var value uint64
+	for _, c := range b.data[b.pos : b.pos+size] {
+		value = value<<8 | uint64(c)
+	}
+	b.pos += size
+	return value
+}
+
+// ifdEntry is a field of a tiff image file directory. value holds the value itself when it fits
+// in 4 bytes and its offset otherwise.
+type ifdEntry struct {
+	count uint32
+	value []byte
+}
+
+// tiffDate reads the DateTimeOriginal from the exif directory of the tiff structure.
+func tiffDate(r *io.SectionReader) (time.Time, bool, error) {
+	var header [8]byte
+	if _, err := r.ReadAt(header[:], 0); err != nil {
+		return time.Time{}, false, errInvalidExif
+	}
+	var order binary.ByteOrder
+	switch string(header[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return time.Time{}, false, errInvalidExif
+	}
+
+	ifd0, err := readIFD(r, order, int64(order.Uint32(header[4:])))
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	pointer, ok := ifd0[tagExifIFD]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	exif, err := readIFD(r, order, int64(order.Uint32(pointer.value)))
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	value, ok := ifdASCII(r, order, exif[tagDateTimeOriginal])
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	date, err := time.ParseInLocation(exifDateLayout, value, time.Local)
+	if err != nil {
+		// cameras without a set clock write zeros or blanks
+		return time.Time{}, false, nil
+	}
+	// the fraction of the second tells the frames of a burst apart
+	if subSec, ok := ifdASCII(r, order, exif[tagSubSecTimeOriginal]); ok {
+		if fraction, err := time.ParseDuration("0." + subSec + "s"); err == nil {
+			date = date.Add(fraction)
+		}
+	}
+	return date, true, nil
+}
+
+func readIFD(r io.ReaderAt, order binary.ByteOrder, offset int64) (map[uint16]ifdEntry, error) {
+	var count [2]byte
+	if _, err := r.ReadAt(count[:], offset); err != nil {
+		return nil, errInvalidExif
+	}
+	n := int(order.Uint16(count[:]))
+	if n > maxIFDEntries {
+		return nil, errInvalidExif
+	}
+	data := make([]byte, 12*n)
+	if _, err := r.ReadAt(data, offset+2); err != nil {
+		return nil, errInvalidExif
+	}
+
+	entries := make(map[uint16]ifdEntry, n)
+	for i := 0; i < n; i++ {
+		field := data[12*i : 12*i+12]
+		entries[order.Uint16(field)] = ifdEntry{count: order.Uint32(field[4:]), value: field[8:12]}
+	}
+	return entries, nil
+}
+
+// ifdASCII returns the text of an ascii field without the trailing NUL and blanks.
+func ifdASCII(r io.ReaderAt, order binary.ByteOrder, entry ifdEntry) (string, bool) {
+	if entry.count == 0 || entry.count > 256 {
+		return "", false
+	}
+	value := entry.value
+	if entry.count > 4 {
+		value = make([]byte, entry.count)
+		if _, err := r.ReadAt(value, int64(order.Uint32(entry.value))); err != nil {
+			return "", false
+		}
+	} else {
+		value = value[:entry.count]
+	}
+	text := strings.TrimSpace(strings.TrimRight(string(value), "\x00"))
+	return text, text != ""
+}
@@ -0,0 +1,288 @@
+package sorter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportedEntry is a catalog entry in the portable json format. The csv format has the same
+// fields as columns named like the columns of the catalog.
+type exportedEntry struct {
+	DestPath   string     `json:"destPath"`
+	SourcePath string     `json:"sourcePath"`
+	Size       int64      `json:"size"`
+	ModTime    time.Time  `json:"modTime"`
+	SHA256     string     `json:"sha256"`
+	CopiedAt   time.Time  `json:"copiedAt"`
+	SortTime   *time.Time `json:"sortTime,omitempty"`
+}
+
+var exportColumns = []string{"dest_path", "source_path", "size", "mod_time", "sha256", "copied_at", "sort_time"}
+
+// exportFormat returns the format passed explicitly or the one matching the extension of the file.
+func exportFormat(format string, path string) (string, error) {
+	if strings.Compare(format, "") == 0 {
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			return "csv", nil
+		}
+		return "json", nil
+	}
+	if format != "json" && format != "csv" {
+		return "", errorf("The format %s is not supported\n", format)
+	}
+	return format, nil
+}
+
+func runExport(args []string) int {
+
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	catalogPath := flags.String("catalog", "", "The catalog database written by previous sort runs.")
+	out := flags.String("out", "", "Optional. The file to export to. The entries are written to the output if not passed")
+	format := flags.String("format", "", "Optional. Either json or csv. By default it is picked by the extension of -out")
+	lang := AddLanguageFlag(flags)
+	parseFlags(flags, args)
+
+	if err := SetLanguage(*lang); err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	if strings.Compare(*catalogPath, "") == 0 {
+		printer.Printf("Usage: filesorter export -catalog <catalog path> [-out <file>] [-format json|csv]\n")
+		flags.PrintDefaults()
+		return 1
+	}
+	exportAs, err := exportFormat(*format, *out)
+	if err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	cat, err := openCatalog(*catalogPath)
+	if err != nil {
+		printer.Printf("An error occurred while trying to open the catalog %s: %v\n", *catalogPath, err)
+		return 1
+	}
+	defer cat.Close()
+
+	entries, err := cat.query(catalogFilter{})
+	if err != nil {
+		printer.Printf("An error occurred while trying to read the catalog %s: %v\n", *catalogPath, err)
+		return 1
+	}
+
+	writer := io.Writer(os.Stdout)
+	if strings.Compare(*out, "") != 0 {
+		file, err := os.Create(*out)
+		if err != nil {
+			printer.Printf("An error occurred while trying to create the file %s: %v\n", *out, err)
+			return 1
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	if exportAs == "csv" {
+		err = writeCSVEntries(writer, entries)
+	} else {
+		err = writeJSONEntries(writer, entries)
+	}
+	if err != nil {
+		printer.Printf("An error occurred while trying to export the catalog %s: %v\n", *catalogPath, err)
+		return 1
+	}
+
+	if strings.Compare(*out, "") != 0 {
+		printer.Printf("Exported %d files to %s\n", len(entries), *out)
+	}
+	return 0
+}
+
+func runImport(args []string) int {
+
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	catalogPath := flags.String("catalog", "", "The catalog database to import into. It is created if it does not exist.")
+	format := flags.String("format", "", "Optional. Either json or csv. By default it is picked by the extension of the file")
+	lang := AddLanguageFlag(flags)
+	parseFlags(flags, args)
+
+	if err := SetLanguage(*lang); err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	if strings.Compare(*catalogPath, "") == 0 || flags.NArg() != 1 {
+		printer.Printf("Usage: filesorter import -catalog <catalog path> [-format json|csv] <exported file>\n")
+		flags.PrintDefaults()
+		return 1
+	}
+	path := flags.Arg(0)
+	importAs, err := exportFormat(*format, path)
+	if err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		printer.Printf("An error occurred while trying to open the file %s: %v\n", path, err)
+		return 1
+	}
+	defer file.Close()
+
+	var entries []catalogEntry
+	if importAs == "csv" {
+		entries, err = readCSVEntries(file)
+	} else {
+		entries, err = readJSONEntries(file)
+	}
+	if err != nil {
+		printer.Printf("An error occurred while trying to read the file %s: %v\n", path, err)
+		return 1
+	}
+
+	cat, err := openCatalog(*catalogPath)
+	if err != nil {
+		printer.Printf("An error occurred while trying to open the catalog %s: %v\n", *catalogPath, err)
+		return 1
+	}
+	defer cat.Close()
+	if err := cat.startRun("import", path); err != nil {
+		printer.Printf("An error occurred while trying to record the run in the catalog %s: %v\n", *catalogPath, err)
+		return 1
+	}
+
+	merged := 0
+	for _, entry := range entries {
+		changed, err := cat.merge(entry)
+		if err != nil {
+			printer.Printf("An error occurred while trying to import %s: %v\n", entry.destPath, err)
+			return 1
+		}
+		if changed {
+			merged++
+		}
+	}
+
+	summary := processedCount{copiedFiles: merged, skippedFiles: len(entries) - merged}
+	if err := cat.finishRun(&summary); err != nil {
+		printer.Printf("An error occurred while trying to record the run in the catalog %s: %v\n", *catalogPath, err)
+	}
+
+	printer.Printf("Completed !\n")
+	printer.Printf("Imported %d of %d files. The others are already in the catalog with the same or a newer copy\n",
+		merged, len(entries))
+	return 0
+}
+
+func writeJSONEntries(writer io.Writer, entries []catalogEntry) error {
+	exported := make([]exportedEntry, len(entries))
+	for i, entry := range entries {
+		exported[i] = exportedEntry{
+			DestPath:   entry.destPath,
+			SourcePath: entry.sourcePath,
+			Size:       entry.size,
+			ModTime:    entry.modTime,
+			SHA256:     entry.hash,
+			CopiedAt:   entry.copiedAt,
+		}
+		if !entry.sortTime.IsZero() {
+			sortTime := entry.sortTime
+			exported[i].SortTime = &sortTime
+		}
+	}
+	content, err := json.MarshalIndent(exported, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(append(content, '\n'))
+	return err
+}
+
+func readJSONEntries(reader io.Reader) ([]catalogEntry, error) {
+	var exported []exportedEntry
+	if err := json.NewDecoder(reader).Decode(&exported); err != nil {
+		return nil, err
+	}
+	entries := make([]catalogEntry, len(exported))
+	for i, e := range exported {
+		entries[i] = catalogEntry{
+			destPath:   e.DestPath,
+			sourcePath: e.SourcePath,
+			size:       e.Size,
+			modTime:    e.ModTime,
+			hash:       e.SHA256,
+			copiedAt:   e.CopiedAt,
+		}
+		if e.SortTime != nil {
+			entries[i].sortTime = *e.SortTime
+		}
+	}
+	return entries, nil
+}
+
+func writeCSVEntries(writer io.Writer, entries []catalogEntry) error {
+	w := csv.NewWriter(writer)
+	if err := w.Write(exportColumns); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		var sortTime string
+		if !entry.sortTime.IsZero() {
+			sortTime = entry.sortTime.Format(time.RFC3339Nano)
+		}
+		err := w.Write([]string{
+			entry.destPath,
+			entry.sourcePath,
+			strconv.FormatInt(entry.size, 10),
+			entry.modTime.Format(time.RFC3339Nano),
+			entry.hash,
+			entry.copiedAt.Format(time.RFC3339Nano),
+			sortTime,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func readCSVEntries(reader io.Reader) ([]catalogEntry, error) {
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 || strings.Join(records[0], ",") != strings.Join(exportColumns, ",") {
+		return nil, fmt.Errorf("the csv does not start with the header %s", strings.Join(exportColumns, ","))
+	}
+
+	var entries []catalogEntry
+	for i, record := range records[1:] {
+		entry := catalogEntry{destPath: record[0], sourcePath: record[1], hash: record[4]}
+		if entry.size, err = strconv.ParseInt(record[2], 10, 64); err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+2, err)
+		}
+		if entry.modTime, err = time.Parse(time.RFC3339Nano, record[3]); err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+2, err)
+		}
+		if entry.copiedAt, err = time.Parse(time.RFC3339Nano, record[5]); err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+2, err)
+		}
+		if record[6] != "" {
+			if entry.sortTime, err = time.Parse(time.RFC3339Nano, record[6]); err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+2, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,39 @@
+package sorter
+
+import "time"
+
+// fileRate limits how many files are copied per second, for destinations like cloud backends
+// with a limit on the requests or SMB servers which slow down under many metadata operations. It
+// applies between files on top of the bandwidth schedule, which limits the bytes.
+type fileRate struct {
+	interval time.Duration
+	// next is the earliest time at which the next file may be copied
+	next time.Time
+}
+
+func newFileRate(perSecond float64, printer *outputPrinter) (*fileRate, error) {
+	if perSecond <= 0 {
+		return nil, printer.errorf("The rate of %v files per second is not valid\n", perSecond)
+	}
+	return &fileRate{interval: time.Duration(float64(time.Second) / perSecond)}, nil
+}
+
+// wait blocks until the next file may be copied. A run idle for a while does not save up files to
+// copy at once. The wait is cut short when the run is cancelled.
+func (r *fileRate) wait(control *runControl) error {
+	now := time.Now()
+	if r.next.Before(now) {
+		r.next = now
+	}
+	for delay := r.next.Sub(now); delay > 0; delay = time.Until(r.next) {
+		if control != nil && control.isCancelled() {
+			return errCancelled
+		}
+		if delay > 100*time.Millisecond {
+			delay = 100 * time.Millisecond
+		}
+		time.Sleep(delay)
+	}
+	r.next = r.next.Add(r.interval)
+	return nil
+}
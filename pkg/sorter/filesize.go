@@ -0,0 +1,229 @@
+package sorter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// fatMaxFileSize is the largest file FAT12, FAT16 and FAT32 can hold, one byte short of 4 GB. The
+// cards and the sticks of cameras and car stereos are mostly formatted with FAT32.
+const fatMaxFileSize = 1<<32 - 1
+
+// splitManifestExt is appended to the path of a file which was split into parts for its manifest.
+const splitManifestExt = ".parts.json"
+
+// destinationMaxFileSize returns the largest file the file system of the destination can hold
+// along with the name of the file system, or 0 when it has no limit which matters. A destination
+// which is not created yet is looked up on its nearest existing parent.
+func destinationMaxFileSize(path string) (int64, string) {
+	path, ok := nearestExistingPath(path)
+	if !ok {
+		return 0, ""
+	}
+	return fileSystemMaxFileSize(path)
+}
+
+// nearestExistingPath returns the absolute path or its nearest parent which exists.
+func nearestExistingPath(path string) (string, bool) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", false
+		}
+		path = parent
+	}
+}
+
+// fileTooLargeError is the reason a file is not copied onto a destination which can not hold it.
+func fileTooLargeError(size int64, dest *destination) error {
+	if strings.Compare(dest.fileSystem, "") == 0 {
+		return fmt.Errorf("the file of %d bytes is larger than the -max-file-size of %d bytes", size, dest.maxFileSize)
+	}
+	return fmt.Errorf("the file of %d bytes is larger than the %d bytes the %s file system of the destination can hold", size, dest.maxFileSize, dest.fileSystem)
+}
+
+// splitManifest is written next to the parts of a split file so that they can be joined and
+// checked again. The parts are joined by concatenating them in their order, like with
+// cat IMG_0001.MOV.001 IMG_0001.MOV.002 > IMG_0001.MOV
+type splitManifest struct {
+	File     string      `json:"file"`
+	Size     int64       `json:"size"`
+	Modified time.Time   `json:"modified"`
+	SHA256   string      `json:"sha256"`
+	Parts    []splitPart `json:"parts"`
+}
+
+type splitPart struct {
+	File   string `json:"file"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// readSplitManifest reads the manifest of a file split by an earlier run. It returns nil when
+// there is none.
+func readSplitManifest(destFilePath string, printer *outputPrinter) (*splitManifest, os.FileInfo, error) {
+	manifestPath := destFilePath + splitManifestExt
+	fileInfo, err := os.Stat(manifestPath)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	var manifest splitManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, nil, printer.errorf("The manifest %s is not valid: %v\n", manifestPath, err)
+	}
+	return &manifest, fileInfo, nil
+}
+
+// splitCopy copies the file into parts of at most partSize bytes named like IMG_0001.MOV.001 and
+// writes their manifest once all of them are written. It returns the number of bytes written,
+// the sha256 of the whole file and the number of parts. The parts are removed again on an error.
+func (r *runIO) splitCopy(path string, destFilePath string, sourceFileStat os.FileInfo, partSize int64) (int64, string, int, error) {
+
+	source, err := r.openSource(path)
+	if err != nil {
+		return 0, "", 0, err
+	}
+	defer source.Close()
+
+	manifest := splitManifest{File: filepath.Base(destFilePath), Size: sourceFileStat.Size(), Modified: sourceFileStat.ModTime()}
+	var written int64
+	var partPaths []string
+	fail := func(err error) (int64, string, int, error) {
+		for _, partPath := range partPaths {
+			os.Remove(partPath)
+		}
+		return written, "", 0, err
+	}
+
+	whole := sha256.New()
+	reader := io.TeeReader(r.cancellable(r.throttle(source)), whole)
+	for i := 1; written < manifest.Size; i++ {
+		// every part is staged and renamed into the destination like a whole copy
+		partPath := fmt.Sprintf("%s.%03d", destFilePath, i)
+		part, err := r.stageDestination(source, partPath)
+		if err != nil {
+			return fail(err)
+		}
+		sum := sha256.New()
+		n, err := io.Copy(io.MultiWriter(part, sum), io.LimitReader(reader, partSize))
+		written += n
+		if err == nil && n == 0 && manifest.Size > 0 {
+			err = fmt.Errorf("the file %s was shorter than %d bytes", path, manifest.Size)
+		}
+		if err := r.unstage(part, partPath, manifest.Modified, err); err != nil {
+			return fail(err)
+		}
+		partPaths = append(partPaths, partPath)
+		manifest.Parts = append(manifest.Parts, splitPart{File: filepath.Base(partPath), Size: n, SHA256: hex.EncodeToString(sum.Sum(nil))})
+	}
+	manifest.SHA256 = hex.EncodeToString(whole.Sum(nil))
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fail(err)
+	}
+	err = r.commit(func() error {
+		return os.WriteFile(destFilePath+splitManifestExt, append(content, '\n'), 0644)
+	})
+	if err != nil {
+		return fail(err)
+	}
+	// the parts of a longer file copied over before are left over otherwise
+	for i := len(manifest.Parts) + 1; ; i++ {
+		if err := os.Remove(fmt.Sprintf("%s.%03d", destFilePath, i)); err != nil {
+			break
+		}
+	}
+	return written, manifest.SHA256, len(manifest.Parts), nil
+}
+
+// prepareSplit decides what happens to a file larger than the destination can hold. It is
+// split into parts with -split-large unless the parts of the same file are there already, and it
+// errors otherwise.
+func prepareSplit(path string, sourceFileStat os.FileInfo, c *fileCopy, opts *sortOptions) *fileCopy {
+	if !opts.splitLarge {
+		c.err = fileTooLargeError(sourceFileStat.Size(), c.dest)
+		opts.printer.Printf("An error occurred while trying to copy the file %s to %s: %v\n", path, c.destFilePath, c.err)
+		return c
+	}
+	c.split = true
+
+	manifest, manifestStat, err := readSplitManifest(c.destFilePath, opts.printer)
+	if err != nil {
+		opts.printer.Printf("An error occurred while trying to read the parts of the file %s", c.destFilePath)
+		c.err = err
+		return c
+	}
+	if manifest != nil {
+		// like a whole file the parts are taken to be the source if their size matches, and with
+		// -compare hash if the sha256 in their manifest does too
+		same := manifest.Size == sourceFileStat.Size()
+		if same && opts.compareHash {
+			hash, err := opts.runIO.hashFile(path)
+			if err != nil {
+				opts.printer.Printf("An error occurred while trying to compare the file %s with %s", path, c.destFilePath)
+				c.err = err
+				return c
+			}
+			same = strings.Compare(hash, manifest.SHA256) == 0
+		}
+		if same {
+			c.skip = true
+			return c
+		}
+		if strings.Compare(opts.onConflict, "skip") == 0 {
+			c.destFileStat, c.skip, c.conflict = manifestStat, true, true
+			return c
+		}
+		c.overwroteConflict = true
+	}
+
+	// the directories are created only when the copy is not a dry run
+	if opts.plan != nil {
+		return c
+	}
+	if err := opts.createdDirs.mkdirAll(c.dest.path, filepath.Dir(c.destFilePath)); err != nil {
+		opts.printer.Printf("An error occurred while trying to create directories for the file %s", c.destFilePath)
+		c.err = err
+	}
+	return c
+}
+
+// finishSplit records a file copied in parts and updates the counts.
+func finishSplit(path string, c *fileCopy, opts *sortOptions, counts *processedCount) error {
+	if c.overwroteConflict {
+		opts.printer.Printf("Copied %s --> %s in %d parts over a different file of the same name\n", path, c.destFilePath, c.parts)
+		counts.overwrittenConflicts++
+	} else {
+		opts.printer.Printf("Copied %s --> %s in %d parts\n", path, c.destFilePath, c.parts)
+	}
+	if opts.output != nil {
+		opts.output.file(path, c.destFilePath, "split", c.written, nil)
+	}
+	c.dest.counts.copiedFiles++
+	c.dest.counts.totalBytesCopied += c.written
+	counts.copiedFiles++
+	counts.totalBytesCopied += c.written
+	return nil
+}
@@ -0,0 +1,27 @@
+//go:build darwin || freebsd
+
+package sorter
+
+import "syscall"
+
+// fileSystemMaxFileSize returns the largest file the file system of the path can hold, or 0 when
+// it has no limit which matters.
+func fileSystemMaxFileSize(path string) (int64, string) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, ""
+	}
+	var name []byte
+	for _, c := range stat.Fstypename {
+		if c == 0 {
+			break
+		}
+		name = append(name, byte(c))
+	}
+	// darwin calls FAT msdos and freebsd msdosfs
+	switch string(name) {
+	case "msdos", "msdosfs":
+		return fatMaxFileSize, "FAT"
+	}
+	return 0, ""
+}
@@ -0,0 +1,19 @@
+package sorter
+
+import "syscall"
+
+// msdosSuperMagic is the type statfs reports for the FAT file systems.
+const msdosSuperMagic = 0x4d44
+
+// fileSystemMaxFileSize returns the largest file the file system of the path can hold, or 0 when
+// it has no limit which matters.
+func fileSystemMaxFileSize(path string) (int64, string) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, ""
+	}
+	if stat.Type == msdosSuperMagic {
+		return fatMaxFileSize, "FAT"
+	}
+	return 0, ""
+}
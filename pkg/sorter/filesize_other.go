@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package sorter
+
+// fileSystemMaxFileSize reports no limit since the file system can not be told on the other
+// platforms. -max-file-size sets one.
+func fileSystemMaxFileSize(path string) (int64, string) {
+	return 0, ""
+}
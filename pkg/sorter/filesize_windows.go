@@ -0,0 +1,29 @@
+package sorter
+
+import (
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var procGetVolumeInformation = syscall.NewLazyDLL("kernel32.dll").NewProc("GetVolumeInformationW")
+
+// fileSystemMaxFileSize returns the largest file the file system of the path can hold, or 0 when
+// it has no limit which matters.
+func fileSystemMaxFileSize(path string) (int64, string) {
+	root, err := syscall.UTF16PtrFromString(filepath.VolumeName(path) + `\`)
+	if err != nil {
+		return 0, ""
+	}
+	name := make([]uint16, syscall.MAX_PATH+1)
+	if ok, _, _ := procGetVolumeInformation.Call(uintptr(unsafe.Pointer(root)), 0, 0, 0, 0, 0,
+		uintptr(unsafe.Pointer(&name[0])), uintptr(len(name))); ok == 0 {
+		return 0, ""
+	}
+	// the name is FAT or FAT32. exFAT has no limit which matters
+	if fileSystem := syscall.UTF16ToString(name); strings.HasPrefix(fileSystem, "FAT") {
+		return fatMaxFileSize, fileSystem
+	}
+	return 0, ""
+}
@@ -0,0 +1,124 @@
+package sorter
+
+import (
+	"flag"
+	"path/filepath"
+	"strings"
+
+	"github.com/karrick/godirwalk"
+)
+
+func runFind(args []string) int {
+
+	flags := flag.NewFlagSet("find", flag.ExitOnError)
+	catalogPath := flags.String("catalog", "", "The catalog database written by previous sort runs.")
+	destPath := flags.String("destination", "", `Optional. Also search the file names in this destination for the files which
+	are not in the catalog`)
+	lang := AddLanguageFlag(flags)
+	parseFlags(flags, args)
+
+	if err := SetLanguage(*lang); err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	if strings.Compare(*catalogPath, "") == 0 || flags.NArg() != 1 {
+		printer.Printf("Usage: filesorter find -catalog <catalog path> [-destination <destination path>] <name, glob or sha256>\n")
+		flags.PrintDefaults()
+		return 1
+	}
+	pattern := flags.Arg(0)
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		printer.Printf("The pattern %s is not valid: %v\n", pattern, err)
+		return 1
+	}
+
+	cat, err := openCatalog(*catalogPath)
+	if err != nil {
+		printer.Printf("An error occurred while trying to open the catalog %s: %v\n", *catalogPath, err)
+		return 1
+	}
+	defer cat.Close()
+
+	entries, err := cat.find(pattern)
+	if err != nil {
+		printer.Printf("An error occurred while trying to search the catalog %s: %v\n", *catalogPath, err)
+		return 1
+	}
+
+	runs := make(map[int64]string)
+	cataloged := make(map[string]struct{})
+	for _, entry := range entries {
+		cataloged[entry.destPath] = struct{}{}
+
+		run, ok := runs[entry.run]
+		if !ok {
+			run, err = describeRun(cat, entry.run)
+			if err != nil {
+				printer.Printf("An error occurred while trying to read the catalog %s: %v\n", *catalogPath, err)
+				return 1
+			}
+			runs[entry.run] = run
+		}
+
+		dateUsed := "none, sorted by its metadata"
+		if !entry.sortTime.IsZero() {
+			dateUsed = entry.sortTime.Format("2006-01-02 15:04:05")
+		}
+		source := entry.sourcePath
+		if strings.Compare(source, "") == 0 {
+			source = "unknown, added by index"
+		}
+		printer.Println(entry.destPath)
+		printer.Printf("  source: %s\n", source)
+		printer.Printf("  copied: %s by %s\n", entry.copiedAt.Format("2006-01-02 15:04:05"), run)
+		printer.Printf("  date used: %s\n", dateUsed)
+		printer.Printf("  size: %d bytes, sha256: %s\n", entry.size, entry.hash)
+		if strings.Compare(entry.volume, "") != 0 {
+			printer.Printf("  volume: %s\n", entry.volume)
+		}
+	}
+
+	uncataloged := 0
+	if strings.Compare(*destPath, "") != 0 {
+		if !isPathValid(destPath, printer) {
+			return 1
+		}
+		godirwalk.Walk(*destPath, &godirwalk.Options{
+			Callback: func(path string, dirent *godirwalk.Dirent) error {
+				if dirent.IsDir() || !matchesName(pattern, path) {
+					return nil
+				}
+				absPath, err := filepath.Abs(path)
+				if err != nil {
+					return nil
+				}
+				if _, ok := cataloged[absPath]; !ok {
+					printer.Println(absPath)
+					printer.Printf("  not in the catalog\n")
+					uncataloged++
+				}
+				return nil
+			},
+			ErrorCallback: func(string, error) godirwalk.ErrorAction {
+				return godirwalk.SkipNode
+			},
+		})
+	}
+
+	printer.Printf("Found %d files in the catalog and %d other files at the destination\n", len(entries), uncataloged)
+	if len(entries)+uncataloged == 0 {
+		return 1
+	}
+	return 0
+}
+
+// describeRun returns a short description of the run which copied a file.
+func describeRun(cat *catalog, id int64) (string, error) {
+	run, ok, err := cat.getRun(id)
+	if err != nil || !ok {
+		return printer.Sprintf("a run before runs were recorded"), err
+	}
+	return printer.Sprintf("run %d (%s of %s started %s)", run.id, run.command, run.source,
+		run.started.Format("2006-01-02 15:04:05")), nil
+}
@@ -0,0 +1,71 @@
+package sorter
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+func runHistory(args []string) int {
+
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	catalogPath := flags.String("catalog", "", "The catalog database written by previous sort runs.")
+	limit := flags.Int("limit", 10, "Optional. The number of recent runs to show")
+	lang := AddLanguageFlag(flags)
+	parseFlags(flags, args)
+
+	if err := SetLanguage(*lang); err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	if strings.Compare(*catalogPath, "") == 0 {
+		printer.Printf("Usage: filesorter history -catalog <catalog path> [-limit <runs>]\n")
+		flags.PrintDefaults()
+		return 1
+	}
+
+	cat, err := openCatalog(*catalogPath)
+	if err != nil {
+		printer.Printf("An error occurred while trying to open the catalog %s: %v\n", *catalogPath, err)
+		return 1
+	}
+	defer cat.Close()
+
+	runs, err := cat.recentRuns(*limit)
+	if err != nil {
+		printer.Printf("An error occurred while trying to read the catalog %s: %v\n", *catalogPath, err)
+		return 1
+	}
+
+	// each run is compared with the previous finished run of the same source since runs over
+	// different sources are not comparable. The first shown run of a source has no deltas.
+	previous := make(map[string]catalogRun)
+	for _, run := range runs {
+		printer.Printf("Run %d: %s of %s started %s\n", run.id, run.command, run.source, run.started.Format("2006-01-02 15:04:05"))
+		if run.finished.IsZero() {
+			printer.Printf("  Did not finish\n")
+			continue
+		}
+
+		last, compared := previous[run.source]
+		printer.Printf("  Copied %s, Skipped %s, Errored %s, Resumed %d, Bytes copied %d in %s\n",
+			withDelta(run.copiedFiles, last.copiedFiles, compared),
+			withDelta(run.skippedFiles, last.skippedFiles, compared),
+			withDelta(run.erroredFiles, last.erroredFiles, compared),
+			run.resumedFiles,
+			run.bytesCopied,
+			run.finished.Sub(run.started).Round(1e9))
+		previous[run.source] = run
+	}
+	printer.Printf("Found %d runs\n", len(runs))
+	return 0
+}
+
+// withDelta formats the count with the change from the previous run like 120 (+20).
+func withDelta(count int, previous int, compared bool) string {
+	if !compared {
+		return fmt.Sprint(count)
+	}
+	return fmt.Sprintf("%d (%+d)", count, count-previous)
+}
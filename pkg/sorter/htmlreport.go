@@ -0,0 +1,205 @@
+package sorter
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// runReport collects what the html report shows besides the counts of the run. It is only kept
+// when -html-report is passed since the duplicates of a large archive can be many.
+type runReport struct {
+	started time.Time
+	// days counts the copied files by the day they were sorted by
+	days map[string]int
+	// duplicates are the files skipped since the same file was at the destination already
+	duplicates []alertFile
+}
+
+func newRunReport() *runReport {
+	return &runReport{started: time.Now(), days: make(map[string]int)}
+}
+
+func (r *runReport) copied(day time.Time) {
+	r.days[day.Format("2006-01-02")]++
+}
+
+func (r *runReport) duplicate(path string, destFilePath string) {
+	r.duplicates = append(r.duplicates, alertFile{Path: path, Detail: destFilePath})
+}
+
+// htmlReportPath returns the file the report is written to. A directory, like the one of the
+// logs, gets a report per run named by the time the run started.
+func htmlReportPath(path string, started time.Time) string {
+	if fileInfo, err := os.Stat(path); err == nil && fileInfo.IsDir() {
+		return filepath.Join(path, "filesorter-"+started.Format("2006-01-02-150405")+".html")
+	}
+	return path
+}
+
+type htmlReportLine struct {
+	Label string
+	Value interface{}
+}
+
+type htmlReportBar struct {
+	Day    string
+	Files  int
+	X      int
+	Y      int
+	Height int
+}
+
+// chartHeight and barWidth are the size of the chart of the files per day in pixels.
+const (
+	chartHeight = 160
+	barWidth    = 6
+)
+
+// writeHTMLReport writes the report of the run into a single html file which needs nothing else
+// to be shown, so that it can be mailed or shared as it is.
+func writeHTMLReport(path string, options *Options, report *Report, counts *processedCount, r *runReport, printer *outputPrinter) error {
+
+	summary := []htmlReportLine{
+		{printer.Sprintf("Source"), options.Source},
+		{printer.Sprintf("Destinations"), strings.Join(append(append([]string{}, options.Destinations...), options.Tiers...), ", ")},
+		{printer.Sprintf("Started"), r.started.Format("2006-01-02 15:04:05")},
+		{printer.Sprintf("Duration"), time.Since(r.started).Round(time.Second).String()},
+		{printer.Sprintf("Copied files"), report.CopiedFiles},
+		{printer.Sprintf("Bytes copied"), formatBytes(report.BytesCopied)},
+		{printer.Sprintf("Skipped files"), report.SkippedFiles},
+		{printer.Sprintf("Duplicates"), len(r.duplicates)},
+		{printer.Sprintf("Errored files"), report.ErroredFiles},
+	}
+	// the counts which are zero for most runs are only shown when they are not
+	for _, line := range []struct {
+		label string
+		value int
+	}{
+		{printer.Sprintf("Moved files"), report.MovedFiles},
+		{printer.Sprintf("Resumed files"), report.ResumedFiles},
+		{printer.Sprintf("Conflicts"), report.Conflicts},
+		{printer.Sprintf("Corrupted copies"), report.CorruptedCopies},
+		{printer.Sprintf("Infected files"), report.Infected},
+		{printer.Sprintf("Invalid dates"), report.InvalidDates},
+		{printer.Sprintf("Salvaged files"), report.Salvaged},
+		{printer.Sprintf("Timed out files"), report.TimedOut},
+	} {
+		if line.value > 0 {
+			summary = append(summary, htmlReportLine{line.label, line.value})
+		}
+	}
+	if report.Cancelled {
+		summary = append(summary, htmlReportLine{printer.Sprintf("Status"), printer.Sprintf("The run was cancelled")})
+	}
+
+	var days []string
+	most := 0
+	for day, files := range r.days {
+		days = append(days, day)
+		if files > most {
+			most = files
+		}
+	}
+	sort.Strings(days)
+	var bars []htmlReportBar
+	for i, day := range days {
+		height := r.days[day] * chartHeight / most
+		if height == 0 {
+			height = 1
+		}
+		bars = append(bars, htmlReportBar{Day: day, Files: r.days[day], X: i * barWidth, Y: chartHeight - height, Height: height})
+	}
+
+	firstDay, lastDay := "", ""
+	if len(days) > 0 {
+		firstDay, lastDay = days[0], days[len(days)-1]
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = htmlReportTemplate.Execute(file, map[string]interface{}{
+		"Title":      printer.Sprintf("filesorter report of %s", r.started.Format("2006-01-02 15:04")),
+		"Summary":    summary,
+		"Bars":       bars,
+		"Width":      len(bars) * barWidth,
+		"Height":     chartHeight,
+		"BarWidth":   barWidth - 1,
+		"FirstDay":   firstDay,
+		"LastDay":    lastDay,
+		"Errors":     counts.errors,
+		"Duplicates": r.duplicates,
+		"Labels": map[string]string{
+			"Summary":     printer.Sprintf("Summary"),
+			"PerDay":      printer.Sprintf("Copied files per day"),
+			"Errors":      printer.Sprintf("Errors"),
+			"Duplicates":  printer.Sprintf("Duplicates"),
+			"File":        printer.Sprintf("File"),
+			"Error":       printer.Sprintf("Error"),
+			"AlreadyAt":   printer.Sprintf("Already at"),
+			"NoFiles":     printer.Sprintf("No files were copied"),
+			"NoErrors":    printer.Sprintf("No errors"),
+			"NoDuplicate": printer.Sprintf("No duplicates"),
+		},
+	})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+td, th { border-bottom: 1px solid #ddd; padding: 4px 12px 4px 0; text-align: left; vertical-align: top; }
+th { color: #555; }
+.chart { overflow-x: auto; margin-bottom: 2em; }
+.chart rect { fill: #4a7fd4; }
+.chart rect:hover { fill: #e07b00; }
+.days { color: #555; font-size: small; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+
+<h2>{{.Labels.Summary}}</h2>
+<table>
+{{range .Summary}}<tr><th>{{.Label}}</th><td>{{.Value}}</td></tr>
+{{end}}</table>
+
+<h2>{{.Labels.PerDay}}</h2>
+{{if .Bars}}<div class="chart">
+<svg width="{{.Width}}" height="{{.Height}}" xmlns="http://www.w3.org/2000/svg">
+{{range .Bars}}<rect x="{{.X}}" y="{{.Y}}" width="{{$.BarWidth}}" height="{{.Height}}"><title>{{.Day}}: {{.Files}}</title></rect>
+{{end}}</svg>
+<div class="days">{{.FirstDay}} &ndash; {{.LastDay}}</div>
+</div>
+{{else}}<p>{{.Labels.NoFiles}}</p>
+{{end}}
+<h2>{{.Labels.Errors}}</h2>
+{{if .Errors}}<table>
+<tr><th>{{.Labels.File}}</th><th>{{.Labels.Error}}</th></tr>
+{{range .Errors}}<tr><td>{{.Path}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+{{else}}<p>{{.Labels.NoErrors}}</p>
+{{end}}
+<h2>{{.Labels.Duplicates}}</h2>
+{{if .Duplicates}}<table>
+<tr><th>{{.Labels.File}}</th><th>{{.Labels.AlreadyAt}}</th></tr>
+{{range .Duplicates}}<tr><td>{{.Path}}</td><td>{{.Detail}}</td></tr>
+{{end}}</table>
+{{else}}<p>{{.Labels.NoDuplicate}}</p>
+{{end}}
+</body>
+</html>
+`))
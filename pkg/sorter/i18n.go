@@ -0,0 +1,173 @@
+package sorter
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// messagePrinter formats the messages shown to the user. The format strings are the keys of the
+// translations in translations.go and messages without a translation are printed as they are.
+type messagePrinter interface {
+	Fprintf(w io.Writer, format string, a ...interface{}) (int, error)
+	Fprintln(w io.Writer, a ...interface{}) (int, error)
+	Sprintf(format string, a ...interface{}) string
+}
+
+// messages formats in English unless another language is selected with -lang or the environment.
+var messages messagePrinter = fmtPrinter{}
+
+type fmtPrinter struct{}
+
+func (fmtPrinter) Fprintf(w io.Writer, format string, a ...interface{}) (int, error) {
+	return fmt.Fprintf(w, format, a...)
+}
+
+func (fmtPrinter) Fprintln(w io.Writer, a ...interface{}) (int, error) {
+	return fmt.Fprintln(w, a...)
+}
+
+func (fmtPrinter) Sprintf(format string, a ...interface{}) string {
+	return fmt.Sprintf(format, a...)
+}
+
+// localizedPrinter adapts message.Printer whose Fprintf takes a message.Reference.
+type localizedPrinter struct {
+	*message.Printer
+}
+
+func (p localizedPrinter) Fprintf(w io.Writer, format string, a ...interface{}) (int, error) {
+	return p.Printer.Fprintf(w, format, a...)
+}
+
+func (p localizedPrinter) Sprintf(format string, a ...interface{}) string {
+	return p.Printer.Sprintf(format, a...)
+}
+
+// outputPrinter prints the messages in the language of the run to its output. Every Sorter has its
+// own, which prints to the standard error with -output json and around the progress bar with
+// -progress, so that several of them can run in the same process.
+type outputPrinter struct {
+	out io.Writer
+	// messages formats in the language of the Sorter. It is nil for the printer of the commands
+	// which run without a Sorter, which print in the language selected with SetLanguage
+	messages messagePrinter
+}
+
+func (p *outputPrinter) language() messagePrinter {
+	if p.messages != nil {
+		return p.messages
+	}
+	return messages
+}
+
+func (p *outputPrinter) Printf(format string, a ...interface{}) (int, error) {
+	return p.language().Fprintf(p.out, format, a...)
+}
+
+func (p *outputPrinter) Println(a ...interface{}) (int, error) {
+	return p.language().Fprintln(p.out, a...)
+}
+
+func (p *outputPrinter) Sprintf(format string, a ...interface{}) string {
+	return p.language().Sprintf(format, a...)
+}
+
+// errorf returns the message in the language of the printer as an error. The trailing newline of
+// the format is left out.
+func (p *outputPrinter) errorf(format string, a ...interface{}) error {
+	return errors.New(strings.TrimSuffix(p.Sprintf(format, a...), "\n"))
+}
+
+// printer prints the messages of the commands which run without a Sorter to the standard output.
+var printer = &outputPrinter{out: os.Stdout}
+
+// Printf prints a message in the language selected with SetLanguage, for the programs which print
+// along with the runs.
+func Printf(format string, a ...interface{}) {
+	printer.Printf(format, a...)
+}
+
+// Println prints the values like fmt.Println, for the programs which print the errors of the runs
+// along with their other messages.
+func Println(a ...interface{}) {
+	printer.Println(a...)
+}
+
+// Errorf returns the message in the language selected with SetLanguage as an error, for the
+// programs which check options of their own. The trailing newline of the format is left out.
+func Errorf(format string, a ...interface{}) error {
+	return errorf(format, a...)
+}
+
+// errorf returns the message in the language selected with SetLanguage as an error, for the
+// commands which run without a Sorter.
+func errorf(format string, a ...interface{}) error {
+	return printer.errorf(format, a...)
+}
+
+// languages are the supported languages. English is first so that it is used when nothing matches.
+var languages = []language.Tag{language.English, language.German, language.Spanish}
+
+// AddLanguageFlag adds the -lang flag whose value is passed to SetLanguage.
+func AddLanguageFlag(flags *flag.FlagSet) *string {
+	return flags.String("lang", "", `Optional. The language of the messages. One of en, de or es. By default it is
+	read from LC_ALL, LC_MESSAGES or LANG`)
+}
+
+// SetLanguage selects the language of the messages of the commands and of the Sorters created
+// afterwards without their own Options.Language. Without one the locale of the environment is used
+// and an unsupported locale falls back to English.
+func SetLanguage(lang string) error {
+	if strings.Compare(lang, "") != 0 {
+		selected, err := languagePrinter(lang)
+		if err != nil {
+			// the error is shown in the language of the environment
+			SetLanguage("")
+			return err
+		}
+		messages = selected
+		return nil
+	}
+	if lang = environmentLanguage(); strings.Compare(lang, "") == 0 {
+		return nil
+	}
+	if selected, err := languagePrinter(lang); err == nil {
+		messages = selected
+	}
+	return nil
+}
+
+// languagePrinter returns the printer of the language, which has to be one of the supported ones.
+func languagePrinter(lang string) (messagePrinter, error) {
+	_, index, confidence := language.NewMatcher(languages).Match(language.Make(lang))
+	if confidence == language.No {
+		return nil, errorf("The language %s is not supported. Use en, de or es\n", lang)
+	}
+	if index == 0 {
+		return fmtPrinter{}, nil
+	}
+	return localizedPrinter{message.NewPrinter(languages[index])}, nil
+}
+
+// environmentLanguage converts a POSIX locale like de_DE.UTF-8 to a language tag like de-DE.
+func environmentLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		locale := os.Getenv(name)
+		if strings.Compare(locale, "") == 0 {
+			continue
+		}
+		locale = strings.SplitN(strings.SplitN(locale, ".", 2)[0], "@", 2)[0]
+		if locale == "C" || locale == "POSIX" {
+			return ""
+		}
+		return strings.ReplaceAll(locale, "_", "-")
+	}
+	return ""
+}
@@ -0,0 +1,119 @@
+package sorter
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Every Sorter prints in its own language, whatever the other Sorters of the process use.
+func TestSorterLanguages(t *testing.T) {
+	source := t.TempDir()
+	writeFiles(t, source, map[string]string{"IMG_0001.jpg": "photo"})
+
+	tests := []struct {
+		language string
+		missing  string
+		done     string
+	}{
+		{"de", "existiert nicht", "Fertig !"},
+		{"es", "no existe", "¡Completado!"},
+		{"", "does not exist", "Completed !"},
+	}
+	var sorters []*Sorter
+	var outputs []*bytes.Buffer
+	for _, test := range tests {
+		_, err := New(Options{Source: filepath.Join(source, "missing"), Destinations: []string{t.TempDir()}, Language: test.language})
+		if err == nil || !strings.Contains(err.Error(), test.missing) {
+			t.Errorf("the error in %q was %v, want one with %q", test.language, err, test.missing)
+		}
+
+		s, err := New(Options{Source: source, Destinations: []string{t.TempDir()}, Language: test.language, GlobalIgnore: "none", MinFreeInodes: -1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		s.opts.printer.out = &out
+		sorters, outputs = append(sorters, s), append(outputs, &out)
+	}
+	for i, s := range sorters {
+		if _, err := s.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(outputs[i].String(), tests[i].done) {
+			t.Errorf("the run in %q printed\n%s\nwithout %q", tests[i].language, outputs[i].String(), tests[i].done)
+		}
+	}
+
+	if _, err := New(Options{Source: source, Destinations: []string{t.TempDir()}, Language: "xx"}); err == nil {
+		t.Errorf("the unsupported language xx was accepted")
+	}
+}
+
+// Every message printed through a printer has a translation in every language, with the same verbs
+// in the same order. Formats without words like "  %s\n" need none.
+func TestTranslations(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := regexp.MustCompile(`[A-Za-z]{3,}`)
+	verbs := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+	fileSet := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		parsed, err := parser.ParseFile(fileSet, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(parsed, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 || !printsMessage(call.Fun) {
+				return true
+			}
+			literal, ok := call.Args[0].(*ast.BasicLit)
+			if !ok || literal.Kind != token.STRING {
+				return true
+			}
+			format, err := strconv.Unquote(literal.Value)
+			if err != nil || !words.MatchString(format) {
+				return true
+			}
+			for tag, messages := range translations {
+				translation, ok := messages[format]
+				if !ok {
+					t.Errorf("%s: %q has no translation in %s", fileSet.Position(literal.Pos()), format, tag)
+					continue
+				}
+				if strings.Join(verbs.FindAllString(translation, -1), "") != strings.Join(verbs.FindAllString(format, -1), "") {
+					t.Errorf("the translation of %q in %s has other verbs: %q", format, tag, translation)
+				}
+			}
+			return true
+		})
+	}
+}
+
+// printsMessage reports whether the function called is one of the printer methods or functions
+// which translate their format.
+func printsMessage(fun ast.Expr) bool {
+	switch fun := fun.(type) {
+	case *ast.Ident:
+		return fun.Name == "errorf"
+	case *ast.SelectorExpr:
+		if x, ok := fun.X.(*ast.Ident); ok && x.Name == "fmt" {
+			return false
+		}
+		return fun.Sel.Name == "Printf" || fun.Sel.Name == "Sprintf" || fun.Sel.Name == "errorf"
+	}
+	return false
+}
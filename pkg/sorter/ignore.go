@@ -0,0 +1,69 @@
+package sorter
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// globalIgnoreFile returns the path of the ignore file of the user, like ~/.config/filesorter/ignore
+// on Linux. Its patterns are excluded from every run like the ones passed with -exclude.
+func globalIgnoreFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "filesorter", "ignore")
+}
+
+// readGlobalIgnore reads the patterns of the global ignore file. The ignore file of the user is
+// skipped when it does not exist, while one passed explicitly has to exist. none turns it off.
+func readGlobalIgnore(ignoreFile string, printer *outputPrinter) ([]string, error) {
+	if strings.Compare(ignoreFile, "none") == 0 {
+		return nil, nil
+	}
+	if strings.Compare(ignoreFile, "") == 0 {
+		ignoreFile = globalIgnoreFile()
+		if _, err := os.Stat(ignoreFile); strings.Compare(ignoreFile, "") == 0 || os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+	patterns, err := readIgnoreFile(ignoreFile)
+	if err != nil {
+		return nil, printer.errorf("An error occurred while trying to read the ignore file %s: %v\n", ignoreFile, err)
+	}
+	return patterns, nil
+}
+
+// readIgnoreFile reads the glob patterns of an ignore file, one per line. The blank lines and the
+// lines starting with a # are skipped.
+func readIgnoreFile(ignoreFile string) ([]string, error) {
+	file, err := os.Open(ignoreFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.Compare(line, "") == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := checkExcludePattern(line, printer); err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+func checkExcludePattern(pattern string, printer *outputPrinter) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return printer.errorf("The exclude pattern %s is not valid\n", pattern)
+	}
+	return nil
+}
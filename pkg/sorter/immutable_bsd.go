@@ -0,0 +1,35 @@
+//go:build darwin || freebsd
+
+package sorter
+
+import (
+	"os"
+	"syscall"
+)
+
+// ufImmutable is the user immutable flag which the owner of the file can set like chflags uchg.
+const ufImmutable = 0x00000002
+
+func setImmutable(path string) error {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	var flags uint32
+	if stat, ok := fileInfo.Sys().(*syscall.Stat_t); ok {
+		flags = stat.Flags
+	}
+	return syscall.Chflags(path, int(flags|ufImmutable))
+}
+
+func clearImmutable(path string) error {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	stat, ok := fileInfo.Sys().(*syscall.Stat_t)
+	if !ok || stat.Flags&ufImmutable == 0 {
+		return nil
+	}
+	return syscall.Chflags(path, int(stat.Flags&^ufImmutable))
+}
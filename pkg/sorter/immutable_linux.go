@@ -0,0 +1,60 @@
+package sorter
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// the ioctls are defined with the size of a long but the kernel reads and writes an int
+	fsIOCGetFlags = 2<<30 | unsafe.Sizeof(uintptr(0))<<16 | 'f'<<8 | 1
+	fsIOCSetFlags = 1<<30 | unsafe.Sizeof(uintptr(0))<<16 | 'f'<<8 | 2
+	fsImmutableFl = 0x00000010
+)
+
+// setImmutable sets the immutable attribute like chattr +i. This needs root or CAP_LINUX_IMMUTABLE
+// and a file system which supports it.
+func setImmutable(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var flags int32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), fsIOCGetFlags, uintptr(unsafe.Pointer(&flags))); errno != 0 {
+		return errno
+	}
+	flags |= fsImmutableFl
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), fsIOCSetFlags, uintptr(unsafe.Pointer(&flags))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// clearImmutable clears the immutable attribute like chattr -i when it is set. A file system which
+// has no attributes can not have set it either.
+func clearImmutable(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var flags int32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), fsIOCGetFlags, uintptr(unsafe.Pointer(&flags))); errno != 0 {
+		if errno == syscall.ENOTTY || errno == syscall.EOPNOTSUPP || errno == syscall.EINVAL {
+			return nil
+		}
+		return errno
+	}
+	if flags&fsImmutableFl == 0 {
+		return nil
+	}
+	flags &^= fsImmutableFl
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), fsIOCSetFlags, uintptr(unsafe.Pointer(&flags))); errno != 0 {
+		return errno
+	}
+	return nil
+}
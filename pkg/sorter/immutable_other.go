@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !freebsd
+
+package sorter
+
+import "fmt"
+
+func setImmutable(path string) error {
+	return fmt.Errorf("the immutable flag is not supported on this platform")
+}
+
+// clearImmutable has nothing to do where the immutable flag can not be set.
+func clearImmutable(path string) error {
+	return nil
+}
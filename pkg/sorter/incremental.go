@@ -0,0 +1,127 @@
+package sorter
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/karrick/godirwalk"
+)
+
+// incrementalScan walks the source like godirwalk but does not list the directories whose modified
+// time is the same as when all their files were sorted by an earlier run. Only their sub directories,
+// which are known from the catalog, are looked at. The modified time of a directory changes when
+// files are added, removed or renamed in it but not when a file is changed in place, so those are
+// only noticed by a run without -incremental.
+type incrementalScan struct {
+	catalog *catalog
+	// key tells apart the runs of the same source into different destinations
+	key  string
+	opts *sortOptions
+
+	// listed, directories and unchanged are written by the scanner goroutine
+	listed      map[string]*scannedDir
+	directories int
+	unchanged   int
+	// files and failed are written by the goroutine processing the files
+	files  map[string]int
+	failed map[string]bool
+}
+
+// scannedDir is the summary of a directory kept in the catalog.
+type scannedDir struct {
+	modTime int64
+	subdirs []string
+	files   int
+}
+
+func newIncrementalScan(cat *catalog, opts *sortOptions) *incrementalScan {
+	var paths []string
+	for _, dest := range opts.destinations {
+		path, _ := filepath.Abs(dest.path)
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return &incrementalScan{
+		catalog: cat,
+		key:     strings.Join(paths, "\n"),
+		opts:    opts,
+		listed:  make(map[string]*scannedDir),
+		files:   make(map[string]int),
+		failed:  make(map[string]bool),
+	}
+}
+
+// walk visits the files under the directory in the same order as godirwalk. It stops with the error
+// returned by visit. Directories which can not be read are skipped like they are by the walk.
+func (s *incrementalScan) walk(dir string, visit func(path string, dirent *godirwalk.Dirent) error) error {
+	stat, err := os.Stat(dir)
+	if err != nil {
+		return nil
+	}
+	s.directories++
+
+	absDir, _ := filepath.Abs(dir)
+	previous, ok, err := s.catalog.scannedDir(absDir, s.key)
+	if err == nil && ok && previous.modTime == stat.ModTime().UnixNano() {
+		s.unchanged++
+		for _, name := range previous.subdirs {
+			if err := s.walk(filepath.Join(dir, name), visit); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	dirents, err := godirwalk.ReadDirents(dir, nil)
+	if err != nil {
+		return nil
+	}
+	sort.Sort(dirents)
+
+	summary := &scannedDir{modTime: stat.ModTime().UnixNano()}
+	for _, dirent := range dirents {
+		path := filepath.Join(dir, dirent.Name())
+		if dirent.IsDir() {
+			if isExcludedDir(path, dirent.Name(), s.opts) {
+				continue
+			}
+			summary.subdirs = append(summary.subdirs, dirent.Name())
+			if err := s.walk(path, visit); err != nil {
+				return err
+			}
+			continue
+		}
+		summary.files++
+		if err := visit(path, dirent); err != nil {
+			return err
+		}
+	}
+	s.listed[absDir] = summary
+	return nil
+}
+
+// processed records the result of processing a file of a listed directory.
+func (s *incrementalScan) processed(path string, err error) {
+	dir, _ := filepath.Abs(filepath.Dir(path))
+	s.files[dir]++
+	if err != nil && err != filepath.SkipDir {
+		s.failed[dir] = true
+	}
+}
+
+// save records the directories all of whose files were processed without errors so that the next
+// run can skip them. It must be called once the walk is done.
+func (s *incrementalScan) save() error {
+	for dir, summary := range s.listed {
+		if s.failed[dir] || s.files[dir] != summary.files {
+			continue
+		}
+		if err := s.catalog.recordScannedDir(dir, s.key, summary); err != nil {
+			return err
+		}
+	}
+	s.opts.printer.Printf("Skipped %d unchanged of %d directories\n", s.unchanged, s.directories)
+	return nil
+}
@@ -0,0 +1,157 @@
+package sorter
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karrick/godirwalk"
+)
+
+type indexCount struct {
+	indexedFiles   int
+	catalogedFiles int
+	erroredFiles   int
+}
+
+// runIndex adds the files of an existing destination to the catalog so that an archive created
+// before the catalog was used, or by other tools, can be verified and searched. The source of
+// these files is not known and is recorded as empty.
+func runIndex(args []string) int {
+
+	flags := flag.NewFlagSet("index", flag.ExitOnError)
+	catalogPath := flags.String("catalog", "", "The catalog database to add the files to. It is created if it does not exist.")
+	destPath := flags.String("destination", "", "The destination whose files should be added to the catalog.")
+	lang := AddLanguageFlag(flags)
+	parseFlags(flags, args)
+
+	if err := SetLanguage(*lang); err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	if strings.Compare(*catalogPath, "") == 0 || strings.Compare(*destPath, "") == 0 {
+		printer.Printf("Usage: filesorter index -catalog <catalog path> -destination <destination path>\n")
+		flags.PrintDefaults()
+		return 1
+	}
+	if !isPathValid(destPath, printer) {
+		return 1
+	}
+
+	cat, err := openCatalog(*catalogPath)
+	if err != nil {
+		printer.Printf("An error occurred while trying to open the catalog %s: %v\n", *catalogPath, err)
+		return 1
+	}
+	defer cat.Close()
+	if err := cat.startRun("index", *destPath); err != nil {
+		printer.Printf("An error occurred while trying to record the run in the catalog %s: %v\n", *catalogPath, err)
+		return 1
+	}
+
+	// the catalog may be kept in the destination itself. its journal files share its name as a prefix
+	catalogAbsPath, _ := filepath.Abs(*catalogPath)
+
+	var counts indexCount
+	godirwalk.Walk(*destPath, &godirwalk.Options{
+		Callback: func(path string, dirent *godirwalk.Dirent) error {
+			if dirent.IsDir() {
+				return nil
+			}
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(absPath, catalogAbsPath) {
+				return nil
+			}
+			if err := indexFile(cat, absPath, &counts); err != nil {
+				printer.Printf("An error occurred while trying to index the file %s: %v\n", path, err)
+				counts.erroredFiles++
+			}
+			return nil
+		},
+		ErrorCallback: func(path string, err error) godirwalk.ErrorAction {
+			printer.Printf("An error occurred while trying to read %s: %v\n", path, err)
+			counts.erroredFiles++
+			return godirwalk.SkipNode
+		},
+	})
+
+	printer.Printf("Completed !\n")
+	printer.Printf("Indexed %d files. Already in the catalog %d, Errored %d\n",
+		counts.indexedFiles,
+		counts.catalogedFiles,
+		counts.erroredFiles)
+
+	// the indexed files are recorded in the history like copies of a run
+	summary := processedCount{copiedFiles: counts.indexedFiles, skippedFiles: counts.catalogedFiles, erroredFiles: counts.erroredFiles}
+	if err := cat.finishRun(&summary); err != nil {
+		printer.Printf("An error occurred while trying to record the run in the catalog %s: %v\n", *catalogPath, err)
+	}
+
+	if counts.erroredFiles > 0 {
+		return 1
+	}
+	return 0
+}
+
+func indexFile(cat *catalog, path string, counts *indexCount) error {
+
+	cataloged, err := cat.has(path)
+	if err != nil {
+		return err
+	}
+	if cataloged {
+		counts.catalogedFiles++
+		return nil
+	}
+
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !fileInfo.Mode().IsRegular() {
+		return nil
+	}
+	hash, err := defaultIO.hashFile(path)
+	if err != nil {
+		return err
+	}
+
+	err = cat.record(catalogEntry{
+		destPath: path,
+		size:     fileInfo.Size(),
+		modTime:  fileInfo.ModTime(),
+		hash:     hash,
+		copiedAt: time.Now(),
+		sortTime: parseDateDestFilePath(path),
+	})
+	if err != nil {
+		return err
+	}
+	printer.Printf("Indexed %s\n", path)
+	counts.indexedFiles++
+	return nil
+}
+
+// parseDateDestFilePath reads the date back from a path of the date layout like
+// <destination>/2020/May/2/abc.txt, also with the folders of -bursts and -max-files-per-dir below
+// the day like <destination>/2020/May/2/burst-153059/001/abc.txt. It returns zero for the paths of
+// other layouts.
+func parseDateDestFilePath(path string) time.Time {
+	parts, _ := trimSubFolders(strings.Split(filepath.Dir(path), string(filepath.Separator)))
+	if len(parts) < 3 {
+		return time.Time{}
+	}
+	date := strings.Join(parts[len(parts)-3:], " ")
+	sortTime, err := time.ParseInLocation("2006 January 2", date, time.Local)
+	if err != nil || strconv.Itoa(sortTime.Day()) != parts[len(parts)-1] {
+		return time.Time{}
+	}
+	return sortTime
+}
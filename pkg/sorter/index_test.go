@@ -0,0 +1,68 @@
+package sorter
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseDateDestFilePath(t *testing.T) {
+	destination := filepath.Join(string(filepath.Separator), "mnt", "photos")
+	day := time.Date(2020, time.May, 2, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name string
+		path string
+		want time.Time
+	}{
+		{"date folders", filepath.Join(destination, "2020", "May", "2", "IMG_0001.jpg"), day},
+		{"bucket", filepath.Join(destination, "2020", "May", "2", "001", "IMG_0001.jpg"), day},
+		{"burst", filepath.Join(destination, "2020", "May", "2", "burst-153059", "IMG_0001.jpg"), day},
+		{"bucket in a burst", filepath.Join(destination, "2020", "May", "2", "burst-153059", "002", "IMG_0001.jpg"), day},
+		{"screenshots", filepath.Join(destination, "Screenshots", "2020", "May", "2", "IMG_0001.png"), day},
+		{"music", filepath.Join(destination, "Artist", "Album", "01 - Title.mp3"), time.Time{}},
+		{"day with a leading zero", filepath.Join(destination, "2020", "May", "02", "IMG_0001.jpg"), time.Time{}},
+		{"bucket without a date", filepath.Join(destination, "Album", "001", "IMG_0001.jpg"), time.Time{}},
+		{"too short", filepath.Join("May", "2", "IMG_0001.jpg"), time.Time{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := parseDateDestFilePath(test.path); !got.Equal(test.want) {
+				t.Errorf("parseDateDestFilePath(%q) = %v, want %v", test.path, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIndexBucketsAndBursts(t *testing.T) {
+	destination := t.TempDir()
+	catalogPath := filepath.Join(t.TempDir(), "catalog.db")
+	files := map[string]time.Time{
+		"2020/May/2/001/IMG_0001.jpg":               time.Date(2020, time.May, 2, 0, 0, 0, 0, time.Local),
+		"2020/May/2/burst-153059/IMG_0002.jpg":      time.Date(2020, time.May, 2, 0, 0, 0, 0, time.Local),
+		"2021/June/3/burst-101500/002/IMG_0003.jpg": time.Date(2021, time.June, 3, 0, 0, 0, 0, time.Local),
+		"Artist/Album/001/01 - Title.mp3":           {},
+	}
+	contents := make(map[string]string)
+	for name := range files {
+		contents[name] = name
+	}
+	writeFiles(t, destination, contents)
+
+	if code := Command("index")([]string{"-catalog", catalogPath, "-destination", destination}); code != 0 {
+		t.Fatalf("index exited with %d", code)
+	}
+	cat, err := openCatalog(catalogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cat.Close()
+	for name, want := range files {
+		entry, ok, err := cat.get(filepath.Join(destination, filepath.FromSlash(name)))
+		if err != nil || !ok {
+			t.Fatalf("%s is not in the catalog: %v", name, err)
+		}
+		if !entry.sortTime.Equal(want) {
+			t.Errorf("%s was indexed with the date %v, want %v", name, entry.sortTime, want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package sorter
+
+import "errors"
+
+// errOutOfInodes stops the walk before a destination runs out of inodes. Millions of small files
+// can use up the inodes of a file system long before its space, and the copies then fail one after
+// the other like on a full disk.
+var errOutOfInodes = errors.New("the destination is running out of inodes")
+
+// defaultMinFreeInodes is the number of inodes left free on a destination so that the directories
+// of the next files and the other programs writing to it still have some.
+const defaultMinFreeInodes = 1000
+
+// inodeGuard stops the run before the free inodes of a destination drop below the minimum. The
+// file systems are only asked every few files. After each look half of the inodes above the
+// minimum are handed out to the copies, since a copy can create some directories too.
+type inodeGuard struct {
+	minFree uint64
+	printer *outputPrinter
+	// budget is the number of copies each destination takes before its free inodes are looked at again
+	budget map[*destination]uint64
+	// exhausted is the destination which stopped the run
+	exhausted *destination
+	free      uint64
+}
+
+func newInodeGuard(minFree uint64, printer *outputPrinter) *inodeGuard {
+	return &inodeGuard{minFree: minFree, printer: printer, budget: make(map[*destination]uint64)}
+}
+
+// check reports the free inodes of the destinations which have less than a tenth of them left and
+// fails when one of them is already below the minimum. The destinations whose file system has no inode limit, like btrfs, are left out
+// and so are the remote destinations.
+func (g *inodeGuard) check(destinations []*destination) error {
+	for _, dest := range destinations {
+		if dest.remote != nil {
+			continue
+		}
+		free, total, ok := destinationInodes(dest.path)
+		if !ok {
+			continue
+		}
+		if free < total/10 {
+			g.printer.Printf("The destination %s has %d of %d inodes free\n", dest.path, free, total)
+		}
+		if free < g.minFree {
+			g.exhausted, g.free = dest, free
+			return errOutOfInodes
+		}
+	}
+	return nil
+}
+
+// take counts a copy of a file onto the destination.
+func (g *inodeGuard) take(dest *destination) error {
+	if dest.remote != nil {
+		return nil
+	}
+	if g.budget[dest] > 0 {
+		g.budget[dest]--
+		return nil
+	}
+	free, _, ok := destinationInodes(dest.path)
+	if !ok {
+		// the budget is not looked at again for a file system without an inode limit
+		g.budget[dest] = ^uint64(0)
+		return nil
+	}
+	if free <= g.minFree {
+		g.exhausted, g.free = dest, free
+		return errOutOfInodes
+	}
+	g.budget[dest] = (free - g.minFree) / 2
+	return nil
+}
+
+// destinationInodes returns the free and total inodes of the file system of the destination. A
+// destination which is not created yet is looked up on its nearest existing parent.
+func destinationInodes(path string) (free uint64, total uint64, ok bool) {
+	path, ok = nearestExistingPath(path)
+	if !ok {
+		return 0, 0, false
+	}
+	free, total, ok = statInodes(path)
+	// file systems without a fixed number of inodes report none at all
+	return free, total, ok && total > 0
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd
+
+package sorter
+
+// statInodes reports no inode limit since the file systems of the other platforms do not have one
+// or do not tell.
+func statInodes(path string) (uint64, uint64, bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,14 @@
+//go:build linux || darwin || freebsd
+
+package sorter
+
+import "syscall"
+
+// statInodes returns the free and total inodes of the file system of the path.
+func statInodes(path string) (uint64, uint64, bool) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, false
+	}
+	return uint64(stat.Ffree), uint64(stat.Files), true
+}
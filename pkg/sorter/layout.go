@@ -0,0 +1,269 @@
+package sorter
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// scheme organizes the files by their metadata instead of their date.
+type scheme struct {
+	defaultLayout string
+	// fields reads the metadata of the file. ok is false if the file is not supported by the scheme
+	// in which case it is sorted by date.
+	fields func(r *runIO, path string) (fields interface{}, ok bool, err error)
+	// sample is used to validate the layout before the run starts.
+	sample interface{}
+}
+
+var schemes = map[string]scheme{
+	"music": {
+		defaultLayout: `{{.AlbumArtist}}/{{.Album}}/{{if .Track}}{{printf "%02d" .Track}} - {{end}}{{.Title}}{{.Ext}}`,
+		fields:        (*runIO).musicFields,
+		sample:        musicLayoutFields{},
+	},
+	"ebook": {
+		defaultLayout: `{{.Author}}/{{.Title}}{{.Ext}}`,
+		fields:        (*runIO).ebookFields,
+		sample:        ebookLayoutFields{},
+	},
+}
+
+// musicLayoutFields are the tokens available to the -layout template when the music scheme is used.
+// Like in every scheme Name is the whole name of the file and Stem the name without its extension.
+type musicLayoutFields struct {
+	Artist      string
+	AlbumArtist string
+	Album       string
+	Title       string
+	Year        int
+	Track       int
+	Name        string
+	Stem        string
+	Ext         string
+}
+
+// ebookLayoutFields are the tokens available to the -layout template when the ebook scheme is used.
+type ebookLayoutFields struct {
+	Author string
+	Title  string
+	Year   int
+	Name   string
+	Stem   string
+	Ext    string
+}
+
+// dateLayoutFields are the tokens available to the -layout template when the files are sorted by
+// date. Month is the name like May and MonthNum the number. Name and File are the whole name of the
+// file and Stem is the name without its extension.
+// SourceFolder is the folder right under the source the file was found in, like PhoneB, to keep
+// the files of several devices imported together apart.
+type dateLayoutFields struct {
+	Year         int
+	MonthNum     int
+	Month        string
+	Day          int
+	Hour         int
+	Minute       int
+	Second       int
+	Name         string
+	Stem         string
+	Ext          string
+	File         string
+	SourceFolder string
+}
+
+func dateFields(file string, sortTime time.Time) dateLayoutFields {
+	ext := filepath.Ext(file)
+	return dateLayoutFields{
+		Year:     sortTime.Year(),
+		MonthNum: int(sortTime.Month()),
+		Month:    sortTime.Month().String(),
+		Day:      sortTime.Day(),
+		Hour:     sortTime.Hour(),
+		Minute:   sortTime.Minute(),
+		Second:   sortTime.Second(),
+		Name:     file,
+		Stem:     strings.TrimSuffix(file, ext),
+		Ext:      ext,
+		File:     file,
+	}
+}
+
+// sourceFolder returns the folder right under the source which holds the file, like PhoneB for
+// <source>/PhoneB/DCIM/abc.jpg. It is empty for the files right in the source.
+func sourceFolder(source string, path string) string {
+	if strings.Compare(source, "") == 0 {
+		return ""
+	}
+	relativePath, err := filepath.Rel(source, path)
+	if err != nil || strings.HasPrefix(relativePath, "..") {
+		return ""
+	}
+	if i := strings.IndexRune(relativePath, filepath.Separator); i >= 0 {
+		return relativePath[:i]
+	}
+	return ""
+}
+
+// parseLayout parses the layout and checks that it only refers to the fields available in sample.
+func parseLayout(layout string, sample interface{}, printer *outputPrinter) (*template.Template, error) {
+	tmpl, err := template.New("layout").Option("missingkey=error").Funcs(layoutFuncs).Parse(layout)
+	if err == nil {
+		_, err = renderLayout(tmpl, sample)
+	}
+	if err != nil {
+		return nil, printer.errorf("The layout %s is not a valid template: %v\n", layout, err)
+	}
+	return tmpl, nil
+}
+
+// layoutFuncs are the functions available to the layouts on top of the ones of text/template, to
+// tidy up messy metadata. The value comes last so that they can be used in pipelines like
+// {{.Album | slugify}} or {{.Title | substr 0 20}}.
+var layoutFuncs = template.FuncMap{
+	"lower":        strings.ToLower,
+	"upper":        strings.ToUpper,
+	"slugify":      slugify,
+	"substr":       substr,
+	"regexReplace": regexReplace,
+	"pad":          pad,
+}
+
+// slugify lowercases the value and replaces everything but letters and digits with single dashes,
+// like Summer Trip: Rome! into summer-trip-rome.
+func slugify(value string) string {
+	var slug strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(value) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && slug.Len() > 0 {
+				slug.WriteRune('-')
+			}
+			slug.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return slug.String()
+}
+
+// substr returns length characters of the value from start on. It is cut short at the end of the
+// value instead of failing.
+func substr(start int, length int, value string) string {
+	runes := []rune(value)
+	if start < 0 {
+		start = 0
+	}
+	if start > len(runes) {
+		start = len(runes)
+	}
+	end := start + length
+	if length < 0 || end > len(runes) {
+		end = len(runes)
+	}
+	return string(runes[start:end])
+}
+
+// regexReplace replaces the matches of the regular expression, in which the groups can be referred
+// to as $1 or ${1}.
+func regexReplace(pattern string, replacement string, value string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", err
+	}
+	return re.ReplaceAllString(value, replacement), nil
+}
+
+// pad adds zeros in front of a number or a text until it has width characters, like 7 into 07.
+func pad(width int, value interface{}) string {
+	text := fmt.Sprint(value)
+	if n := utf8.RuneCountInString(text); n < width {
+		text = strings.Repeat("0", width-n) + text
+	}
+	return text
+}
+
+func (r *runIO) musicFields(path string) (interface{}, bool, error) {
+	tags, ok, err := r.readMusicTags(path)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	// files without any tags are sorted by their date like the other files
+	if tags.empty() {
+		return nil, false, nil
+	}
+
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(filepath.Base(path), ext)
+
+	fields := musicLayoutFields{
+		Artist:      sanitizePathElement(tags.artist, "Unknown Artist"),
+		AlbumArtist: sanitizePathElement(tags.albumArtist, ""),
+		Album:       sanitizePathElement(tags.album, "Unknown Album"),
+		Title:       sanitizePathElement(tags.title, stem),
+		Year:        tags.year,
+		Track:       tags.track,
+		Name:        filepath.Base(path),
+		Stem:        stem,
+		Ext:         ext,
+	}
+	// compilations are kept together in one folder by using the album artist when it is available
+	if fields.AlbumArtist == "" {
+		fields.AlbumArtist = fields.Artist
+	}
+	return fields, true, nil
+}
+
+func (r *runIO) ebookFields(path string) (interface{}, bool, error) {
+	metadata, ok, err := r.readEbookMetadata(path)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(filepath.Base(path), ext)
+
+	return ebookLayoutFields{
+		Author: sanitizePathElement(metadata.author, "Unknown Author"),
+		Title:  sanitizePathElement(metadata.title, stem),
+		Year:   metadata.year,
+		Name:   filepath.Base(path),
+		Stem:   stem,
+		Ext:    ext,
+	}, true, nil
+}
+
+// renderLayout executes the layout and returns the resulting path relative to the destination.
+func renderLayout(layout *template.Template, fields interface{}) (string, error) {
+	var path bytes.Buffer
+	if err := layout.Execute(&path, fields); err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(path.String()), nil
+}
+
+// sanitizePathElement makes a metadata value safe to be used as a single file or folder name.
+// fallback is used if nothing remains of the value.
+func sanitizePathElement(value string, fallback string) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, value)
+
+	// windows does not allow names ending with a dot or space and a name of only dots is not a valid name anywhere
+	value = strings.TrimRight(strings.TrimSpace(value), ". ")
+	if value == "" {
+		return fallback
+	}
+	return value
+}
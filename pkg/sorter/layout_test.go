@@ -0,0 +1,36 @@
+package sorter
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDateLayout(t *testing.T) {
+	sortTime := time.Date(2023, time.July, 5, 14, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		layout string
+		want   string
+	}{
+		{"month numbers", `{{.Year}}/{{printf "%02d" .MonthNum}}/{{.Name}}`, filepath.Join("2023", "07", "IMG_0001.JPG")},
+		{"file", `{{.Year}}/{{.Month}}/{{.Day}}/{{.File}}`, filepath.Join("2023", "July", "5", "IMG_0001.JPG")},
+		{"stem and extension", `{{.Year}}/{{.Stem | lower}}{{.Ext | lower}}`, filepath.Join("2023", "img_0001.jpg")},
+		{"time", `{{.Hour}}{{.Minute}}-{{.Stem}}{{.Ext}}`, "1430-IMG_0001.JPG"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			layout, err := parseLayout(test.layout, dateLayoutFields{}, printer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := renderLayout(layout, dateFields("IMG_0001.JPG", sortTime))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("renderLayout(%q) = %q, want %q", test.layout, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package sorter
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// errManifestMismatch is returned when a copied file does not match the hash in the MHL manifest
+// of the source, which means the camera media or the copy is corrupt.
+var errManifestMismatch = errors.New("the copied file does not match the MHL manifest")
+
+// mhlHashes are the hashes of the MHL manifests which can be checked, in the order of preference.
+var mhlHashes = []struct {
+	algorithm string
+	new       func() hash.Hash
+}{
+	{"xxh64", func() hash.Hash { return newXXH64() }},
+	{"md5", md5.New},
+	{"sha1", sha1.New},
+	{"sha256", sha256.New},
+}
+
+// mhlEntry is a file of an MHL manifest. Version 1 lists the files in hash elements under the root
+// and ASC MHL version 2 in a hashes element, with the path and size named differently.
+type mhlEntry struct {
+	File string `xml:"file"`
+	Size int64  `xml:"size"`
+	Path struct {
+		Value string `xml:",chardata"`
+		Size  int64  `xml:"size,attr"`
+	} `xml:"path"`
+	MD5    string `xml:"md5"`
+	SHA1   string `xml:"sha1"`
+	SHA256 string `xml:"sha256"`
+	XXH64  string `xml:"xxh64"`
+	// version 1 writes the xxHash64 either as a decimal number or as hex
+	XXHash64   string `xml:"xxhash64"`
+	XXHash64BE string `xml:"xxhash64be"`
+}
+
+type mhlManifest struct {
+	V1 []mhlEntry `xml:"hash"`
+	V2 []mhlEntry `xml:"hashes>hash"`
+}
+
+// mhlHash is the expected size and hash of a source file.
+type mhlHash struct {
+	manifest  string
+	size      int64
+	algorithm string
+	value     string
+}
+
+// mhlIndex holds the hashes of the MHL manifests found in the source. The manifests of a folder
+// are read when a file below it is first copied, from the folder itself for version 1 and from its
+// ascmhl sub folder for version 2.
+type mhlIndex struct {
+	root   string
+	runIO  *runIO
+	loaded map[string]struct{}
+	hashes map[string]mhlHash
+}
+
+func newMHLIndex(root string, runIO *runIO) *mhlIndex {
+	if absRoot, err := filepath.Abs(root); err == nil {
+		root = absRoot
+	}
+	return &mhlIndex{root: root, runIO: runIO, loaded: make(map[string]struct{}), hashes: make(map[string]mhlHash)}
+}
+
+// lookup returns the hash of the source file from the manifests of its folder or of any folder
+// above it up to the source.
+func (m *mhlIndex) lookup(path string) (mhlHash, bool) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return mhlHash{}, false
+	}
+	for dir := filepath.Dir(absPath); ; dir = filepath.Dir(dir) {
+		m.load(dir)
+		if dir == m.root || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	h, ok := m.hashes[absPath]
+	return h, ok
+}
+
+func (m *mhlIndex) load(dir string) {
+	if _, ok := m.loaded[dir]; ok {
+		return
+	}
+	m.loaded[dir] = struct{}{}
+
+	v1, _ := filepath.Glob(filepath.Join(dir, "*.mhl"))
+	v2, _ := filepath.Glob(filepath.Join(dir, "ascmhl", "*.mhl"))
+	for _, manifest := range append(v1, v2...) {
+		count, err := m.read(manifest, dir)
+		if err != nil {
+			m.runIO.printer.Printf("An error occurred while trying to read the MHL manifest %s: %v\n", manifest, err)
+			continue
+		}
+		m.runIO.printer.Printf("Read the hashes of %d files from the MHL manifest %s\n", count, manifest)
+	}
+}
+
+// read adds the hashes of the manifest. The paths in it are relative to dir.
+func (m *mhlIndex) read(manifest string, dir string) (int, error) {
+	file, err := m.runIO.openSource(manifest)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var content mhlManifest
+	if err := xml.NewDecoder(file).Decode(&content); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, entry := range append(content.V1, content.V2...) {
+		path, size := entry.File, entry.Size
+		if strings.Compare(path, "") == 0 {
+			path, size = entry.Path.Value, entry.Path.Size
+		}
+		path = strings.TrimSpace(path)
+		h, ok := entry.hash()
+		if strings.Compare(path, "") == 0 || !ok {
+			continue
+		}
+		// the manifests written on windows separate the folders with a backslash
+		path = filepath.Join(dir, filepath.FromSlash(strings.ReplaceAll(path, "\\", "/")))
+		h.manifest, h.size = manifest, size
+		m.hashes[path] = h
+		count++
+	}
+	return count, nil
+}
+
+// hash returns the preferred hash of the entry with the value as lower case hex.
+func (entry *mhlEntry) hash() (mhlHash, bool) {
+	xxh := strings.TrimSpace(entry.XXH64)
+	if strings.Compare(xxh, "") == 0 {
+		xxh = strings.TrimSpace(entry.XXHash64BE)
+	}
+	if strings.Compare(xxh, "") == 0 && strings.Compare(strings.TrimSpace(entry.XXHash64), "") != 0 {
+		if n, err := strconv.ParseUint(strings.TrimSpace(entry.XXHash64), 10, 64); err == nil {
+			xxh = fmt.Sprintf("%016x", n)
+		}
+	}
+	values := map[string]string{"xxh64": xxh, "md5": entry.MD5, "sha1": entry.SHA1, "sha256": entry.SHA256}
+	for _, h := range mhlHashes {
+		if value := strings.ToLower(strings.TrimSpace(values[h.algorithm])); strings.Compare(value, "") != 0 {
+			return mhlHash{algorithm: h.algorithm, value: value}, true
+		}
+	}
+	return mhlHash{}, false
+}
+
+// check reads back the copy of a source file listed in a manifest and compares it with the hash
+// in the manifest. A mismatch is counted as a corrupted copy.
+func (m *mhlIndex) check(path string, destFilePath string, counts *processedCount) error {
+	expected, ok := m.lookup(path)
+	if !ok {
+		return nil
+	}
+	counts.manifestChecked++
+
+	var sum hash.Hash
+	for _, h := range mhlHashes {
+		if strings.Compare(h.algorithm, expected.algorithm) == 0 {
+			sum = h.new()
+		}
+	}
+	file, err := os.Open(destFilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	size, err := io.Copy(sum, file)
+	if err != nil {
+		return err
+	}
+
+	if (expected.size > 0 && size != expected.size) || strings.Compare(hex.EncodeToString(sum.Sum(nil)), expected.value) != 0 {
+		m.runIO.printer.Printf("The copied file %s does not match the %s of %s\n", destFilePath, expected.algorithm, expected.manifest)
+		detail := m.runIO.printer.Sprintf("does not match the %s of %s", expected.algorithm, expected.manifest)
+		counts.corruptedCopies = append(counts.corruptedCopies, alertFile{Path: destFilePath, Detail: detail})
+		return errManifestMismatch
+	}
+	return nil
+}
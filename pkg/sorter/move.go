@@ -0,0 +1,131 @@
+package sorter
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// moveByRename moves the source to the destination with a rename when both are on the same file
+// system, which is found by comparing their device ids. It returns false when the file has to be
+// copied instead.
+func moveByRename(path string, sourceFileStat os.FileInfo, c *fileCopy, opts *sortOptions) bool {
+	if !sameDevice(sourceFileStat, filepath.Dir(c.destFilePath)) {
+		return false
+	}
+	if err := os.Rename(path, c.destFilePath); err != nil {
+		return false
+	}
+	c.moved = true
+	// the hash is only read back when something needs it since that is what the rename saves
+	if opts.catalog != nil || opts.protect {
+		c.hash, c.err = opts.runIO.hashFile(c.destFilePath)
+	}
+	return true
+}
+
+// sameDevice reports whether the source file and the destination directory are on the same
+// device. It is true when that is not known so that the rename is tried anyway.
+func sameDevice(sourceFileStat os.FileInfo, destDir string) bool {
+	sourceDevice, ok := deviceOf(sourceFileStat)
+	if !ok {
+		return true
+	}
+	destDirStat, err := os.Stat(destDir)
+	if err != nil {
+		return true
+	}
+	destDevice, ok := deviceOf(destDirStat)
+	return !ok || sourceDevice == destDevice
+}
+
+// confirmDuplicate tells whether the file a skipped copy found at the destination has the same
+// content as the source, so that -move can remove the source. The sizes matching is not enough to
+// remove it for, so the contents are compared unless -compare hash compared them already. An
+// uploaded file is only known to be the same with -compare hash. The hash of the source is kept
+// in the copy for the manifest of undo.
+func confirmDuplicate(path string, sourceFileStat os.FileInfo, c *fileCopy, sourceHash *string, opts *sortOptions) (bool, error) {
+	if c.conflict || c.destFileStat == nil || isSameFile(sourceFileStat, c.destFileStat) {
+		return false, nil
+	}
+	if !opts.compareHash && c.dest.remote != nil {
+		return false, nil
+	}
+	var err error
+	if *sourceHash == "" {
+		if *sourceHash, err = opts.runIO.hashFile(path); err != nil {
+			return false, err
+		}
+	}
+	c.hash = *sourceHash
+	if opts.compareHash {
+		return true, nil
+	}
+	destHash, err := opts.runIO.hashFile(c.destFilePath)
+	if err != nil {
+		return false, err
+	}
+	return destHash == *sourceHash, nil
+}
+
+// removeMovedSource removes the source once it was copied to every destination. A source which was
+// renamed into the destination is already gone. Every copy is read back and compared with the hash
+// of the source before, so that the source is never removed for a copy which does not match it.
+// The duplicates, which were at their destination already, were compared before they were skipped.
+func removeMovedSource(path string, size int64, copies []*fileCopy, duplicates []*fileCopy, opts *sortOptions, counts *processedCount) error {
+	for _, c := range copies {
+		if c.moved {
+			counts.movedFiles++
+			counts.totalBytesMoved += size
+			return nil
+		}
+	}
+	for _, c := range copies {
+		if c.unreadable > 0 {
+			opts.printer.Printf("The moved file %s is left in the source since parts of it could not be read\n", path)
+			return nil
+		}
+		if c.split {
+			opts.printer.Printf("The moved file %s is left in the source since it was copied in parts\n", path)
+			return nil
+		}
+	}
+	for _, c := range copies {
+		// -protect and -verify already compared the copy. the transfer of an upload was checked
+		// by the protocol
+		if opts.protect || opts.verify || c.dest.remote != nil {
+			continue
+		}
+		destHash, err := opts.runIO.hashFile(c.destFilePath)
+		if err != nil {
+			opts.printer.Printf("An error occurred while trying to verify the copy %s of the moved file %s. It is left in the source\n", c.destFilePath, path)
+			return err
+		}
+		if destHash != c.hash {
+			opts.printer.Printf("The copy %s does not match the moved file %s. It is left in the source\n", c.destFilePath, path)
+			counts.corruptedCopies = append(counts.corruptedCopies, alertFile{Path: c.destFilePath, Detail: errCopyMismatch.Error()})
+			return errCopyMismatch
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		opts.printer.Printf("An error occurred while trying to remove the moved file %s from the source", path)
+		return err
+	}
+	if len(copies) == 0 {
+		opts.printer.Printf("Removed the moved file %s from the source, the same file is already at %s\n", path, duplicates[0].destFilePath)
+	}
+	// undo copies the source back from the first copy, or the file which was there already
+	if opts.undoManifest != nil {
+		first := duplicates[0]
+		if len(copies) > 0 {
+			first = copies[0]
+		}
+		op := manifestOp{Op: "remove-source", Source: path, Destination: first.destFilePath, Hash: first.hash}
+		if err := opts.undoManifest.record(op); err != nil {
+			opts.printer.Printf("An error occurred while trying to record the moved file %s in the manifest", path)
+			return err
+		}
+	}
+	counts.movedFiles++
+	counts.totalBytesMoved += size
+	return nil
+}
@@ -0,0 +1,57 @@
+package sorter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMoveRemovesDuplicates(t *testing.T) {
+	source, destination := t.TempDir(), t.TempDir()
+	writeFiles(t, source, map[string]string{
+		"IMG_0001.jpg": "same",
+		"IMG_0002.jpg": "ours",
+		"IMG_0003.jpg": "new",
+	})
+	writeFiles(t, destination, map[string]string{
+		"2023/July/15/IMG_0001.jpg": "same",
+		"2023/July/15/IMG_0002.jpg": "them",
+	})
+	taken := time.Date(2023, time.July, 15, 12, 0, 0, 0, time.Local)
+	for _, name := range []string{"IMG_0001.jpg", "IMG_0002.jpg", "IMG_0003.jpg"} {
+		if err := os.Chtimes(filepath.Join(source, name), taken, taken); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := New(Options{Source: source, Destinations: []string{destination}, Move: true, GlobalIgnore: "none", MinFreeInodes: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.MovedFiles != 2 {
+		t.Errorf("moved %d files, want 2", report.MovedFiles)
+	}
+	tests := []struct {
+		path   string
+		exists bool
+	}{
+		{filepath.Join(source, "IMG_0001.jpg"), false},
+		{filepath.Join(source, "IMG_0002.jpg"), true},
+		{filepath.Join(source, "IMG_0003.jpg"), false},
+		{filepath.Join(destination, "2023", "July", "15", "IMG_0003.jpg"), true},
+	}
+	for _, test := range tests {
+		if _, err := os.Stat(test.path); (err == nil) != test.exists {
+			t.Errorf("%s exists %v, want %v", test.path, err == nil, test.exists)
+		}
+	}
+	if content, err := os.ReadFile(filepath.Join(destination, "2023", "July", "15", "IMG_0002.jpg")); err != nil || string(content) != "them" {
+		t.Errorf("the file of the same size at the destination was changed to %q, %v", content, err)
+	}
+}
@@ -0,0 +1,482 @@
+package sorter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// musicTags are the tags read from the ID3 or vorbis comments of an audio file.
+type musicTags struct {
+	artist      string
+	albumArtist string
+	album       string
+	title       string
+	year        int
+	track       int
+}
+
+func (tags musicTags) empty() bool {
+	return tags.artist == "" && tags.albumArtist == "" && tags.album == "" && tags.title == ""
+}
+
+// musicExtensions are the audio files whose tags are read.
+var musicExtensions = map[string]func(r *runIO, path string) (musicTags, error){
+	".mp3":  (*runIO).readID3Tags,
+	".flac": (*runIO).readFLACTags,
+	".m4a":  (*runIO).readMP4Tags,
+	".m4b":  (*runIO).readMP4Tags,
+	".ogg":  (*runIO).readOggTags,
+	".oga":  (*runIO).readOggTags,
+	".opus": (*runIO).readOggTags,
+}
+
+// readMusicTags reads the tags of mp3, flac, mp4 and ogg files. ok is false if the file is not one
+// of those.
+func (r *runIO) readMusicTags(path string) (musicTags, bool, error) {
+	read, ok := musicExtensions[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return musicTags{}, false, nil
+	}
+	tags, err := read(r, path)
+	return tags, err == nil, err
+}
+
+// readID3Tags reads the ID3v2 tag at the start of the file and fills in anything missing from
+// the ID3v1 tag at the end of the file.
+func (r *runIO) readID3Tags(path string) (musicTags, error) {
+
+	file, err := r.openSource(path)
+	if err != nil {
+		return musicTags{}, err
+	}
+	defer file.Close()
+
+	var tags musicTags
+
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(file, header); err == nil && bytes.Equal(header[:3], []byte("ID3")) {
+		body := make([]byte, syncsafe(header[6:10]))
+		if _, err := io.ReadFull(file, body); err != nil {
+			return musicTags{}, err
+		}
+		tags = parseID3v2(header[3], header[5], body)
+	}
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return musicTags{}, err
+	}
+	if fileInfo.Size() >= 128 {
+		trailer := make([]byte, 128)
+		if _, err := file.ReadAt(trailer, fileInfo.Size()-128); err != nil {
+			return musicTags{}, err
+		}
+		if bytes.Equal(trailer[:3], []byte("TAG")) {
+			mergeID3v1(&tags, trailer)
+		}
+	}
+
+	return tags, nil
+}
+
+func parseID3v2(version byte, flags byte, body []byte) musicTags {
+	var tags musicTags
+
+	// skip the extended header
+	if flags&0x40 != 0 && len(body) >= 4 {
+		size := int(binary.BigEndian.Uint32(body))
+		if version == 4 {
+			size = syncsafe(body[:4])
+		} else {
+			size += 4
+		}
+		if size > len(body) {
+			return tags
+		}
+		body = body[size:]
+	}
+
+	// v2.2 uses 3 character ids with 3 byte sizes and no flags
+	idLength, headerLength := 4, 10
+	if version == 2 {
+		idLength, headerLength = 3, 6
+	}
+
+	for len(body) >= headerLength && body[0] != 0 {
+		id := string(body[:idLength])
+		var size int
+		switch version {
+		case 2:
+			size = int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+		case 3:
+			size = int(binary.BigEndian.Uint32(body[4:8]))
+		default:
+			size = syncsafe(body[4:8])
+		}
+		if size > len(body)-headerLength {
+			break
+		}
+		frame := body[headerLength : headerLength+size]
+		var frameFlags byte
+		if version != 2 {
+			frameFlags = body[9]
+		}
+		body = body[headerLength+size:]
+
+		// compressed and encrypted frames are not supported
+		if (version == 3 && frameFlags&0xC0 != 0) || (version == 4 && frameFlags&0x0C != 0) {
+			continue
+		}
+
+		value := decodeID3Text(frame)
+		switch id {
+		case "TPE1", "TP1":
+			tags.artist = value
+		case "TPE2", "TP2":
+			tags.albumArtist = value
+		case "TALB", "TAL":
+			tags.album = value
+		case "TIT2", "TT2":
+			tags.title = value
+		case "TRCK", "TRK":
+			tags.track = leadingNumber(value)
+		case "TYER", "TYE", "TDRC":
+			tags.year = leadingNumber(value)
+		}
+	}
+	return tags
+}
+
+func mergeID3v1(tags *musicTags, trailer []byte) {
+	field := func(from, to int) string {
+		return strings.TrimSpace(string(bytes.TrimRight(trailer[from:to], "\x00 ")))
+	}
+	if tags.title == "" {
+		tags.title = field(3, 33)
+	}
+	if tags.artist == "" {
+		tags.artist = field(33, 63)
+	}
+	if tags.album == "" {
+		tags.album = field(63, 93)
+	}
+	if tags.year == 0 {
+		tags.year = leadingNumber(field(93, 97))
+	}
+	// ID3v1.1 stores the track in the last byte of the comment if the byte before it is zero
+	if tags.track == 0 && trailer[125] == 0 {
+		tags.track = int(trailer[126])
+	}
+}
+
+// decodeID3Text decodes a text frame whose first byte is the encoding of the rest of the frame.
+func decodeID3Text(frame []byte) string {
+	if len(frame) < 1 {
+		return ""
+	}
+	encoding, text := frame[0], frame[1:]
+
+	switch encoding {
+	case 1, 2:
+		var order binary.ByteOrder = binary.BigEndian
+		if len(text) >= 2 && text[0] == 0xFF && text[1] == 0xFE {
+			order, text = binary.LittleEndian, text[2:]
+		} else if len(text) >= 2 && text[0] == 0xFE && text[1] == 0xFF {
+			text = text[2:]
+		}
+		units := make([]uint16, 0, len(text)/2)
+		for i := 0; i+1 < len(text); i += 2 {
+			unit := order.Uint16(text[i:])
+			if unit == 0 {
+				break
+			}
+			units = append(units, unit)
+		}
+		return strings.TrimSpace(string(utf16.Decode(units)))
+	case 0:
+		// ISO-8859-1 maps directly to the first 256 code points
+		runes := make([]rune, 0, len(text))
+		for _, b := range text {
+			if b == 0 {
+				break
+			}
+			runes = append(runes, rune(b))
+		}
+		return strings.TrimSpace(string(runes))
+	default:
+		if i := bytes.IndexByte(text, 0); i >= 0 {
+			text = text[:i]
+		}
+		return strings.TrimSpace(string(text))
+	}
+}
+
+// readFLACTags reads the vorbis comment block from the metadata blocks at the start of a flac file.
+func (r *runIO) readFLACTags(path string) (musicTags, error) {
+
+	file, err := r.openSource(path)
+	if err != nil {
+		return musicTags{}, err
+	}
+	defer file.Close()
+
+	var tags musicTags
+
+	marker := make([]byte, 4)
+	if _, err := io.ReadFull(file, marker); err != nil || !bytes.Equal(marker, []byte("fLaC")) {
+		return tags, err
+	}
+
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(file, header); err != nil {
+			return tags, err
+		}
+		last, blockType := header[0]&0x80 != 0, header[0]&0x7F
+		size := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+
+		if blockType == 4 {
+			block := make([]byte, size)
+			if _, err := io.ReadFull(file, block); err != nil {
+				return tags, err
+			}
+			return parseVorbisComments(block), nil
+		}
+		if last {
+			return tags, nil
+		}
+		if _, err := file.Seek(size, io.SeekCurrent); err != nil {
+			return tags, err
+		}
+	}
+}
+
+// parseVorbisComments parses a vorbis comment block which is a vendor string followed by KEY=value
+// comments, all of them prefixed by their little endian length.
+func parseVorbisComments(block []byte) musicTags {
+	var tags musicTags
+
+	next := func() (string, bool) {
+		if len(block) < 4 {
+			return "", false
+		}
+		size := binary.LittleEndian.Uint32(block)
+		if uint64(size) > uint64(len(block)-4) {
+			return "", false
+		}
+		value := string(block[4 : 4+size])
+		block = block[4+size:]
+		return value, true
+	}
+
+	if _, ok := next(); !ok {
+		return tags
+	}
+	if len(block) < 4 {
+		return tags
+	}
+	count := binary.LittleEndian.Uint32(block)
+	block = block[4:]
+
+	for i := uint32(0); i < count; i++ {
+		comment, ok := next()
+		if !ok {
+			break
+		}
+		key, value, found := strings.Cut(comment, "=")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToUpper(key) {
+		case "ARTIST":
+			tags.artist = value
+		case "ALBUMARTIST", "ALBUM ARTIST":
+			tags.albumArtist = value
+		case "ALBUM":
+			tags.album = value
+		case "TITLE":
+			tags.title = value
+		case "TRACKNUMBER":
+			tags.track = leadingNumber(value)
+		case "DATE", "YEAR":
+			tags.year = leadingNumber(value)
+		}
+	}
+	return tags
+}
+
+// maxTagBytes limits how much of a file is read for its tags, which can hold a large cover image.
+const maxTagBytes = 64 << 20
+
+// readMP4Tags reads the iTunes style tags from the moov/udta/meta/ilst atoms of an m4a file.
+func (r *runIO) readMP4Tags(path string) (musicTags, error) {
+
+	file, err := r.openSource(path)
+	if err != nil {
+		return musicTags{}, err
+	}
+	defer file.Close()
+
+	// the moov atom is read as a whole. the others at the top like the media data are skipped
+	var moov []byte
+	header := make([]byte, 8)
+	for moov == nil {
+		if _, err := io.ReadFull(file, header); err != nil {
+			if err == io.EOF {
+				return musicTags{}, nil
+			}
+			return musicTags{}, err
+		}
+		size, headerSize := int64(binary.BigEndian.Uint32(header)), int64(8)
+		switch size {
+		case 0:
+			// the last atom runs to the end of the file
+			return musicTags{}, nil
+		case 1:
+			large := make([]byte, 8)
+			if _, err := io.ReadFull(file, large); err != nil {
+				return musicTags{}, err
+			}
+			size, headerSize = int64(binary.BigEndian.Uint64(large)), 16
+		}
+		if size < headerSize {
+			return musicTags{}, nil
+		}
+		if string(header[4:8]) != "moov" {
+			if _, err := file.Seek(size-headerSize, io.SeekCurrent); err != nil {
+				return musicTags{}, err
+			}
+			continue
+		}
+		if size-headerSize > maxTagBytes {
+			return musicTags{}, nil
+		}
+		moov = make([]byte, size-headerSize)
+		if _, err := io.ReadFull(file, moov); err != nil {
+			return musicTags{}, err
+		}
+	}
+
+	ilst := childBox(childBox(moov, "udta"), "meta")
+	// meta is a full atom with a version and flags before its children
+	if len(ilst) < 4 {
+		return musicTags{}, nil
+	}
+	ilst = childBox(ilst[4:], "ilst")
+
+	var tags musicTags
+	for len(ilst) >= 8 {
+		size := int(binary.BigEndian.Uint32(ilst))
+		if size < 8 || size > len(ilst) {
+			break
+		}
+		name, data := string(ilst[4:8]), childBox(ilst[8:size], "data")
+		ilst = ilst[size:]
+		// the value follows the type and the locale of the data atom
+		if len(data) < 8 {
+			continue
+		}
+		value := data[8:]
+		switch name {
+		case "\xa9ART":
+			tags.artist = strings.TrimSpace(string(value))
+		case "aART":
+			tags.albumArtist = strings.TrimSpace(string(value))
+		case "\xa9alb":
+			tags.album = strings.TrimSpace(string(value))
+		case "\xa9nam":
+			tags.title = strings.TrimSpace(string(value))
+		case "\xa9day":
+			tags.year = leadingNumber(string(value))
+		case "trkn":
+			// the track and the number of tracks after two bytes of padding
+			if len(value) >= 4 {
+				tags.track = int(binary.BigEndian.Uint16(value[2:4]))
+			}
+		}
+	}
+	return tags, nil
+}
+
+// readOggTags reads the comment header of an ogg vorbis or opus file, the second packet of the
+// stream, which holds the same comments as a flac file.
+func (r *runIO) readOggTags(path string) (musicTags, error) {
+
+	file, err := r.openSource(path)
+	if err != nil {
+		return musicTags{}, err
+	}
+	defer file.Close()
+
+	// the packets are split into segments of up to 255 bytes over the pages. a shorter segment ends
+	// a packet
+	var packet []byte
+	packets := 0
+	read := 0
+	header := make([]byte, 27)
+	for read < maxTagBytes {
+		if _, err := io.ReadFull(file, header); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return musicTags{}, nil
+			}
+			return musicTags{}, err
+		}
+		if !bytes.Equal(header[:4], []byte("OggS")) {
+			return musicTags{}, nil
+		}
+		segments := make([]byte, header[26])
+		if _, err := io.ReadFull(file, segments); err != nil {
+			return musicTags{}, err
+		}
+		for _, segment := range segments {
+			content := make([]byte, segment)
+			if _, err := io.ReadFull(file, content); err != nil {
+				return musicTags{}, err
+			}
+			read += len(content)
+			if packets == 1 {
+				packet = append(packet, content...)
+			}
+			if segment < 255 {
+				packets++
+				if packets == 2 {
+					return parseOggComments(packet), nil
+				}
+			}
+		}
+	}
+	return musicTags{}, nil
+}
+
+// parseOggComments parses the comment header of vorbis, which starts with \x03vorbis, or of opus,
+// which starts with OpusTags.
+func parseOggComments(packet []byte) musicTags {
+	switch {
+	case bytes.HasPrefix(packet, []byte("\x03vorbis")):
+		return parseVorbisComments(packet[7:])
+	case bytes.HasPrefix(packet, []byte("OpusTags")):
+		return parseVorbisComments(packet[8:])
+	}
+	return musicTags{}
+}
+
+// syncsafe decodes the 28 bit integers used by ID3v2 where the top bit of every byte is unused.
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
+
+// leadingNumber returns the number at the start of values like "3/12" or "2020-05-02".
+func leadingNumber(value string) int {
+	end := 0
+	for end < len(value) && value[end] >= '0' && value[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(value[:end])
+	return n
+}
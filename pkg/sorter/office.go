@@ -0,0 +1,154 @@
+package sorter
+
+import (
+	"encoding/binary"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	officeOpenXMLTypes = map[string]struct{}{
+		".docx": {}, ".docm": {}, ".dotx": {}, ".xlsx": {}, ".xlsm": {}, ".pptx": {}, ".pptm": {},
+	}
+	openDocumentTypes = map[string]struct{}{
+		".odt": {}, ".ods": {}, ".odp": {}, ".odg": {},
+	}
+	legacyOfficeTypes = map[string]struct{}{
+		".doc": {}, ".dot": {}, ".xls": {}, ".ppt": {},
+	}
+
+	coreCreatedDate  = regexp.MustCompile(`<dcterms:created[^>]*>([^<]+)<`)
+	coreModifiedDate = regexp.MustCompile(`<dcterms:modified[^>]*>([^<]+)<`)
+	odfCreationDate  = regexp.MustCompile(`<meta:creation-date>([^<]+)<`)
+	odfModifiedDate  = regexp.MustCompile(`<dc:date>([^<]+)<`)
+)
+
+const (
+	// property ids of the dates in the SummaryInformation property set
+	pidCreateDate   = 0x0C
+	pidLastSaveDate = 0x0D
+	vtFileTime      = 0x40
+)
+
+// officeDate reads the creation date, falling back to the last modified date, from the document
+// properties of office documents. Attachments and downloads carry the download time as their
+// modified time which makes it useless for sorting them.
+func (r *runIO) officeDate(path string) (time.Time, bool, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+
+	if _, ok := officeOpenXMLTypes[ext]; ok {
+		return r.zipMetadataDate(path, "docProps/core.xml", coreCreatedDate, coreModifiedDate)
+	}
+	if _, ok := openDocumentTypes[ext]; ok {
+		return r.zipMetadataDate(path, "meta.xml", odfCreationDate, odfModifiedDate)
+	}
+	if _, ok := legacyOfficeTypes[ext]; ok {
+		return r.legacyOfficeDate(path)
+	}
+	return time.Time{}, false, nil
+}
+
+// zipMetadataDate reads the metadata file from the zip container and returns the first date
+// matched by the patterns in order.
+func (r *runIO) zipMetadataDate(path string, metadataFile string, patterns ...*regexp.Regexp) (time.Time, bool, error) {
+
+	archive, source, err := r.openSourceZip(path)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	defer source.Close()
+
+	for _, file := range archive.File {
+		if file.Name != metadataFile {
+			continue
+		}
+
+		reader, err := file.Open()
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		content, err := io.ReadAll(io.LimitReader(reader, 1024*1024))
+		reader.Close()
+		if err != nil {
+			return time.Time{}, false, err
+		}
+
+		for _, pattern := range patterns {
+			match := pattern.FindSubmatch(content)
+			if match == nil {
+				continue
+			}
+			if date, err := parseISODate(string(match[1])); err == nil {
+				return date, true, nil
+			}
+		}
+	}
+	return time.Time{}, false, nil
+}
+
+// legacyOfficeDate reads the dates from the SummaryInformation stream of the pre 2007 office formats.
+func (r *runIO) legacyOfficeDate(path string) (time.Time, bool, error) {
+
+	cfb, err := r.openCFB(path)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	defer cfb.Close()
+
+	stream, ok, err := cfb.readStream("\x05SummaryInformation")
+	if err != nil || !ok {
+		return time.Time{}, false, err
+	}
+
+	properties := readPropertySetDates(stream)
+	for _, id := range []uint32{pidCreateDate, pidLastSaveDate} {
+		if date, ok := properties[id]; ok {
+			return date, true, nil
+		}
+	}
+	return time.Time{}, false, nil
+}
+
+// readPropertySetDates returns the FILETIME properties from the first section of a property set stream.
+func readPropertySetDates(stream []byte) map[uint32]time.Time {
+	dates := make(map[uint32]time.Time)
+
+	// the header is 28 bytes followed by the format id and offset of each section
+	if len(stream) < 48 {
+		return dates
+	}
+	section := int(binary.LittleEndian.Uint32(stream[44:]))
+	if section+8 > len(stream) {
+		return dates
+	}
+
+	count := int(binary.LittleEndian.Uint32(stream[section+4:]))
+	for i := 0; i < count; i++ {
+		entry := section + 8 + i*8
+		if entry+8 > len(stream) {
+			break
+		}
+		id := binary.LittleEndian.Uint32(stream[entry:])
+		offset := section + int(binary.LittleEndian.Uint32(stream[entry+4:]))
+		if offset+12 > len(stream) || binary.LittleEndian.Uint32(stream[offset:]) != vtFileTime {
+			continue
+		}
+		if date, ok := fileTimeToTime(binary.LittleEndian.Uint64(stream[offset+4:])); ok {
+			dates[id] = date
+		}
+	}
+	return dates
+}
+
+// fileTimeToTime converts a windows FILETIME which counts 100 nanosecond intervals since 1601.
+func fileTimeToTime(fileTime uint64) (time.Time, bool) {
+	// the difference between 1601 and 1970 in 100 nanosecond intervals
+	const unixEpoch = 116444736000000000
+	if fileTime <= unixEpoch {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(fileTime-unixEpoch)*100), true
+}
@@ -0,0 +1,100 @@
+package sorter
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/karrick/godirwalk"
+)
+
+// queuedFile is a file found while walking the source which is processed after the walk completes.
+type queuedFile struct {
+	path     string
+	dirent   *godirwalk.Dirent
+	fileInfo os.FileInfo
+}
+
+// orders are the supported -order values. Each one reports whether a should be processed before b.
+var orders = map[string]func(a, b *queuedFile) bool{
+	"newest":   func(a, b *queuedFile) bool { return a.fileInfo.ModTime().After(b.fileInfo.ModTime()) },
+	"oldest":   func(a, b *queuedFile) bool { return a.fileInfo.ModTime().Before(b.fileInfo.ModTime()) },
+	"smallest": func(a, b *queuedFile) bool { return a.fileInfo.Size() < b.fileInfo.Size() },
+	"largest":  func(a, b *queuedFile) bool { return a.fileInfo.Size() > b.fileInfo.Size() },
+	"path":     func(a, b *queuedFile) bool { return a.path < b.path },
+}
+
+func parseOrder(order string, printer *outputPrinter) (func(a, b *queuedFile) bool, error) {
+	if order == "" {
+		return nil, nil
+	}
+	less, ok := orders[order]
+	if !ok {
+		return nil, printer.errorf("The order %s is not supported\n", order)
+	}
+	return less, nil
+}
+
+// walkSourceInOrder walks the whole source first and then processes the files in the requested order
+// or a random sample of them.
+func walkSourceInOrder(sourcePath string, opts *sortOptions, counts *processedCount) error {
+
+	var queue []*queuedFile
+	err := godirwalk.Walk(sourcePath, &godirwalk.Options{
+		// sorted like walkSource so that files which compare equal keep the same relative order
+		Unsorted: false,
+		Callback: func(path string, dirent *godirwalk.Dirent) error {
+			if opts.control != nil && opts.control.wait() {
+				return errCancelled
+			}
+			if dirent.IsDir() {
+				if isExcludedDir(path, dirent.Name(), opts) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			// a file which can not be stat'ed is still queued so that the error gets reported when it is processed
+			fileInfo, _ := os.Stat(path)
+			queue = append(queue, &queuedFile{path: path, dirent: dirent, fileInfo: fileInfo})
+			return nil
+		},
+		PostChildrenCallback: func(path string, dirent *godirwalk.Dirent) error {
+			return postVisitDir(path, dirent, counts)
+		},
+		ErrorCallback: func(_ string, err error) godirwalk.ErrorAction {
+			if stopsRun(err) {
+				return godirwalk.Halt
+			}
+			return godirwalk.SkipNode
+		},
+	})
+	if err != nil && !stopsRun(err) {
+		return opts.printer.errorf("An error occurred while trying to walk the source %s: %v\n", sourcePath, err)
+	}
+
+	if opts.order != nil {
+		sort.SliceStable(queue, func(i, j int) bool {
+			a, b := queue[i], queue[j]
+			if a.fileInfo == nil || b.fileInfo == nil {
+				return b.fileInfo == nil && a.fileInfo != nil
+			}
+			return opts.order(a, b)
+		})
+	}
+
+	if strings.Compare(opts.sample, "") != 0 {
+		total := len(queue)
+		if queue, err = sampleQueue(queue, opts.sample, opts); err != nil {
+			return err
+		}
+		opts.printer.Printf("Sampled %d of %d files\n", len(queue), total)
+	}
+
+	for _, file := range queue {
+		if stopsRun(processFile(file.path, file.dirent, file.fileInfo, opts, counts)) {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package sorter
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The entries of every directory are walked in the byte order of their names, which puts the digits
+// before the upper case and the upper case before the lower case and the accented letters, whatever
+// order the file system lists them in. Files which compare equal under -order keep that order.
+func TestWalkOrder(t *testing.T) {
+	source := t.TempDir()
+	names := []string{"éclair.jpg", "apple.jpg", "a/1.jpg", "Zebra.jpg", "B/2.jpg", "9.jpg", "10.jpg"}
+	taken := time.Date(2023, time.July, 15, 12, 0, 0, 0, time.Local)
+	for _, name := range names {
+		writeFiles(t, source, map[string]string{name: name})
+		if err := os.Chtimes(filepath.Join(source, filepath.FromSlash(name)), taken, taken); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"10.jpg", "9.jpg", "B/2.jpg", "Zebra.jpg", "a/1.jpg", "apple.jpg", "éclair.jpg"}
+
+	for _, order := range []string{"", "newest"} {
+		t.Run("order "+order, func(t *testing.T) {
+			s, err := New(Options{Source: source, Destinations: []string{t.TempDir()}, DryRun: true, Order: order, GlobalIgnore: "none", MinFreeInodes: -1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			s.opts.printer.out = &out
+			if _, err := s.Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, line := range strings.Split(out.String(), "\n") {
+				if !strings.HasPrefix(line, "Would copy ") {
+					continue
+				}
+				path := strings.SplitN(strings.TrimPrefix(line, "Would copy "), " --> ", 2)[0]
+				relativePath, err := filepath.Rel(source, path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, filepath.ToSlash(relativePath))
+			}
+			if strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("the files were walked in the order %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package sorter
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// outputFormats are the supported -output values.
+var outputFormats = map[string]bool{"": true, "text": true, "json": true}
+
+// fileEvent is what happened to a source file at a destination. The action is one of copied,
+// resumed, updated, moved, salvaged, uploaded, split, skipped, conflict, infected or error. A file
+// skipped or failed before a destination was picked has none.
+type fileEvent struct {
+	Type        string `json:"type"`
+	Source      string `json:"source"`
+	Destination string `json:"destination,omitempty"`
+	Action      string `json:"action"`
+	Bytes       int64  `json:"bytes"`
+	Error       string `json:"error,omitempty"`
+}
+
+// jsonOutput prints a line of json for every processed file and one with the report at the end,
+// for scripts which drive filesorter. The events are printed from the goroutine of the run only.
+type jsonOutput struct {
+	encoder *json.Encoder
+	// events is the number of events printed, so that a file which got none can be reported
+	events int
+}
+
+func newJSONOutput(w io.Writer) *jsonOutput {
+	return &jsonOutput{encoder: json.NewEncoder(w)}
+}
+
+func (o *jsonOutput) file(source string, destination string, action string, bytes int64, err error) {
+	event := fileEvent{Type: "file", Source: source, Destination: destination, Action: action, Bytes: bytes}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	o.encoder.Encode(event)
+	o.events++
+}
+
+// fileResult reports a file which got no event while it was processed, either since it failed or
+// was skipped before a destination was picked.
+func (o *jsonOutput) fileResult(source string, eventsBefore int, skippedBefore int, err error, counts *processedCount) {
+	if o.events != eventsBefore {
+		return
+	}
+	if err != nil {
+		o.file(source, "", "error", 0, err)
+	} else if counts.skippedFiles != skippedBefore {
+		o.file(source, "", "skipped", 0, nil)
+	}
+}
+
+func (o *jsonOutput) summary(report *Report) {
+	o.encoder.Encode(struct {
+		Type string `json:"type"`
+		*Report
+	}{"summary", report})
+}
@@ -0,0 +1,79 @@
+package sorter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// staleAfter is how long a temporary copy has not been written to before it is taken to be left
+// behind by a crashed run. The copies of a run sorting into the same destination at the same time
+// are written to all along and are left alone.
+const staleAfter = time.Hour
+
+// stalePartials removes the temporary copies which crashed runs left behind and reports them.
+type stalePartials struct {
+	printer *outputPrinter
+	dryRun  bool
+	found   int
+}
+
+// clean removes the temporary copy if it was not written to for staleAfter and reports whether it did.
+func (p *stalePartials) clean(path string) bool {
+	fileInfo, err := os.Lstat(path)
+	if err != nil || !fileInfo.Mode().IsRegular() || time.Since(fileInfo.ModTime()) < staleAfter {
+		return false
+	}
+	p.found++
+	if p.dryRun {
+		p.printer.Printf("Would remove the partial copy %s left by an earlier run\n", path)
+		return false
+	}
+	if err := os.Remove(path); err != nil {
+		p.printer.Printf("An error occurred while trying to remove the partial copy %s: %v\n", path, err)
+		return false
+	}
+	p.printer.Printf("Removed the partial copy %s left by an earlier run\n", path)
+	return true
+}
+
+// cleanDir removes the stale temporary copies next to the copies in a destination directory. It is
+// called when the directory is first listed by the run, so that only the folders the run sorts into
+// are looked at instead of the whole archive.
+func (p *stalePartials) cleanDir(dir string, names map[string]struct{}) {
+	for name := range names {
+		if strings.HasSuffix(name, tempSuffix) && p.clean(filepath.Join(dir, name)) {
+			delete(names, name)
+		}
+	}
+}
+
+// cleanStalePartials removes the copies which crashed runs left in the staging directory before the
+// run and returns the cleaner for the destination folders, which is nil with -resume-partial since
+// that continues the temporary copies next to the destinations instead.
+func cleanStalePartials(opts *sortOptions, stagingDir string, resume bool, dryRun bool) *stalePartials {
+	partials := &stalePartials{printer: opts.printer, dryRun: dryRun}
+
+	// only the files named like the staged copies are looked at, since the staging directory may
+	// be any directory with other hidden files in it
+	if strings.Compare(stagingDir, "") != 0 {
+		entries, err := os.ReadDir(stagingDir)
+		if err != nil {
+			opts.printer.Printf("An error occurred while trying to read the staging directory %s: %v\n", stagingDir, err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasPrefix(entry.Name(), stagePrefix) {
+				partials.clean(filepath.Join(stagingDir, entry.Name()))
+			}
+		}
+		if partials.found > 0 {
+			opts.printer.Printf("Found %d partial copies left by earlier runs in the staging directory %s\n", partials.found, stagingDir)
+		}
+	}
+
+	if resume {
+		return nil
+	}
+	return partials
+}
@@ -0,0 +1,61 @@
+package sorter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCleanStalePartials(t *testing.T) {
+	source, destination, staging := t.TempDir(), t.TempDir(), t.TempDir()
+	taken := time.Date(2023, time.July, 15, 12, 0, 0, 0, time.Local)
+	writeFiles(t, source, map[string]string{"IMG_0001.jpg": "photo"})
+	if err := os.Chtimes(filepath.Join(source, "IMG_0001.jpg"), taken, taken); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, destination, map[string]string{
+		"2023/July/15/IMG_0002.jpg" + tempSuffix: "crashed",
+		"2019/May/2/IMG_0003.jpg" + tempSuffix:   "not sorted into",
+	})
+	writeFiles(t, staging, map[string]string{
+		stagePrefix + "IMG_0004.jpg.abc": "crashed",
+		".bashrc":                        "not staged",
+	})
+	old := time.Now().Add(-2 * staleAfter)
+	for _, path := range []string{
+		filepath.Join(destination, "2023", "July", "15", "IMG_0002.jpg"+tempSuffix),
+		filepath.Join(destination, "2019", "May", "2", "IMG_0003.jpg"+tempSuffix),
+		filepath.Join(staging, stagePrefix+"IMG_0004.jpg.abc"),
+		filepath.Join(staging, ".bashrc"),
+	} {
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := New(Options{Source: source, Destinations: []string{destination}, Staging: staging, GlobalIgnore: "none", MinFreeInodes: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path   string
+		exists bool
+	}{
+		{filepath.Join(destination, "2023", "July", "15", "IMG_0001.jpg"), true},
+		{filepath.Join(destination, "2023", "July", "15", "IMG_0002.jpg"+tempSuffix), false},
+		{filepath.Join(destination, "2019", "May", "2", "IMG_0003.jpg"+tempSuffix), true},
+		{filepath.Join(staging, stagePrefix+"IMG_0004.jpg.abc"), false},
+		{filepath.Join(staging, ".bashrc"), true},
+	}
+	for _, test := range tests {
+		if _, err := os.Stat(test.path); (err == nil) != test.exists {
+			t.Errorf("%s exists %v, want %v", test.path, err == nil, test.exists)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package sorter
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// the metadata of a pdf is either in the document information dictionary which is usually at the end
+// of the file or in the xmp packet which is usually at the start. Reading this much from both ends
+// covers most files without reading the whole document.
+const pdfMetadataReadSize = 256 * 1024
+
+var (
+	pdfCreationDate = regexp.MustCompile(`/CreationDate\s*\(([^)]*)\)`)
+	pdfModDate      = regexp.MustCompile(`/ModDate\s*\(([^)]*)\)`)
+	xmpCreateDate   = regexp.MustCompile(`xmp:CreateDate(?:>([^<]+)<|="([^"]+)")`)
+	xmpModifyDate   = regexp.MustCompile(`xmp:ModifyDate(?:>([^<]+)<|="([^"]+)")`)
+	xmpCreator      = regexp.MustCompile(`<dc:creator>\s*<rdf:Seq>\s*<rdf:li[^>]*>([^<]+)<`)
+	xmpTitle        = regexp.MustCompile(`<dc:title>\s*<rdf:Alt>\s*<rdf:li[^>]*>([^<]+)<`)
+
+	// D:YYYYMMDDHHmmSSOHH'mm' where everything after the year is optional
+	pdfDateFormat = regexp.MustCompile(`^(?:D:)?(\d{4})(\d{2})?(\d{2})?(\d{2})?(\d{2})?(\d{2})?(?:([Z+\-])(\d{2})?'?(\d{2})?'?)?`)
+)
+
+// pdfDate reads the creation date of a pdf from its metadata falling back to the modification date.
+func (r *runIO) pdfDate(path string) (time.Time, bool, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".pdf") {
+		return time.Time{}, false, nil
+	}
+
+	head, tail, err := r.readHeadAndTail(path, pdfMetadataReadSize)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	content := append(head, tail...)
+
+	// prefer the creation dates and use the dictionary over xmp since that is what most readers show
+	if date, ok := findPDFDate(content, pdfCreationDate); ok {
+		return date, true, nil
+	}
+	if date, ok := findXMPDate(content, xmpCreateDate); ok {
+		return date, true, nil
+	}
+	if date, ok := findPDFDate(content, pdfModDate); ok {
+		return date, true, nil
+	}
+	if date, ok := findXMPDate(content, xmpModifyDate); ok {
+		return date, true, nil
+	}
+	return time.Time{}, false, nil
+}
+
+func findPDFDate(content []byte, pattern *regexp.Regexp) (time.Time, bool) {
+	for _, match := range pattern.FindAllSubmatch(content, -1) {
+		if date, err := parsePDFDate(string(match[1])); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func findXMPDate(content []byte, pattern *regexp.Regexp) (time.Time, bool) {
+	for _, match := range pattern.FindAllSubmatch(content, -1) {
+		value := match[1]
+		if len(value) == 0 {
+			value = match[2]
+		}
+		if date, err := parseISODate(string(bytes.TrimSpace(value))); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parsePDFDate parses the date string format defined in the pdf specification.
+func parsePDFDate(value string) (time.Time, error) {
+	match := pdfDateFormat.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return time.Time{}, fmt.Errorf("The date %s is not a valid pdf date", value)
+	}
+
+	// month and day default to 1 and the rest to 0 when they are not present
+	fields := []int{0, 1, 1, 0, 0, 0}
+	for i := range fields {
+		if match[i+1] != "" {
+			fields[i], _ = strconv.Atoi(match[i+1])
+		}
+	}
+	if fields[1] < 1 || fields[1] > 12 || fields[2] < 1 || fields[2] > 31 {
+		return time.Time{}, fmt.Errorf("The date %s is not a valid pdf date", value)
+	}
+
+	location := time.Local
+	switch match[7] {
+	case "Z":
+		location = time.UTC
+	case "+", "-":
+		hours, _ := strconv.Atoi(match[8])
+		minutes, _ := strconv.Atoi(match[9])
+		offset := hours*3600 + minutes*60
+		if match[7] == "-" {
+			offset = -offset
+		}
+		location = time.FixedZone("", offset)
+	}
+
+	return time.Date(fields[0], time.Month(fields[1]), fields[2], fields[3], fields[4], fields[5], 0, location), nil
+}
+
+// readPDFMetadata reads the author and title from the document information dictionary falling back to xmp.
+func (r *runIO) readPDFMetadata(path string) (ebookMetadata, error) {
+
+	head, tail, err := r.readHeadAndTail(path, pdfMetadataReadSize)
+	if err != nil {
+		return ebookMetadata{}, err
+	}
+	content := append(head, tail...)
+
+	metadata := ebookMetadata{
+		author: findPDFString(content, "/Author"),
+		title:  findPDFString(content, "/Title"),
+	}
+	if metadata.author == "" {
+		if match := xmpCreator.FindSubmatch(content); match != nil {
+			metadata.author = html.UnescapeString(string(bytes.TrimSpace(match[1])))
+		}
+	}
+	if metadata.title == "" {
+		if match := xmpTitle.FindSubmatch(content); match != nil {
+			metadata.title = html.UnescapeString(string(bytes.TrimSpace(match[1])))
+		}
+	}
+
+	if date, ok := findPDFDate(content, pdfCreationDate); ok {
+		metadata.year = date.Year()
+	} else if date, ok := findXMPDate(content, xmpCreateDate); ok {
+		metadata.year = date.Year()
+	}
+	return metadata, nil
+}
+
+// findPDFString returns the value of the first non empty string entry with the given key.
+func findPDFString(content []byte, key string) string {
+	for offset := 0; ; {
+		i := bytes.Index(content[offset:], []byte(key))
+		if i < 0 {
+			return ""
+		}
+		offset += i + len(key)
+
+		value := bytes.TrimLeft(content[offset:], " \t\r\n")
+		if len(value) == 0 {
+			return ""
+		}
+		var decoded []byte
+		switch value[0] {
+		case '(':
+			decoded = parsePDFLiteralString(value[1:])
+		case '<':
+			end := bytes.IndexByte(value, '>')
+			if end < 0 {
+				continue
+			}
+			hexValue := bytes.Map(func(r rune) rune {
+				if strings.ContainsRune(" \t\r\n", r) {
+					return -1
+				}
+				return r
+			}, value[1:end])
+			if len(hexValue)%2 == 1 {
+				hexValue = append(hexValue, '0')
+			}
+			decoded, _ = hex.DecodeString(string(hexValue))
+		}
+
+		if text := strings.TrimSpace(decodePDFText(decoded)); text != "" {
+			return text
+		}
+	}
+}
+
+// parsePDFLiteralString reads a literal string up to its closing parenthesis resolving the escapes.
+func parsePDFLiteralString(value []byte) []byte {
+	var decoded []byte
+	depth := 0
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth == 0 {
+				return decoded
+			}
+			depth--
+		case c == '\\' && i+1 < len(value):
+			i++
+			switch value[i] {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				// up to three octal digits
+				n := 0
+				j := i
+				for ; j < len(value) && j < i+3 && value[j] >= '0' && value[j] <= '7'; j++ {
+					n = n*8 + int(value[j]-'0')
+				}
+				i = j - 1
+				c = byte(n)
+			case '\r', '\n':
+				// an escaped end of line continues the string on the next line
+				continue
+			default:
+				c = value[i]
+			}
+		}
+		decoded = append(decoded, c)
+	}
+	return decoded
+}
+
+// decodePDFText decodes a text string which is either utf-16 with a byte order mark or a single byte encoding.
+func decodePDFText(value []byte) string {
+	if len(value) >= 2 && value[0] == 0xFE && value[1] == 0xFF {
+		units := make([]uint16, 0, len(value)/2)
+		for i := 2; i+1 < len(value); i += 2 {
+			units = append(units, uint16(value[i])<<8|uint16(value[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	runes := make([]rune, len(value))
+	for i, b := range value {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
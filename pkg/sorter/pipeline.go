@@ -0,0 +1,131 @@
+package sorter
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/karrick/godirwalk"
+)
+
+// pipelineDepth bounds the files which are scanned ahead of the one being copied and statWorkers
+// is the number of files stat'ed at the same time. Both hide the latency of network shares where
+// every directory listing and stat is a round-trip.
+const (
+	pipelineDepth = 256
+	statWorkers   = 8
+)
+
+// scannedFile is a file found by the scanner. ready is closed once fileInfo was looked up, which
+// stays nil if the stat failed so that the error is reported when the file is processed.
+type scannedFile struct {
+	path     string
+	dirent   *godirwalk.Dirent
+	fileInfo os.FileInfo
+	ready    chan struct{}
+}
+
+// walkSource processes the files of the source in three overlapping stages. The source is walked
+// in one goroutine, the files found are stat'ed by several others and they are copied in the order
+// they were found while the next ones are being scanned.
+func walkSource(sourcePath string, opts *sortOptions, counts *processedCount) error {
+
+	files := make(chan *scannedFile, pipelineDepth)
+	stats := make(chan *scannedFile, pipelineDepth)
+	done := make(chan struct{})
+	var directories int
+	var walkErr error
+
+	send := func(path string, dirent *godirwalk.Dirent) error {
+		file := &scannedFile{path: path, dirent: dirent, ready: make(chan struct{})}
+		select {
+		case stats <- file:
+		case <-done:
+			return errCancelled
+		}
+		select {
+		case files <- file:
+		case <-done:
+			return errCancelled
+		}
+		return nil
+	}
+
+	go func() {
+		defer close(files)
+		defer close(stats)
+
+		if opts.incremental != nil {
+			walkErr = opts.incremental.walk(sourcePath, send)
+			directories = opts.incremental.directories
+			return
+		}
+
+		walkErr = godirwalk.Walk(sourcePath, &godirwalk.Options{
+			// the entries of every directory are visited in byte order of their names so that the output
+			// of two runs over the same source can be diffed, even across machines and file systems.
+			Unsorted: false,
+			Callback: func(path string, dirent *godirwalk.Dirent) error {
+				if dirent.IsDir() {
+					if isExcludedDir(path, dirent.Name(), opts) {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				return send(path, dirent)
+			},
+			PostChildrenCallback: func(path string, dirent *godirwalk.Dirent) error {
+				directories++
+				return nil
+			},
+			ErrorCallback: func(_ string, err error) godirwalk.ErrorAction {
+				if stopsRun(err) {
+					return godirwalk.Halt
+				}
+				// try processing all files even if one of the files errored.
+				return godirwalk.SkipNode
+			},
+		})
+	}()
+
+	for i := 0; i < statWorkers; i++ {
+		go func() {
+			for file := range stats {
+				if fileInfo, err := os.Stat(file.path); err == nil {
+					file.fileInfo = fileInfo
+				}
+				close(file.ready)
+			}
+		}()
+	}
+
+	// once the run stops the rest of the files are drained so that the scanner is done before the
+	// directories are counted
+	stopped := false
+	for file := range files {
+		if stopped {
+			continue
+		}
+		<-file.ready
+		err := processFile(file.path, file.dirent, file.fileInfo, opts, counts)
+		if stopsRun(err) {
+			stopped = true
+			close(done)
+			continue
+		}
+		if opts.incremental != nil {
+			opts.incremental.processed(file.path, err)
+		}
+	}
+	counts.visitedDirectories += directories
+
+	if opts.incremental != nil {
+		if err := opts.incremental.save(); err != nil {
+			opts.printer.Printf("An error occurred while trying to record the scanned directories in the catalog: %v\n", err)
+		}
+	}
+	// the runs stopped by a cancel or a limit are told apart by the report
+	if walkErr != nil && !stopsRun(walkErr) {
+		return opts.printer.errorf("An error occurred while trying to walk the source %s: %v\n", sourcePath, walkErr)
+	}
+	return nil
+}
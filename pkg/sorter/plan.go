@@ -0,0 +1,309 @@
+package sorter
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// plan is the list of actions recorded by 'filesorter plan' which 'filesorter apply' executes later.
+type plan struct {
+	Created time.Time       `json:"created"`
+	Actions []plannedAction `json:"actions"`
+}
+
+// plannedAction is the copy of a source file to one of the destinations. Along with the paths it
+// records the state of the source and the destination file when the plan was made so that apply
+// can refuse to run an action whose preconditions no longer hold.
+type plannedAction struct {
+	// Action is one of copy, resume or update.
+	Action          string    `json:"action"`
+	Source          string    `json:"source"`
+	SourceSize      int64     `json:"sourceSize"`
+	SourceModTime   time.Time `json:"sourceModTime"`
+	Destination     string    `json:"destination"`
+	DestinationPath string    `json:"destinationPath"`
+	// DestinationSize is -1 if the destination file did not exist.
+	DestinationSize    int64     `json:"destinationSize"`
+	DestinationModTime time.Time `json:"destinationModTime"`
+	// ModTime is set on the copied file. It differs from the modified time of the source for
+	// files of a backup which carry the time from the manifest.
+	ModTime time.Time `json:"modTime"`
+	// SortTime is the date the file was sorted by. It is zero for files sorted by their tags.
+	SortTime time.Time `json:"sortTime"`
+}
+
+// planCopies records the copies of the file in the plan instead of executing them.
+func planCopies(path string, sourceFileStat os.FileInfo, copies []*fileCopy, opts *sortOptions, counts *processedCount) error {
+
+	sourceModTime := sourceFileStat.ModTime()
+	if info, ok := sourceFileStat.(backupFileInfo); ok {
+		sourceModTime = info.FileInfo.ModTime()
+	}
+	source, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	for _, c := range copies {
+		if c.err != nil {
+			c.dest.counts.erroredFiles++
+			failures = append(failures, fmt.Sprintf("%s: %v", c.destFilePath, c.err))
+			continue
+		}
+		action := plannedAction{
+			Action:          "copy",
+			Source:          source,
+			SourceSize:      sourceFileStat.Size(),
+			SourceModTime:   sourceModTime,
+			DestinationSize: -1,
+			ModTime:         sourceFileStat.ModTime(),
+			SortTime:        c.sortTime,
+		}
+		if action.Destination, err = filepath.Abs(c.dest.path); err != nil {
+			return err
+		}
+		if action.DestinationPath, err = filepath.Abs(c.destFilePath); err != nil {
+			return err
+		}
+		if c.destFileStat != nil {
+			action.DestinationSize = c.destFileStat.Size()
+			action.DestinationModTime = c.destFileStat.ModTime()
+		}
+		if c.resumeFrom > 0 {
+			action.Action = "resume"
+		} else if c.update {
+			action.Action = "update"
+		}
+		opts.plan.Actions = append(opts.plan.Actions, action)
+		if opts.dryRun {
+			opts.planned[c.destFilePath] = path
+			printDryRunAction(path, c, sourceFileStat.Size(), opts)
+		} else {
+			opts.printer.Printf("Planned %s %s --> %s\n", action.Action, path, c.destFilePath)
+		}
+		counts.plannedFiles++
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// printDryRunAction prints what sort would do with the copy. A file of another size at the
+// destination is a conflict which is overwritten unless it is resumed or updated, and so is an
+// earlier file of the run planned to be copied to the same path.
+func printDryRunAction(path string, c *fileCopy, size int64, opts *sortOptions) {
+	switch {
+	case c.resumeFrom > 0:
+		opts.printer.Printf("Would resume %s --> %s from %d bytes\n", path, c.destFilePath, c.resumeFrom)
+	case c.update:
+		opts.printer.Printf("Would update %s --> %s\n", path, c.destFilePath)
+	case c.renamedConflict && c.plannedSource != "":
+		opts.printer.Printf("Would rename %s --> %s, %s is sorted under the same name before\n", path, c.destFilePath, c.plannedSource)
+	case c.renamedConflict:
+		opts.printer.Printf("Would rename %s --> %s, a different file of the same name is already there\n", path, c.destFilePath)
+	case c.destFileStat != nil && c.plannedSource != "":
+		opts.printer.Printf("Would conflict %s --> %s with %s sorted there before and overwrite it\n", path, c.destFilePath, c.plannedSource)
+	case c.destFileStat != nil:
+		opts.printer.Printf("Would overwrite %s --> %s (%d and %d bytes)\n", path, c.destFilePath, size, c.destFileStat.Size())
+	default:
+		opts.printer.Printf("Would copy %s --> %s\n", path, c.destFilePath)
+	}
+}
+
+func writePlan(path string, p *plan) error {
+	if p.Actions == nil {
+		p.Actions = []plannedAction{}
+	}
+	content, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
+
+func readPlan(path string) (*plan, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p plan
+	if err := json.Unmarshal(content, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func runApply(args []string) int {
+
+	flags := flag.NewFlagSet("apply", flag.ExitOnError)
+	catalogPath := flags.String("catalog", "", `Optional. A catalog database in which every copied file is recorded
+	so that the archive can be verified later using 'filesorter verify'`)
+	errorReport := flags.String("error-report", "", `Optional. Write the files which could not be processed along with the errors
+	to this json file`)
+	lang := AddLanguageFlag(flags)
+	parseFlags(flags, args)
+
+	if err := SetLanguage(*lang); err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	if flags.NArg() != 1 {
+		printer.Printf("Usage: filesorter apply [-catalog <catalog path>] [-error-report <report path>] <plan path>\n")
+		flags.PrintDefaults()
+		return 1
+	}
+
+	p, err := readPlan(flags.Arg(0))
+	if err != nil {
+		printer.Printf("An error occurred while trying to read the plan %s: %v\n", flags.Arg(0), err)
+		return 1
+	}
+
+	opts := sortOptions{printer: printer}
+	if strings.Compare(*catalogPath, "") != 0 {
+		opts.catalog, err = openCatalog(*catalogPath)
+		if err != nil {
+			printer.Printf("An error occurred while trying to open the catalog %s: %v\n", *catalogPath, err)
+			return 1
+		}
+		defer opts.catalog.Close()
+		if err := opts.catalog.startRun("apply", flags.Arg(0)); err != nil {
+			printer.Printf("An error occurred while trying to record the run in the catalog %s: %v\n", *catalogPath, err)
+			return 1
+		}
+	}
+
+	destinations := make(map[string]*destination)
+	var counts processedCount
+	// the directories are created up front. one which can not be created is reported along with
+	// the actions copying into it
+	opts.createdDirs = newCreatedDirs(0, false)
+	for _, action := range p.Actions {
+		opts.createdDirs.mkdirAll(action.Destination, filepath.Dir(action.DestinationPath))
+	}
+
+	for _, action := range p.Actions {
+		dest, ok := destinations[action.Destination]
+		if !ok {
+			dest = &destination{path: action.Destination}
+			destinations[action.Destination] = dest
+			opts.destinations = append(opts.destinations, dest)
+		}
+		if err := applyAction(action, dest, &opts, &counts); err != nil {
+			printer.Printf("An error occurred while trying to apply %s --> %s: %v\n", action.Source, action.DestinationPath, err)
+			dest.counts.erroredFiles++
+			counts.erroredFiles++
+			counts.errors = append(counts.errors, newFileError(action.Source, err))
+		}
+	}
+
+	printReport(&counts, &opts)
+
+	if opts.catalog != nil {
+		if err := opts.catalog.finishRun(&counts); err != nil {
+			printer.Printf("An error occurred while trying to record the run in the catalog %s: %v\n", *catalogPath, err)
+		}
+	}
+
+	if strings.Compare(*errorReport, "") != 0 {
+		if err := writeErrorReport(*errorReport, counts.errors); err != nil {
+			printer.Printf("An error occurred while trying to write the error report %s: %v\n", *errorReport, err)
+			return 1
+		}
+	}
+
+	if counts.erroredFiles > 0 {
+		return 1
+	}
+	return 0
+}
+
+// applyAction executes an action of the plan after checking that neither the source nor the
+// destination file changed since the plan was made.
+func applyAction(action plannedAction, dest *destination, opts *sortOptions, counts *processedCount) error {
+
+	sourceFileStat, err := os.Stat(action.Source)
+	if err != nil {
+		return err
+	}
+	if sourceFileStat.Size() != action.SourceSize || !sourceFileStat.ModTime().Equal(action.SourceModTime) {
+		return fmt.Errorf("the source %s has changed since the plan was made", action.Source)
+	}
+
+	destFileStat, err := os.Stat(action.DestinationPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if action.DestinationSize < 0 && err == nil {
+		return fmt.Errorf("the file %s was created since the plan was made", action.DestinationPath)
+	}
+	if action.DestinationSize >= 0 && (err != nil || destFileStat.Size() != action.DestinationSize ||
+		!destFileStat.ModTime().Equal(action.DestinationModTime)) {
+		return fmt.Errorf("the file %s has changed since the plan was made", action.DestinationPath)
+	}
+
+	relativePath, err := filepath.Rel(action.Destination, action.DestinationPath)
+	if err != nil {
+		return err
+	}
+	if err := checkRelativePath(relativePath); err != nil {
+		return err
+	}
+	if err := opts.createdDirs.mkdirAll(action.Destination, filepath.Dir(action.DestinationPath)); err != nil {
+		return err
+	}
+
+	c := &fileCopy{dest: dest, destFilePath: action.DestinationPath, sortTime: action.SortTime}
+	switch action.Action {
+	case "copy":
+		c.written, c.hash, err = opts.runIO.copyFile(action.Source, action.DestinationPath)
+	case "resume":
+		c.resumeFrom = action.DestinationSize
+		c.written, c.hash, err = opts.runIO.resumeCopy(action.Source, action.DestinationPath, c.resumeFrom)
+	case "update":
+		c.update = true
+		c.written, c.hash, err = opts.runIO.deltaCopy(action.Source, action.DestinationPath)
+	default:
+		return fmt.Errorf("the action %s is not supported", action.Action)
+	}
+	if err != nil {
+		return err
+	}
+
+	return finishCopy(action.Source, plannedFileInfo{FileInfo: sourceFileStat, modTime: action.ModTime}, c, opts, counts)
+}
+
+// plannedFileInfo presents the source with the modified time which was decided when the plan was made.
+type plannedFileInfo struct {
+	os.FileInfo
+	modTime time.Time
+}
+
+func (info plannedFileInfo) ModTime() time.Time {
+	return info.modTime
+}
+
+// statPlanned stats the destination file like the listing of its folder does. In a dry run the path
+// an earlier file of the run is planned to be copied to has the stat of that file instead, and
+// its path is returned to compare the contents with. It is the destination path otherwise.
+func statPlanned(destFilePath string, opts *sortOptions) (os.FileInfo, string, error) {
+	if source, ok := opts.planned[destFilePath]; ok {
+		fileInfo, err := os.Stat(source)
+		return fileInfo, source, err
+	}
+	if opts.destEntries != nil {
+		fileInfo, err := opts.destEntries.stat(destFilePath)
+		return fileInfo, destFilePath, err
+	}
+	fileInfo, err := os.Stat(destFilePath)
+	return fileInfo, destFilePath, err
+}
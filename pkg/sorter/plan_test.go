@@ -0,0 +1,73 @@
+package sorter
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The files of the same name from several folders are sorted into the same path, so the dry run
+// has to see the ones planned before as conflicts.
+func TestDryRunPlannedConflicts(t *testing.T) {
+	source := t.TempDir()
+	writeFiles(t, source, map[string]string{
+		"a/IMG_0001.jpg": "first",
+		"b/IMG_0001.jpg": "second file",
+		"c/IMG_0001.jpg": "again",
+	})
+	taken := time.Date(2023, time.July, 15, 12, 0, 0, 0, time.Local)
+	for _, folder := range []string{"a", "b", "c"} {
+		if err := os.Chtimes(filepath.Join(source, folder, "IMG_0001.jpg"), taken, taken); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		onConflict string
+		want       []string
+	}{
+		{"", []string{
+			"Would copy " + filepath.Join(source, "a", "IMG_0001.jpg"),
+			"Would conflict " + filepath.Join(source, "b", "IMG_0001.jpg"),
+			"and overwrite it",
+		}},
+		{"skip", []string{
+			"Would copy " + filepath.Join(source, "a", "IMG_0001.jpg"),
+			"Would conflict " + filepath.Join(source, "b", "IMG_0001.jpg"),
+			"and skip it",
+			"Would skip " + filepath.Join(source, "c", "IMG_0001.jpg"),
+		}},
+		{"rename", []string{
+			"Would copy " + filepath.Join(source, "a", "IMG_0001.jpg"),
+			"Would rename " + filepath.Join(source, "b", "IMG_0001.jpg"),
+			"IMG_0001-1.jpg, " + filepath.Join(source, "a", "IMG_0001.jpg") + " is sorted under the same name before",
+			"Would skip " + filepath.Join(source, "c", "IMG_0001.jpg"),
+		}},
+	}
+	for _, test := range tests {
+		t.Run("on conflict "+test.onConflict, func(t *testing.T) {
+			destination := t.TempDir()
+			s, err := New(Options{Source: source, Destinations: []string{destination}, DryRun: true, OnConflict: test.onConflict, GlobalIgnore: "none", MinFreeInodes: -1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			s.opts.printer.out = &out
+			if _, err := s.Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("the dry run printed\n%s\nwithout %q", out.String(), want)
+				}
+			}
+			if names := fileNames(t, destination); len(names) > 0 {
+				t.Errorf("the dry run wrote %v", names)
+			}
+		})
+	}
+}
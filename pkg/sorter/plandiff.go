@@ -0,0 +1,52 @@
+package sorter
+
+import (
+	"sort"
+	"strings"
+)
+
+// destinationsBySource groups the destination paths of the actions of a plan by their source.
+func destinationsBySource(p *plan) map[string][]string {
+	bySource := make(map[string][]string)
+	for _, action := range p.Actions {
+		bySource[action.Source] = append(bySource[action.Source], action.DestinationPath)
+	}
+	for _, destinations := range bySource {
+		sort.Strings(destinations)
+	}
+	return bySource
+}
+
+// printPlanDiff prints the source files which are new in the plan, the ones which are not in it
+// anymore and the ones which are sorted into other paths than in the previous plan.
+func printPlanDiff(previous *plan, current *plan, previousPath string, opts *sortOptions) {
+	before, after := destinationsBySource(previous), destinationsBySource(current)
+
+	var sources []string
+	for source := range before {
+		sources = append(sources, source)
+	}
+	for source := range after {
+		if _, ok := before[source]; !ok {
+			sources = append(sources, source)
+		}
+	}
+	sort.Strings(sources)
+
+	added, removed, rerouted := 0, 0, 0
+	for _, source := range sources {
+		old, new := before[source], after[source]
+		switch {
+		case len(old) == 0:
+			opts.printer.Printf("Added: %s --> %s\n", source, strings.Join(new, ", "))
+			added++
+		case len(new) == 0:
+			opts.printer.Printf("Removed: %s --> %s\n", source, strings.Join(old, ", "))
+			removed++
+		case strings.Join(old, "\n") != strings.Join(new, "\n"):
+			opts.printer.Printf("Re-routed: %s from %s to %s\n", source, strings.Join(old, ", "), strings.Join(new, ", "))
+			rerouted++
+		}
+	}
+	opts.printer.Printf("Compared with the plan %s: %d added, %d removed, %d re-routed\n", previousPath, added, removed, rerouted)
+}
@@ -0,0 +1,202 @@
+package sorter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// builtinHook is a post-processing command which comes with filesorter. Its output is written to
+// a sub folder next to the copied file.
+type builtinHook struct {
+	command string
+	types   string
+	folder  string
+}
+
+// builtinHooks are the -post-process names which stand for an ffmpeg command.
+var builtinHooks = map[string]builtinHook{
+	"thumbnail": {
+		command: "ffmpeg -nostdin -loglevel error -y -i {file} -vf thumbnail,scale=320:-2 -frames:v 1 {dir}/thumbnails/{name}.jpg",
+		types:   "videos",
+		folder:  "thumbnails",
+	},
+	"proxy": {
+		command: "ffmpeg -nostdin -loglevel error -y -i {file} -vf scale=-2:540 -c:v libx264 -preset veryfast -crf 28 -c:a aac -b:a 96k {dir}/proxies/{name}.mp4",
+		types:   "videos",
+		folder:  "proxies",
+	},
+}
+
+// hook is a command run for every copied file of its types.
+type hook struct {
+	spec   string
+	args   []string
+	types  map[string]struct{}
+	folder string
+}
+
+// parseHook parses a -post-process value, either the name of a built-in hook or a command with the
+// placeholders {file}, {dir}, {name} and {source}. Both can be limited to some types with a prefix
+// like videos=thumbnail or mp4:mov=command.
+func parseHook(spec string, printer *outputPrinter) (*hook, error) {
+	h := &hook{spec: spec}
+	command := strings.TrimSpace(spec)
+	// a command can contain a '=' too, like ffmpeg -vf scale=320:-2, but not before its first blank
+	if i := strings.Index(command, "="); i > 0 && !strings.ContainsAny(command[:i], " \t") {
+		h.types = parseTypes(command[:i])
+		command = strings.TrimSpace(command[i+1:])
+	}
+	if builtin, ok := builtinHooks[command]; ok {
+		command, h.folder = builtin.command, builtin.folder
+		if h.types == nil {
+			h.types = parseTypes(builtin.types)
+		}
+	}
+	h.args = strings.Fields(command)
+	if len(h.args) == 0 {
+		return nil, printer.errorf("The post-processing command %s is not valid\n", spec)
+	}
+	return h, nil
+}
+
+func (h *hook) matches(name string) bool {
+	if h.types == nil {
+		return true
+	}
+	_, ok := h.types[fileType(name)]
+	return ok
+}
+
+// command returns the command of the hook for the copied file. The placeholders are replaced after
+// the command was split so that the paths may contain blanks.
+func (h *hook) command(sourcePath string, destFilePath string) *exec.Cmd {
+	base := filepath.Base(destFilePath)
+	replacer := strings.NewReplacer(
+		"{file}", destFilePath,
+		"{dir}", filepath.Dir(destFilePath),
+		"{name}", strings.TrimSuffix(base, filepath.Ext(base)),
+		"{source}", sourcePath,
+	)
+	args := make([]string, len(h.args))
+	for i, arg := range h.args {
+		args[i] = replacer.Replace(arg)
+	}
+	return exec.Command(args[0], args[1:]...)
+}
+
+// hookJob is a copied file waiting for the hooks.
+type hookJob struct {
+	sourcePath   string
+	destFilePath string
+}
+
+// postProcessor runs the hooks for the copied files in a few goroutines while the next files are
+// copied. The queue is short so that the copies do not run far ahead of a slow transcode.
+type postProcessor struct {
+	hooks   []*hook
+	workers int
+	printer *outputPrinter
+	jobs    chan hookJob
+	wg      sync.WaitGroup
+
+	mu        sync.Mutex
+	processed int
+	failures  []fileError
+}
+
+func newPostProcessor(specs []string, workers int, printer *outputPrinter) (*postProcessor, error) {
+	if workers < 1 {
+		return nil, printer.errorf("The number of post-processing jobs %d is not valid\n", workers)
+	}
+	p := &postProcessor{workers: workers, printer: printer}
+	for _, spec := range specs {
+		h, err := parseHook(spec, printer)
+		if err != nil {
+			return nil, err
+		}
+		p.hooks = append(p.hooks, h)
+	}
+	return p, nil
+}
+
+// start starts the workers. A paused run pauses the hooks too and the files queued when the run
+// is cancelled are left out.
+func (p *postProcessor) start(control *runControl) {
+	p.jobs = make(chan hookJob, p.workers)
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for job := range p.jobs {
+				if control.wait() {
+					continue
+				}
+				p.run(job)
+			}
+		}()
+	}
+}
+
+// queue hands the copied file to the workers. It blocks while all of them are busy.
+func (p *postProcessor) queue(sourcePath string, destFilePath string) {
+	for _, h := range p.hooks {
+		if h.matches(destFilePath) {
+			p.jobs <- hookJob{sourcePath: sourcePath, destFilePath: destFilePath}
+			return
+		}
+	}
+}
+
+// finish waits for the queued files and adds the results to the counts.
+func (p *postProcessor) finish(counts *processedCount) {
+	close(p.jobs)
+	p.wg.Wait()
+	counts.postProcessed = p.processed
+	counts.postProcessErrors = p.failures
+}
+
+// run runs the hooks of the file one after the other, since a later one may use the output of an
+// earlier one. The first one which fails stops the others.
+func (p *postProcessor) run(job hookJob) {
+	for _, h := range p.hooks {
+		if !h.matches(job.destFilePath) {
+			continue
+		}
+		if err := runHook(h, job); err != nil {
+			p.printer.Printf("An error occurred while trying to post-process the file %s with %s: %v\n", job.destFilePath, h.spec, err)
+			p.mu.Lock()
+			p.failures = append(p.failures, newFileError(job.destFilePath, err))
+			p.mu.Unlock()
+			return
+		}
+	}
+	p.printer.Printf("Post-processed %s\n", job.destFilePath)
+	p.mu.Lock()
+	p.processed++
+	p.mu.Unlock()
+}
+
+func runHook(h *hook, job hookJob) error {
+	if strings.Compare(h.folder, "") != 0 {
+		if err := os.MkdirAll(filepath.Join(filepath.Dir(job.destFilePath), h.folder), os.ModePerm); err != nil {
+			return err
+		}
+	}
+	cmd := h.command(job.sourcePath, job.destFilePath)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		// the last line of the output usually tells what went wrong
+		if lines := strings.Split(strings.TrimSpace(output.String()), "\n"); strings.Compare(lines[len(lines)-1], "") != 0 {
+			return fmt.Errorf("%v: %s", err, lines[len(lines)-1])
+		}
+		return err
+	}
+	return nil
+}
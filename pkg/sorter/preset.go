@@ -0,0 +1,170 @@
+package sorter
+
+import (
+	"database/sql"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// preset is a bundle of settings tuned for a commonly backed up folder structure. The settings of
+// a preset are used only for the options which are not passed explicitly.
+type preset struct {
+	types       []string
+	dateSources []string
+	// excludes are glob patterns matched against the names of the files and the directories. Excluded
+	// directories are not walked at all.
+	excludes []string
+	// iphoneBackup is set when the source is an iTunes/Finder backup which stores the files under
+	// the hash of their original path.
+	iphoneBackup bool
+}
+
+var presets = map[string]preset{
+	// the DCIM folder of android phones. Trashed and pending files are hidden files which the gallery
+	// apps leave behind and the thumbnails are regenerated by the phone.
+	"android": {
+		types:       []string{"jpg", "jpeg", "png", "heic", "heif", "gif", "webp", "dng", "mp4", "3gp", "mkv", "webm", "mov"},
+		dateSources: []string{"filename", "mtime"},
+		excludes:    []string{".thumbnails", ".trashed-*", ".pending-*", ".nomedia"},
+	},
+	// the WhatsApp/Media folder. The media is named like IMG-20200502-WA0001.jpg which has the date
+	// it was received. Statuses are the media posted by contacts and not something which is kept.
+	"whatsapp": {
+		dateSources: []string{"filename", "mtime"},
+		excludes:    []string{".Statuses", ".Links", ".nomedia", "WhatsApp Stickers"},
+	},
+	// the camera roll of an unencrypted iTunes/Finder backup. The edits are kept by the phone in .aae
+	// sidecar files which are not of any use outside the phone.
+	"iphone-backup": {
+		types:        []string{"jpg", "jpeg", "png", "heic", "gif", "mov", "mp4"},
+		dateSources:  []string{"mtime"},
+		iphoneBackup: true,
+	},
+}
+
+// isExcluded checks the name of the file or directory against the exclude patterns.
+func isExcluded(name string, excludes []string) bool {
+	for _, pattern := range excludes {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isExcludedDir checks a directory found by the walk against the exclude patterns, the ignore
+// files and the destinations inside the source.
+func isExcludedDir(path string, name string, opts *sortOptions) bool {
+	if _, ok := opts.destinationsInSource[filepath.Clean(path)]; ok {
+		return true
+	}
+	return isExcluded(name, opts.excludes) || (opts.ignores != nil && opts.ignores.ignored(path, true))
+}
+
+// backupFile is a file listed in the manifest of an iTunes/Finder backup.
+type backupFile struct {
+	name    string
+	modTime time.Time
+}
+
+// backupFileInfo presents a backup file with its original name and modified time instead of the
+// ones of the hashed file in the backup.
+type backupFileInfo struct {
+	os.FileInfo
+	file backupFile
+}
+
+// isSameFile is like os.SameFile but also sees through backupFileInfo.
+func isSameFile(a os.FileInfo, b os.FileInfo) bool {
+	if info, ok := a.(backupFileInfo); ok {
+		a = info.FileInfo
+	}
+	return os.SameFile(a, b)
+}
+
+func (info backupFileInfo) Name() string {
+	return info.file.name
+}
+
+func (info backupFileInfo) ModTime() time.Time {
+	if info.file.modTime.IsZero() {
+		return info.FileInfo.ModTime()
+	}
+	return info.file.modTime
+}
+
+// readBackupManifest reads the camera roll files from the Manifest.db of a backup. The returned map
+// is keyed by the file id which is the name the file is stored with in the backup.
+func readBackupManifest(backupPath string, printer *outputPrinter) (map[string]backupFile, error) {
+
+	manifestPath := filepath.Join(backupPath, "Manifest.db")
+	if _, err := os.Stat(manifestPath); err != nil {
+		return nil, printer.errorf("The source %s is not an iTunes/Finder backup: %v\n", backupPath, err)
+	}
+
+	// the backup is only read, so that not even a journal is written next to the manifest
+	db, err := sql.Open("sqlite3", "file:"+manifestPath+"?mode=ro&immutable=1")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// flags 1 marks a file as opposed to a directory or a symlink
+	rows, err := db.Query(`SELECT fileID, relativePath, file FROM Files
+		WHERE domain = 'CameraRollDomain' AND flags = 1 AND relativePath LIKE 'Media/DCIM/%'`)
+	if err != nil {
+		return nil, printer.errorf("The manifest of the backup %s could not be read. Encrypted backups are not supported: %v\n", backupPath, err)
+	}
+	defer rows.Close()
+
+	files := make(map[string]backupFile)
+	for rows.Next() {
+		var fileID, relativePath string
+		var metadata []byte
+		if err := rows.Scan(&fileID, &relativePath, &metadata); err != nil {
+			return nil, err
+		}
+		files[fileID] = backupFile{
+			name:    path.Base(relativePath),
+			modTime: backupFileModTime(metadata),
+		}
+	}
+	return files, rows.Err()
+}
+
+// backupFileModTime reads the birth or the last modified time from the keyed archive of the file
+// metadata. The files in the backup itself carry the time of the backup.
+func backupFileModTime(metadata []byte) time.Time {
+	plist, err := parseBinaryPlist(metadata)
+	if err != nil {
+		return time.Time{}
+	}
+	archive, _ := plist.(map[string]interface{})
+	objects, _ := archive["$objects"].([]interface{})
+
+	for _, object := range objects {
+		properties, ok := object.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"Birth", "LastModified"} {
+			if seconds, ok := properties[key].(int64); ok && seconds > 0 {
+				return time.Unix(seconds, 0)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// applyPreset fills in the options which were not passed explicitly from the preset.
+func applyPreset(p preset, fileTypeFilter *string, dateSource *string, explicit map[string]bool) {
+	if !explicit["types"] && len(p.types) > 0 {
+		*fileTypeFilter = strings.Join(p.types, ":")
+	}
+	if !explicit["date-source"] && len(p.dateSources) > 0 {
+		*dateSource = strings.Join(p.dateSources, ",")
+	}
+}
@@ -0,0 +1,158 @@
+package sorter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigFile returns the path of the config file of the user, like ~/.config/filesorter/config.yaml
+// on Linux.
+func ConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "filesorter", "config.yaml")
+}
+
+// ReadProfile reads a profile of the config file, which is the one of the user when configFile is
+// empty. A profile maps the names of the options to their values, several for the options which
+// can be repeated like destination. The config file is yaml with the profiles under profiles:
+//
+//	profiles:
+//	  photos:
+//	    source: /media/card/DCIM
+//	    destination: [/mnt/archive, /mnt/backup]
+//	    types: images:videos
+//	    workers: 4
+//	    exclude:
+//	      - "*.tmp"
+//
+// Only this subset of yaml is read: maps nested by indentation with spaces, plain or quoted values
+// and lists either in brackets or one item per line.
+func ReadProfile(configFile string, name string) (map[string][]string, error) {
+	if strings.Compare(configFile, "") == 0 {
+		configFile = ConfigFile()
+	}
+	profiles, err := readProfiles(configFile)
+	if err != nil {
+		return nil, errorf("An error occurred while trying to read the config file %s: %v\n", configFile, err)
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, errorf("The profile %s is not in the config file %s\n", name, configFile)
+	}
+	return profile, nil
+}
+
+func readProfiles(configFile string) (map[string]map[string][]string, error) {
+	file, err := os.Open(configFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	profiles := make(map[string]map[string][]string)
+	var profile map[string][]string
+	var key string
+	inProfiles := false
+	profileIndent := -1
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := stripYAMLComment(scanner.Text())
+		text := strings.TrimSpace(line)
+		if strings.Compare(text, "") == 0 {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if strings.Contains(line[:indent], "\t") {
+			return nil, fmt.Errorf("line %d: indent with spaces instead of tabs", n)
+		}
+
+		// the other top level keys are left for later versions
+		if indent == 0 {
+			inProfiles, profile, key = strings.Compare(text, "profiles:") == 0, nil, ""
+			continue
+		}
+		if !inProfiles {
+			continue
+		}
+		if profileIndent < 0 {
+			profileIndent = indent
+		}
+
+		switch {
+		case indent == profileIndent:
+			if !strings.HasSuffix(text, ":") {
+				return nil, fmt.Errorf("line %d: expected the name of a profile like photos:", n)
+			}
+			profile, key = make(map[string][]string), ""
+			profiles[unquoteYAML(strings.TrimSuffix(text, ":"))] = profile
+		case indent < profileIndent:
+			return nil, fmt.Errorf("line %d: unexpected indent", n)
+		case profile == nil:
+			return nil, fmt.Errorf("line %d: expected the name of a profile like photos:", n)
+		case strings.Compare(text, "-") == 0 || strings.HasPrefix(text, "- "):
+			if strings.Compare(key, "") == 0 {
+				return nil, fmt.Errorf("line %d: a list item without an option", n)
+			}
+			profile[key] = append(profile[key], unquoteYAML(strings.TrimSpace(text[1:])))
+		default:
+			i := strings.Index(text, ":")
+			if i <= 0 {
+				return nil, fmt.Errorf("line %d: expected an option like types: images", n)
+			}
+			key = unquoteYAML(strings.TrimSpace(text[:i]))
+			value := strings.TrimSpace(text[i+1:])
+			switch {
+			case strings.Compare(value, "") == 0:
+				// the items follow on the next lines
+				profile[key] = nil
+			case strings.HasPrefix(value, "["):
+				if !strings.HasSuffix(value, "]") {
+					return nil, fmt.Errorf("line %d: a list has to end with ] on the same line", n)
+				}
+				profile[key] = nil
+				for _, item := range strings.Split(value[1:len(value)-1], ",") {
+					if item = strings.TrimSpace(item); strings.Compare(item, "") != 0 {
+						profile[key] = append(profile[key], unquoteYAML(item))
+					}
+				}
+			default:
+				profile[key] = []string{unquoteYAML(value)}
+			}
+		}
+	}
+	return profiles, scanner.Err()
+}
+
+// stripYAMLComment removes a comment starting with a # at the start of the line or after a space,
+// unless the # is inside quotes.
+func stripYAMLComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquoteYAML removes the quotes around a value. Quotes are needed for the values starting with
+// a * like the exclude patterns.
+func unquoteYAML(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,180 @@
+package sorter
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// progressSocket serves the progress of a run over a local unix socket so that GUI frontends can
+// follow and control it. The protocol is newline delimited json in both directions. The server
+// sends events like
+//
+//	{"event":"progress","path":"/media/phone/IMG_1.jpg","copiedFiles":10,"skippedFiles":2,"erroredFiles":0,"bytesCopied":52428800}
+//
+// and the clients send commands like {"command":"pause"}. The commands are pause, resume, cancel
+// and status which replies with the last progress event.
+type progressSocket struct {
+	path     string
+	listener net.Listener
+	control  *runControl
+
+	mu      sync.Mutex
+	clients map[net.Conn]*json.Encoder
+	last    progressEvent
+}
+
+type progressEvent struct {
+	Event        string `json:"event"`
+	Path         string `json:"path,omitempty"`
+	CopiedFiles  int    `json:"copiedFiles"`
+	SkippedFiles int    `json:"skippedFiles"`
+	ErroredFiles int    `json:"erroredFiles"`
+	BytesCopied  int64  `json:"bytesCopied"`
+	Error        string `json:"error,omitempty"`
+}
+
+func newProgressEvent(event string, path string, counts *processedCount) progressEvent {
+	return progressEvent{
+		Event:        event,
+		Path:         path,
+		CopiedFiles:  counts.copiedFiles,
+		SkippedFiles: counts.skippedFiles,
+		ErroredFiles: counts.erroredFiles,
+		BytesCopied:  counts.totalBytesCopied,
+	}
+}
+
+type progressCommand struct {
+	Command string `json:"command"`
+}
+
+func listenProgressSocket(path string, control *runControl) (*progressSocket, error) {
+	// a socket left behind by a run which crashed is replaced
+	if fileInfo, err := os.Lstat(path); err == nil && fileInfo.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &progressSocket{
+		path:     path,
+		listener: listener,
+		control:  control,
+		clients:  make(map[net.Conn]*json.Encoder),
+		last:     progressEvent{Event: "progress"},
+	}
+	control.onChange = func(state string) {
+		s.mu.Lock()
+		event := s.last
+		s.mu.Unlock()
+		event.Event = state
+		s.broadcast(event)
+	}
+	go s.accept()
+	return s, nil
+}
+
+func (s *progressSocket) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.clients[conn] = json.NewEncoder(conn)
+		s.mu.Unlock()
+		go s.serve(conn)
+	}
+}
+
+func (s *progressSocket) serve(conn net.Conn) {
+	defer s.drop(conn)
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var command progressCommand
+		if err := json.Unmarshal(scanner.Bytes(), &command); err != nil {
+			s.send(conn, progressEvent{Event: "error", Error: err.Error()})
+			continue
+		}
+		switch command.Command {
+		case "pause":
+			s.control.pause()
+		case "resume":
+			s.control.resume()
+		case "cancel":
+			s.control.cancel()
+		case "status":
+			s.mu.Lock()
+			event := s.last
+			s.mu.Unlock()
+			s.send(conn, event)
+		default:
+			s.send(conn, progressEvent{Event: "error", Error: "unknown command " + command.Command})
+		}
+	}
+}
+
+// publish sends the progress after a file was processed to all the clients.
+func (s *progressSocket) publish(path string, counts *processedCount) {
+	event := newProgressEvent("progress", path, counts)
+	s.mu.Lock()
+	s.last = event
+	s.mu.Unlock()
+	s.broadcast(event)
+}
+
+func (s *progressSocket) broadcast(event progressEvent) {
+	s.mu.Lock()
+	conns := make([]net.Conn, 0, len(s.clients))
+	for conn := range s.clients {
+		conns = append(conns, conn)
+	}
+	s.mu.Unlock()
+
+	for _, conn := range conns {
+		s.send(conn, event)
+	}
+}
+
+// send writes the event to the client. A client which does not keep up is dropped so that it can
+// not stall the run.
+func (s *progressSocket) send(conn net.Conn, event progressEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	encoder, ok := s.clients[conn]
+	if !ok {
+		return
+	}
+	conn.SetWriteDeadline(time.Now().Add(time.Second))
+	if err := encoder.Encode(event); err != nil {
+		conn.Close()
+		delete(s.clients, conn)
+	}
+}
+
+func (s *progressSocket) drop(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conn.Close()
+	delete(s.clients, conn)
+}
+
+// Close sends the completed event and removes the socket.
+func (s *progressSocket) Close(counts *processedCount) {
+	s.broadcast(newProgressEvent("completed", "", counts))
+
+	s.listener.Close()
+	s.mu.Lock()
+	for conn := range s.clients {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	os.Remove(s.path)
+}
@@ -0,0 +1,209 @@
+package sorter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/karrick/godirwalk"
+)
+
+// progressBarWidth is the number of characters of the bar itself.
+const progressBarWidth = 30
+
+// progressBar draws the progress of a run on the last line of the terminal with the percentage,
+// the throughput and the time left. The source is counted ahead so that the total is known. The
+// messages of the run are written through it so that they scroll above the bar.
+type progressBar struct {
+	out        io.Writer
+	totalFiles int
+	totalBytes int64
+	started    time.Time
+
+	mu      sync.Mutex
+	files   int
+	bytes   int64
+	partial []byte
+	// drawing is cleared once the bar is stopped and the messages are then written straight through
+	drawing bool
+	stopped chan struct{}
+	done    chan struct{}
+}
+
+// isTerminal reports whether the file is a terminal rather than a pipe or a regular file.
+func isTerminal(file *os.File) bool {
+	fileInfo, err := file.Stat()
+	return err == nil && fileInfo.Mode()&os.ModeCharDevice != 0
+}
+
+// startProgressBar counts the files of the source which are sorted and starts drawing the bar.
+func startProgressBar(sourcePath string, out io.Writer, opts *sortOptions) (*progressBar, error) {
+	bar := &progressBar{out: out, stopped: make(chan struct{}), done: make(chan struct{})}
+
+	opts.printer.Printf("Counting the files of %s\n", sourcePath)
+	err := godirwalk.Walk(sourcePath, &godirwalk.Options{
+		Unsorted: true,
+		Callback: func(path string, dirent *godirwalk.Dirent) error {
+			if opts.control != nil && opts.control.wait() {
+				return errCancelled
+			}
+			if dirent.IsDir() {
+				if isExcludedDir(path, dirent.Name(), opts) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !progressCounts(path, dirent.Name(), opts) {
+				return nil
+			}
+			// a file which can not be stat'ed is counted without its size
+			bar.totalFiles++
+			if fileInfo, err := os.Stat(path); err == nil {
+				bar.totalBytes += fileInfo.Size()
+			}
+			return nil
+		},
+		ErrorCallback: func(_ string, err error) godirwalk.ErrorAction {
+			return godirwalk.SkipNode
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	opts.printer.Printf("Found %d files with %s to sort\n", bar.totalFiles, formatBytes(bar.totalBytes))
+
+	bar.started, bar.drawing = time.Now(), true
+	go bar.redraw()
+	return bar, nil
+}
+
+// progressCounts reports whether the file is counted by the bar. Only the files which are not
+// excluded and of the sorted types are, the others are skipped without reading them.
+func progressCounts(path string, name string, opts *sortOptions) bool {
+	if isExcluded(name, opts.excludes) || (opts.ignores != nil && opts.ignores.ignored(path, false)) {
+		return false
+	}
+	if opts.backupFiles != nil {
+		file, ok := opts.backupFiles[name]
+		if !ok {
+			return false
+		}
+		name = file.name
+	}
+	return matchesTypes(name, opts)
+}
+
+// add advances the bar by a processed file, whether it was copied, skipped or failed.
+func (bar *progressBar) add(path string, name string, fileInfo os.FileInfo, opts *sortOptions) {
+	if !progressCounts(path, name, opts) {
+		return
+	}
+	if fileInfo == nil {
+		fileInfo, _ = os.Stat(path)
+	}
+	bar.mu.Lock()
+	defer bar.mu.Unlock()
+	bar.files++
+	if fileInfo != nil {
+		bar.bytes += fileInfo.Size()
+	}
+}
+
+// Write prints the complete lines of the messages above the bar and draws the bar again.
+func (bar *progressBar) Write(p []byte) (int, error) {
+	bar.mu.Lock()
+	defer bar.mu.Unlock()
+	if !bar.drawing {
+		return bar.out.Write(p)
+	}
+	bar.partial = append(bar.partial, p...)
+	end := bytes.LastIndexByte(bar.partial, '\n')
+	if end < 0 {
+		return len(p), nil
+	}
+	fmt.Fprint(bar.out, "\r\033[K")
+	if _, err := bar.out.Write(bar.partial[:end+1]); err != nil {
+		return 0, err
+	}
+	bar.partial = append(bar.partial[:0], bar.partial[end+1:]...)
+	bar.draw()
+	return len(p), nil
+}
+
+// redraw draws the bar every half a second so that the throughput and the time left stay up to
+// date during a large file.
+func (bar *progressBar) redraw() {
+	defer close(bar.done)
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			bar.mu.Lock()
+			bar.draw()
+			bar.mu.Unlock()
+		case <-bar.stopped:
+			return
+		}
+	}
+}
+
+// draw writes the bar over the current line. It is called with the lock held.
+func (bar *progressBar) draw() {
+	fraction := 1.0
+	if bar.totalBytes > 0 {
+		fraction = float64(bar.bytes) / float64(bar.totalBytes)
+	} else if bar.totalFiles > 0 {
+		fraction = float64(bar.files) / float64(bar.totalFiles)
+	}
+	// files added to the source during the run go beyond the count
+	if fraction > 1 {
+		fraction = 1
+	}
+	filled := int(fraction * progressBarWidth)
+	line := "[" + strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled) + "]"
+
+	elapsed := time.Since(bar.started)
+	rate := float64(bar.bytes) / elapsed.Seconds()
+	eta := "--"
+	if rate > 0 && bar.bytes > 0 {
+		left := time.Duration(float64(bar.totalBytes-bar.bytes) / rate * float64(time.Second))
+		if left < 0 {
+			left = 0
+		}
+		eta = left.Round(time.Second).String()
+	}
+	fmt.Fprintf(bar.out, "\r\033[K%s %3.0f%% %d/%d files %s/s ETA %s", line, fraction*100, bar.files, bar.totalFiles, formatBytes(int64(rate)), eta)
+}
+
+// stop clears the bar and prints what is left of the messages. The messages printed after it
+// are written as they are, like the ones of the copies still finishing.
+func (bar *progressBar) stop() {
+	close(bar.stopped)
+	<-bar.done
+	bar.mu.Lock()
+	defer bar.mu.Unlock()
+	fmt.Fprint(bar.out, "\r\033[K")
+	bar.out.Write(bar.partial)
+	bar.partial, bar.drawing = nil, false
+}
+
+// formatBytes formats a size with the largest unit which keeps it above 1, like 3.2 GB.
+func formatBytes(size int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
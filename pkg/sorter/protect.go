@@ -0,0 +1,66 @@
+package sorter
+
+import "os"
+
+// protectFile makes a copied file read-only once its content is verified against the hash
+// computed while copying. With immutable the file is also marked immutable so that not even its
+// owner can modify or delete it until the flag is cleared again.
+func (r *runIO) protectFile(path string, hash string, immutable bool) error {
+
+	destHash, err := r.hashFile(path)
+	if err != nil {
+		return err
+	}
+	if destHash != hash {
+		return errCopyMismatch
+	}
+
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	// clear all the write bits and keep the rest
+	if err := os.Chmod(path, fileInfo.Mode().Perm()&^0222); err != nil {
+		return err
+	}
+
+	if immutable {
+		return setImmutable(path)
+	}
+	return nil
+}
+
+// openForRewrite opens a copy to be rewritten in place by -update or a resumed copy. A copy
+// protected by an earlier run is made writable again first. -protect makes it read-only again
+// once it was rewritten and verified.
+func openForRewrite(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if !os.IsPermission(err) {
+		return file, err
+	}
+	// an immutable copy can not be changed and keeps the error of the open
+	fileInfo, statErr := os.Stat(path)
+	if statErr != nil || os.Chmod(path, fileInfo.Mode().Perm()|0200) != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_RDWR, 0)
+}
+
+// unprotectFile makes a copy protected by an earlier run writable and mutable again, so that
+// verify -repair can replace it. A copy which is gone or was not protected is left as it is.
+func unprotectFile(path string) error {
+	fileInfo, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := clearImmutable(path); err != nil {
+		return err
+	}
+	if fileInfo.Mode().Perm()&0200 != 0 {
+		return nil
+	}
+	return os.Chmod(path, fileInfo.Mode().Perm()|0200)
+}
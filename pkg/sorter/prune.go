@@ -0,0 +1,242 @@
+package sorter
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karrick/godirwalk"
+)
+
+type pruneCount struct {
+	prunedFiles  int
+	keptFiles    int
+	undatedFiles int
+	erroredFiles int
+	prunedBytes  int64
+}
+
+func runPrune(args []string) int {
+
+	flags := flag.NewFlagSet("prune", flag.ExitOnError)
+	destPath := flags.String("destination", "", "The destination to prune.")
+	olderThan := flags.String("older-than", "", `The retention window. The files sorted into a date older than this are pruned.
+	For eg: 5y, 18m (months), 6w or 30d`)
+	catalogPath := flags.String("catalog", "", `Optional. The catalog of the destination. The dates of the files are read
+	from it and the pruned files are removed from it`)
+	trash := flags.String("trash", "", `Optional. Move the pruned files into this directory keeping their path
+	relative to the destination instead of deleting them`)
+	dryRun := flags.Bool("dry-run", false, "Optional. Only print the files which would be pruned")
+	lang := AddLanguageFlag(flags)
+	parseFlags(flags, args)
+
+	if err := SetLanguage(*lang); err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	if strings.Compare(*destPath, "") == 0 || strings.Compare(*olderThan, "") == 0 {
+		printer.Printf("Usage: filesorter prune -destination <destination path> -older-than <age> [-catalog <catalog path>] [-trash <path>] [-dry-run]\n")
+		flags.PrintDefaults()
+		return 1
+	}
+	if !isPathValid(destPath, printer) {
+		return 1
+	}
+	cutoff, err := parseAge(*olderThan, time.Now(), printer)
+	if err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	var cat *catalog
+	if strings.Compare(*catalogPath, "") != 0 {
+		cat, err = openCatalog(*catalogPath)
+		if err != nil {
+			printer.Printf("An error occurred while trying to open the catalog %s: %v\n", *catalogPath, err)
+			return 1
+		}
+		defer cat.Close()
+	}
+
+	// the trash and the catalog may be kept in the destination itself
+	var skipPaths []string
+	for _, path := range []string{*trash, *catalogPath} {
+		if strings.Compare(path, "") != 0 {
+			absPath, _ := filepath.Abs(path)
+			skipPaths = append(skipPaths, absPath)
+		}
+	}
+
+	var counts pruneCount
+	// the directories from which files were pruned are removed if they end up empty
+	emptied := make(map[string]bool)
+	godirwalk.Walk(*destPath, &godirwalk.Options{
+		Callback: func(path string, dirent *godirwalk.Dirent) error {
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				return err
+			}
+			for _, skipPath := range skipPaths {
+				if absPath == skipPath || strings.HasPrefix(absPath, skipPath+string(filepath.Separator)) {
+					if dirent.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+			}
+			if dirent.IsDir() {
+				return nil
+			}
+			pruned, err := pruneFile(absPath, *destPath, cutoff, cat, *trash, *dryRun, &counts)
+			if err != nil {
+				printer.Printf("An error occurred while trying to prune the file %s: %v\n", path, err)
+				counts.erroredFiles++
+			}
+			if pruned {
+				emptied[filepath.Dir(absPath)] = true
+			}
+			return nil
+		},
+		PostChildrenCallback: func(path string, dirent *godirwalk.Dirent) error {
+			// the day, month and year directories which got emptied are removed. removing fails
+			// for the ones which are not empty
+			absPath, err := filepath.Abs(path)
+			if err != nil || !emptied[absPath] || *dryRun || filepath.Clean(path) == filepath.Clean(*destPath) {
+				return nil
+			}
+			if os.Remove(absPath) == nil {
+				emptied[filepath.Dir(absPath)] = true
+			}
+			return nil
+		},
+		ErrorCallback: func(path string, err error) godirwalk.ErrorAction {
+			printer.Printf("An error occurred while trying to read %s: %v\n", path, err)
+			counts.erroredFiles++
+			return godirwalk.SkipNode
+		},
+	})
+
+	printer.Printf("Completed !\n")
+	format := "Pruned %d files (%d bytes) older than %s. Kept %d, Without a date %d, Errored %d\n"
+	if *dryRun {
+		format = "Would prune %d files (%d bytes) older than %s. Would keep %d, Without a date %d, Errored %d\n"
+	}
+	printer.Printf(format,
+		counts.prunedFiles,
+		counts.prunedBytes,
+		cutoff.Format("2006-01-02"),
+		counts.keptFiles,
+		counts.undatedFiles,
+		counts.erroredFiles)
+
+	if counts.erroredFiles > 0 {
+		return 1
+	}
+	return 0
+}
+
+// pruneFile deletes or moves the file to the trash if the date it was sorted by is before the cutoff
+// and returns whether it did. The date is read from the catalog or else from the path of the date
+// layout. Files without either, like the ones sorted by their tags, are never pruned.
+func pruneFile(path string, destPath string, cutoff time.Time, cat *catalog, trash string, dryRun bool, counts *pruneCount) (bool, error) {
+
+	sortTime := parseDateDestFilePath(path)
+	if cat != nil {
+		entry, ok, err := cat.get(path)
+		if err != nil {
+			return false, err
+		}
+		if ok && !entry.sortTime.IsZero() {
+			sortTime = entry.sortTime
+		}
+	}
+	if sortTime.IsZero() {
+		counts.undatedFiles++
+		return false, nil
+	}
+	if !sortTime.Before(cutoff) {
+		counts.keptFiles++
+		return false, nil
+	}
+
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+
+	if dryRun {
+		printer.Printf("Would prune %s\n", path)
+	} else if strings.Compare(trash, "") != 0 {
+		relativePath, err := filepath.Rel(destPath, path)
+		if err != nil {
+			return false, err
+		}
+		trashPath := filepath.Join(trash, relativePath)
+		if err := os.MkdirAll(filepath.Dir(trashPath), os.ModePerm); err != nil {
+			return false, err
+		}
+		if err := moveFile(path, trashPath); err != nil {
+			return false, err
+		}
+		printer.Printf("Moved %s --> %s\n", path, trashPath)
+	} else {
+		if err := os.Remove(path); err != nil {
+			return false, err
+		}
+		printer.Printf("Deleted %s\n", path)
+	}
+
+	if cat != nil && !dryRun {
+		if err := cat.remove(path); err != nil {
+			return false, err
+		}
+	}
+	counts.prunedFiles++
+	counts.prunedBytes += fileInfo.Size()
+	return true, nil
+}
+
+// moveFile renames the file or copies and deletes it when the destination is on another file system.
+func moveFile(source string, destination string) error {
+	if err := os.Rename(source, destination); err == nil {
+		return nil
+	}
+
+	fileInfo, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if _, _, err := defaultIO.copyFile(source, destination); err != nil {
+		return err
+	}
+	if err := os.Chtimes(destination, fileInfo.ModTime(), fileInfo.ModTime()); err != nil {
+		return err
+	}
+	return os.Remove(source)
+}
+
+// parseAge converts an age like 5y, 18m, 6w or 30d to the time that long before now.
+func parseAge(age string, now time.Time, printer *outputPrinter) (time.Time, error) {
+	if len(age) < 2 {
+		return time.Time{}, printer.errorf("The age %s is not valid\n", age)
+	}
+	n, err := strconv.Atoi(age[:len(age)-1])
+	if err != nil || n <= 0 {
+		return time.Time{}, printer.errorf("The age %s is not valid\n", age)
+	}
+	switch age[len(age)-1] {
+	case 'y':
+		return now.AddDate(-n, 0, 0), nil
+	case 'm':
+		return now.AddDate(0, -n, 0), nil
+	case 'w':
+		return now.AddDate(0, 0, -7*n), nil
+	case 'd':
+		return now.AddDate(0, 0, -n), nil
+	}
+	return time.Time{}, printer.errorf("The age %s is not valid. Use one of the units y, m, w or d\n", age)
+}
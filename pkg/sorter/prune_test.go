@@ -0,0 +1,38 @@
+package sorter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPruneBucketsAndBursts(t *testing.T) {
+	destination := t.TempDir()
+	writeFiles(t, destination, map[string]string{
+		"2001/May/2/IMG_0001.jpg":                  "old",
+		"2001/May/2/001/IMG_0002.jpg":              "old bucket",
+		"2001/May/2/burst-153059/IMG_0003.jpg":     "old burst",
+		"2001/May/2/burst-153059/002/IMG_0004.jpg": "old bucket in a burst",
+		"2999/May/2/001/IMG_0005.jpg":              "new bucket",
+		"2999/May/2/burst-153059/IMG_0006.jpg":     "new burst",
+		"Album/001/IMG_0007.jpg":                   "undated",
+	})
+
+	if code := Command("prune")([]string{"-destination", destination, "-older-than", "5y"}); code != 0 {
+		t.Fatalf("prune exited with %d", code)
+	}
+	tests := []struct {
+		path   string
+		exists bool
+	}{
+		{"2001", false},
+		{"2999/May/2/001/IMG_0005.jpg", true},
+		{"2999/May/2/burst-153059/IMG_0006.jpg", true},
+		{"Album/001/IMG_0007.jpg", true},
+	}
+	for _, test := range tests {
+		if _, err := os.Stat(filepath.Join(destination, filepath.FromSlash(test.path))); (err == nil) != test.exists {
+			t.Errorf("%s exists %v, want %v", test.path, err == nil, test.exists)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package sorter
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+)
+
+func runQuery(args []string) int {
+
+	flags := flag.NewFlagSet("query", flag.ExitOnError)
+	catalogPath := flags.String("catalog", "", "The catalog database written by previous sort runs.")
+	ext := flags.String("ext", "", "Optional. Only the files with this extension. For eg: jpg")
+	year := flags.Int("year", 0, "Optional. Only the files sorted by a date in this year")
+	minSize := flags.String("min-size", "", "Optional. Only the files of at least this size. For eg: 5MB")
+	maxSize := flags.String("max-size", "", "Optional. Only the files of at most this size. For eg: 1.5GB")
+	name := flags.String("name", "", `Optional. Only the files whose source or destination path contains this text.
+	Useful to find where a file from the source went`)
+	lang := AddLanguageFlag(flags)
+	parseFlags(flags, args)
+
+	if err := SetLanguage(*lang); err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	if strings.Compare(*catalogPath, "") == 0 {
+		printer.Printf("Usage: filesorter query -catalog <catalog path> [-ext <ext>] [-year <year>] [-min-size <size>] [-max-size <size>] [-name <text>]\n")
+		flags.PrintDefaults()
+		return 1
+	}
+
+	filter := catalogFilter{ext: *ext, year: *year, name: *name}
+	var err error
+	if filter.minSize, err = parseSize(*minSize, printer); err != nil {
+		printer.Println(err)
+		return 1
+	}
+	if filter.maxSize, err = parseSize(*maxSize, printer); err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	cat, err := openCatalog(*catalogPath)
+	if err != nil {
+		printer.Printf("An error occurred while trying to open the catalog %s: %v\n", *catalogPath, err)
+		return 1
+	}
+	defer cat.Close()
+
+	entries, err := cat.query(filter)
+	if err != nil {
+		printer.Printf("An error occurred while trying to query the catalog %s: %v\n", *catalogPath, err)
+		return 1
+	}
+
+	for _, entry := range entries {
+		printer.Printf("%s --> %s (%d bytes, modified %s)\n",
+			entry.sourcePath, entry.destPath, entry.size, entry.modTime.Format("2006-01-02 15:04:05"))
+	}
+	printer.Printf("Found %d files\n", len(entries))
+	return 0
+}
+
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	// the longer suffixes are checked first so that MB is not read as a B
+	{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+}
+
+// parseSize parses a size like 500, 100KB, 5MB or 1.5G. The units are multiples of 1024.
+// An empty size is returned as 0.
+func parseSize(size string, printer *outputPrinter) (int64, error) {
+	if strings.Compare(size, "") == 0 {
+		return 0, nil
+	}
+
+	value, multiplier := strings.ToUpper(strings.TrimSpace(size)), int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value, multiplier = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix)), unit.multiplier
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, printer.errorf("The size %s is not valid\n", size)
+	}
+	return int64(number * float64(multiplier)), nil
+}
@@ -0,0 +1,49 @@
+package sorter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+)
+
+// readBackHash returns the sha256 of a copy read back from the destination. The copy is flushed
+// and dropped from the page cache first where supported, so that it is read from the drive rather
+// than from the memory it was just written from.
+func (r *runIO) readBackHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := file.Sync(); err != nil {
+		return "", err
+	}
+	dropPageCache(file)
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r.cancellable(file)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// verifyCopy reads a copy back and compares it with the hash of the source computed while copying.
+// A copy which does not match is removed so that the next run copies the file again instead of
+// skipping it for its size.
+func (r *runIO) verifyCopy(path string, c *fileCopy, counts *processedCount) error {
+	destHash, err := r.readBackHash(c.destFilePath)
+	if err != nil {
+		r.printer.Printf("An error occurred while trying to verify the copy %s", c.destFilePath)
+		return err
+	}
+	if destHash != c.hash {
+		r.printer.Printf("The copy %s does not match the source %s and is removed\n", c.destFilePath, path)
+		counts.corruptedCopies = append(counts.corruptedCopies, alertFile{Path: c.destFilePath, Detail: errCopyMismatch.Error()})
+		os.Remove(c.destFilePath)
+		return errCopyMismatch
+	}
+	counts.verifiedCopies++
+	return nil
+}
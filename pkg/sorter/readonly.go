@@ -0,0 +1,77 @@
+package sorter
+
+import (
+	"archive/zip"
+	"os"
+	"strings"
+)
+
+// openSource opens a file of the source for reading. With -assert-readonly-source for forensic
+// copies it is read without updating its access time where the platform supports it.
+func (r *runIO) openSource(path string) (*os.File, error) {
+	if r == nil || !r.readOnlySource {
+		return os.Open(path)
+	}
+	return openNoAtime(path)
+}
+
+// openSourceZip opens a zip container of the source like zip.OpenReader. The file has to be closed
+// once the reader is not used anymore.
+func (r *runIO) openSourceZip(path string) (*zip.Reader, *os.File, error) {
+	file, err := r.openSource(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	archive, err := zip.NewReader(file, fileInfo.Size())
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return archive, file, nil
+}
+
+// isInsideSource tells whether the path is the source or below it, also through a symlink.
+func isInsideSource(source string, path string) bool {
+	return checkInsideDestination(source, path) == nil
+}
+
+// checkReadOnlySource refuses the options of the run which would modify the source.
+func checkReadOnlySource(options *Options, opts *sortOptions) error {
+	if options.Move {
+		return opts.printer.errorf("The -move option removes the files from the source and cannot be used with -assert-readonly-source\n")
+	}
+	source := options.Source
+	if strings.Compare(options.RetryFrom, "") != 0 {
+		return nil
+	}
+	for _, dest := range opts.destinations {
+		if isInsideSource(source, dest.path) {
+			return opts.printer.errorf("The destination %s is inside the source which -assert-readonly-source does not allow\n", dest.path)
+		}
+	}
+	written := []struct {
+		option string
+		path   string
+	}{
+		{"-catalog", options.Catalog},
+		{"-error-report", options.ErrorReport},
+		{"-out", options.PlanOut},
+		{"-staging", options.Staging},
+		{"-control-socket", options.ControlSocket},
+		{"-watch-queue", options.WatchQueue},
+		{"-conflicts", options.Conflicts},
+		{"-manifest", options.Manifest},
+		{"-quarantine", options.Quarantine},
+	}
+	for _, w := range written {
+		if strings.Compare(w.path, "") != 0 && isInsideSource(source, w.path) {
+			return opts.printer.errorf("The path %s of %s is inside the source which -assert-readonly-source does not allow\n", w.path, w.option)
+		}
+	}
+	return nil
+}
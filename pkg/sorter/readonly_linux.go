@@ -0,0 +1,20 @@
+package sorter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+const noAtimeSupported = true
+
+// openNoAtime opens the file with O_NOATIME which the kernel only allows to the owner of the file
+// and to root.
+func openNoAtime(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NOATIME, 0)
+	if errors.Is(err, syscall.EPERM) {
+		return nil, fmt.Errorf("%v: reading the file without updating its access time needs its owner or root", err)
+	}
+	return file, err
+}
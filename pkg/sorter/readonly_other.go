@@ -0,0 +1,11 @@
+//go:build !linux
+
+package sorter
+
+import "os"
+
+const noAtimeSupported = false
+
+func openNoAtime(path string) (*os.File, error) {
+	return os.Open(path)
+}
@@ -0,0 +1,104 @@
+package sorter
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// remoteTarget is a destination which is not a local directory, like a bucket or an sftp server.
+// Its files are uploaded on their own instead of being written together with the local copies.
+type remoteTarget interface {
+	// url returns the url the file sorted into the relative path is uploaded to, which stands for
+	// its path in the messages and reports
+	url(relativePath string) string
+	// check makes sure that the destination can be reached before any file is copied
+	check() error
+	// stat returns the info of the file at the url, or nil when there is none
+	stat(destURL string) (os.FileInfo, error)
+	// sameContent compares the file at the destination with the source for -compare hash
+	sameContent(path string, destFileStat os.FileInfo) (bool, error)
+	// upload copies the file to the url with the modified time and returns the number of bytes
+	// and the sha256 of the file
+	upload(path string, destURL string, modTime time.Time) (int64, string, error)
+	close() error
+}
+
+// isRemote reports whether the destination is an s3:// or sftp:// url.
+func isRemote(destPath string) bool {
+	return strings.HasPrefix(destPath, s3Scheme) || strings.HasPrefix(destPath, sftpScheme)
+}
+
+// newRemoteTarget returns the target of an s3:// or sftp:// destination, or nil for a local one.
+func newRemoteTarget(destPath string, options *Options, dirMode os.FileMode, runIO *runIO) (remoteTarget, error) {
+	if bucket, prefix, ok := parseS3URL(destPath); ok {
+		if strings.Compare(bucket, "") == 0 {
+			return nil, runIO.printer.errorf("The destination %s has no bucket\n", destPath)
+		}
+		client, err := newS3Client(options.S3, runIO.printer)
+		if err != nil {
+			return nil, err
+		}
+		return newS3Target(client, bucket, prefix, options.S3.InFlight, runIO), nil
+	}
+	if u, ok, err := parseSFTPURL(destPath, runIO.printer); ok {
+		if err != nil {
+			return nil, err
+		}
+		return newSFTPTarget(destPath, u, options.SSHCommand, options.CompressTransit, dirMode, runIO), nil
+	}
+	return nil, nil
+}
+
+// prepareUpload decides whether the file needs to be uploaded to the remote destination. A file
+// of the same size is taken to be the source like at the local destinations, and with -compare
+// hash its content has to match too.
+func prepareUpload(path string, sourceFileStat os.FileInfo, dest *destination, destFilePath string, opts *sortOptions) *fileCopy {
+	c := &fileCopy{dest: dest, destFilePath: destFilePath}
+
+	destFileStat, err := dest.remote.stat(destFilePath)
+	if err != nil {
+		opts.printer.Printf("An error occurred while trying to stat the file %s", c.destFilePath)
+		c.err = err
+		return c
+	}
+	if destFileStat == nil {
+		return c
+	}
+	c.destFileStat = destFileStat
+	same := destFileStat.Size() == sourceFileStat.Size()
+	if same && opts.compareHash {
+		if same, err = dest.remote.sameContent(path, destFileStat); err != nil {
+			opts.printer.Printf("An error occurred while trying to compare the file %s with %s", path, c.destFilePath)
+			c.err = err
+			return c
+		}
+	}
+	switch {
+	case same:
+		c.skip = true
+	case strings.Compare(opts.onConflict, "skip") == 0:
+		c.skip, c.conflict = true, true
+	default:
+		c.overwroteConflict = true
+	}
+	return c
+}
+
+// finishUpload records an uploaded file and updates the counts.
+func finishUpload(path string, sourceFileStat os.FileInfo, c *fileCopy, opts *sortOptions, counts *processedCount) error {
+	if c.overwroteConflict {
+		opts.printer.Printf("Uploaded %s --> %s over a different file of the same name\n", path, c.destFilePath)
+		counts.overwrittenConflicts++
+	} else {
+		opts.printer.Printf("Uploaded %s --> %s\n", path, c.destFilePath)
+	}
+	if opts.output != nil {
+		opts.output.file(path, c.destFilePath, "uploaded", c.written, nil)
+	}
+	c.dest.counts.copiedFiles++
+	c.dest.counts.totalBytesCopied += c.written
+	counts.copiedFiles++
+	counts.totalBytesCopied += c.written
+	return nil
+}
@@ -0,0 +1,62 @@
+package sorter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+)
+
+// errPartialMismatch is returned when the partial file at the destination is not a prefix of the source.
+var errPartialMismatch = errors.New("the partial file does not match the start of the source")
+
+// resumeCopy continues an interrupted copy by appending the rest of the source to the partial
+// destination file. The partial content is first compared with the start of the source so that
+// an unrelated file of a smaller size is never extended. Like copyFile it returns the number of
+// bytes written and the sha256 of the complete file.
+func (r *runIO) resumeCopy(source string, destination string, offset int64) (int64, string, error) {
+
+	sourceFile, err := r.openSource(source)
+	if err != nil {
+		return 0, "", err
+	}
+	defer sourceFile.Close()
+
+	destFile, err := openForRewrite(destination)
+	if err != nil {
+		return 0, "", err
+	}
+	defer destFile.Close()
+
+	hash := sha256.New()
+	sourcePrefix := sha256.New()
+	if _, err := io.CopyN(io.MultiWriter(hash, sourcePrefix), sourceFile, offset); err != nil {
+		return 0, "", err
+	}
+	destPrefix := sha256.New()
+	if _, err := io.CopyN(destPrefix, destFile, offset); err != nil {
+		return 0, "", err
+	}
+	if !bytes.Equal(sourcePrefix.Sum(nil), destPrefix.Sum(nil)) {
+		return 0, "", errPartialMismatch
+	}
+
+	// anything after the offset is overwritten in case the file grew after it was checked
+	if err := destFile.Truncate(offset); err != nil {
+		return 0, "", err
+	}
+	if _, err := destFile.Seek(offset, io.SeekStart); err != nil {
+		return 0, "", err
+	}
+
+	written, err := io.Copy(io.MultiWriter(destFile, hash), r.throttle(sourceFile))
+	if err == nil {
+		// a continued temporary file is renamed into the destination right after
+		err = destFile.Sync()
+	}
+	if err != nil {
+		return written, "", err
+	}
+	return written, hex.EncodeToString(hash.Sum(nil)), nil
+}
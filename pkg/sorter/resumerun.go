@@ -0,0 +1,96 @@
+package sorter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resumeIndex holds the source files copied by earlier runs according to the catalog, so that a
+// run continuing an interrupted one skips them without looking at the destinations.
+type resumeIndex struct {
+	// copied maps the absolute source path to the destinations it was copied to
+	copied       map[string][]copiedSource
+	destinations []string
+	tiered       []bool
+}
+
+type copiedSource struct {
+	size    int64
+	modTime int64
+	// destination is the index of the destination of the run the file was copied to
+	destination int
+}
+
+// loadResumeIndex reads the copies of the catalog into the destinations of the run.
+func loadResumeIndex(cat *catalog, destinations []*destination) (*resumeIndex, error) {
+	index := &resumeIndex{copied: make(map[string][]copiedSource)}
+	for _, dest := range destinations {
+		path, err := filepath.Abs(dest.path)
+		if err != nil {
+			return nil, err
+		}
+		index.destinations = append(index.destinations, path+string(filepath.Separator))
+		index.tiered = append(index.tiered, dest.tiered)
+	}
+
+	err := cat.copiedSources(func(sourcePath string, size int64, modTime int64, destPath string) {
+		for i, dest := range index.destinations {
+			if strings.HasPrefix(destPath, dest) {
+				index.copied[sourcePath] = append(index.copied[sourcePath], copiedSource{size: size, modTime: modTime, destination: i})
+				return
+			}
+		}
+	})
+	return index, err
+}
+
+// done tells whether the file was copied unchanged to every destination of the run and to one of
+// the tiers.
+func (index *resumeIndex) done(path string, fileInfo os.FileInfo) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	copies, ok := index.copied[absPath]
+	if !ok {
+		return false
+	}
+
+	covered := make([]bool, len(index.destinations))
+	for _, c := range copies {
+		if c.size == fileInfo.Size() && c.modTime == fileInfo.ModTime().UnixNano() {
+			covered[c.destination] = true
+		}
+	}
+	hasTiers, tierCovered := false, false
+	for i, tiered := range index.tiered {
+		if tiered {
+			hasTiers = true
+			tierCovered = tierCovered || covered[i]
+		} else if !covered[i] {
+			return false
+		}
+	}
+	return !hasTiers || tierCovered
+}
+
+// loadResume reads the copies of the earlier runs and reports whether the last run of the same
+// source was interrupted.
+func loadResume(opts *sortOptions, command string, source string) error {
+	run, ok, err := opts.catalog.previousRun(command, source)
+	if err != nil {
+		return err
+	}
+	if ok && run.finished.IsZero() {
+		files, err := opts.catalog.runFiles(run.id)
+		if err != nil {
+			return err
+		}
+		opts.printer.Printf("Resuming the run started at %s which was interrupted after copying %d files\n",
+			run.started.Format("2006-01-02 15:04:05"), files)
+	}
+
+	opts.resumeIndex, err = loadResumeIndex(opts.catalog, opts.destinations)
+	return err
+}
@@ -0,0 +1,1024 @@
+package sorter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrUsage is returned by New when the source, a destination or the plan file of plan are missing.
+var ErrUsage = errors.New("the source and a destination are required")
+
+// Options are the settings of a run. They match the flags of the sort, plan and diff commands of
+// filesorter which are described in its help, and the zero value of a field is the default of its flag.
+type Options struct {
+	// Command is one of sort, plan or diff. It is sort when empty.
+	Command string
+	// PlanOut is the json file the copies are written to by plan.
+	PlanOut string
+	// PlanDiff is a plan written by an earlier plan which the new plan is compared with.
+	PlanDiff string
+
+	Source       string
+	Destinations []string
+	Tiers        []string
+
+	Types        string
+	ExcludeTypes string
+	// Excludes are glob patterns of the names of the files and directories to leave out, on top of
+	// the ones of the preset and of the GlobalIgnore file. GlobalIgnore is the ignore file of the
+	// user when empty and none turns it off
+	Excludes     []string
+	GlobalIgnore string
+	NoExtension  string
+	Preset       string
+	DateSource   string
+	Scheme       string
+	Layout       string
+	Screenshots  bool
+
+	Catalog     string
+	ErrorReport string
+	RetryFrom   string
+
+	ResumePartial     bool
+	Delta             bool
+	Order             string
+	Protect           bool
+	Immutable         bool
+	WindowsAttributes bool
+	BandwidthSchedule string
+	DuplicateNames    string
+	Move              bool
+	Staging           string
+	DryRun            bool
+	Incremental       bool
+	Sample            string
+	MaxFiles          int
+	MaxBytes          string
+	MaxFilesPerDir    int
+	ControlSocket     string
+	Alerts            AlertConfig
+	Volume            string
+	VolumeSize        string
+	Bursts            bool
+	// PostProcess are the hooks run for every copied file and PostProcessJobs how many run at a time
+	PostProcess     []string
+	PostProcessJobs int
+	// Workers is the number of files copied at the same time. The files are copied one after the
+	// other when it is 0 or 1
+	Workers int
+	// Compare is how a file at the destination is found to be the same as the source, either size
+	// or hash. It is size when empty
+	Compare string
+	// AssertReadOnlySource refuses the options which write to the source and reads the source
+	// files without updating their access time where supported
+	AssertReadOnlySource bool
+	// Resume skips the files which the catalog records as copied unchanged to the destinations, to
+	// continue an interrupted run without looking at the files already copied
+	Resume bool
+	// Watch keeps sorting the files added to the source after the walk until the run is cancelled
+	Watch bool
+	// WatchSettle is how long a new file has to stay unchanged before Watch sorts it. It is 5
+	// seconds when 0
+	WatchSettle time.Duration
+	// WatchQueue is a json file which keeps the files Watch waits to sort, so that they are sorted
+	// after a crash or a reboot too
+	WatchQueue string
+	// MinFreeInodes is the number of inodes left free on the destinations. The run stops before a
+	// destination has fewer. It is 1000 when 0 and the inodes are not checked when negative
+	MinFreeInodes int64
+	// FilesPerSec limits how many files are copied per second. It is not limited when 0
+	FilesPerSec float64
+	// OnConflict is what happens to a file when a different file of the same name is at the
+	// destination, either overwrite, skip, rename which appends -1, -2... to its name or hash-suffix
+	// which appends the start of its hash. It is overwrite when empty
+	OnConflict string
+	// Conflicts is a json file the files skipped by OnConflict skip are added to, to be resolved
+	// later with 'filesorter resolve'
+	Conflicts string
+	// Output is text to print the messages or json to print a line of json for every processed
+	// file and the report at the end instead. The messages go to the standard error then
+	Output string
+	// Language is the language of the messages of the run, like de. It is the one selected with
+	// SetLanguage when empty
+	Language string
+	// Progress counts the source before the run and draws a progress bar with the time left. It is
+	// only drawn when the standard output is a terminal
+	Progress bool
+	// Clamd is the unix socket or the host:port of the clamd daemon of ClamAV which scans every
+	// file before it is copied. Infected files are not copied and are put into the Quarantine
+	// directory when one is passed
+	Clamd      string
+	Quarantine string
+	// Manifest is a file of json lines every copy and move is appended to, so that the run can be
+	// reversed with 'filesorter undo'
+	Manifest string
+	// After and Before limit the run to the files with a sort date inside them, dates like
+	// 2023-01-01. After is inclusive and Before is not. The files sorted by their tags are limited
+	// by their modification time
+	After  string
+	Before string
+	// DirMode is the octal mode like 0750 set on the directories created in the destinations
+	// regardless of the umask. PreserveDirOwner gives them the owner and group of the directory
+	// they are created in, like when sorting as root into a shared archive
+	DirMode          string
+	PreserveDirOwner bool
+	// MinSize and MaxSize skip the files smaller and larger than them, sizes like 500KB or 2GB.
+	// Both are inclusive and a size which is empty or 0 does not limit
+	MinSize string
+	MaxSize string
+	// Verify reads every copy back from the destination and compares it with the source before it
+	// counts as copied. A copy which does not match is removed and counted in CorruptedCopies
+	Verify bool
+	// Unsorted is the folder of the destinations the files with a date before 1980 or in the future
+	// are sorted into instead, _unsorted when empty. none sorts them by their date anyway
+	Unsorted string
+	// MinDate replaces 1980 as the earliest valid date, a date like 1990-01-01. MaxFuture is how far
+	// in the future a date may be, a day when 0
+	MinDate   string
+	MaxFuture time.Duration
+	// FileTimeout is how long the run waits for reading the date of a file and for its copy before
+	// it gives up on the file and goes on with the next one. There is no timeout when 0
+	FileTimeout time.Duration
+	// Salvage copies the files of failing media with the parts which can not be read filled with
+	// zeros, retrying the failed reads in smaller blocks first. The salvaged files are listed in
+	// the report and are not removed from the source by Move
+	Salvage bool
+	// S3 configures the destinations like s3://bucket/prefix, which are uploaded to the bucket
+	S3 S3Config
+	// HTMLReport is the html file the report of the run is written to, with the copied files per
+	// day, the errors and the duplicates. A directory gets a new file for every run
+	HTMLReport string
+	// SSHCommand is the ssh command with its options the destinations like sftp://user@host/path
+	// are reached with. It is ssh when empty
+	SSHCommand string
+	// CompressTransit compresses the files on their way to the sftp:// destinations. The files at
+	// the destination are the same
+	CompressTransit bool
+	// MaxFileSize is the largest file the destinations can hold, like 4GB. It is read from the file
+	// system of each destination when empty, which only knows the limit of FAT
+	MaxFileSize string
+	// SplitLarge copies the files larger than a destination can hold in parts along with a manifest
+	// to join them again. They are not copied there otherwise
+	SplitLarge bool
+}
+
+// Report sums up a run.
+type Report struct {
+	VisitedDirectories int   `json:"visitedDirectories"`
+	CopiedFiles        int   `json:"copiedFiles"`
+	SkippedFiles       int   `json:"skippedFiles"`
+	ErroredFiles       int   `json:"erroredFiles"`
+	ResumedFiles       int   `json:"resumedFiles"`
+	PlannedFiles       int   `json:"plannedFiles"`
+	MovedFiles         int   `json:"movedFiles"`
+	BytesCopied        int64 `json:"bytesCopied"`
+	// BytesMoved is the size of the moved files. The ones moved with a rename are not in CopiedFiles
+	// and BytesCopied
+	BytesMoved int64 `json:"bytesMoved"`
+	// CorruptedCopies is the number of copies which did not match the source when read back
+	CorruptedCopies int `json:"corruptedCopies"`
+	// Differences is the number of files which differ between the source and the destinations in a diff
+	Differences int  `json:"differences"`
+	Cancelled   bool `json:"cancelled"`
+	// LimitReached is set when MaxFiles or MaxBytes stopped the run before all the files were copied
+	LimitReached bool `json:"limitReached"`
+	// VolumeFull is set when the run stopped since the next file did not fit on the volume
+	VolumeFull bool `json:"volumeFull"`
+	// PostProcessed and PostProcessFailed count the copied files the hooks succeeded and failed for
+	PostProcessed     int `json:"postProcessed"`
+	PostProcessFailed int `json:"postProcessFailed"`
+	// ManifestChecked is the number of copies compared with the MHL manifests of the source. The
+	// ones which did not match are counted in CorruptedCopies
+	ManifestChecked int `json:"manifestChecked"`
+	// Conflicts is the number of files skipped by OnConflict skip
+	Conflicts int `json:"conflicts"`
+	// OutOfInodes is set when the run stopped before a destination ran out of inodes
+	OutOfInodes bool `json:"outOfInodes"`
+	// RenamedConflicts and OverwrittenConflicts are the files copied under another name by
+	// OnConflict rename or hash-suffix and the ones copied over a different file of the same name
+	RenamedConflicts     int `json:"renamedConflicts"`
+	OverwrittenConflicts int `json:"overwrittenConflicts"`
+	// Infected is the number of files which were not copied since clamd found a virus in them
+	Infected int `json:"infected"`
+	// InvalidDates is the number of files sorted into the Unsorted folder since their date was not valid
+	InvalidDates int `json:"invalidDates"`
+	// VerifiedCopies is the number of copies read back and found to match their source by Verify
+	VerifiedCopies int `json:"verifiedCopies"`
+	// TimedOut is the number of files given up on after FileTimeout. They are counted in ErroredFiles too
+	TimedOut int `json:"timedOut"`
+	// ClonedFiles are the copies cloned on a copy on write file system and KernelCopiedFiles the
+	// ones copied by the kernel with copy_file_range. The others of CopiedFiles were written
+	ClonedFiles       int `json:"clonedFiles"`
+	KernelCopiedFiles int `json:"kernelCopiedFiles"`
+	// Salvaged is the number of files Salvage copied with unreadable parts filled with zeros
+	Salvaged int `json:"salvaged"`
+}
+
+// Sorter sorts the files of a source into the destinations like the filesorter command does. The
+// messages are printed to the standard output in the language of the options. Every Sorter keeps
+// its own settings, so several of them can run at the same time.
+type Sorter struct {
+	options Options
+	opts    sortOptions
+	// previousPlan is the plan passed with PlanDiff
+	previousPlan *plan
+}
+
+// New checks the options and prepares a run.
+func New(options Options) (*Sorter, error) {
+
+	if strings.Compare(options.Command, "") == 0 {
+		options.Command = "sort"
+	}
+	planning := strings.Compare(options.Command, "plan") == 0
+	diffing := strings.Compare(options.Command, "diff") == 0
+	retrying := strings.Compare(options.RetryFrom, "") != 0
+	if !planning && !diffing && strings.Compare(options.Command, "sort") != 0 {
+		return nil, fmt.Errorf("the command %s is not supported", options.Command)
+	}
+
+	if (strings.Compare(options.Source, "") == 0 && !retrying) || len(options.Destinations)+len(options.Tiers) == 0 ||
+		planning != (strings.Compare(options.PlanOut, "") != 0) {
+		return nil, ErrUsage
+	}
+
+	// the language is kept by the run so that selecting another one later does not change it
+	var err error
+	runPrinter := &outputPrinter{out: os.Stdout, messages: messages}
+	if strings.Compare(options.Language, "") != 0 {
+		if runPrinter.messages, err = languagePrinter(options.Language); err != nil {
+			return nil, err
+		}
+	}
+
+	// the paths are made absolute once so that every path joined from them is too
+	if !retrying {
+		if options.Source, err = normalizePath(options.Source); err != nil {
+			return nil, runPrinter.errorf("An error occurred while trying to resolve the source %s: %v\n", options.Source, err)
+		}
+		if err := checkDir(options.Source, runPrinter); err != nil {
+			return nil, err
+		}
+	}
+	for i, destPath := range options.Destinations {
+		if isRemote(destPath) {
+			continue
+		}
+		if options.Destinations[i], err = normalizePath(destPath); err != nil {
+			return nil, runPrinter.errorf("An error occurred while trying to resolve the destination %s: %v\n", destPath, err)
+		}
+	}
+	if strings.Compare(options.Staging, "") != 0 {
+		if options.Staging, err = normalizePath(options.Staging); err != nil {
+			return nil, runPrinter.errorf("An error occurred while trying to resolve the staging directory %s: %v\n", options.Staging, err)
+		}
+	}
+
+	if err := options.Alerts.validate(runPrinter); err != nil {
+		return nil, err
+	}
+
+	if options.WindowsAttributes && !fileAttributesSupported {
+		return nil, runPrinter.errorf("The -windows-attributes option is only supported on Windows\n")
+	}
+	if options.PreserveDirOwner && !dirOwnerSupported {
+		return nil, runPrinter.errorf("The -preserve-dir-owner option is not supported on this platform\n")
+	}
+	dirMode, err := parseDirMode(options.DirMode, runPrinter)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Sorter{options: options}
+	opts := &s.opts
+	*opts = sortOptions{
+		filterTypes: make(map[string]struct{}),
+		source:      options.Source,
+		screenshots: options.Screenshots,
+		resume:      options.ResumePartial,
+		delta:       options.Delta,
+		protect:     options.Protect || options.Immutable,
+		immutable:   options.Immutable,
+		attributes:  options.WindowsAttributes,
+		sample:      options.Sample,
+		move:        options.Move,
+		noExtension: options.NoExtension,
+		dryRun:      options.DryRun,
+		verify:      options.Verify,
+		fileTimeout: options.FileTimeout,
+		destEntries: newDirEntryCache(),
+		control:     newRunControl(),
+		printer:     runPrinter,
+		createdDirs: newCreatedDirs(dirMode, options.PreserveDirOwner),
+	}
+	opts.runIO = &runIO{
+		keepPartial:    options.ResumePartial,
+		readOnlySource: options.AssertReadOnlySource,
+		salvage:        options.Salvage,
+		control:        opts.control,
+		printer:        opts.printer,
+	}
+	// plan, diff and dry runs write nothing to stage
+	if !planning && !diffing && !options.DryRun {
+		opts.runIO.staging = options.Staging
+	}
+	if options.Bursts {
+		opts.bursts = newBurstIndex()
+	}
+	switch options.DuplicateNames {
+	case "", "copy":
+	case "alias":
+		if strings.Compare(options.Catalog, "") == 0 {
+			return nil, runPrinter.errorf("The -duplicate-names alias option needs the catalog passed with -catalog\n")
+		}
+		opts.aliasDuplicates = true
+	default:
+		return nil, runPrinter.errorf("The duplicate names policy %s is not supported. Use copy or alias\n", options.DuplicateNames)
+	}
+	if options.DryRun && (planning || diffing) {
+		return nil, runPrinter.errorf("The -dry-run option is only supported by sort. plan and diff do not touch the destination anyway\n")
+	}
+	if planning || options.DryRun {
+		opts.plan = &plan{Created: time.Now()}
+	}
+	if options.DryRun {
+		opts.planned = make(map[string]string)
+	}
+	if diffing {
+		opts.diff = &diff{mapped: make(map[string]struct{})}
+	}
+
+	remote, sftp, s3 := false, false, false
+	for _, destPath := range options.Destinations {
+		target, err := newRemoteTarget(destPath, &options, dirMode, opts.runIO)
+		if err != nil {
+			return nil, err
+		}
+		if target != nil {
+			opts.destinations = append(opts.destinations, &destination{path: destPath, remote: target})
+			remote = true
+			sftp = sftp || strings.HasPrefix(destPath, sftpScheme)
+			s3 = s3 || strings.HasPrefix(destPath, s3Scheme)
+			continue
+		}
+		if err := checkDir(destPath, runPrinter); err != nil {
+			return nil, err
+		}
+		opts.destinations = append(opts.destinations, &destination{path: destPath})
+	}
+	// S3 stores the body of a request as it is sent, so an upload can not be compressed on its way
+	// without the object being compressed too
+	if options.CompressTransit && !sftp {
+		return nil, runPrinter.errorf("The -compress-transit option needs an sftp:// destination. The uploads to S3 can not be compressed without compressing the stored files\n")
+	}
+	now := time.Now()
+	for _, tier := range options.Tiers {
+		dest, err := parseTier(tier, now, runPrinter)
+		if err != nil {
+			return nil, err
+		}
+		if isRemote(dest.path) {
+			return nil, runPrinter.errorf("The tier %s can not be a remote destination. Use -destination\n", tier)
+		}
+		if dest.path, err = normalizePath(dest.path); err != nil {
+			return nil, runPrinter.errorf("An error occurred while trying to resolve the tier %s: %v\n", tier, err)
+		}
+		if err := checkDir(dest.path, runPrinter); err != nil {
+			return nil, err
+		}
+		opts.destinations = append(opts.destinations, dest)
+	}
+	if !retrying {
+		opts.destinationsInSource = destinationsInSource(options.Source, opts.destinations)
+	}
+
+	// a file larger than a destination can hold is not copied there or is split into parts
+	maxFileSize, err := parseSize(options.MaxFileSize, runPrinter)
+	if err != nil {
+		return nil, err
+	}
+	for _, dest := range opts.destinations {
+		if dest.remote != nil {
+			continue
+		}
+		if maxFileSize > 0 {
+			dest.maxFileSize = maxFileSize
+		} else {
+			dest.maxFileSize, dest.fileSystem = destinationMaxFileSize(dest.path)
+		}
+	}
+	if options.SplitLarge && (planning || diffing || opts.protect || options.Verify || options.WindowsAttributes ||
+		strings.Compare(options.Catalog, "") != 0 || strings.Compare(options.Manifest, "") != 0 ||
+		strings.Compare(options.OnConflict, "rename") == 0 || strings.Compare(options.OnConflict, "hash-suffix") == 0) {
+		return nil, runPrinter.errorf("The -split-large option is not supported by plan, diff, -protect, -immutable, -verify, -windows-attributes, -catalog, -manifest and -on-conflict rename or hash-suffix\n")
+	}
+	opts.splitLarge = options.SplitLarge
+
+	// the settings of the preset are used for the options which are not set
+	fileTypeFilter, dateSource := options.Types, options.DateSource
+	if strings.Compare(options.Preset, "") != 0 {
+		p, ok := presets[options.Preset]
+		if !ok {
+			return nil, runPrinter.errorf("The preset %s is not supported\n", options.Preset)
+		}
+		applyPreset(p, &fileTypeFilter, &dateSource, map[string]bool{
+			"types":       strings.Compare(fileTypeFilter, "") != 0,
+			"date-source": strings.Compare(dateSource, "") != 0,
+		})
+		opts.excludes = append([]string{}, p.excludes...)
+		if p.iphoneBackup {
+			opts.backupFiles, err = readBackupManifest(options.Source, runPrinter)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if strings.Compare(dateSource, "") == 0 {
+		dateSource = "mtime"
+	}
+
+	// the patterns of the global ignore file apply to every run besides the ones passed to it
+	globalExcludes, err := readGlobalIgnore(options.GlobalIgnore, runPrinter)
+	if err != nil {
+		return nil, err
+	}
+	for _, pattern := range options.Excludes {
+		if err := checkExcludePattern(pattern, runPrinter); err != nil {
+			return nil, err
+		}
+	}
+	opts.excludes = append(append(opts.excludes, globalExcludes...), options.Excludes...)
+	if strings.Compare(options.Source, "") != 0 {
+		opts.ignores = newSourceIgnores(options.Source, opts.runIO)
+	}
+
+	if strings.Compare(fileTypeFilter, "") != 0 {
+		opts.filterTypes = parseTypes(fileTypeFilter)
+	}
+	if strings.Compare(options.ExcludeTypes, "") != 0 {
+		opts.excludeTypes = parseTypes(options.ExcludeTypes)
+	}
+
+	if strings.Compare(options.BandwidthSchedule, "") != 0 {
+		opts.runIO.bandwidth, err = parseBandwidthSchedule(options.BandwidthSchedule, runPrinter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if strings.Compare(options.Staging, "") != 0 && !planning && !diffing && !options.DryRun {
+		if err := checkDir(options.Staging, runPrinter); err != nil {
+			return nil, err
+		}
+		for _, dest := range opts.destinations {
+			if err := checkStaging(options.Staging, dest.path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !noExtensionPolicies[options.NoExtension] {
+		return nil, runPrinter.errorf("The policy %s for files without an extension is not supported. Use include or skip\n", options.NoExtension)
+	}
+
+	if _, err := sampleSize(options.Sample, 0); err != nil {
+		return nil, err
+	}
+
+	limit := batchLimit{maxFiles: options.MaxFiles}
+	limit.maxBytes, err = parseSize(options.MaxBytes, runPrinter)
+	if err != nil {
+		return nil, err
+	}
+	if limit.maxFiles > 0 || limit.maxBytes > 0 {
+		opts.limit = &limit
+	}
+
+	if options.MaxFilesPerDir < 0 {
+		return nil, runPrinter.errorf("The maximum number of files per directory %d is not valid\n", options.MaxFilesPerDir)
+	}
+	if options.MaxFilesPerDir > 0 {
+		opts.buckets = newDirBuckets(options.MaxFilesPerDir, opts.destEntries)
+	}
+
+	opts.order, err = parseOrder(options.Order, runPrinter)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.dateSources, err = parseDateSources(dateSource, runPrinter)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.Compare(options.Scheme, "") != 0 && strings.Compare(options.Scheme, "date") != 0 {
+		sch, ok := schemes[options.Scheme]
+		if !ok {
+			return nil, runPrinter.errorf("The scheme %s is not supported\n", options.Scheme)
+		}
+		layout := options.Layout
+		if strings.Compare(layout, "") == 0 {
+			layout = sch.defaultLayout
+		}
+		opts.scheme = &sch
+		opts.layout, err = parseLayout(layout, sch.sample, runPrinter)
+		if err != nil {
+			return nil, err
+		}
+	} else if strings.Compare(options.Layout, "") != 0 {
+		opts.dateLayout, err = parseLayout(options.Layout, dateLayoutFields{}, runPrinter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.Move && (planning || diffing || options.DryRun) {
+		return nil, runPrinter.errorf("The -move option is not supported by plan, diff and -dry-run\n")
+	}
+
+	if options.Incremental && (strings.Compare(options.Catalog, "") == 0 || planning || diffing ||
+		opts.order != nil || strings.Compare(opts.sample, "") != 0 || options.DryRun) {
+		return nil, runPrinter.errorf("The -incremental option needs -catalog and is not supported by plan, diff, -order, -sample and -dry-run\n")
+	}
+
+	if strings.Compare(options.Volume, "") != 0 || strings.Compare(options.VolumeSize, "") != 0 {
+		if strings.Compare(options.Catalog, "") == 0 || len(opts.destinations) != 1 || len(options.Tiers) > 0 || planning || diffing {
+			return nil, runPrinter.errorf("The -volume option needs -catalog and a single -destination and is not supported by plan and diff\n")
+		}
+		opts.volume, err = newVolumeSpan(options.Volume, options.VolumeSize, runPrinter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the copies of camera media are checked against the checksum manifests written by the camera
+	// or by the tool which offloaded it
+	if !planning && !diffing && !options.DryRun {
+		opts.manifests = newMHLIndex(options.Source, opts.runIO)
+	}
+
+	if strings.Compare(options.PlanDiff, "") != 0 {
+		if !planning {
+			return nil, runPrinter.errorf("The -diff option is only supported by plan\n")
+		}
+		s.previousPlan, err = readPlan(options.PlanDiff)
+		if err != nil {
+			return nil, runPrinter.errorf("An error occurred while trying to read the plan %s: %v\n", options.PlanDiff, err)
+		}
+	}
+
+	if options.Resume && (strings.Compare(options.Catalog, "") == 0 || diffing) {
+		return nil, runPrinter.errorf("The -resume option needs -catalog and is not supported by diff\n")
+	}
+
+	if options.Watch && (planning || diffing || options.DryRun || retrying) {
+		return nil, runPrinter.errorf("The -watch option is not supported by plan, diff, -dry-run and -retry-from\n")
+	}
+	if strings.Compare(options.WatchQueue, "") != 0 && !options.Watch {
+		return nil, runPrinter.errorf("The -watch-queue option needs -watch\n")
+	}
+	if options.FileTimeout < 0 {
+		return nil, runPrinter.errorf("The file timeout %v is not valid\n", options.FileTimeout)
+	}
+	if options.WatchSettle < 0 {
+		return nil, runPrinter.errorf("The settle time %v of -watch is not valid\n", options.WatchSettle)
+	}
+
+	if options.MinFreeInodes >= 0 && !planning && !diffing {
+		minFree := options.MinFreeInodes
+		if minFree == 0 {
+			minFree = defaultMinFreeInodes
+		}
+		opts.inodes = newInodeGuard(uint64(minFree), opts.printer)
+	}
+
+	if options.AssertReadOnlySource {
+		if err := checkReadOnlySource(&options, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	if !compareModes[options.Compare] {
+		return nil, runPrinter.errorf("The compare mode %s is not supported. Use size or hash\n", options.Compare)
+	}
+	opts.compareHash = strings.Compare(options.Compare, "hash") == 0
+
+	if !conflictPolicies[options.OnConflict] {
+		return nil, runPrinter.errorf("The conflict policy %s is not supported. Use overwrite, skip, rename or hash-suffix\n", options.OnConflict)
+	}
+	opts.onConflict = options.OnConflict
+	if strings.Compare(options.Conflicts, "") != 0 && strings.Compare(opts.onConflict, "skip") != 0 {
+		return nil, runPrinter.errorf("The -conflicts option needs -on-conflict skip\n")
+	}
+
+	if strings.Compare(options.Quarantine, "") != 0 {
+		if strings.Compare(options.Clamd, "") == 0 {
+			return nil, runPrinter.errorf("The -quarantine option needs -clamd\n")
+		}
+		if options.Quarantine, err = normalizePath(options.Quarantine); err != nil {
+			return nil, runPrinter.errorf("An error occurred while trying to resolve the quarantine directory %s: %v\n", options.Quarantine, err)
+		}
+		if err := checkDir(options.Quarantine, runPrinter); err != nil {
+			return nil, err
+		}
+	}
+
+	opts.dateRange, err = newDateRange(options.After, options.Before, runPrinter)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.unsorted, err = newUnsortedDates(options.Unsorted, options.MinDate, options.MaxFuture, runPrinter); err != nil {
+		return nil, err
+	}
+
+	if opts.minSize, err = parseSize(options.MinSize, runPrinter); err != nil {
+		return nil, err
+	}
+	if opts.maxSize, err = parseSize(options.MaxSize, runPrinter); err != nil {
+		return nil, err
+	}
+	if opts.maxSize > 0 && opts.minSize > opts.maxSize {
+		return nil, runPrinter.errorf("The -min-size %s is larger than the -max-size %s\n", options.MinSize, options.MaxSize)
+	}
+
+	if !outputFormats[options.Output] {
+		return nil, runPrinter.errorf("The output format %s is not supported. Use text or json\n", options.Output)
+	}
+	if strings.Compare(options.Output, "json") == 0 {
+		if planning || diffing || options.DryRun {
+			return nil, runPrinter.errorf("The -output json option is not supported by plan, diff and -dry-run\n")
+		}
+		opts.output = newJSONOutput(os.Stdout)
+		// the standard output carries only the json
+		opts.printer.out = os.Stderr
+	}
+
+	if options.FilesPerSec != 0 {
+		opts.fileRate, err = newFileRate(options.FilesPerSec, runPrinter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.Workers < 0 {
+		return nil, runPrinter.errorf("The number of workers %d is not valid\n", options.Workers)
+	}
+	if options.S3.InFlight < 0 {
+		return nil, runPrinter.errorf("The number of uploads in flight %d is not valid\n", options.S3.InFlight)
+	}
+	workers := options.Workers
+	// the small files are uploaded side by side since most of the time of an upload is spent waiting
+	if s3 && options.S3.InFlight > workers {
+		workers = options.S3.InFlight
+	}
+	if workers > 1 {
+		opts.copyPool = newCopyPool(workers, options.FileTimeout)
+	}
+
+	// the remote files are only uploaded. nothing is written next to them, read back or changed in place
+	if remote && (planning || diffing || options.DryRun || strings.Compare(options.Staging, "") != 0 ||
+		options.ResumePartial || options.Delta || opts.protect || options.WindowsAttributes || options.MaxFilesPerDir > 0 ||
+		strings.Compare(options.Catalog, "") != 0 || strings.Compare(options.Manifest, "") != 0 || len(options.PostProcess) > 0 ||
+		strings.Compare(options.OnConflict, "rename") == 0 || strings.Compare(options.OnConflict, "hash-suffix") == 0 || options.Verify) {
+		return nil, runPrinter.errorf("The s3:// and sftp:// destinations are not supported by plan, diff, -dry-run, -staging, -resume-partial, -delta, -protect, -immutable, -windows-attributes, -max-files-per-dir, -catalog, -manifest, -post-process, -verify and -on-conflict rename or hash-suffix\n")
+	}
+
+	if len(options.PostProcess) > 0 {
+		if planning || diffing || options.DryRun {
+			return nil, runPrinter.errorf("The -post-process option is not supported by plan, diff and -dry-run\n")
+		}
+		jobs := options.PostProcessJobs
+		if jobs == 0 {
+			jobs = 2
+		}
+		opts.postProcess, err = newPostProcessor(options.PostProcess, jobs, opts.printer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Pause holds the run before the next file until Resume is called.
+func (s *Sorter) Pause() {
+	s.opts.control.pause()
+}
+
+// Resume continues a paused run.
+func (s *Sorter) Resume() {
+	s.opts.control.resume()
+}
+
+// Run walks the source and copies, plans or compares the files. Cancelling the context stops the
+// run after the current file. The error is set only when the run could not be started or its
+// results could not be written, while the files which failed are counted in the report.
+func (s *Sorter) Run(ctx context.Context) (*Report, error) {
+
+	options, opts := &s.options, &s.opts
+	planning := strings.Compare(options.Command, "plan") == 0
+	diffing := strings.Compare(options.Command, "diff") == 0
+	retrying := strings.Compare(options.RetryFrom, "") != 0
+
+	for path := range opts.destinationsInSource {
+		opts.printer.Printf("The destination %s is inside the source and is left out of the walk\n", path)
+	}
+
+	if options.AssertReadOnlySource && !noAtimeSupported {
+		opts.printer.Printf("The access times of the source files may be updated since this platform cannot read files without updating them\n")
+	}
+
+	var err error
+	if strings.Compare(options.Catalog, "") != 0 {
+		opts.catalog, err = openCatalog(options.Catalog)
+		if err != nil {
+			return nil, opts.printer.errorf("An error occurred while trying to open the catalog %s: %v\n", options.Catalog, err)
+		}
+		defer opts.catalog.Close()
+		// plan, diff and dry runs do not record anything
+		runSource := options.Source
+		if retrying {
+			runSource = options.RetryFrom
+		}
+		if !planning && !diffing && !options.DryRun {
+			if err := opts.catalog.startRun(options.Command, runSource); err != nil {
+				return nil, opts.printer.errorf("An error occurred while trying to record the run in the catalog %s: %v\n", options.Catalog, err)
+			}
+		}
+		if options.Incremental {
+			opts.incremental = newIncrementalScan(opts.catalog, opts)
+		}
+		if options.Resume {
+			if err := loadResume(opts, options.Command, runSource); err != nil {
+				return nil, opts.printer.errorf("An error occurred while trying to read the catalog %s: %v\n", options.Catalog, err)
+			}
+		}
+		if opts.volume != nil {
+			if err := opts.volume.open(opts.destinations[0].path, opts.catalog, !options.DryRun, opts.printer); err != nil {
+				return nil, err
+			}
+			opts.printer.Printf("The volume %s has %d of %d bytes used\n", opts.volume.label, opts.volume.used, opts.volume.size)
+		}
+	}
+
+	// plan, diff and dry runs do not copy anything to undo
+	if strings.Compare(options.Manifest, "") != 0 && !planning && !diffing && !options.DryRun {
+		opts.undoManifest, err = openUndoManifest(options.Manifest)
+		if err != nil {
+			return nil, opts.printer.errorf("An error occurred while trying to open the manifest %s: %v\n", options.Manifest, err)
+		}
+		defer opts.undoManifest.Close()
+	}
+
+	// plan, diff and dry runs do not copy anything to scan
+	if strings.Compare(options.Clamd, "") != 0 && !planning && !diffing && !options.DryRun {
+		opts.virusScan, err = newClamdScanner(options.Clamd, options.Quarantine, opts.runIO)
+		if err != nil {
+			return nil, opts.printer.errorf("An error occurred while trying to connect to clamd at %s: %v\n", options.Clamd, err)
+		}
+	}
+
+	// a destination which is out of inodes fails before any file is copied
+	if opts.inodes != nil {
+		if err := opts.inodes.check(opts.destinations); err != nil {
+			return nil, opts.printer.errorf("The destination %s has only %d inodes free, fewer than the %d kept free. Free up inodes, like by archiving many small files into one, or sort onto a file system with more of them\n", opts.inodes.exhausted.path, opts.inodes.free, opts.inodes.minFree)
+		}
+	}
+
+	// the temporary copies crashed runs left behind are cleaned up before new ones are written, the
+	// ones next to the destinations when their folder is first sorted into
+	if !planning && !diffing {
+		opts.destEntries.partials = cleanStalePartials(opts, options.Staging, options.ResumePartial, options.DryRun)
+	}
+
+	// a remote destination which does not exist or can not be reached fails before any file is copied
+	for _, dest := range opts.destinations {
+		if dest.remote == nil {
+			continue
+		}
+		defer dest.remote.close()
+		if err := dest.remote.check(); err != nil {
+			return nil, opts.printer.errorf("An error occurred while trying to access the destination %s: %v\n", dest.path, err)
+		}
+	}
+
+	var counts processedCount
+	if strings.Compare(options.HTMLReport, "") != 0 {
+		counts.report = newRunReport()
+	}
+
+	if strings.Compare(options.ControlSocket, "") != 0 {
+		opts.progress, err = listenProgressSocket(options.ControlSocket, opts.control)
+		if err != nil {
+			return nil, opts.printer.errorf("An error occurred while trying to listen on the control socket %s: %v\n", options.ControlSocket, err)
+		}
+		// the socket is removed however the run ends so that the next run can listen on it again
+		defer opts.progress.Close(&counts)
+	}
+
+	// cancelling the context cancels the run like the control socket does
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			opts.control.cancel()
+		case <-done:
+		}
+	}()
+
+	// the dry runs show how the filters were understood
+	if planning || diffing || options.DryRun {
+		if !retrying {
+			opts.printer.Printf("Source: %s\n", options.Source)
+		}
+		for _, dest := range opts.destinations {
+			opts.printer.Printf("Destination: %s\n", dest.path)
+		}
+		opts.printer.Println(describeTypeFilter(opts))
+		if len(opts.excludes) > 0 {
+			opts.printer.Printf("Excluded names: %s\n", strings.Join(opts.excludes, ", "))
+		}
+	}
+
+	if opts.postProcess != nil {
+		opts.postProcess.start(opts.control)
+	}
+	if opts.copyPool != nil {
+		opts.copyPool.start()
+	}
+	// the bar needs the whole source and the terminal to itself
+	if options.Progress && !retrying && opts.output == nil && isTerminal(os.Stdout) {
+		opts.progressBar, err = startProgressBar(options.Source, os.Stdout, opts)
+		if err != nil && err != errCancelled {
+			return nil, opts.printer.errorf("An error occurred while trying to count the files of the source %s: %v\n", options.Source, err)
+		}
+		if opts.progressBar != nil {
+			opts.printer.out = opts.progressBar
+		}
+	}
+	if retrying {
+		err = retryFiles(options.RetryFrom, opts, &counts)
+	} else if opts.order != nil || strings.Compare(opts.sample, "") != 0 {
+		err = walkSourceInOrder(options.Source, opts, &counts)
+	} else {
+		err = walkSource(options.Source, opts, &counts)
+	}
+	if opts.progressBar != nil {
+		opts.progressBar.stop()
+	}
+	// -watch goes on with the new files unless the walk already stopped the run
+	stopped := opts.control.wait() || (opts.limit != nil && opts.limit.reached) || (opts.volume != nil && opts.volume.full) ||
+		(opts.inodes != nil && opts.inodes.exhausted != nil)
+	if err == nil && options.Watch && !stopped {
+		settle := options.WatchSettle
+		if settle == 0 {
+			settle = defaultWatchSettle
+		}
+		var queue *watchQueue
+		if strings.Compare(options.WatchQueue, "") != 0 {
+			queue = &watchQueue{path: options.WatchQueue}
+		}
+		err = watchSource(options.Source, settle, queue, opts, &counts)
+	}
+	// the copies in progress and their hooks finish even when the run failed
+	if opts.copyPool != nil {
+		opts.copyPool.drain(opts, &counts)
+	}
+	if opts.postProcess != nil {
+		opts.postProcess.finish(&counts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if diffing {
+		diffDestinations(opts, &counts)
+		printDiffReport(opts, &counts)
+	} else if planning || options.DryRun {
+		printPlanReport(&counts, opts)
+	} else {
+		printReport(&counts, opts)
+	}
+
+	if opts.catalog != nil {
+		if err := opts.catalog.finishRun(&counts); err != nil {
+			opts.printer.Printf("An error occurred while trying to record the run in the catalog %s: %v\n", options.Catalog, err)
+		}
+	}
+
+	if len(counts.corruptedCopies) > 0 {
+		sendAlert(&options.Alerts, "corruption", opts.printer.Sprintf("%d copies did not match their source", len(counts.corruptedCopies)), counts.corruptedCopies, opts.printer)
+	}
+	if len(counts.infected) > 0 {
+		sendAlert(&options.Alerts, "virus", opts.printer.Sprintf("%d infected files were found in %s", len(counts.infected), options.Source), counts.infected, opts.printer)
+	}
+
+	report := &Report{
+		VisitedDirectories: counts.visitedDirectories,
+		CopiedFiles:        counts.copiedFiles,
+		SkippedFiles:       counts.skippedFiles,
+		ErroredFiles:       counts.erroredFiles,
+		ResumedFiles:       counts.resumedFiles,
+		PlannedFiles:       counts.plannedFiles,
+		MovedFiles:         counts.movedFiles,
+		BytesCopied:        counts.totalBytesCopied,
+		BytesMoved:         counts.totalBytesMoved,
+		CorruptedCopies:    len(counts.corruptedCopies),
+		Cancelled:          opts.control.wait(),
+		LimitReached:       opts.limit != nil && opts.limit.reached,
+		VolumeFull:         opts.volume != nil && opts.volume.full,
+		PostProcessed:      counts.postProcessed,
+		PostProcessFailed:  len(counts.postProcessErrors),
+		ManifestChecked:    counts.manifestChecked,
+		OutOfInodes:        opts.inodes != nil && opts.inodes.exhausted != nil,
+		Conflicts:          len(counts.conflicts),
+
+		RenamedConflicts:     counts.renamedConflicts,
+		OverwrittenConflicts: counts.overwrittenConflicts,
+		Infected:             len(counts.infected),
+		InvalidDates:         len(counts.invalidDates),
+		VerifiedCopies:       counts.verifiedCopies,
+		TimedOut:             counts.timedOut,
+		ClonedFiles:          counts.clonedFiles,
+		KernelCopiedFiles:    counts.kernelCopiedFiles,
+		Salvaged:             len(counts.salvaged),
+	}
+	if diffing {
+		report.Differences = opts.diff.onlyInSource + opts.diff.onlyInDestination + opts.diff.differentFiles
+	}
+	if report.Cancelled {
+		opts.printer.Printf("The run was cancelled\n")
+	}
+	if report.LimitReached {
+		opts.printer.Printf("The limit of the run was reached. Run again to copy the remaining files\n")
+	}
+	if report.VolumeFull {
+		opts.printer.Printf("The volume %s is full. Run again with the next volume to copy the remaining files\n", opts.volume.label)
+	}
+	if report.OutOfInodes {
+		opts.printer.Printf("The destination %s has only %d inodes free. Free up inodes and run again to copy the remaining files\n", opts.inodes.exhausted.path, opts.inodes.free)
+	}
+	if opts.output != nil {
+		opts.output.summary(report)
+	}
+
+	if planning {
+		if err := writePlan(options.PlanOut, opts.plan); err != nil {
+			return report, opts.printer.errorf("An error occurred while trying to write the plan %s: %v\n", options.PlanOut, err)
+		}
+		if s.previousPlan != nil {
+			printPlanDiff(s.previousPlan, opts.plan, options.PlanDiff, opts)
+		}
+	}
+	if strings.Compare(options.Conflicts, "") != 0 && !planning && !options.DryRun {
+		if err := writeConflicts(options.Conflicts, counts.conflicts); err != nil {
+			return report, opts.printer.errorf("An error occurred while trying to write the conflicts %s: %v\n", options.Conflicts, err)
+		}
+		if len(counts.conflicts) > 0 {
+			opts.printer.Printf("Resolve the conflicts later with 'filesorter resolve %s'\n", options.Conflicts)
+		}
+	}
+	if strings.Compare(options.ErrorReport, "") != 0 {
+		if err := writeErrorReport(options.ErrorReport, counts.errors); err != nil {
+			return report, opts.printer.errorf("An error occurred while trying to write the error report %s: %v\n", options.ErrorReport, err)
+		}
+	}
+	if counts.report != nil {
+		path := htmlReportPath(options.HTMLReport, counts.report.started)
+		if err := writeHTMLReport(path, options, report, &counts, counts.report, opts.printer); err != nil {
+			return report, opts.printer.errorf("An error occurred while trying to write the html report %s: %v\n", path, err)
+		}
+		opts.printer.Printf("The report of the run was written to %s\n", path)
+	}
+	return report, nil
+}
+
+// commands are the other commands of filesorter. They parse their own flags.
+var commands = map[string]func(args []string) int{
+	"verify":         runVerify,
+	"apply":          runApply,
+	"query":          runQuery,
+	"find":           runFind,
+	"export":         runExport,
+	"import":         runImport,
+	"index":          runIndex,
+	"prune":          runPrune,
+	"history":        runHistory,
+	"estimate-dedup": runEstimateDedup,
+	"resolve":        runResolve,
+	"undo":           runUndo,
+	"detect-layout":  runDetectLayout,
+}
+
+// Command returns the command of filesorter with the name, like verify or prune, or nil if there
+// is none. It is run with the arguments after the name and returns the exit code.
+func Command(name string) func(args []string) int {
+	return commands[name]
+}
@@ -0,0 +1,32 @@
+package sorter
+
+// runIO holds how a run reads the files of the source and writes the copies. Every Sorter has its
+// own so that several of them can run in the same process. A nil runIO reads and writes without
+// any of the options, like the commands which run without a Sorter.
+type runIO struct {
+	// bandwidth limits the rate at which the copies are written. It is nil when there is no limit
+	bandwidth *bandwidthSchedule
+	// staging is the directory the copies are written to before they are renamed into the
+	// destination. It is empty when they are staged next to the destination
+	staging string
+	// keepPartial keeps the temporary file of a copy which failed or was cancelled for
+	// -resume-partial, which continues it on the next run
+	keepPartial bool
+	// readOnlySource reads the files of the source without updating their access time for
+	// -assert-readonly-source
+	readOnlySource bool
+	// salvage reads the sources with salvageReader for -salvage
+	salvage bool
+	// control stops the copies in progress once the run is cancelled so that a large file does not
+	// hold up the cancel
+	control *runControl
+	// printer prints the messages of the run
+	printer *outputPrinter
+	// abandonment is set for a copy with -file-timeout, which discards what it wrote once the run
+	// gave up on it
+	abandonment *abandonment
+}
+
+// defaultIO reads and writes the files of the commands which run without a Sorter, like undo,
+// verify or prune.
+var defaultIO *runIO
@@ -0,0 +1,669 @@
+package sorter
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// s3Scheme is the prefix of the destinations which are buckets of S3 or of a compatible object
+// storage like MinIO, like s3://bucket/prefix.
+const s3Scheme = "s3://"
+
+// s3PartSize is the size of the parts of a multipart upload. The files up to it are uploaded with a
+// single request. It grows for the files which would need more than s3MaxParts parts.
+const s3PartSize = 16 << 20
+
+const s3MaxParts = 10000
+
+// S3Config holds the settings of the S3 destinations. The credentials are read from
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN or else from the profile of the
+// ~/.aws/credentials file.
+type S3Config struct {
+	// Endpoint is the URL of a compatible object storage like http://localhost:9000. It is the
+	// endpoint of AWS for the Region when empty
+	Endpoint string
+	// Region is the one of the bucket, AWS_REGION or us-east-1 when empty
+	Region string
+	// PathStyle puts the bucket into the path of the URLs instead of the host name, which most
+	// compatible object storages need
+	PathStyle bool
+	// Profile is the profile of the credentials file, AWS_PROFILE or default when empty
+	Profile string
+	// InFlight is the number of uploads at the same time. The files are copied with that many
+	// workers when -workers is lower, since the latency of every request outweighs the upload of
+	// a photo. 0 leaves it to -workers
+	InFlight int
+}
+
+// AddS3Flags adds the flags of the S3 destinations.
+func AddS3Flags(flags *flag.FlagSet) *S3Config {
+	config := &S3Config{}
+	flags.StringVar(&config.Endpoint, "s3-endpoint", "", `Optional. The URL of an S3 compatible object storage for the s3:// destinations, like
+	http://localhost:9000 for MinIO. By default the one of AWS for the region`)
+	flags.StringVar(&config.Region, "s3-region", "", "Optional. The region of the buckets. By default AWS_REGION or us-east-1")
+	flags.BoolVar(&config.PathStyle, "s3-path-style", false, `Optional. Put the bucket into the path of the URLs instead of the host name, which
+	most S3 compatible object storages need`)
+	flags.StringVar(&config.Profile, "s3-profile", "", `Optional. The profile of ~/.aws/credentials to read the credentials from when
+	AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are not set. By default AWS_PROFILE or default`)
+	flags.IntVar(&config.InFlight, "s3-in-flight", 0, `Optional. The number of uploads to the s3:// destinations at the same time. The files
+	are copied with that many workers when -workers is lower. By default the number of -workers`)
+	return config
+}
+
+// parseS3URL splits a destination like s3://bucket/prefix. ok is false for a local destination.
+func parseS3URL(destPath string) (bucket string, prefix string, ok bool) {
+	if !strings.HasPrefix(destPath, s3Scheme) {
+		return "", "", false
+	}
+	rest := strings.TrimPrefix(destPath, s3Scheme)
+	if i := strings.Index(rest, "/"); i >= 0 {
+		rest, prefix = rest[:i], strings.Trim(rest[i+1:], "/")
+	}
+	return rest, prefix, true
+}
+
+// s3Client signs the requests to the object storage with AWS signature version 4.
+type s3Client struct {
+	endpoint     *url.URL
+	region       string
+	pathStyle    bool
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	http         *http.Client
+}
+
+func newS3Client(config S3Config, printer *outputPrinter) (*s3Client, error) {
+	c := &s3Client{region: config.Region, pathStyle: config.PathStyle, http: &http.Client{}}
+	if strings.Compare(c.region, "") == 0 {
+		c.region = os.Getenv("AWS_REGION")
+	}
+	if strings.Compare(c.region, "") == 0 {
+		c.region = "us-east-1"
+	}
+	endpoint := config.Endpoint
+	if strings.Compare(endpoint, "") == 0 {
+		endpoint = "https://s3." + c.region + ".amazonaws.com"
+	}
+	var err error
+	if c.endpoint, err = url.Parse(endpoint); err != nil || strings.Compare(c.endpoint.Host, "") == 0 {
+		return nil, printer.errorf("The S3 endpoint %s is not a valid URL\n", endpoint)
+	}
+
+	c.accessKey, c.secretKey, c.sessionToken = os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN")
+	if strings.Compare(c.accessKey, "") == 0 || strings.Compare(c.secretKey, "") == 0 {
+		profile := config.Profile
+		if strings.Compare(profile, "") == 0 {
+			profile = os.Getenv("AWS_PROFILE")
+		}
+		if strings.Compare(profile, "") == 0 {
+			profile = "default"
+		}
+		if c.accessKey, c.secretKey, c.sessionToken, err = readAWSCredentials(profile); err != nil {
+			return nil, printer.errorf("An error occurred while trying to read the S3 credentials of the profile %s: %v\n", profile, err)
+		}
+	}
+	return c, nil
+}
+
+// readAWSCredentials reads a profile of the credentials file shared with the AWS tools.
+func readAWSCredentials(profile string) (accessKey string, secretKey string, sessionToken string, err error) {
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if strings.Compare(path, "") == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", "", err
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", "", "", err
+	}
+	defer file.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		i := strings.Index(line, "=")
+		if strings.Compare(section, profile) != 0 || i < 0 {
+			continue
+		}
+		value := strings.TrimSpace(line[i+1:])
+		switch strings.TrimSpace(line[:i]) {
+		case "aws_access_key_id":
+			accessKey = value
+		case "aws_secret_access_key":
+			secretKey = value
+		case "aws_session_token":
+			sessionToken = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", "", err
+	}
+	if strings.Compare(accessKey, "") == 0 || strings.Compare(secretKey, "") == 0 {
+		return "", "", "", fmt.Errorf("%s has no keys for the profile", path)
+	}
+	return accessKey, secretKey, sessionToken, nil
+}
+
+// objectURL returns the URL of the object, or of the bucket when the key is empty.
+func (c *s3Client) objectURL(bucket string, key string, query url.Values) *url.URL {
+	u := *c.endpoint
+	path := "/" + key
+	if c.pathStyle {
+		path = "/" + bucket + path
+	} else {
+		u.Host = bucket + "." + u.Host
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawPath = s3EscapePath(u.Path)
+	u.RawQuery = s3CanonicalQuery(query)
+	return &u
+}
+
+// do signs and sends a request with the body and returns the response when it succeeded. The
+// errors of the object storage are returned with their code and message.
+func (c *s3Client) do(method string, u *url.URL, header http.Header, body []byte) (*http.Response, error) {
+	request, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	request.ContentLength = int64(len(body))
+	c.sign(request, body, time.Now())
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode >= 300 {
+		defer response.Body.Close()
+		var s3Err struct {
+			Code    string
+			Message string
+		}
+		content, _ := io.ReadAll(io.LimitReader(response.Body, 64*1024))
+		if xml.Unmarshal(content, &s3Err) == nil && strings.Compare(s3Err.Code, "") != 0 {
+			return response, fmt.Errorf("%s %s: %s: %s", method, u.Path, s3Err.Code, s3Err.Message)
+		}
+		return response, fmt.Errorf("%s %s: %s", method, u.Path, response.Status)
+	}
+	return response, nil
+}
+
+// sign adds the AWS signature version 4 to the request.
+func (c *s3Client) sign(request *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256.Sum256(body)
+
+	request.Header.Set("Host", request.URL.Host)
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	if strings.Compare(c.sessionToken, "") != 0 {
+		request.Header.Set("X-Amz-Security-Token", c.sessionToken)
+	}
+
+	var names []string
+	headers := make(map[string]string)
+	for name, values := range request.Header {
+		name = strings.ToLower(name)
+		names = append(names, name)
+		headers[name] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	// the Host header is sent from the URL by net/http
+	request.Header.Del("Host")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	scope := day + "/" + c.region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), day)
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+c.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3EscapePath escapes everything in the path but the unreserved characters and the slashes, as
+// the signature expects.
+func s3EscapePath(path string) string {
+	var escaped strings.Builder
+	for _, b := range []byte(path) {
+		if b == '/' || isUnreserved(b) {
+			escaped.WriteByte(b)
+		} else {
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		}
+	}
+	return escaped.String()
+}
+
+func s3Escape(value string) string {
+	return strings.ReplaceAll(s3EscapePath(value), "/", "%2F")
+}
+
+func isUnreserved(b byte) bool {
+	return 'A' <= b && b <= 'Z' || 'a' <= b && b <= 'z' || '0' <= b && b <= '9' || b == '-' || b == '_' || b == '.' || b == '~'
+}
+
+// s3CanonicalQuery encodes the query sorted by the names, with an = after the names without a value.
+func s3CanonicalQuery(query url.Values) string {
+	var names []string
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var pairs []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			pairs = append(pairs, s3Escape(name)+"="+s3Escape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// s3Target is a bucket and the prefix below which the files are sorted.
+type s3Target struct {
+	client *s3Client
+	bucket string
+	prefix string
+	runIO  *runIO
+	// slots holds a value for every upload going on when -s3-in-flight limits them
+	slots chan struct{}
+	// folders are the keys of the objects in the folders listed so far. A folder is listed with
+	// one request the first time a file is sorted into it, so that the new files need no request
+	// of their own to find out they are not there yet
+	mu      sync.Mutex
+	folders map[string]map[string]struct{}
+}
+
+func newS3Target(client *s3Client, bucket string, prefix string, inFlight int, runIO *runIO) *s3Target {
+	t := &s3Target{client: client, bucket: bucket, prefix: prefix, runIO: runIO, folders: make(map[string]map[string]struct{})}
+	if inFlight > 0 {
+		t.slots = make(chan struct{}, inFlight)
+	}
+	return t
+}
+
+// url returns the s3:// url of the object a file sorted into the relative path is uploaded to,
+// which stands for its path in the messages and reports.
+func (t *s3Target) url(relativePath string) string {
+	return s3Scheme + t.bucket + "/" + t.key(relativePath)
+}
+
+func (t *s3Target) key(relativePath string) string {
+	key := filepath.ToSlash(relativePath)
+	if strings.Compare(t.prefix, "") != 0 {
+		key = t.prefix + "/" + key
+	}
+	return key
+}
+
+// keyOf returns the key of the object at the s3:// url.
+func (t *s3Target) keyOf(objectURL string) string {
+	return strings.TrimPrefix(objectURL, s3Scheme+t.bucket+"/")
+}
+
+// check makes sure that the bucket exists and the credentials give access to it.
+func (t *s3Target) check() error {
+	response, err := t.client.do(http.MethodHead, t.client.objectURL(t.bucket, "", nil), nil, nil)
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("the bucket %s does not exist", t.bucket)
+		}
+		return err
+	}
+	response.Body.Close()
+	return nil
+}
+
+// stat returns the info of the object at the url with its ETag, or nil when there is no such object.
+func (t *s3Target) stat(objectURL string) (os.FileInfo, error) {
+	key := t.keyOf(objectURL)
+	if listed, ok := t.listed(key); ok && !listed {
+		return nil, nil
+	}
+	response, err := t.client.do(http.MethodHead, t.client.objectURL(t.bucket, key, nil), nil, nil)
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	response.Body.Close()
+
+	// the objects uploaded by filesorter carry the modified time of the file
+	info := s3ObjectInfo{key: key, size: response.ContentLength, etag: strings.Trim(response.Header.Get("ETag"), `"`)}
+	if seconds, err := strconv.ParseInt(response.Header.Get("X-Amz-Meta-Mtime"), 10, 64); err == nil {
+		info.modTime = time.Unix(seconds, 0)
+	} else if modTime, err := http.ParseTime(response.Header.Get("Last-Modified")); err == nil {
+		info.modTime = modTime
+	}
+	return info, nil
+}
+
+// listed reports whether the key is in the listing of its folder. ok is false when the folder could
+// not be listed, like without the permission to list the bucket, and the object has to be asked for.
+func (t *s3Target) listed(key string) (listed bool, ok bool) {
+	folder := path.Dir(key)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	keys, ok := t.folders[folder]
+	if !ok {
+		var err error
+		if keys, err = t.list(folder); err != nil {
+			return false, false
+		}
+		t.folders[folder] = keys
+	}
+	_, listed = keys[key]
+	return listed, true
+}
+
+// list returns the keys of the objects in the folder, in as many requests as the listing has pages.
+func (t *s3Target) list(folder string) (map[string]struct{}, error) {
+	prefix := ""
+	if folder != "." {
+		prefix = folder + "/"
+	}
+	keys := make(map[string]struct{})
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}, "delimiter": {"/"}}
+	for {
+		response, err := t.client.do(http.MethodGet, t.client.objectURL(t.bucket, "", query), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			IsTruncated           bool
+			NextContinuationToken string
+			Contents              []struct {
+				Key string
+			}
+		}
+		err = xml.NewDecoder(response.Body).Decode(&result)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range result.Contents {
+			keys[object.Key] = struct{}{}
+		}
+		if !result.IsTruncated || strings.Compare(result.NextContinuationToken, "") == 0 {
+			return keys, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+// sameContent compares the ETag of the object with the md5 of the file, which is what it is for
+// the objects uploaded by filesorter.
+func (t *s3Target) sameContent(path string, destFileStat os.FileInfo) (bool, error) {
+	etag := destFileStat.(s3ObjectInfo).etag
+	sum, err := t.runIO.s3ETag(path, strings.Contains(etag, "-"))
+	if err != nil {
+		return false, err
+	}
+	return strings.Compare(sum, etag) == 0, nil
+}
+
+// close does nothing since every request is made on its own.
+func (t *s3Target) close() error {
+	return nil
+}
+
+// s3ObjectInfo describes an object like the info of a file at a local destination.
+type s3ObjectInfo struct {
+	key     string
+	size    int64
+	modTime time.Time
+	etag    string
+}
+
+func (i s3ObjectInfo) Name() string       { return path.Base(i.key) }
+func (i s3ObjectInfo) Size() int64        { return i.size }
+func (i s3ObjectInfo) Mode() os.FileMode  { return 0 }
+func (i s3ObjectInfo) ModTime() time.Time { return i.modTime }
+func (i s3ObjectInfo) IsDir() bool        { return false }
+func (i s3ObjectInfo) Sys() interface{}   { return nil }
+
+// s3PartSizeFor returns the size of the parts of a multipart upload of a file of the size.
+func s3PartSizeFor(size int64) int64 {
+	partSize := int64(s3PartSize)
+	for size > partSize*s3MaxParts {
+		partSize *= 2
+	}
+	return partSize
+}
+
+// upload uploads the file to the object at the url and returns the number of bytes and the sha256
+// of the file.
+// Every request carries the md5 of its content so that the object storage rejects what was
+// corrupted on the way. The files larger than a part are uploaded in parts, and an upload which
+// fails part way is aborted so that its parts do not stay around.
+func (t *s3Target) upload(source string, objectURL string, modTime time.Time) (int64, string, error) {
+	key := t.keyOf(objectURL)
+	if t.slots != nil {
+		t.slots <- struct{}{}
+		defer func() { <-t.slots }()
+	}
+	file, err := t.runIO.openSource(source)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return 0, "", err
+	}
+
+	header := http.Header{}
+	header.Set("X-Amz-Meta-Mtime", strconv.FormatInt(modTime.Unix(), 10))
+	reader := t.runIO.cancellable(t.runIO.throttle(file))
+	hash := sha256.New()
+
+	if fileInfo.Size() <= s3PartSize {
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return 0, "", err
+		}
+		hash.Write(content)
+		header.Set("Content-Md5", md5Base64(content))
+		response, err := t.client.do(http.MethodPut, t.client.objectURL(t.bucket, key, nil), header, content)
+		if err != nil {
+			return 0, "", err
+		}
+		response.Body.Close()
+		t.uploaded(key)
+		return int64(len(content)), hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	response, err := t.client.do(http.MethodPost, t.client.objectURL(t.bucket, key, url.Values{"uploads": {""}}), header, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	err = xml.NewDecoder(response.Body).Decode(&initiated)
+	response.Body.Close()
+	if err != nil {
+		return 0, "", err
+	}
+
+	written, err := t.uploadParts(reader, hash, key, initiated.UploadID, s3PartSizeFor(fileInfo.Size()))
+	if err != nil {
+		if response, abortErr := t.client.do(http.MethodDelete, t.client.objectURL(t.bucket, key, url.Values{"uploadId": {initiated.UploadID}}), nil, nil); abortErr == nil {
+			response.Body.Close()
+		}
+		return written, "", err
+	}
+	t.uploaded(key)
+	return written, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// uploaded adds the object to the listing of its folder for the files sorted to the same key later.
+func (t *s3Target) uploaded(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if keys, ok := t.folders[path.Dir(key)]; ok {
+		keys[key] = struct{}{}
+	}
+}
+
+type s3CompletedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+// uploadParts uploads the parts of a multipart upload one after the other and completes it.
+func (t *s3Target) uploadParts(reader io.Reader, hash io.Writer, key string, uploadID string, partSize int64) (int64, error) {
+	var parts []s3CompletedPart
+	var written int64
+	part := make([]byte, partSize)
+	for number := 1; ; number++ {
+		n, err := io.ReadFull(reader, part)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return written, err
+		}
+		content := part[:n]
+		hash.Write(content)
+		header := http.Header{}
+		header.Set("Content-Md5", md5Base64(content))
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+		response, err := t.client.do(http.MethodPut, t.client.objectURL(t.bucket, key, query), header, content)
+		if err != nil {
+			return written, err
+		}
+		response.Body.Close()
+		parts = append(parts, s3CompletedPart{PartNumber: number, ETag: response.Header.Get("ETag")})
+		written += int64(n)
+		if n < len(part) {
+			break
+		}
+	}
+
+	completion, err := xml.Marshal(struct {
+		XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+		Parts   []s3CompletedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return written, err
+	}
+	response, err := t.client.do(http.MethodPost, t.client.objectURL(t.bucket, key, url.Values{"uploadId": {uploadID}}), nil, completion)
+	if err != nil {
+		return written, err
+	}
+	defer response.Body.Close()
+	// a completion which fails after the response started is reported in its body with 200 OK
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return written, err
+	}
+	if bytes.Contains(content, []byte("<Error>")) {
+		return written, fmt.Errorf("the upload of %s could not be completed: %s", key, content)
+	}
+	return written, nil
+}
+
+func md5Base64(content []byte) string {
+	sum := md5.Sum(content)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// s3ETag computes the ETag an object uploaded from the file has, the md5 of the content for a
+// single upload and the md5 of the md5s of the parts followed by the number of parts for a
+// multipart upload. An object uploaded in parts of another size than filesorter uses does not match.
+func (r *runIO) s3ETag(path string, multipart bool) (string, error) {
+	file, err := r.openSource(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	if !multipart {
+		sum := md5.New()
+		if _, err := io.Copy(sum, r.cancellable(file)); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(sum.Sum(nil)), nil
+	}
+
+	partSize := s3PartSizeFor(fileInfo.Size())
+	sums := md5.New()
+	parts := 0
+	for {
+		sum := md5.New()
+		n, err := io.Copy(sum, io.LimitReader(r.cancellable(file), partSize))
+		if err != nil {
+			return "", err
+		}
+		if n == 0 && parts > 0 {
+			break
+		}
+		sums.Write(sum.Sum(nil))
+		parts++
+		if n < partSize {
+			break
+		}
+	}
+	return hex.EncodeToString(sums.Sum(nil)) + "-" + strconv.Itoa(parts), nil
+}
@@ -0,0 +1,62 @@
+package sorter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// checkRelativePath makes sure that a destination path built from the names and the metadata of
+// the source files stays inside the destination, so that a hostile name or tag like ../../.bashrc
+// or an absolute path can not write anywhere else. The drive and UNC paths and the backslashes of
+// Windows are refused on every system too, since the archive may be read on Windows later.
+func checkRelativePath(relativePath string) error {
+	slashed := strings.ReplaceAll(relativePath, `\`, "/")
+	if filepath.IsAbs(relativePath) || filepath.VolumeName(relativePath) != "" || strings.HasPrefix(slashed, "/") || hasDriveLetter(slashed) {
+		return fmt.Errorf("the destination path %s is absolute", relativePath)
+	}
+	cleaned := filepath.Clean(filepath.FromSlash(slashed))
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("the destination path %s is outside of the destination", relativePath)
+	}
+	return nil
+}
+
+// hasDriveLetter reports whether the path starts with a drive of Windows like C:.
+func hasDriveLetter(path string) bool {
+	return len(path) >= 2 && path[1] == ':' && ('a' <= path[0] && path[0] <= 'z' || 'A' <= path[0] && path[0] <= 'Z')
+}
+
+// checkInsideDestination makes sure that the directory does not lead out of the destination through
+// a symlink. The deepest part of it which exists is resolved since the rest is created as plain
+// directories.
+func checkInsideDestination(destination string, dir string) error {
+	root, err := resolvePath(destination)
+	if err != nil {
+		return err
+	}
+	existing, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		existing = parent
+	}
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return err
+	}
+	relativePath, err := filepath.Rel(root, resolved)
+	if err != nil || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("the directory %s leads out of the destination %s through a symlink", dir, destination)
+	}
+	return nil
+}
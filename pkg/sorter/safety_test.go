@@ -0,0 +1,91 @@
+package sorter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckRelativePath(t *testing.T) {
+	tests := []struct {
+		name         string
+		relativePath string
+		valid        bool
+	}{
+		{"date folders", filepath.Join("2023", "07", "IMG_0001.jpg"), true},
+		{"dots inside a name", filepath.Join("2023", "..IMG_0001..jpg"), true},
+		{"dot dot inside", filepath.Join("2023", "..", "07", "IMG_0001.jpg"), true},
+		{"dot", ".", false},
+		{"dot dot", "..", false},
+		{"dot dot first", filepath.Join("..", "IMG_0001.jpg"), false},
+		{"dot dot out of a folder", filepath.Join("2023", "..", "..", ".bashrc"), false},
+		{"dot dot with slashes", "2023/../../.bashrc", false},
+		{"dot dot with backslashes", `2023\..\..\.bashrc`, false},
+		{"absolute", "/etc/passwd", false},
+		{"absolute with backslash", `\Windows\win.ini`, false},
+		{"drive", `C:\Windows\win.ini`, false},
+		{"drive with slashes", "c:/Windows/win.ini", false},
+		{"drive relative", "C:win.ini", false},
+		{"unc", `\\server\share\file`, false},
+		{"unc with slashes", "//server/share/file", false},
+		{"device", `\\?\C:\Windows`, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := checkRelativePath(test.relativePath); (err == nil) != test.valid {
+				t.Errorf("checkRelativePath(%q) = %v, want valid %v", test.relativePath, err, test.valid)
+			}
+		})
+	}
+}
+
+func TestCheckInsideDestination(t *testing.T) {
+	root := t.TempDir()
+	destination := filepath.Join(root, "destination")
+	outside := filepath.Join(root, "outside")
+	for _, dir := range []string{filepath.Join(destination, "2023"), filepath.Join(outside, "2023")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		filepath.Join(destination, "escape"):             outside,
+		filepath.Join(destination, "2023", "escape"):     filepath.Join("..", "..", "outside"),
+		filepath.Join(destination, "inside"):             filepath.Join(destination, "2023"),
+		filepath.Join(destination, "dangling"):           filepath.Join(outside, "missing"),
+		filepath.Join(root, "linked-destination"):        destination,
+		filepath.Join(root, "linked-destination-parent"): root,
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("symlinks are not supported: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		destination string
+		dir         string
+		valid       bool
+	}{
+		{"existing folder", destination, filepath.Join(destination, "2023"), true},
+		{"new folders", destination, filepath.Join(destination, "2024", "01", "15"), true},
+		{"destination itself", destination, destination, true},
+		{"symlink inside", destination, filepath.Join(destination, "inside", "07"), true},
+		{"symlinked destination", filepath.Join(root, "linked-destination"), filepath.Join(root, "linked-destination", "2023", "07"), true},
+		{"symlinked parent of the destination", filepath.Join(root, "linked-destination-parent", "destination"), filepath.Join(destination, "2023"), true},
+		{"symlink escaping", destination, filepath.Join(destination, "escape"), false},
+		{"new folders under a symlink escaping", destination, filepath.Join(destination, "escape", "2023", "07"), false},
+		{"relative symlink escaping", destination, filepath.Join(destination, "2023", "escape", "07"), false},
+		{"dangling symlink escaping", destination, filepath.Join(destination, "dangling", "07"), false},
+		{"dot dot", destination, filepath.Join(destination, "2023", "..", "..", "outside"), false},
+		{"outside", destination, filepath.Join(outside, "2023"), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := checkInsideDestination(test.destination, test.dir); (err == nil) != test.valid {
+				t.Errorf("checkInsideDestination(%q, %q) = %v, want valid %v", test.destination, test.dir, err, test.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package sorter
+
+import (
+	"io"
+	"os"
+)
+
+// salvageBlockSizes are the sizes a read which failed is retried in, down to a sector.
+var salvageBlockSizes = []int{64 * 1024, 4096, 512}
+
+// salvageRetries is how often a block is read before it is split into smaller ones.
+const salvageRetries = 3
+
+// salvageReader reads a file like ddrescue does. A read which fails is retried in smaller and
+// smaller blocks, and the sectors which still can not be read are returned as zeros so that the
+// rest of the file is kept.
+type salvageReader struct {
+	file   io.ReaderAt
+	path   string
+	offset int64
+	size   int64
+	// printer reports the blocks which could not be read
+	printer *outputPrinter
+	// unreadable is the number of bytes which were filled with zeros
+	unreadable int64
+}
+
+func newSalvageReader(file *os.File, size int64, printer *outputPrinter) *salvageReader {
+	return &salvageReader{file: file, path: file.Name(), size: size, printer: printer}
+}
+
+func (r *salvageReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.size-r.offset {
+		p = p[:r.size-r.offset]
+	}
+	n, err := r.file.ReadAt(p, r.offset)
+	if err == io.EOF && n == 0 {
+		// the file got shorter since it was stat'ed
+		return 0, io.EOF
+	}
+	if err != nil && err != io.EOF {
+		before := r.unreadable
+		r.salvageBlock(p, r.offset, 0)
+		if lost := r.unreadable - before; lost > 0 {
+			r.printer.Printf("Could not read %d bytes of %s in the block at %d, they are filled with zeros\n", lost, r.path, r.offset)
+		}
+		n = len(p)
+	}
+	r.offset += int64(n)
+	return n, nil
+}
+
+// salvageBlock reads p in blocks of the size of the level, going down a level for a block which
+// can not be read. At the last level the block is filled with zeros.
+func (r *salvageReader) salvageBlock(p []byte, offset int64, level int) {
+	if level == len(salvageBlockSizes) {
+		for i := range p {
+			p[i] = 0
+		}
+		r.unreadable += int64(len(p))
+		return
+	}
+	size := salvageBlockSizes[level]
+	for start := 0; start < len(p); start += size {
+		end := start + size
+		if end > len(p) {
+			end = len(p)
+		}
+		if !r.readFully(p[start:end], offset+int64(start)) {
+			r.salvageBlock(p[start:end], offset+int64(start), level+1)
+		}
+	}
+}
+
+// readFully reads the block and retries a read which fails or comes up short.
+func (r *salvageReader) readFully(p []byte, offset int64) bool {
+	for attempt := 0; attempt < salvageRetries; attempt++ {
+		if n, err := r.file.ReadAt(p, offset); n == len(p) && (err == nil || err == io.EOF) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package sorter
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// sampleQueue picks a random subset of the queued files of the types being sorted for a trial
+// run. The picked files keep their order in the queue.
+func sampleQueue(queue []*queuedFile, sample string, opts *sortOptions) ([]*queuedFile, error) {
+	var matching []*queuedFile
+	for _, file := range queue {
+		if matchesTypes(file.dirent.Name(), opts) {
+			matching = append(matching, file)
+		}
+	}
+
+	size, err := sampleSize(sample, len(matching))
+	if err != nil {
+		return nil, err
+	}
+	if size >= len(matching) {
+		return matching, nil
+	}
+
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	picked := random.Perm(len(matching))[:size]
+	sort.Ints(picked)
+	sampled := make([]*queuedFile, size)
+	for i, index := range picked {
+		sampled[i] = matching[index]
+	}
+	return sampled, nil
+}
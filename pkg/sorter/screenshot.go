@@ -0,0 +1,93 @@
+package sorter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const screenshotsFolder = "Screenshots"
+
+// the names given to screenshots by the common phones and desktops
+var screenshotNames = regexp.MustCompile(`(?i)^(screenshot|screen shot|screen_shot|scrnshot|bildschirmfoto|schermafbeelding|captura de pantalla|capture d.écran)|[_-]screenshot`)
+
+// screenSizes are the resolutions of common phone and desktop displays. A png of exactly one of
+// these sizes, in either orientation, is very likely a screenshot.
+var screenSizes = map[[2]uint32]struct{}{
+	{1280, 720}: {}, {1280, 800}: {}, {1366, 768}: {}, {1440, 900}: {}, {1536, 864}: {}, {1600, 900}: {},
+	{1680, 1050}: {}, {1920, 1080}: {}, {1920, 1200}: {}, {2560, 1440}: {}, {2560, 1600}: {}, {2880, 1800}: {},
+	{3024, 1964}: {}, {3456, 2234}: {}, {3840, 2160}: {}, {5120, 2880}: {},
+	{1334, 750}: {}, {1792, 828}: {}, {2208, 1242}: {}, {2436, 1125}: {}, {2532, 1170}: {}, {2556, 1179}: {},
+	{2688, 1242}: {}, {2778, 1284}: {}, {2796, 1290}: {}, {2340, 1080}: {}, {2400, 1080}: {}, {3120, 1440}: {},
+	{3200, 1440}: {},
+}
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+
+// isScreenshot detects screenshots from their file name, the software recorded in the png metadata
+// or the png dimensions matching a common screen size.
+func (r *runIO) isScreenshot(path string) bool {
+	if screenshotNames.MatchString(filepath.Base(path)) {
+		return true
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".png") {
+		return false
+	}
+
+	file, err := r.openSource(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	return isPNGScreenshot(file)
+}
+
+// isPNGScreenshot reads the chunks before the image data, which is where the dimensions and the
+// text metadata are usually kept.
+func isPNGScreenshot(reader io.Reader) bool {
+	signature := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(reader, signature); err != nil || !bytes.Equal(signature, pngSignature) {
+		return false
+	}
+
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return false
+		}
+		length, chunkType := binary.BigEndian.Uint32(header), string(header[4:])
+		if chunkType == "IDAT" || length > 1024*1024 {
+			return false
+		}
+
+		// the data is followed by a 4 byte crc
+		data := make([]byte, length+4)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return false
+		}
+
+		switch chunkType {
+		case "IHDR":
+			if length < 8 {
+				return false
+			}
+			width, height := binary.BigEndian.Uint32(data), binary.BigEndian.Uint32(data[4:])
+			if _, ok := screenSizes[[2]uint32{width, height}]; ok {
+				return true
+			}
+			if _, ok := screenSizes[[2]uint32{height, width}]; ok {
+				return true
+			}
+		case "tEXt", "iTXt", "zTXt":
+			// macOS records "Screenshot" in the xmp user comment and linux tools record their own name
+			// as the software
+			if bytes.Contains(bytes.ToLower(data), []byte("screenshot")) {
+				return true
+			}
+		}
+	}
+}
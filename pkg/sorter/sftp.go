@@ -0,0 +1,653 @@
+package sorter
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sftpScheme is the prefix of the destinations on an ssh server, like sftp://user@host/path. The
+// path is absolute, or relative to the home directory when it starts with /~/.
+const sftpScheme = "sftp://"
+
+// the packet types, flags and status codes of version 3 of the sftp protocol
+const (
+	sftpInit     = 1
+	sftpVersion  = 2
+	sftpOpen     = 3
+	sftpClose    = 4
+	sftpRead     = 5
+	sftpWrite    = 6
+	sftpSetstat  = 9
+	sftpRemove   = 13
+	sftpMkdir    = 14
+	sftpStat     = 17
+	sftpRename   = 18
+	sftpStatus   = 101
+	sftpHandle   = 102
+	sftpData     = 103
+	sftpAttrs    = 105
+	sftpOpenRead = 0x01
+	// write, create and truncate
+	sftpOpenWrite       = 0x02 | 0x08 | 0x10
+	sftpAttrSize        = 0x01
+	sftpAttrUIDGID      = 0x02
+	sftpAttrPermissions = 0x04
+	sftpAttrTimes       = 0x08
+	sftpAttrExtended    = 0x80000000
+	sftpOK              = 0
+	sftpEOF             = 1
+	sftpNoSuchFile      = 2
+)
+
+// sftpChunk is the size of the reads and writes, which every server supports. sftpInFlight writes
+// are sent before their status is read so that the latency of the connection is not paid for each.
+const (
+	sftpChunk    = 32 * 1024
+	sftpInFlight = 16
+)
+
+// parseSFTPURL splits a destination like sftp://user@host:port/path. ok is false for other destinations.
+func parseSFTPURL(destPath string, printer *outputPrinter) (u *url.URL, ok bool, err error) {
+	if !strings.HasPrefix(destPath, sftpScheme) {
+		return nil, false, nil
+	}
+	u, err = url.Parse(destPath)
+	if err != nil || strings.Compare(u.Hostname(), "") == 0 {
+		return nil, true, printer.errorf("The destination %s is not a valid sftp:// url\n", destPath)
+	}
+	// the host and the user are passed to ssh, which would read them as options
+	if strings.HasPrefix(u.Hostname(), "-") || (u.User != nil && strings.HasPrefix(u.User.Username(), "-")) {
+		return nil, true, printer.errorf("The host and the user of the sftp destination %s must not start with -\n", destPath)
+	}
+	return u, true, nil
+}
+
+// sftpTarget is a directory on an ssh server. The files are uploaded over the sftp subsystem of
+// an ssh process, so that the keys, the agent and the ~/.ssh/config of the user are used as they are.
+type sftpTarget struct {
+	destURL string
+	// root is the directory of the destination on the server
+	root    string
+	command []string
+	dirMode os.FileMode
+	runIO   *runIO
+
+	// mu is held for a whole request or upload since the session handles one at a time
+	mu      sync.Mutex
+	session *sftpSession
+	// dirs are the directories known to exist on the server
+	dirs map[string]bool
+}
+
+func newSFTPTarget(destPath string, u *url.URL, sshCommand string, compress bool, dirMode os.FileMode, runIO *runIO) *sftpTarget {
+	root := u.Path
+	switch {
+	case strings.Compare(root, "") == 0 || strings.Compare(root, "/~") == 0 || strings.Compare(root, "/~/") == 0:
+		root = "."
+	case strings.HasPrefix(root, "/~/"):
+		root = strings.TrimPrefix(root, "/~/")
+	}
+	root = path.Clean(root)
+
+	command := strings.Fields(sshCommand)
+	if len(command) == 0 {
+		command = []string{"ssh"}
+	}
+	if strings.Compare(u.Port(), "") != 0 {
+		command = append(command, "-p", u.Port())
+	}
+	// ssh compresses the whole connection, which speeds up documents over a slow uplink but only
+	// costs time for photos and videos which are compressed already
+	if compress {
+		command = append(command, "-C")
+	}
+	host := u.Hostname()
+	if u.User != nil {
+		host = u.User.Username() + "@" + host
+	}
+	command = append(command, "-s", "--", host, "sftp")
+
+	return &sftpTarget{destURL: strings.TrimSuffix(destPath, "/"), root: root, command: command, dirMode: dirMode, runIO: runIO, dirs: make(map[string]bool)}
+}
+
+func (t *sftpTarget) url(relativePath string) string {
+	return t.destURL + "/" + filepath.ToSlash(relativePath)
+}
+
+// remotePath returns the path on the server of the file at the url.
+func (t *sftpTarget) remotePath(destURL string) string {
+	return path.Join(t.root, strings.TrimPrefix(destURL, t.destURL+"/"))
+}
+
+// check connects to the server and makes sure that the directory of the destination is there.
+func (t *sftpTarget) check() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	session, err := startSFTPSession(t.command)
+	if err != nil {
+		return err
+	}
+	t.session = session
+	attrs, err := t.session.stat(t.root)
+	if err != nil {
+		return err
+	}
+	if attrs == nil || attrs.mode&0170000 != 0040000 {
+		return fmt.Errorf("%s is not a directory on the server", t.root)
+	}
+	t.dirs[t.root] = true
+	return nil
+}
+
+func (t *sftpTarget) stat(destURL string) (os.FileInfo, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	remotePath := t.remotePath(destURL)
+	attrs, err := t.session.stat(remotePath)
+	if err != nil || attrs == nil {
+		return nil, err
+	}
+	return sftpFileInfo{path: remotePath, attrs: *attrs}, nil
+}
+
+// sameContent reads the file back from the server and compares its sha256 with the one of the source.
+func (t *sftpTarget) sameContent(path string, destFileStat os.FileInfo) (bool, error) {
+	sourceHash, err := t.runIO.hashFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	handle, err := t.session.open(destFileStat.(sftpFileInfo).path, sftpOpenRead)
+	if err != nil {
+		return false, err
+	}
+	hash := sha256.New()
+	readErr := t.session.readAll(handle, hash)
+	if err := t.session.close(handle); readErr == nil {
+		readErr = err
+	}
+	if readErr != nil {
+		return false, readErr
+	}
+	return strings.Compare(hex.EncodeToString(hash.Sum(nil)), sourceHash) == 0, nil
+}
+
+// upload streams the file to a temporary file next to the url, sets its modified time and renames
+// it into place, so that an interrupted upload never leaves a partial file under the final name.
+// The temporary file of an upload which failed is removed.
+func (t *sftpTarget) upload(source string, destURL string, modTime time.Time) (int64, string, error) {
+	file, err := t.runIO.openSource(source)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	remotePath := t.remotePath(destURL)
+	if err := t.mkdirAll(path.Dir(remotePath)); err != nil {
+		return 0, "", err
+	}
+	tempPath := remotePath + tempSuffix
+	handle, err := t.session.open(tempPath, sftpOpenWrite)
+	if err != nil {
+		return 0, "", err
+	}
+	hash := sha256.New()
+	written, err := t.session.writeAll(handle, io.TeeReader(t.runIO.cancellable(t.runIO.throttle(file)), hash))
+	if closeErr := t.session.close(handle); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = t.session.setTimes(tempPath, modTime)
+	}
+	if err == nil {
+		err = t.replace(tempPath, remotePath)
+	}
+	if err != nil {
+		t.session.remove(tempPath)
+		return written, "", err
+	}
+	return written, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// replace renames the uploaded file over the file at the path. Version 3 of the protocol does not
+// rename over an existing file, so a different file of the same name is removed first.
+func (t *sftpTarget) replace(tempPath string, remotePath string) error {
+	err := t.session.rename(tempPath, remotePath)
+	if err == nil {
+		return nil
+	}
+	if attrs, statErr := t.session.stat(remotePath); statErr != nil || attrs == nil {
+		return err
+	}
+	if err := t.session.remove(remotePath); err != nil {
+		return err
+	}
+	return t.session.rename(tempPath, remotePath)
+}
+
+// mkdirAll creates the directory and the parents which are not there yet.
+func (t *sftpTarget) mkdirAll(dir string) error {
+	var missing []string
+	for dir != "." && dir != "/" && !t.dirs[dir] {
+		missing = append(missing, dir)
+		dir = path.Dir(dir)
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		attrs, err := t.session.stat(missing[i])
+		if err != nil {
+			return err
+		}
+		if attrs == nil {
+			if err := t.session.mkdir(missing[i], t.dirMode); err != nil {
+				return err
+			}
+		}
+		t.dirs[missing[i]] = true
+	}
+	return nil
+}
+
+func (t *sftpTarget) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.session == nil {
+		return nil
+	}
+	err := t.session.stop()
+	t.session = nil
+	return err
+}
+
+// sftpAttributes are the attributes of a file on the server.
+type sftpAttributes struct {
+	size    int64
+	mode    uint32
+	modTime time.Time
+}
+
+// sftpFileInfo describes a file on the server like the info of a file at a local destination.
+type sftpFileInfo struct {
+	path  string
+	attrs sftpAttributes
+}
+
+func (i sftpFileInfo) Name() string       { return path.Base(i.path) }
+func (i sftpFileInfo) Size() int64        { return i.attrs.size }
+func (i sftpFileInfo) Mode() os.FileMode  { return os.FileMode(i.attrs.mode & 0777) }
+func (i sftpFileInfo) ModTime() time.Time { return i.attrs.modTime }
+func (i sftpFileInfo) IsDir() bool        { return i.attrs.mode&0170000 == 0040000 }
+func (i sftpFileInfo) Sys() interface{}   { return nil }
+
+// sftpStatusError is a status other than ok returned by the server.
+type sftpStatusError struct {
+	code    uint32
+	message string
+}
+
+func (e *sftpStatusError) Error() string {
+	return fmt.Sprintf("sftp status %d: %s", e.code, e.message)
+}
+
+// sftpSession speaks the sftp protocol with the ssh process over its standard input and output.
+type sftpSession struct {
+	cmd    *exec.Cmd
+	input  io.WriteCloser
+	output *bufio.Reader
+	nextID uint32
+}
+
+func startSFTPSession(command []string) (*sftpSession, error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stderr = os.Stderr
+	input, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	output, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	s := &sftpSession{cmd: cmd, input: input, output: bufio.NewReaderSize(output, 64*1024)}
+
+	var init sftpPacket
+	init.uint32(3)
+	if err := s.send(sftpInit, init); err != nil {
+		s.stop()
+		return nil, err
+	}
+	packetType, _, err := s.receive()
+	if err == nil && packetType != sftpVersion {
+		err = fmt.Errorf("the server answered the sftp version with the packet %d", packetType)
+	}
+	if err != nil {
+		s.stop()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *sftpSession) stop() error {
+	s.input.Close()
+	return s.cmd.Wait()
+}
+
+// sftpPacket builds the content of a packet.
+type sftpPacket []byte
+
+func (p *sftpPacket) uint32(v uint32) {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	*p = append(*p, b...)
+}
+
+func (p *sftpPacket) uint64(v uint64) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	*p = append(*p, b...)
+}
+
+func (p *sftpPacket) string(v string) {
+	p.bytes([]byte(v))
+}
+
+func (p *sftpPacket) bytes(v []byte) {
+	p.uint32(uint32(len(v)))
+	*p = append(*p, v...)
+}
+
+func (s *sftpSession) send(packetType byte, content sftpPacket) error {
+	header := make([]byte, 5)
+	binary.BigEndian.PutUint32(header, uint32(len(content)+1))
+	header[4] = packetType
+	_, err := s.input.Write(append(header, content...))
+	return err
+}
+
+// request sends a request with the next id and returns that id.
+func (s *sftpSession) request(packetType byte, build func(p *sftpPacket)) (uint32, error) {
+	s.nextID++
+	var p sftpPacket
+	p.uint32(s.nextID)
+	build(&p)
+	return s.nextID, s.send(packetType, p)
+}
+
+func (s *sftpSession) receive() (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(s.output, header); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header)
+	if length == 0 || length > 1<<20 {
+		return 0, nil, fmt.Errorf("the sftp packet of %d bytes is not valid", length)
+	}
+	content := make([]byte, length-1)
+	if _, err := io.ReadFull(s.output, content); err != nil {
+		return 0, nil, err
+	}
+	return header[4], content, nil
+}
+
+// response reads the response to the request with the id. A status other than ok is returned as
+// an sftpStatusError.
+func (s *sftpSession) response(id uint32) (byte, *sftpReader, error) {
+	packetType, content, err := s.receive()
+	if err != nil {
+		return 0, nil, err
+	}
+	r := &sftpReader{content: content}
+	if responseID := r.uint32(); responseID != id {
+		return 0, nil, fmt.Errorf("the sftp response %d does not match the request %d", responseID, id)
+	}
+	if packetType == sftpStatus {
+		code, message := r.uint32(), r.string()
+		if code != sftpOK {
+			return packetType, nil, &sftpStatusError{code: code, message: message}
+		}
+	}
+	return packetType, r, r.err
+}
+
+// call sends a request and waits for its response.
+func (s *sftpSession) call(packetType byte, build func(p *sftpPacket)) (byte, *sftpReader, error) {
+	id, err := s.request(packetType, build)
+	if err != nil {
+		return 0, nil, err
+	}
+	return s.response(id)
+}
+
+// stat returns the attributes of the file or nil when there is no such file.
+func (s *sftpSession) stat(remotePath string) (*sftpAttributes, error) {
+	packetType, r, err := s.call(sftpStat, func(p *sftpPacket) { p.string(remotePath) })
+	if statusErr, ok := err.(*sftpStatusError); ok && statusErr.code == sftpNoSuchFile {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if packetType != sftpAttrs {
+		return nil, fmt.Errorf("the server answered the stat of %s with the packet %d", remotePath, packetType)
+	}
+	attrs := r.attrs()
+	return &attrs, r.err
+}
+
+func (s *sftpSession) open(remotePath string, flags uint32) (string, error) {
+	packetType, r, err := s.call(sftpOpen, func(p *sftpPacket) {
+		p.string(remotePath)
+		p.uint32(flags)
+		p.uint32(0)
+	})
+	if err != nil {
+		return "", err
+	}
+	if packetType != sftpHandle {
+		return "", fmt.Errorf("the server answered the open of %s with the packet %d", remotePath, packetType)
+	}
+	handle := r.string()
+	return handle, r.err
+}
+
+func (s *sftpSession) close(handle string) error {
+	_, _, err := s.call(sftpClose, func(p *sftpPacket) { p.string(handle) })
+	return err
+}
+
+func (s *sftpSession) mkdir(remotePath string, mode os.FileMode) error {
+	_, _, err := s.call(sftpMkdir, func(p *sftpPacket) {
+		p.string(remotePath)
+		if mode == 0 {
+			p.uint32(0)
+			return
+		}
+		p.uint32(sftpAttrPermissions)
+		p.uint32(uint32(mode.Perm()))
+	})
+	return err
+}
+
+func (s *sftpSession) setTimes(remotePath string, modTime time.Time) error {
+	_, _, err := s.call(sftpSetstat, func(p *sftpPacket) {
+		p.string(remotePath)
+		p.uint32(sftpAttrTimes)
+		p.uint32(uint32(modTime.Unix()))
+		p.uint32(uint32(modTime.Unix()))
+	})
+	return err
+}
+
+func (s *sftpSession) remove(remotePath string) error {
+	_, _, err := s.call(sftpRemove, func(p *sftpPacket) { p.string(remotePath) })
+	return err
+}
+
+func (s *sftpSession) rename(oldPath string, newPath string) error {
+	_, _, err := s.call(sftpRename, func(p *sftpPacket) {
+		p.string(oldPath)
+		p.string(newPath)
+	})
+	return err
+}
+
+// writeAll writes the reader to the file keeping up to sftpInFlight writes in flight.
+func (s *sftpSession) writeAll(handle string, reader io.Reader) (int64, error) {
+	var pending []uint32
+	var written int64
+	var err error
+	chunk := make([]byte, sftpChunk)
+	for {
+		n, readErr := io.ReadFull(reader, chunk)
+		if n > 0 {
+			offset := written
+			var id uint32
+			id, err = s.request(sftpWrite, func(p *sftpPacket) {
+				p.string(handle)
+				p.uint64(uint64(offset))
+				p.bytes(chunk[:n])
+			})
+			if err != nil {
+				break
+			}
+			pending = append(pending, id)
+			written += int64(n)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			err = readErr
+			break
+		}
+		if len(pending) == sftpInFlight {
+			_, _, err = s.response(pending[0])
+			pending = pending[1:]
+			if err != nil {
+				break
+			}
+		}
+	}
+	// the responses of the writes sent are read even after an error so that the next request
+	// gets its own response
+	for _, id := range pending {
+		if _, _, responseErr := s.response(id); err == nil {
+			err = responseErr
+		}
+	}
+	return written, err
+}
+
+// readAll reads the whole file into the writer.
+func (s *sftpSession) readAll(handle string, writer io.Writer) error {
+	var offset uint64
+	for {
+		packetType, r, err := s.call(sftpRead, func(p *sftpPacket) {
+			p.string(handle)
+			p.uint64(offset)
+			p.uint32(sftpChunk)
+		})
+		if statusErr, ok := err.(*sftpStatusError); ok && statusErr.code == sftpEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if packetType != sftpData {
+			return fmt.Errorf("the server answered a read with the packet %d", packetType)
+		}
+		data := r.bytes()
+		if r.err != nil {
+			return r.err
+		}
+		if _, err := writer.Write(data); err != nil {
+			return err
+		}
+		offset += uint64(len(data))
+	}
+}
+
+// sftpReader reads the fields of a response. The first field which does not fit sets err.
+type sftpReader struct {
+	content []byte
+	err     error
+}
+
+func (r *sftpReader) take(n int) []byte {
+	if r.err != nil || len(r.content) < n {
+		if r.err == nil {
+			r.err = fmt.Errorf("the sftp packet is too short")
+		}
+		return make([]byte, n)
+	}
+	b := r.content[:n]
+	r.content = r.content[n:]
+	return b
+}
+
+func (r *sftpReader) uint32() uint32 {
+	return binary.BigEndian.Uint32(r.take(4))
+}
+
+func (r *sftpReader) uint64() uint64 {
+	return binary.BigEndian.Uint64(r.take(8))
+}
+
+func (r *sftpReader) bytes() []byte {
+	n := r.uint32()
+	if n > uint32(len(r.content)) {
+		return r.take(len(r.content) + 1)
+	}
+	return r.take(int(n))
+}
+
+func (r *sftpReader) string() string {
+	return string(r.bytes())
+}
+
+func (r *sftpReader) attrs() sftpAttributes {
+	var attrs sftpAttributes
+	flags := r.uint32()
+	if flags&sftpAttrSize != 0 {
+		attrs.size = int64(r.uint64())
+	}
+	if flags&sftpAttrUIDGID != 0 {
+		r.uint32()
+		r.uint32()
+	}
+	if flags&sftpAttrPermissions != 0 {
+		attrs.mode = r.uint32()
+	}
+	if flags&sftpAttrTimes != 0 {
+		r.uint32()
+		attrs.modTime = time.Unix(int64(r.uint32()), 0)
+	}
+	if flags&sftpAttrExtended != 0 {
+		for i := r.uint32(); i > 0 && r.err == nil; i-- {
+			r.string()
+			r.string()
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,35 @@
+package sorter
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseSFTPURL(t *testing.T) {
+	tests := []struct {
+		destPath string
+		valid    bool
+	}{
+		{"sftp://user@host:2222/photos", true},
+		{"sftp://host/~/photos", true},
+		{"sftp://-oProxyCommand=touch%20pwned/photos", false},
+		{"sftp://-oProxyCommand=touch@host/photos", false},
+		{"sftp:///photos", false},
+	}
+	for _, test := range tests {
+		u, ok, err := parseSFTPURL(test.destPath, printer)
+		if !ok {
+			t.Fatalf("%s was not taken as an sftp destination", test.destPath)
+		}
+		if (err == nil) != test.valid {
+			t.Errorf("parseSFTPURL(%q) returned %v, want valid %v", test.destPath, err, test.valid)
+		}
+		if err != nil {
+			continue
+		}
+		command := newSFTPTarget(test.destPath, u, "ssh", false, os.ModePerm, nil).command
+		if len(command) < 4 || command[len(command)-3] != "--" {
+			t.Errorf("the ssh command %v does not end the options before the host", command)
+		}
+	}
+}
@@ -0,0 +1,1090 @@
+package sorter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/karrick/godirwalk"
+)
+
+type processedCount struct {
+	visitedDirectories int
+	copiedFiles        int
+	skippedFiles       int
+	erroredFiles       int
+	resumedFiles       int
+	plannedFiles       int
+	movedFiles         int
+	totalBytesCopied   int64
+	// totalBytesMoved is the size of the moved files. The ones moved with a rename are not counted
+	// as copied
+	totalBytesMoved int64
+	errors          []fileError
+	// corruptedCopies are the copies which did not match the source when read back
+	corruptedCopies []alertFile
+	// postProcessed and postProcessErrors are the copied files the hooks succeeded and failed for
+	postProcessed     int
+	postProcessErrors []fileError
+	// manifestChecked is the number of copies compared with the MHL manifests of the source
+	manifestChecked int
+	// verifiedCopies is the number of copies read back and compared with the source by -verify
+	verifiedCopies int
+	// timedOut is the number of files given up on after -file-timeout
+	timedOut int
+	// clonedFiles and kernelCopiedFiles are the copies which were cloned and the ones which the
+	// kernel copied, out of copiedFiles
+	clonedFiles       int
+	kernelCopiedFiles int
+	// salvaged are the files -salvage copied with unreadable parts filled with zeros
+	salvaged []alertFile
+	// conflicts are the files skipped by -on-conflict skip
+	conflicts []conflict
+	// renamedConflicts and overwrittenConflicts are the files copied under another name and over
+	// a different file of the same name
+	renamedConflicts     int
+	overwrittenConflicts int
+	// infected are the files clamd found a virus in, with the name of the signature
+	infected []alertFile
+	// invalidDates are the files sorted into the -unsorted folder, with their date
+	invalidDates []alertFile
+	// report collects the copied files per day and the duplicates for -html-report
+	report *runReport
+}
+
+// sortOptions holds the settings which apply to every file visited during a run.
+type sortOptions struct {
+	destinations []*destination
+	filterTypes  map[string]struct{}
+	excludeTypes map[string]struct{}
+	dateSources  []string
+	scheme       *scheme
+	layout       *template.Template
+	screenshots  bool
+	excludes     []string
+	backupFiles  map[string]backupFile
+	resume       bool
+	delta        bool
+	order        func(a, b *queuedFile) bool
+	protect      bool
+	immutable    bool
+	attributes   bool
+	catalog      *catalog
+	// plan is set when the copies are only recorded by 'filesorter plan'
+	plan *plan
+	// diff is set when the source is only compared with the destinations by 'filesorter diff'
+	diff *diff
+	// control pauses and cancels the run between files and progress publishes it to frontends
+	control  *runControl
+	progress *progressSocket
+	// buckets splits the destination folders in sub folders when -max-files-per-dir is passed
+	buckets *dirBuckets
+	// destEntries saves the stat of the destination files which do not exist
+	destEntries *dirEntryCache
+	createdDirs *createdDirs
+	// limit is set when -max-files or -max-bytes is passed
+	limit *batchLimit
+	// noExtension is the -no-extension policy for the files without an extension
+	noExtension string
+	// aliasDuplicates skips the files copied before under another date and records them as aliases
+	aliasDuplicates bool
+	// move removes the files from the source once they are copied to every destination
+	move bool
+	// destinationsInSource are left out of the walk so that an archive is not sorted into itself
+	destinationsInSource map[string]struct{}
+	// incremental skips the source directories which did not change since the last run
+	incremental *incrementalScan
+	// sample is the count or percentage of the files to pick at random for a trial run
+	sample string
+	// dryRun prints what sort would do. The copies are recorded in plan which is not written
+	dryRun bool
+	// planned maps the destination paths of the dry run to the source files planned to be copied
+	// there, so that a later file sorted into the same path is seen to conflict with them
+	planned map[string]string
+	// volume is set when the destination is one of several volumes of a fixed size
+	volume *volumeSpan
+	// bursts groups the photos taken in a burst into a sub folder of the day
+	bursts *burstIndex
+	// dateLayout replaces the year/month/day folders of the files sorted by date when -layout is passed
+	dateLayout *template.Template
+	// source is the directory the files are sorted from, for the .SourceFolder of the layout
+	source string
+	// splitLarge copies the files larger than a destination can hold in parts
+	splitLarge bool
+	// postProcess runs the -post-process hooks for the copied files
+	postProcess *postProcessor
+	// manifests are the MHL manifests of camera media which the copies are checked against
+	manifests *mhlIndex
+	// copyPool writes the copies in several goroutines when -workers is passed
+	copyPool *copyPool
+	// runIO is how the files of the source are read and the copies written
+	runIO *runIO
+	// printer prints the messages of the run
+	printer *outputPrinter
+	// compareHash compares the contents of the files of the same size at the destination
+	compareHash bool
+	// resumeIndex holds the files copied by earlier runs when -resume is passed
+	resumeIndex *resumeIndex
+	// inodes stops the run before a destination runs out of inodes
+	inodes *inodeGuard
+	// ignores are the rules of the .filesorterignore files in the source
+	ignores *sourceIgnores
+	// fileRate limits the files copied per second when -files-per-sec is passed
+	fileRate *fileRate
+	// onConflict is the -on-conflict policy for a different file of the same name at the destination
+	onConflict string
+	// output prints the processed files as json lines when -output json is passed
+	output *jsonOutput
+	// progressBar draws the progress of the run when -progress is passed
+	progressBar *progressBar
+	// undoManifest records the copies and moves for 'filesorter undo' when -manifest is passed
+	undoManifest *undoManifest
+	// virusScan scans the files with clamd before they are copied when -clamd is passed
+	virusScan *clamdScanner
+	// dateRange skips the files sorted into a date outside of -after and -before
+	dateRange *dateRange
+	// minSize and maxSize skip the files smaller or larger than them when they are not 0
+	minSize int64
+	maxSize int64
+	// unsorted sends the files with an invalid date into a folder of their own
+	unsorted *unsortedDates
+	// verify reads every copy back and compares it with the source before it counts as copied
+	verify bool
+	// fileTimeout is how long the run waits for reading the date of a file and for its copy
+	fileTimeout time.Duration
+}
+
+// retryFiles visits only the files listed in the error report of a previous run.
+func retryFiles(errorReport string, opts *sortOptions, counts *processedCount) error {
+
+	retries, err := readErrorReport(errorReport)
+	if err != nil {
+		return opts.printer.errorf("An error occurred while trying to read the error report %s: %v\n", errorReport, err)
+	}
+
+	for _, retry := range retries {
+		dirent, err := godirwalk.NewDirent(retry.Path)
+		if err != nil {
+			counts.erroredFiles++
+			counts.errors = append(counts.errors, newFileError(retry.Path, err))
+			continue
+		}
+		if stopsRun(processFile(retry.Path, dirent, nil, opts, counts)) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// processFile visits the file and records it in the counts if it errored. It waits before the file
+// while the run is paused and returns errCancelled once the run is cancelled. The file is stat'ed
+// when fileInfo was not looked up ahead.
+func processFile(path string, dirent *godirwalk.Dirent, fileInfo os.FileInfo, opts *sortOptions, counts *processedCount) error {
+	if opts.control != nil && opts.control.wait() {
+		return errCancelled
+	}
+
+	var eventsBefore, skippedBefore int
+	if opts.output != nil {
+		eventsBefore, skippedBefore = opts.output.events, counts.skippedFiles
+	}
+	visitErr := visitFile(path, dirent, fileInfo, opts, counts)
+	failed := visitErr != nil && visitErr != filepath.SkipDir && !stopsRun(visitErr)
+	if failed {
+		counts.erroredFiles++
+		counts.errors = append(counts.errors, newFileError(path, visitErr))
+	}
+	if opts.output != nil && !dirent.IsDir() {
+		if !failed {
+			visitErr = nil
+		}
+		opts.output.fileResult(path, eventsBefore, skippedBefore, visitErr, counts)
+	}
+
+	if opts.progress != nil && !dirent.IsDir() {
+		opts.progress.publish(path, counts)
+	}
+	if opts.progressBar != nil && !dirent.IsDir() {
+		opts.progressBar.add(path, dirent.Name(), fileInfo, opts)
+	}
+	return visitErr
+}
+
+func visitFile(path string, dirent *godirwalk.Dirent, sourceFileStat os.FileInfo, opts *sortOptions, counts *processedCount) error {
+
+	if isExcluded(dirent.Name(), opts.excludes) || (opts.ignores != nil && opts.ignores.ignored(path, dirent.IsDir())) {
+		if dirent.IsDir() {
+			return filepath.SkipDir
+		}
+		counts.skippedFiles++
+		return nil
+	}
+
+	// walk returns directories also. skip those
+	if dirent.IsDir() {
+		return nil
+	}
+
+	var err error
+	if sourceFileStat == nil {
+		sourceFileStat, err = os.Stat(path)
+		if err != nil {
+			opts.printer.Printf("An error occurred while trying to stat the source path %s", path)
+			return err
+		}
+	}
+
+	if !sourceFileStat.Mode().IsRegular() {
+		return opts.printer.errorf("The file %s is not a regular file\n", path)
+	}
+
+	// the files of a backup are stored under a hash. only the ones listed in its manifest are copied
+	// and with their original name and time
+	if opts.backupFiles != nil {
+		file, ok := opts.backupFiles[dirent.Name()]
+		if !ok {
+			counts.skippedFiles++
+			return nil
+		}
+		sourceFileStat = backupFileInfo{FileInfo: sourceFileStat, file: file}
+	}
+
+	// if file type filter were passed apply those
+	if !matchesTypes(sourceFileStat.Name(), opts) {
+		if opts.dryRun {
+			opts.printer.Printf("Would skip %s, its type is not sorted\n", path)
+		}
+		counts.skippedFiles++
+		return nil
+	}
+
+	if (opts.minSize > 0 && sourceFileStat.Size() < opts.minSize) || (opts.maxSize > 0 && sourceFileStat.Size() > opts.maxSize) {
+		if opts.dryRun {
+			opts.printer.Printf("Would skip %s, its size of %s is outside of -min-size and -max-size\n", path, formatBytes(sourceFileStat.Size()))
+		}
+		counts.skippedFiles++
+		return nil
+	}
+
+	// the files copied onto an earlier volume are not copied again
+	if opts.volume != nil {
+		copied, err := opts.volume.copiedElsewhere(path, sourceFileStat, opts.catalog)
+		if err != nil {
+			opts.printer.Printf("An error occurred while trying to look up the file %s in the catalog", path)
+			return err
+		}
+		if copied {
+			counts.skippedFiles++
+			return nil
+		}
+	}
+
+	// a file copied by an earlier run is skipped without looking at the destinations
+	if opts.resumeIndex != nil && opts.resumeIndex.done(path, sourceFileStat) {
+		counts.skippedFiles++
+		return nil
+	}
+
+	relativePath, sortTime, err := getDestFilePath(path, sourceFileStat, opts)
+	if err == nil {
+		err = checkRelativePath(relativePath)
+	}
+	if err != nil {
+		opts.printer.Printf("An error occurred while trying to get the destination path of the file %s", path)
+		if errors.Is(err, errTimeout) {
+			counts.timedOut++
+		}
+		return err
+	}
+
+	// a file with a date which is clearly wrong is not sorted by it. it has no sort time like the
+	// files sorted by their tags
+	if opts.unsorted != nil && !sortTime.IsZero() && !opts.unsorted.valid(sortTime) {
+		if opts.dryRun {
+			opts.printer.Printf("Would sort %s into %s, its date %s is not valid\n", path, opts.unsorted.folder, sortTime.Format("2006-01-02 15:04:05"))
+		}
+		counts.invalidDates = append(counts.invalidDates, alertFile{Path: path, Detail: sortTime.Format("2006-01-02 15:04:05")})
+		relativePath, sortTime = opts.unsorted.path(sourceFileStat.Name()), time.Time{}
+	}
+
+	// the files sorted by their tags have no sort time. their age is that of the file
+	ageTime := sortTime
+	if ageTime.IsZero() {
+		ageTime = sourceFileStat.ModTime()
+	}
+	if opts.dateRange != nil && !opts.dateRange.contains(ageTime) {
+		if opts.dryRun {
+			opts.printer.Printf("Would skip %s, its date %s is outside of -after and -before\n", path, ageTime.Format("2006-01-02"))
+		}
+		counts.skippedFiles++
+		return nil
+	}
+	destinations := routeDestinations(opts.destinations, ageTime)
+	if len(destinations) == 0 {
+		if opts.dryRun {
+			opts.printer.Printf("Would skip %s, it is older than all the tiers\n", path)
+		}
+		counts.skippedFiles++
+		return nil
+	}
+
+	if opts.diff != nil {
+		return diffFile(path, sourceFileStat, relativePath, destinations, opts)
+	}
+
+	// decide for every destination on its own whether the file needs to be copied there
+	var copies []*fileCopy
+	var sourceHash string
+	// with -move the files already at a destination are removed from the source once they are known
+	// to be the same. the others keep the source
+	var duplicates []*fileCopy
+	unconfirmedDestinations := 0
+	for _, dest := range destinations {
+		destFilePath := filepath.Join(dest.path, relativePath)
+		if dest.remote != nil {
+			destFilePath = dest.remote.url(relativePath)
+		}
+		if opts.buckets != nil {
+			if destFilePath, err = opts.buckets.place(destFilePath); err != nil {
+				opts.printer.Printf("An error occurred while trying to read the destination folder of the file %s", path)
+				return err
+			}
+		}
+		// a file sorted into the same path as one still being copied sees the finished copy
+		if opts.copyPool != nil {
+			opts.copyPool.waitFor(destFilePath, opts, counts)
+		}
+		var c *fileCopy
+		if dest.remote != nil {
+			c = prepareUpload(path, sourceFileStat, dest, destFilePath, opts)
+		} else {
+			c = prepareCopy(path, sourceFileStat, dest, destFilePath, opts)
+		}
+		c.sortTime = sortTime
+		if opts.aliasDuplicates && !c.skip && c.err == nil && c.destFileStat == nil {
+			if c.skip, err = aliasDuplicate(path, sourceFileStat, c, &sourceHash, opts); err != nil {
+				return err
+			}
+		}
+		if c.skip {
+			if c.conflict {
+				if opts.dryRun && c.plannedSource != "" {
+					opts.printer.Printf("Would conflict %s --> %s with %s sorted there before and skip it\n", path, c.destFilePath, c.plannedSource)
+				} else if opts.dryRun {
+					opts.printer.Printf("Would skip %s --> %s, a different file of the same name is already there\n", path, c.destFilePath)
+				} else if opts.plan == nil {
+					opts.printer.Printf("Skipped %s --> %s, a different file of the same name is already there\n", path, c.destFilePath)
+					counts.conflicts = append(counts.conflicts, newConflict(path, sourceFileStat, c))
+				}
+			} else if opts.dryRun && c.plannedSource != "" {
+				if opts.compareHash {
+					opts.printer.Printf("Would skip %s --> %s, %s with the same content is sorted there before\n", path, c.destFilePath, c.plannedSource)
+				} else {
+					opts.printer.Printf("Would skip %s --> %s, %s of the same size is sorted there before\n", path, c.destFilePath, c.plannedSource)
+				}
+			} else if opts.dryRun && c.destFileStat != nil && !isSameFile(sourceFileStat, c.destFileStat) {
+				if opts.compareHash {
+					opts.printer.Printf("Would skip %s --> %s, a file with the same content is already there\n", path, c.destFilePath)
+				} else {
+					opts.printer.Printf("Would skip %s --> %s, a file of the same size is already there\n", path, c.destFilePath)
+				}
+			}
+			if counts.report != nil && !c.conflict && c.destFileStat != nil && !isSameFile(sourceFileStat, c.destFileStat) {
+				counts.report.duplicate(path, c.destFilePath)
+			}
+			if opts.output != nil {
+				action := "skipped"
+				if c.conflict {
+					action = "conflict"
+				}
+				opts.output.file(path, c.destFilePath, action, 0, nil)
+			}
+			dest.counts.skippedFiles++
+			counts.skippedFiles++
+			if opts.move {
+				same, err := confirmDuplicate(path, sourceFileStat, c, &sourceHash, opts)
+				if err != nil {
+					opts.printer.Printf("An error occurred while trying to compare the moved file %s with %s", path, c.destFilePath)
+					return err
+				}
+				if same {
+					duplicates = append(duplicates, c)
+				} else {
+					unconfirmedDestinations++
+				}
+			}
+			continue
+		}
+		copies = append(copies, c)
+	}
+
+	// only the files which are copied are scanned so that the ones in the archive already are not
+	// scanned again on every run
+	if opts.virusScan != nil && len(copies) > 0 && opts.plan == nil {
+		if infected, err := scanFile(path, opts, counts); err != nil || infected {
+			return err
+		}
+	}
+
+	if opts.volume != nil && len(copies) > 0 {
+		if err := opts.volume.take(sourceFileStat.Size()); err != nil {
+			return err
+		}
+	}
+
+	if opts.plan != nil {
+		return planCopies(path, sourceFileStat, copies, opts, counts)
+	}
+
+	if opts.limit != nil && len(copies) > 0 && !opts.limit.take(sourceFileStat.Size()) {
+		return errBatchLimit
+	}
+
+	if opts.inodes != nil {
+		for _, c := range copies {
+			if err := opts.inodes.take(c.dest); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.fileRate != nil && len(copies) > 0 {
+		if err := opts.fileRate.wait(opts.control); err != nil {
+			return err
+		}
+	}
+
+	// a file which goes to only one destination is moved with a rename when it is on the same file
+	// system. the others are copied and the source removed once the copies are verified. a file
+	// which was skipped at some destination is only moved when the file there has the same content.
+	move := opts.move && unconfirmedDestinations == 0
+	renamed := move && len(copies) == 1 && copies[0].dest.remote == nil && copies[0].err == nil && copies[0].resumeFrom == 0 && !copies[0].update &&
+		moveByRename(path, sourceFileStat, copies[0], opts)
+
+	job := &copyJob{path: path, sourceFileStat: sourceFileStat, copies: copies, duplicates: duplicates, move: move, renamed: renamed, runIO: opts.runIO}
+	if opts.copyPool != nil && len(copies) > 0 {
+		opts.copyPool.submit(job, opts, counts)
+		return nil
+	}
+	job.copyWithin(opts.fileTimeout)
+	return job.finish(opts, counts)
+}
+
+// copyJob is the copy of a source file to the destinations which need it.
+type copyJob struct {
+	path           string
+	sourceFileStat os.FileInfo
+	copies         []*fileCopy
+	// duplicates are the destinations which already had the file, for removing the source of a move
+	duplicates []*fileCopy
+	move       bool
+	renamed    bool
+	runIO      *runIO
+}
+
+// copy writes the copies. It only reads and writes the files so that it can run in a copy worker.
+func (job *copyJob) copy() {
+	path := job.path
+
+	// resumed and updated copies read the source on their own. all the others are written
+	// together while reading the source once.
+	var plain []*fileCopy
+	var plainPaths []string
+	for _, c := range job.copies {
+		switch {
+		case job.renamed:
+		case c.err != nil:
+		case c.resumeTemp:
+			c.written, c.hash, c.err = job.runIO.resumeCopy(path, c.destFilePath+tempSuffix, c.resumeFrom)
+			if c.err == nil {
+				c.err = job.runIO.commit(func() error {
+					return os.Rename(c.destFilePath+tempSuffix, c.destFilePath)
+				})
+			}
+			if c.err == errPartialMismatch {
+				job.runIO.printer.Printf("The partial file %s does not match the source and will be copied again\n", c.destFilePath+tempSuffix)
+				c.resumeFrom, c.resumeTemp, c.err = 0, false, nil
+				plain, plainPaths = append(plain, c), append(plainPaths, c.destFilePath)
+			}
+		case c.resumeFrom > 0:
+			c.written, c.hash, c.err = job.runIO.resumeCopy(path, c.destFilePath, c.resumeFrom)
+			if c.err == errPartialMismatch {
+				job.runIO.printer.Printf("The partial file %s does not match the source and will be copied again\n", c.destFilePath)
+				c.resumeFrom, c.err = 0, nil
+				plain, plainPaths = append(plain, c), append(plainPaths, c.destFilePath)
+			}
+		case c.update:
+			c.written, c.hash, c.err = job.runIO.deltaCopy(path, c.destFilePath)
+		case c.split:
+			c.written, c.hash, c.parts, c.err = job.runIO.splitCopy(path, c.destFilePath, job.sourceFileStat, c.dest.maxFileSize)
+			if c.err != nil && c.err != errCancelled && c.err != errAbandoned {
+				job.runIO.printer.Printf("An error occurred while trying to copy the file %s to %s in parts", path, c.destFilePath)
+			}
+		case c.dest.remote != nil:
+			c.written, c.hash, c.err = c.dest.remote.upload(path, c.destFilePath, job.sourceFileStat.ModTime())
+			if c.err != nil && c.err != errCancelled {
+				job.runIO.printer.Printf("An error occurred while trying to upload the file %s to %s", path, c.destFilePath)
+			}
+		default:
+			plain, plainPaths = append(plain, c), append(plainPaths, c.destFilePath)
+		}
+	}
+	if len(plain) > 0 {
+		written, hash, unreadable, methods, errs, err := job.runIO.copyFileToAll(path, plainPaths)
+		for i, c := range plain {
+			c.written, c.hash, c.unreadable, c.method, c.err = written, hash, unreadable, methods[i], errs[i]
+			if err != nil {
+				c.err = err
+			}
+			if c.err != nil && c.err != errCancelled && c.err != errAbandoned {
+				job.runIO.printer.Printf("An error occurred while trying to copy the file %s to %s", path, c.destFilePath)
+			}
+		}
+	}
+}
+
+// finish records the copies once they are written and removes the source of a move.
+func (job *copyJob) finish(opts *sortOptions, counts *processedCount) error {
+	path, sourceFileStat, copies := job.path, job.sourceFileStat, job.copies
+
+	var failures []string
+	cancelled, timedOut := false, false
+	for _, c := range copies {
+		// the partial copy was removed and the next run copies the file again
+		if c.err == errCancelled {
+			cancelled = true
+			continue
+		}
+		// an uploaded file is not read back. its transfer was checked by the protocol
+		if c.err == nil && opts.manifests != nil && c.dest.remote == nil {
+			c.err = opts.manifests.check(path, c.destFilePath, counts)
+		}
+		if c.err == nil {
+			c.err = finishCopy(path, sourceFileStat, c, opts, counts)
+		}
+		if c.err != nil {
+			timedOut = timedOut || errors.Is(c.err, errTimeout)
+			c.dest.counts.erroredFiles++
+			failures = append(failures, fmt.Sprintf("%s: %v", c.destFilePath, c.err))
+			if opts.output != nil {
+				opts.output.file(path, c.destFilePath, "error", c.written, c.err)
+			}
+			continue
+		}
+		if opts.postProcess != nil {
+			opts.postProcess.queue(path, c.destFilePath)
+		}
+		if counts.report != nil {
+			sortTime := c.sortTime
+			if sortTime.IsZero() {
+				sortTime = sourceFileStat.ModTime()
+			}
+			counts.report.copied(sortTime)
+		}
+	}
+
+	if timedOut {
+		counts.timedOut++
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+	if cancelled {
+		return errCancelled
+	}
+	if job.move && len(copies)+len(job.duplicates) > 0 {
+		return removeMovedSource(path, sourceFileStat.Size(), copies, job.duplicates, opts, counts)
+	}
+	return nil
+}
+
+// fileCopy tracks the copy of a source file to one of the destinations.
+type fileCopy struct {
+	dest         *destination
+	destFilePath string
+	destFileStat os.FileInfo
+	sortTime     time.Time
+	skip         bool
+	resumeFrom   int64
+	update       bool
+	written      int64
+	hash         string
+	err          error
+	// moved is set when the source was renamed to the destination instead of being copied
+	moved bool
+	// conflict is set when a different file of the same name at the destination was skipped
+	conflict bool
+	// renamedConflict and overwroteConflict are set when the file is copied under another name or
+	// over a different file of the same name
+	renamedConflict   bool
+	overwroteConflict bool
+	// method is whether the copy was written, cloned or copied by the kernel
+	method copyMethod
+	// unreadable is the number of bytes of the source which -salvage filled with zeros
+	unreadable int64
+	// resumeTemp is set when the partial copy is the temporary file of an interrupted copy
+	resumeTemp bool
+	// split is set when the file is larger than the destination can hold and is copied in parts
+	split bool
+	parts int
+	// plannedSource is the file an earlier copy of the dry run is planned to write to the path
+	plannedSource string
+}
+
+func prepareCopy(path string, sourceFileStat os.FileInfo, dest *destination, destFilePath string, opts *sortOptions) *fileCopy {
+
+	c := &fileCopy{dest: dest, destFilePath: destFilePath}
+
+	if dest.maxFileSize > 0 && sourceFileStat.Size() > dest.maxFileSize {
+		return prepareSplit(path, sourceFileStat, c, opts)
+	}
+
+	destFileStat, comparePath, err := statPlanned(destFilePath, opts)
+	if strings.Compare(comparePath, destFilePath) != 0 {
+		c.plannedSource = comparePath
+	}
+	if err != nil {
+		// stat returns an error if the file does not exist.
+		// we can ignore that but if the error is of some other type then skip processing this file
+		if !os.IsNotExist(err) {
+			opts.printer.Printf("An error occurred while trying to stat the file %s", destFilePath)
+			c.err = err
+			return c
+		}
+		// a copy interrupted with -resume-partial left its temporary file, which is continued and
+		// then renamed into the destination
+		if opts.resume {
+			if partialStat, err := os.Stat(destFilePath + tempSuffix); err == nil && partialStat.Size() > 0 && partialStat.Size() < sourceFileStat.Size() {
+				c.resumeFrom, c.resumeTemp = partialStat.Size(), true
+			}
+		}
+	} else {
+		c.destFileStat = destFileStat
+		if isSameFile(sourceFileStat, destFileStat) {
+			opts.printer.Printf("The source %s and the destination %s are the same file. Skipping it\n", sourceFileStat.Name(), destFilePath)
+			c.skip = true
+			return c
+		}
+		// we assume the file in the destination is the same as the source file if their sizes match
+		// this might be useful in cases where cop file fails and an empty is created at the destination.
+		// with -compare hash their contents have to match too
+		same, err := sameContent(path, sourceFileStat, comparePath, destFileStat, opts)
+		if err != nil {
+			opts.printer.Printf("An error occurred while trying to compare the file %s with %s", path, destFilePath)
+			c.err = err
+			return c
+		}
+		if same {
+			c.skip = true
+			return c
+		}
+		// a file the dry run only plans to copy there can not be resumed or updated
+		if opts.resume && destFileStat.Size() > 0 && destFileStat.Size() < sourceFileStat.Size() && c.plannedSource == "" {
+			c.resumeFrom = destFileStat.Size()
+		}
+		c.update = opts.delta && c.plannedSource == ""
+		if c.resumeFrom == 0 && !c.update {
+			switch opts.onConflict {
+			case "skip":
+				c.skip, c.conflict = true, true
+				return c
+			case "rename", "hash-suffix":
+				renamed, same, err := conflictName(path, sourceFileStat, destFilePath, opts)
+				if err != nil {
+					opts.printer.Printf("An error occurred while trying to find another name for the file %s", destFilePath)
+					c.err = err
+					return c
+				}
+				if same {
+					c.destFilePath, c.skip = renamed, true
+					return c
+				}
+				c.destFilePath, c.destFileStat, c.renamedConflict = renamed, nil, true
+			default:
+				c.overwroteConflict = true
+			}
+		}
+	}
+	if opts.destEntries != nil {
+		opts.destEntries.add(c.destFilePath)
+	}
+
+	// the directories are created only when the plan is applied
+	if opts.plan != nil {
+		return c
+	}
+
+	err = opts.createdDirs.mkdirAll(dest.path, filepath.Dir(destFilePath))
+	if err != nil {
+		opts.printer.Printf("An error occurred while trying to create directories for the file %s", destFilePath)
+		c.err = err
+	}
+	return c
+}
+
+// finishCopy sets the times of a successfully copied file, records it and updates the counts.
+func finishCopy(path string, sourceFileStat os.FileInfo, c *fileCopy, opts *sortOptions, counts *processedCount) error {
+
+	// an uploaded file got the modified time of the source with the upload
+	if c.dest.remote != nil {
+		return finishUpload(path, sourceFileStat, c, opts, counts)
+	}
+	// the parts got the modified time of the source when they were written
+	if c.split {
+		return finishSplit(path, c, opts, counts)
+	}
+
+	// maintain the access and modified time of the file so that the correct time can be
+	// used if the file again needs to be sorted and copied somewhere else
+	err := os.Chtimes(c.destFilePath, sourceFileStat.ModTime(), sourceFileStat.ModTime())
+	if err != nil {
+		opts.printer.Printf("An error occurred while trying to set the access time of the copied file %s", c.destFilePath)
+		return err
+	}
+
+	// a renamed file was not copied so there is nothing to read back
+	if opts.verify && !c.moved {
+		if err := opts.runIO.verifyCopy(path, c, counts); err != nil {
+			return err
+		}
+	}
+
+	// the attributes are set before protecting so that -protect wins over a writable source. a
+	// renamed file kept its own
+	if opts.attributes && !c.moved {
+		if err := copyFileAttributes(path, c.destFilePath); err != nil {
+			opts.printer.Printf("An error occurred while trying to set the attributes of the copied file %s", c.destFilePath)
+			return err
+		}
+	}
+
+	if opts.protect {
+		if err := opts.runIO.protectFile(c.destFilePath, c.hash, opts.immutable); err != nil {
+			opts.printer.Printf("An error occurred while trying to protect the copied file %s", c.destFilePath)
+			if err == errCopyMismatch {
+				counts.corruptedCopies = append(counts.corruptedCopies, alertFile{Path: c.destFilePath, Detail: err.Error()})
+			}
+			return err
+		}
+	}
+
+	if opts.catalog != nil {
+		err = opts.catalog.record(catalogEntry{
+			sourcePath: path,
+			destPath:   c.destFilePath,
+			size:       sourceFileStat.Size(),
+			modTime:    sourceFileStat.ModTime(),
+			hash:       c.hash,
+			copiedAt:   time.Now(),
+			sortTime:   c.sortTime,
+		})
+		if err != nil {
+			opts.printer.Printf("An error occurred while trying to record the file %s in the catalog", c.destFilePath)
+			return err
+		}
+	}
+
+	if opts.undoManifest != nil {
+		op := manifestOp{Op: "copy", Source: path, Destination: c.destFilePath, Root: c.dest.path, Hash: c.hash, Replaced: c.destFileStat != nil}
+		if c.moved {
+			op.Op = "move"
+		}
+		if err := opts.undoManifest.record(op); err != nil {
+			opts.printer.Printf("An error occurred while trying to record the file %s in the manifest", c.destFilePath)
+			return err
+		}
+	}
+
+	action := "copied"
+	if c.resumeFrom > 0 {
+		opts.printer.Printf("Resumed %s --> %s from %d bytes\n", path, c.destFilePath, c.resumeFrom)
+		c.dest.counts.resumedFiles++
+		counts.resumedFiles++
+		action = "resumed"
+	} else if c.update {
+		opts.printer.Printf("Updated %s --> %s writing %d of %d bytes\n", path, c.destFilePath, c.written, sourceFileStat.Size())
+		action = "updated"
+	} else if c.moved {
+		opts.printer.Printf("Moved %s --> %s\n", path, c.destFilePath)
+		action = "moved"
+	} else if c.unreadable > 0 {
+		opts.printer.Printf("Salvaged %s --> %s, %d unreadable bytes were filled with zeros\n", path, c.destFilePath, c.unreadable)
+		action = "salvaged"
+	} else if c.renamedConflict {
+		opts.printer.Printf("Copied %s --> %s, renamed since a different file of the same name is at the destination\n", path, c.destFilePath)
+		counts.renamedConflicts++
+	} else if c.overwroteConflict {
+		opts.printer.Printf("Copied %s --> %s over a different file of the same name\n", path, c.destFilePath)
+		counts.overwrittenConflicts++
+	} else {
+		opts.printer.Printf("Copied %s --> %s\n", path, c.destFilePath)
+	}
+	// a renamed file was not copied, so it is reported with its size and not counted as copied
+	if c.moved {
+		if opts.output != nil {
+			opts.output.file(path, c.destFilePath, action, sourceFileStat.Size(), nil)
+		}
+		return nil
+	}
+	if opts.output != nil {
+		opts.output.file(path, c.destFilePath, action, c.written, nil)
+	}
+	c.dest.counts.copiedFiles++
+	c.dest.counts.totalBytesCopied += c.written
+	counts.copiedFiles++
+	if c.unreadable > 0 {
+		counts.salvaged = append(counts.salvaged, alertFile{Path: path, Detail: opts.printer.Sprintf("%d bytes unreadable", c.unreadable)})
+	}
+	switch c.method {
+	case copyCloned:
+		counts.clonedFiles++
+	case copyInKernel:
+		counts.kernelCopiedFiles++
+	}
+	counts.totalBytesCopied += c.written
+
+	return nil
+}
+
+func postVisitDir(path string, dirent *godirwalk.Dirent, counts *processedCount) error {
+	counts.visitedDirectories++
+	return nil
+}
+
+// getDestFilePath returns the path of the file relative to the destination along with the date
+// it was sorted by. The date is zero for files sorted by their metadata.
+func getDestFilePath(path string, fileInfo os.FileInfo, opts *sortOptions) (string, time.Time, error) {
+
+	if opts.scheme != nil {
+		var fields interface{}
+		var ok bool
+		var err error
+		if !withTimeout(opts.fileTimeout, func() { fields, ok, err = opts.scheme.fields(opts.runIO, path) }) {
+			return "", time.Time{}, timeoutError(opts.fileTimeout)
+		}
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		if ok {
+			relativePath, err := renderLayout(opts.layout, fields)
+			return relativePath, time.Time{}, err
+		}
+	}
+
+	var destPathBase string
+	if opts.screenshots && opts.runIO.isScreenshot(path) {
+		destPathBase = screenshotsFolder
+	}
+
+	var burst string
+	if opts.bursts != nil {
+		burst = opts.bursts.folder(path, opts)
+	}
+
+	var sortTime time.Time
+	if !withTimeout(opts.fileTimeout, func() { sortTime = opts.runIO.getSortTime(path, fileInfo, opts.dateSources) }) {
+		return "", time.Time{}, timeoutError(opts.fileTimeout)
+	}
+	if opts.dateLayout == nil {
+		return getDateDestFilePath(destPathBase, filepath.Join(burst, fileInfo.Name()), sortTime), sortTime, nil
+	}
+
+	fields := dateFields(fileInfo.Name(), sortTime)
+	fields.SourceFolder = sourceFolder(opts.source, path)
+	relativePath, err := renderLayout(opts.dateLayout, fields)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	// the burst folder goes right above the file wherever the layout puts it
+	if burst != "" {
+		relativePath = filepath.Join(filepath.Dir(relativePath), burst, filepath.Base(relativePath))
+	}
+	return filepath.Join(destPathBase, relativePath), sortTime, nil
+}
+
+func getDateDestFilePath(destPathBase string, name string, sortTime time.Time) string {
+
+	// a file with the name abc.txt which was last modified at May 2 2020 will end up with the path -
+	// <destination directory>/2020/May/2/abc.txt
+	return filepath.Join(destPathBase,
+		strconv.Itoa(sortTime.Year()),
+		sortTime.Month().String(),
+		strconv.Itoa(sortTime.Day()),
+		name)
+}
+
+func isPathValid(path *string, printer *outputPrinter) bool {
+	normalized, err := normalizePath(*path)
+	if err != nil {
+		printer.Printf("An error occurred while trying to resolve the path %s: %v\n", *path, err)
+		return false
+	}
+	*path = normalized
+	if err := checkDir(*path, printer); err != nil {
+		printer.Println(err)
+		return false
+	}
+	return true
+}
+
+// normalizePath returns the cleaned absolute path, relative to the working directory, so that
+// paths with and without a trailing slash or with . and .. in them name the same directory. A
+// leading ~ is expanded to the home directory since the shell leaves it alone in -source=~/Pictures.
+func normalizePath(path string) (string, error) {
+	if strings.Compare(path, "~") == 0 || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return filepath.Abs(path)
+}
+
+// checkDir returns an error unless the path is an existing directory.
+func checkDir(path string, printer *outputPrinter) error {
+
+	fileInfo, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return printer.errorf("The path %s does not exist.", path)
+	}
+	if err != nil {
+		return err
+	}
+	if !fileInfo.IsDir() {
+		return printer.errorf("The path %s is not a directory.", path)
+	}
+	return nil
+}
+
+// copyFile copies the source to the destination and returns the number of bytes written along
+// with the sha256 of the content so that the copy can be verified later without reading the source again.
+func (r *runIO) copyFile(source string, destination string) (int64, string, error) {
+
+	sourceFile, err := r.openSource(source)
+	if err != nil {
+		return 0, "", err
+	}
+	defer sourceFile.Close()
+	sourceStat, err := sourceFile.Stat()
+	if err != nil {
+		return 0, "", err
+	}
+
+	destFile, err := r.stageDestination(sourceFile, destination)
+	if err != nil {
+		return 0, "", err
+	}
+
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(destFile, hash), r.cancellable(r.throttle(sourceFile)))
+	if err = r.unstage(destFile, destination, sourceStat.ModTime(), err); err != nil {
+		return written, "", err
+	}
+	return written, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hashFile returns the sha256 of a file of the source or of a destination.
+func (r *runIO) hashFile(path string) (string, error) {
+
+	file, err := r.openSource(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func printPlanReport(counts *processedCount, opts *sortOptions) {
+	opts.printer.Printf("Completed !\n")
+	opts.printer.Printf("Planned %d copies from %d directories. Skipped %d, Errored %d\n",
+		counts.plannedFiles,
+		counts.visitedDirectories,
+		counts.skippedFiles,
+		counts.erroredFiles)
+	if len(counts.invalidDates) > 0 {
+		opts.printer.Printf("Planned %d files with an invalid date into the unsorted folder\n", len(counts.invalidDates))
+	}
+}
+
+func printReport(counts *processedCount, opts *sortOptions) {
+	opts.printer.Printf("Completed !\n")
+	opts.printer.Printf("Copied %d files from %d directories. Skipped %d, Errored %d, Resumed %d, Bytes copied %d\n",
+		counts.copiedFiles,
+		counts.visitedDirectories,
+		counts.skippedFiles,
+		counts.erroredFiles,
+		counts.resumedFiles,
+		counts.totalBytesCopied)
+
+	if len(opts.destinations) > 1 {
+		for _, dest := range opts.destinations {
+			opts.printer.Printf("  %s: Copied %d, Skipped %d, Errored %d, Resumed %d, Bytes copied %d\n",
+				dest.path,
+				dest.counts.copiedFiles,
+				dest.counts.skippedFiles,
+				dest.counts.erroredFiles,
+				dest.counts.resumedFiles,
+				dest.counts.totalBytesCopied)
+		}
+	}
+	if counts.movedFiles > 0 {
+		opts.printer.Printf("Moved %d files out of the source. Bytes moved %d\n", counts.movedFiles, counts.totalBytesMoved)
+	}
+	if len(counts.conflicts) > 0 {
+		opts.printer.Printf("Skipped %d files since a different file of the same name was at the destination\n", len(counts.conflicts))
+	}
+	if len(counts.infected) > 0 {
+		opts.printer.Printf("Found %d infected files which were not copied\n", len(counts.infected))
+	}
+	if len(counts.invalidDates) > 0 {
+		opts.printer.Printf("Sorted %d files with an invalid date into the unsorted folder, to be sorted by hand:\n", len(counts.invalidDates))
+		for _, file := range counts.invalidDates {
+			opts.printer.Printf("  %s: %s\n", file.Path, file.Detail)
+		}
+	}
+	if counts.renamedConflicts > 0 {
+		opts.printer.Printf("Renamed %d files since a different file of the same name was at the destination\n", counts.renamedConflicts)
+	}
+	if counts.overwrittenConflicts > 0 {
+		opts.printer.Printf("Overwrote %d different files of the same name at the destination\n", counts.overwrittenConflicts)
+	}
+	if counts.clonedFiles > 0 || counts.kernelCopiedFiles > 0 {
+		opts.printer.Printf("Cloned %d and copied %d in the kernel of the %d copied files, the others were written\n",
+			counts.clonedFiles, counts.kernelCopiedFiles, counts.copiedFiles)
+	}
+	if len(counts.salvaged) > 0 {
+		opts.printer.Printf("Salvaged %d files with unreadable parts, which are filled with zeros in the copies:\n", len(counts.salvaged))
+		for _, file := range counts.salvaged {
+			opts.printer.Printf("  %s: %s\n", file.Path, file.Detail)
+		}
+	}
+	if counts.timedOut > 0 {
+		opts.printer.Printf("Gave up on %d files which did not respond within the file timeout\n", counts.timedOut)
+	}
+	if counts.verifiedCopies > 0 {
+		opts.printer.Printf("Verified %d copies by reading them back\n", counts.verifiedCopies)
+	}
+	if counts.manifestChecked > 0 {
+		opts.printer.Printf("Checked %d copies against the MHL manifests of the source\n", counts.manifestChecked)
+	}
+	if counts.postProcessed > 0 || len(counts.postProcessErrors) > 0 {
+		opts.printer.Printf("Post-processed %d files, %d failed\n", counts.postProcessed, len(counts.postProcessErrors))
+		for _, failure := range counts.postProcessErrors {
+			opts.printer.Printf("  %s: %s\n", failure.Path, failure.Error)
+		}
+	}
+}
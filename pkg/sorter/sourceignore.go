@@ -0,0 +1,173 @@
+package sorter
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// sourceIgnoreFile is the name of the ignore files in the source. Like a .gitignore one applies to
+// the directory it is in and the ones below it.
+const sourceIgnoreFile = ".filesorterignore"
+
+// ignoreRule is a line of an ignore file in the gitignore syntax.
+type ignoreRule struct {
+	// base is the directory of the ignore file
+	base    string
+	pattern string
+	// negate re-includes what an earlier rule ignored, for a line starting with a !
+	negate bool
+	// dirOnly matches only directories, for a line ending with a /
+	dirOnly bool
+	// anchored matches the path relative to base instead of the name, for a line with a / before its end
+	anchored bool
+}
+
+// sourceIgnores holds the rules of the ignore files in the source. The ignore file of a directory
+// is read when the walk first gets to it. The rules are looked up both by the scanner of the
+// pipeline for the directories and by the main goroutine for the files.
+type sourceIgnores struct {
+	root  string
+	runIO *runIO
+	mu    sync.Mutex
+	// rules are the rules which apply in a directory, the ones of the directories above it first
+	rules map[string][]ignoreRule
+}
+
+func newSourceIgnores(root string, runIO *runIO) *sourceIgnores {
+	if absRoot, err := filepath.Abs(root); err == nil {
+		root = absRoot
+	}
+	return &sourceIgnores{root: root, runIO: runIO, rules: make(map[string][]ignoreRule)}
+}
+
+// ignored reports whether the file or directory is ignored. Like git the last rule which matches
+// decides, so a later !pattern can re-include a file. A file in an ignored directory is not seen at
+// all since the walk skips the directory. The ignore files themselves are never sorted.
+func (s *sourceIgnores) ignored(filePath string, isDir bool) bool {
+	if !isDir && strings.Compare(filepath.Base(filePath), sourceIgnoreFile) == 0 {
+		return true
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ignored := false
+	for _, rule := range s.rulesFor(filepath.Dir(absPath)) {
+		if rule.matches(absPath, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// reset forgets the rules read so far, for when an ignore file changed during -watch.
+func (s *sourceIgnores) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = make(map[string][]ignoreRule)
+}
+
+// rulesFor must be called with the lock held.
+func (s *sourceIgnores) rulesFor(dir string) []ignoreRule {
+	if rules, ok := s.rules[dir]; ok {
+		return rules
+	}
+	var rules []ignoreRule
+	if dir != s.root {
+		// the files outside of the source, like the ones retried from an error report, have none
+		if relativePath, err := filepath.Rel(s.root, dir); err != nil || strings.HasPrefix(relativePath, "..") {
+			return nil
+		}
+		rules = append(rules, s.rulesFor(filepath.Dir(dir))...)
+	}
+	own, err := s.readIgnoreRules(filepath.Join(dir, sourceIgnoreFile))
+	if err != nil && !os.IsNotExist(err) {
+		s.runIO.printer.Printf("An error occurred while trying to read the ignore file %s: %v\n", filepath.Join(dir, sourceIgnoreFile), err)
+	}
+	rules = append(rules, own...)
+	s.rules[dir] = rules
+	return rules
+}
+
+// readIgnoreRules reads an ignore file. The blank lines and the lines starting with a # are
+// skipped, and a leading \ escapes a # or a ! which starts a pattern.
+func (s *sourceIgnores) readIgnoreRules(ignoreFile string) ([]ignoreRule, error) {
+	file, err := s.runIO.openSource(ignoreFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if strings.Compare(line, "") == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{base: filepath.Dir(ignoreFile)}
+		if strings.HasPrefix(line, "!") {
+			rule.negate, line = true, line[1:]
+		} else if strings.HasPrefix(line, "\\#") || strings.HasPrefix(line, "\\!") {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly, line = true, strings.TrimRight(line, "/")
+		}
+		rule.anchored = strings.Contains(line, "/")
+		rule.pattern = strings.TrimPrefix(line, "/")
+		if strings.Compare(rule.pattern, "") == 0 {
+			continue
+		}
+		if _, err := path.Match(strings.ReplaceAll(rule.pattern, "**", "*"), ""); err != nil {
+			s.runIO.printer.Printf("The pattern %s of the ignore file %s is not valid and is skipped\n", scanner.Text(), ignoreFile)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+func (rule *ignoreRule) matches(absPath string, isDir bool) bool {
+	if rule.dirOnly && !isDir {
+		return false
+	}
+	if !rule.anchored {
+		matched, _ := path.Match(rule.pattern, filepath.Base(absPath))
+		return matched
+	}
+	relativePath, err := filepath.Rel(rule.base, absPath)
+	if err != nil {
+		return false
+	}
+	return matchSegments(strings.Split(rule.pattern, "/"), strings.Split(filepath.ToSlash(relativePath), "/"))
+}
+
+// matchSegments matches a path against a pattern one folder at a time. A ** matches any number of
+// folders, including none.
+func matchSegments(pattern []string, names []string) bool {
+	if len(pattern) == 0 {
+		return len(names) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(names); i++ {
+			if matchSegments(pattern[1:], names[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(names) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(pattern[0], names[0]); !matched {
+		return false
+	}
+	return matchSegments(pattern[1:], names[1:])
+}
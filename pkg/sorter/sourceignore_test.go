@@ -0,0 +1,91 @@
+package sorter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// writeFiles creates the files with their contents under root.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// fileNames lists the names of the regular files under root in order.
+func fileNames(t *testing.T, root string) []string {
+	t.Helper()
+	var names []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			names = append(names, info.Name())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// The directories are checked against the ignore files by the scanner of the pipeline while the
+// files are checked by the main goroutine, so this is run with -race. The many folders keep the
+// scanner reading ignore files while the files found before are checked.
+func TestSourceIgnoresDuringWalk(t *testing.T) {
+	source, destination := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		"keep.jpg":                     "keep",
+		"album/.filesorterignore":      "*.tmp\ncache/\n",
+		"album/photo.jpg":              "photo",
+		"album/draft.tmp":              "draft",
+		"album/cache/thumb.jpg":        "thumb",
+		"album/trip/beach.jpg":         "beach",
+		"album/trip/notes.tmp":         "notes",
+		"album/trip/cache/preview.jpg": "preview",
+		"other/draft.tmp":              "other",
+	}
+	want := []string{"beach.jpg", "draft.tmp", "keep.jpg", "photo.jpg"}
+	for i := 0; i < 100; i++ {
+		files[fmt.Sprintf("album/day%03d/photo%03d.jpg", i, i)] = "day"
+		files[fmt.Sprintf("album/day%03d/photo%03d.tmp", i, i)] = "day"
+		want = append(want, fmt.Sprintf("photo%03d.jpg", i))
+	}
+	sort.Strings(want)
+	writeFiles(t, source, files)
+
+	s, err := New(Options{Source: source, Destinations: []string{destination}, Workers: 4, GlobalIgnore: "none", MinFreeInodes: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fileNames(t, destination)
+	if len(got) != len(want) {
+		t.Fatalf("sorted %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sorted %v, want %v", got, want)
+		}
+	}
+	if report.ErroredFiles != 0 {
+		t.Errorf("%d files errored, want none", report.ErroredFiles)
+	}
+}
@@ -0,0 +1,84 @@
+package sorter
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// tempSuffix is appended to the name of a copy while it is written next to the destination.
+const tempSuffix = ".filesorter.tmp"
+
+// stagePrefix starts the name of a copy while it is written in the staging directory, so that the
+// copies crashed runs left there are told apart from the other files of the directory.
+const stagePrefix = ".filesorter-stage-"
+
+// checkStaging makes sure that a file in the staging directory can be renamed into the destination,
+// which only works when both are on the same file system.
+func checkStaging(dir string, destination string) error {
+	probe, err := os.CreateTemp(dir, ".filesorter-probe-*")
+	if err != nil {
+		return err
+	}
+	probe.Close()
+	target := filepath.Join(destination, filepath.Base(probe.Name()))
+	if err := os.Rename(probe.Name(), target); err != nil {
+		os.Remove(probe.Name())
+		return fmt.Errorf("the staging directory %s is not on the same file system as the destination %s", dir, destination)
+	}
+	return os.Remove(target)
+}
+
+// stageDestination creates the file the copy to the destination is written to. It is a temporary
+// file next to the destination or in the staging directory, which is renamed into the destination
+// once complete, so that a partially copied file never shows up in the archive.
+func (r *runIO) stageDestination(sourceFile *os.File, destination string) (*os.File, error) {
+	if r == nil || r.staging == "" {
+		// the rename would replace the source when it is the destination
+		if sourceStat, err := sourceFile.Stat(); err == nil {
+			if destStat, err := os.Stat(destination); err == nil && os.SameFile(sourceStat, destStat) {
+				return nil, errSameFile
+			}
+		}
+		return os.OpenFile(destination+tempSuffix, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	}
+	// unlike os.CreateTemp the file is created with the same permissions as the copies in place
+	for i := 0; ; i++ {
+		path := filepath.Join(r.staging, stagePrefix+filepath.Base(destination)+"."+strconv.FormatInt(rand.Int63(), 36))
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) && i < 100 {
+			continue
+		}
+		return file, err
+	}
+}
+
+// unstage closes the copy and renames it into the destination with the modified time of the
+// source. It is flushed to the disk before, so that neither a crash nor a power loss leaves a
+// partially written file in the destination which a later run takes for a complete copy by its
+// size. A copy which failed or was cancelled is removed unless it is kept for -resume-partial,
+// which continues it on the next run. Only that copy is written to it again, the others are staged
+// like always. A copy the run gave up on after -file-timeout is removed even when it completed.
+func (r *runIO) unstage(file *os.File, destination string, modTime time.Time, err error) error {
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chtimes(file.Name(), modTime, modTime)
+	}
+	if err == nil {
+		err = r.commit(func() error {
+			return os.Rename(file.Name(), destination)
+		})
+	}
+	if err != nil && (err == errAbandoned || !(r != nil && r.keepPartial && r.staging == "")) {
+		os.Remove(file.Name())
+	}
+	return err
+}
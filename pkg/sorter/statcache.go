@@ -0,0 +1,178 @@
+package sorter
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// dirEntryCache lists every destination directory once and keeps the names of its entries for the
+// run, so that the files which are not there yet, usually most of them, are known without a stat
+// of their own. The names of the files being copied during the run are added as they are prepared.
+type dirEntryCache struct {
+	names map[string]map[string]struct{}
+	// partials removes the temporary copies crashed runs left in the directories as they are listed
+	partials *stalePartials
+}
+
+func newDirEntryCache() *dirEntryCache {
+	return &dirEntryCache{names: make(map[string]map[string]struct{})}
+}
+
+// entries returns the names in the directory. A directory which does not exist has none.
+func (d *dirEntryCache) entries(dir string) (map[string]struct{}, error) {
+	dir = filepath.Clean(dir)
+	if names, ok := d.names[dir]; ok {
+		return names, nil
+	}
+
+	names := make(map[string]struct{})
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		names[entry.Name()] = struct{}{}
+	}
+	if d.partials != nil {
+		d.partials.cleanDir(dir, names)
+	}
+	d.names[dir] = names
+	return names, nil
+}
+
+// stat is like os.Stat but only stats the files whose name is in the listing of their directory.
+func (d *dirEntryCache) stat(path string) (os.FileInfo, error) {
+	dir, name := filepath.Split(path)
+	names, err := d.entries(dir)
+	if err != nil {
+		return os.Stat(path)
+	}
+	if _, ok := names[name]; !ok {
+		return nil, &os.PathError{Op: "stat", Path: path, Err: os.ErrNotExist}
+	}
+	return os.Stat(path)
+}
+
+// reset forgets the listings, for -watch where the destination may change between the files.
+func (d *dirEntryCache) reset() {
+	d.names = make(map[string]map[string]struct{})
+}
+
+// add records that the file is being created.
+func (d *dirEntryCache) add(path string) {
+	dir, name := filepath.Split(path)
+	if names, ok := d.names[filepath.Clean(dir)]; ok {
+		names[name] = struct{}{}
+	}
+}
+
+// createdDirs are the directories created during the run so that a folder shared by many files is
+// created only once. On network destinations every MkdirAll is a round-trip per path element.
+type createdDirs struct {
+	dirs map[string]struct{}
+	// mode is set on the new directories regardless of the umask when -dir-mode is passed
+	mode os.FileMode
+	// preserveOwner gives the new directories the owner and group of the directory they are in
+	preserveOwner bool
+}
+
+func newCreatedDirs(mode os.FileMode, preserveOwner bool) *createdDirs {
+	return &createdDirs{dirs: make(map[string]struct{}), mode: mode, preserveOwner: preserveOwner}
+}
+
+// reset forgets the created directories, for -watch where they may be removed between the files.
+func (d *createdDirs) reset() {
+	d.dirs = make(map[string]struct{})
+}
+
+// parseDirMode parses an octal mode like 0750, or 2775 to also set the setgid bit so that the
+// files created in the directories get its group.
+func parseDirMode(value string, printer *outputPrinter) (os.FileMode, error) {
+	if strings.Compare(value, "") == 0 {
+		return 0, nil
+	}
+	bits, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || bits == 0 || bits > 07777 {
+		return 0, printer.errorf("The directory mode %s is not valid. Use an octal mode like 0750\n", value)
+	}
+	mode := os.FileMode(bits & 0777)
+	if bits&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if bits&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if bits&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode, nil
+}
+
+// mkdirAll creates the directory inside the destination. It is checked to not lead out of the
+// destination the first time.
+func (d *createdDirs) mkdirAll(destination string, dir string) error {
+	dir = filepath.Clean(dir)
+	if _, ok := d.dirs[dir]; ok {
+		return nil
+	}
+	if err := checkInsideDestination(destination, dir); err != nil {
+		return err
+	}
+	var err error
+	if d.mode == 0 && !d.preserveOwner {
+		err = os.MkdirAll(dir, os.ModePerm)
+	} else {
+		err = d.mkdirAllWith(dir)
+	}
+	if err != nil {
+		return err
+	}
+	d.dirs[dir] = struct{}{}
+	return nil
+}
+
+// mkdirAllWith creates the missing directories one by one from the top so that the mode and the
+// owner are set on each of them, while the existing ones are left as they are.
+func (d *createdDirs) mkdirAllWith(dir string) error {
+	var missing []string
+	existing := dir
+	for {
+		if _, err := os.Stat(existing); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		missing = append(missing, existing)
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		existing = parent
+	}
+	parentInfo, err := os.Stat(existing)
+	if err != nil {
+		return err
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := os.Mkdir(missing[i], os.ModePerm); err != nil {
+			// another run may have created it in the meantime
+			if os.IsExist(err) {
+				continue
+			}
+			return err
+		}
+		if d.mode != 0 {
+			if err := os.Chmod(missing[i], d.mode); err != nil {
+				return err
+			}
+		}
+		if d.preserveOwner {
+			if err := chownLike(missing[i], parentInfo); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
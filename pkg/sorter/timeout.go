@@ -0,0 +1,107 @@
+package sorter
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// errTimeout is returned for a file which did not respond within -file-timeout.
+var errTimeout = errors.New("the file did not respond in time, the disk or the mount may be stuck")
+
+// errAbandoned is returned by a copy which finished after -file-timeout gave up on it.
+var errAbandoned = errors.New("the copy was given up on")
+
+// timeoutError tells how long the run waited for the file.
+func timeoutError(timeout time.Duration) error {
+	return fmt.Errorf("%w after %v", errTimeout, timeout)
+}
+
+// withTimeout runs work and stops waiting for it after the timeout, in which case it returns
+// false. A read stuck on a dying disk or a hung network mount can not be interrupted, so the work
+// goes on in the background until the read returns, if ever, and the process exits without waiting
+// for it. It must not change anything the run uses once it gave up on it. work runs in place when
+// there is no timeout.
+func withTimeout(timeout time.Duration, work func()) bool {
+	if timeout <= 0 {
+		work()
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		work()
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// abandonment tells a copy that the run gave up on it. A finished copy is renamed into the
+// destination while the lock is held, so that once abandon returns nothing shows up there anymore.
+type abandonment struct {
+	mu        sync.Mutex
+	abandoned bool
+}
+
+func (a *abandonment) abandon() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.abandoned = true
+}
+
+// commit renames a finished copy into the destination unless the run gave up on the copy, in which
+// case it returns errAbandoned and the copy is discarded.
+func (r *runIO) commit(rename func() error) error {
+	if r == nil || r.abandonment == nil {
+		return rename()
+	}
+	r.abandonment.mu.Lock()
+	defer r.abandonment.mu.Unlock()
+	if r.abandonment.abandoned {
+		return errAbandoned
+	}
+	return rename()
+}
+
+// copyWithin writes the copies like copy but gives up on them after the timeout. The copy is
+// written into copies of its own so that one which is given up on can not change them later, and
+// with a runIO of its own which tells it that it was given up on so that it does not rename what it
+// wrote into the destination if the stuck read returns after all.
+func (job *copyJob) copyWithin(timeout time.Duration) {
+	if timeout <= 0 {
+		job.copy()
+		return
+	}
+	work := *job
+	work.copies = make([]*fileCopy, len(job.copies))
+	for i, c := range job.copies {
+		copied := *c
+		work.copies[i] = &copied
+	}
+	var workIO runIO
+	if job.runIO != nil {
+		workIO = *job.runIO
+	}
+	workIO.abandonment = &abandonment{}
+	work.runIO = &workIO
+	if withTimeout(timeout, work.copy) {
+		for i, c := range work.copies {
+			*job.copies[i] = *c
+		}
+		return
+	}
+	workIO.abandonment.abandon()
+	job.runIO.printer.Printf("Gave up on the copy of %s after %v\n", job.path, timeout)
+	for _, c := range job.copies {
+		if c.err == nil {
+			c.err = timeoutError(timeout)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+//go:build linux || darwin || freebsd
+
+package sorter
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// The source is a named pipe, which blocks the copy like a stuck mount until something is written
+// to it. That only happens after the run gave up on the copy.
+func TestCopyWithinDiscardsAbandonedCopy(t *testing.T) {
+	source, destDir := filepath.Join(t.TempDir(), "IMG_0001.jpg"), t.TempDir()
+	if err := syscall.Mkfifo(source, 0644); err != nil {
+		t.Skipf("named pipes are not supported: %v", err)
+	}
+	c := &fileCopy{dest: &destination{path: destDir}, destFilePath: filepath.Join(destDir, "IMG_0001.jpg")}
+	job := &copyJob{path: source, copies: []*fileCopy{c}, runIO: &runIO{printer: &outputPrinter{out: io.Discard}}}
+
+	job.copyWithin(50 * time.Millisecond)
+	if !errors.Is(c.err, errTimeout) {
+		t.Fatalf("the copy returned %v, want a timeout", c.err)
+	}
+
+	writer, err := os.OpenFile(source, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte("photo")); err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+
+	// the abandoned copy finishes in the background
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(c.destFilePath); err == nil {
+			t.Fatalf("the abandoned copy was renamed into %s", c.destFilePath)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	entries, err := os.ReadDir(destDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		t.Errorf("the abandoned copy left %s behind", entry.Name())
+	}
+}
@@ -0,0 +1,785 @@
+package sorter
+
+import (
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// translations are keyed by the English format string passed to printer.Printf. The verbs of a
+// translation have to be in the same order as in the English message.
+var translations = map[language.Tag]map[string]string{
+	language.German: {
+		"Usage: filesorter [sort] <source path> <destination path> [file types]\n": "Aufruf: filesorter [sort] <Quellpfad> <Zielpfad> [Dateitypen]\n",
+		"The path %s does not exist.":                "Der Pfad %s existiert nicht.",
+		"The path %s is not a directory.":            "Der Pfad %s ist kein Verzeichnis.",
+		"The preset %s is not supported\n":           "Die Vorgabe %s wird nicht unterstützt\n",
+		"The scheme %s is not supported\n":           "Das Schema %s wird nicht unterstützt\n",
+		"Completed !\n":                              "Fertig !\n",
+		"Copied %s --> %s\n":                         "Kopiert %s --> %s\n",
+		"Resumed %s --> %s from %d bytes\n":          "Fortgesetzt %s --> %s ab %d Bytes\n",
+		"Updated %s --> %s writing %d of %d bytes\n": "Aktualisiert %s --> %s, %d von %d Bytes geschrieben\n",
+		"The partial file %s does not match the source and will be copied again\n":                   "Die unvollständige Datei %s passt nicht zur Quelle und wird neu kopiert\n",
+		"An error occurred while trying to copy the file %s to %s":                                   "Beim Kopieren der Datei %s nach %s ist ein Fehler aufgetreten",
+		"An error occurred while trying to open the catalog %s: %v\n":                                "Beim Öffnen des Katalogs %s ist ein Fehler aufgetreten: %v\n",
+		"Copied %d files from %d directories. Skipped %d, Errored %d, Resumed %d, Bytes copied %d\n": "%d Dateien aus %d Verzeichnissen kopiert. Übersprungen %d, Fehler %d, Fortgesetzt %d, Bytes kopiert %d\n",
+		"  %s: Copied %d, Skipped %d, Errored %d, Resumed %d, Bytes copied %d\n":                     "  %s: Kopiert %d, Übersprungen %d, Fehler %d, Fortgesetzt %d, Bytes kopiert %d\n",
+		"Planned %d copies from %d directories. Skipped %d, Errored %d\n":                            "%d Kopien aus %d Verzeichnissen geplant. Übersprungen %d, Fehler %d\n",
+		"Only in source: %s --> %s\n":                                                                "Nur in der Quelle: %s --> %s\n",
+		"Differs: %s --> %s (%d and %d bytes)\n":                                                     "Unterschiedlich: %s --> %s (%d und %d Bytes)\n",
+		"Only in destination: %s\n":                                                                  "Nur im Ziel: %s\n",
+		"Only in source %d, Only in destination %d, Differ %d, Same %d. Skipped %d, Errored %d\n":    "Nur in der Quelle %d, Nur im Ziel %d, Unterschiedlich %d, Gleich %d. Übersprungen %d, Fehler %d\n",
+		"Missing %s\n":              "Fehlt %s\n",
+		"Corrupted %s\n":            "Beschädigt %s\n",
+		"Repaired %s --> %s\n":      "Repariert %s --> %s\n",
+		"Could not repair %s: %v\n": "%s konnte nicht repariert werden: %v\n",
+		"Verified %d of %d files. Missing %d, Corrupted %d, Repaired %d\n":                                                             "%d von %d Dateien geprüft. Fehlend %d, Beschädigt %d, Repariert %d\n",
+		"The following files could not be repaired:\n":                                                                                 "Die folgenden Dateien konnten nicht repariert werden:\n",
+		"Usage: filesorter verify -catalog <catalog path> [-sample <count or percentage>] [-repair -destination <destination path>]\n": "Aufruf: filesorter verify -catalog <Katalogpfad> [-sample <Anzahl oder Prozentsatz>] [-repair -destination <Zielpfad>]\n",
+		"The sample %s is not a valid percentage\n":                                                                                    "Die Stichprobe %s ist kein gültiger Prozentsatz\n",
+		"The sample %s is not a valid count\n":                                                                                         "Die Stichprobe %s ist keine gültige Anzahl\n",
+		"the source is not known since the file was added by index\n":                                                                  "die Quelle ist unbekannt, da die Datei mit index hinzugefügt wurde\n",
+		"the file is not in the destination %s\n":                                                                                      "die Datei liegt nicht im Ziel %s\n",
+		"the source %s is no longer available\n":                                                                                       "die Quelle %s ist nicht mehr vorhanden\n",
+		"the source %s has changed since it was copied\n":                                                                              "die Quelle %s hat sich seit dem Kopieren geändert\n",
+		"missing":                            "fehlt",
+		"unreadable: %v":                     "nicht lesbar: %v",
+		"corrupted: sha256 %s instead of %s": "beschädigt: sha256 %s statt %s",
+		", could not be repaired: %v":        ", konnte nicht repariert werden: %v",
+		", repaired":                         ", repariert",
+		"%d corrupted and %d missing files found by verify": "%d beschädigte und %d fehlende Dateien von verify gefunden",
+		"Found %d files\n":        "%d Dateien gefunden\n",
+		"The run was cancelled\n": "Der Lauf wurde abgebrochen\n",
+		"       filesorter diff <source path> <destination path> [file types]\n":                  "       filesorter diff <Quellpfad> <Zielpfad> [Dateitypen]\n",
+		"       filesorter plan -out <plan path> <source path> <destination path> [file types]\n": "       filesorter plan -out <Planpfad> <Quellpfad> <Zielpfad> [Dateitypen]\n",
+		"  %d%% of the files are sorted differently, like %s\n":                                   "  %d%% der Dateien sind anders einsortiert, wie %s\n",
+		"  Copied %s, Skipped %s, Errored %s, Resumed %d, Bytes copied %d in %s\n":                "  Kopiert %s, Übersprungen %s, Fehler %s, Fortgesetzt %d, Bytes kopiert %d in %s\n",
+		"  Did not finish\n":                     "  Nicht beendet\n",
+		"  copied: %s by %s\n":                   "  kopiert: %s von %s\n",
+		"  date used: %s\n":                      "  verwendetes Datum: %s\n",
+		"  destination: %d bytes, modified %s\n": "  Ziel: %d Bytes, geändert %s\n",
+		"  not in the catalog\n":                 "  nicht im Katalog\n",
+		"  size: %d bytes, sha256: %s\n":         "  Größe: %d Bytes, sha256: %s\n",
+		"  source %s: %d bytes, modified %s\n":   "  Quelle %s: %d Bytes, geändert %s\n",
+		"  source: %s\n":                         "  Quelle: %s\n",
+		"  volume: %s\n":                         "  Datenträger: %s\n",
+		"%d bytes unreadable":                    "%d Bytes nicht lesbar",
+		"%d copies did not match their source":   "%d Kopien stimmten nicht mit ihrer Quelle überein",
+		"%d copies of %d bytes:\n":               "%d Kopien mit %d Bytes:\n",
+		"%d files are duplicates in %d groups. Deduplicating them would save %d bytes (%.1f%%)\n": "%d Dateien sind Duplikate in %d Gruppen. Eine Deduplizierung würde %d Bytes sparen (%.1f%%)\n",
+		"%d infected files were found in %s":                                                      "%d infizierte Dateien wurden in %s gefunden",
+		"%d%% of the files are sorted like %s\n":                                                  "%d%% der Dateien sind wie %s einsortiert\n",
+		"%s --> %s (%d bytes, modified %s)\n":                                                     "%s --> %s (%d Bytes, geändert %s)\n",
+		"Added: %s --> %s\n":                                                                      "Hinzugefügt: %s --> %s\n",
+		"Already at":                                                                              "Bereits unter",
+		"Already copied %s to %s, recorded %s as an alias\n":                                      "%s wurde bereits nach %s kopiert, %s als Alias erfasst\n",
+		"Bytes copied":                                                                            "Bytes kopiert",
+		"Checked %d copies against the MHL manifests of the source\n":                             "%d Kopien mit den MHL-Manifesten der Quelle abgeglichen\n",
+		"Cloned %d and copied %d in the kernel of the %d copied files, the others were written\n": "%d geklont und %d im Kernel kopiert von den %d kopierten Dateien, die anderen wurden geschrieben\n",
+		"Compared with the plan %s: %d added, %d removed, %d re-routed\n":                         "Verglichen mit dem Plan %s: %d hinzugefügt, %d entfernt, %d umgeleitet\n",
+		"Conflicts": "Konflikte",
+		"Copied %s --> %s in %d parts over a different file of the same name\n":                     "Kopiert %s --> %s in %d Teilen über eine andere Datei gleichen Namens\n",
+		"Copied %s --> %s in %d parts\n":                                                            "Kopiert %s --> %s in %d Teilen\n",
+		"Copied %s --> %s over a different file of the same name\n":                                 "Kopiert %s --> %s über eine andere Datei gleichen Namens\n",
+		"Copied %s --> %s, renamed since a different file of the same name is at the destination\n": "Kopiert %s --> %s, umbenannt, da eine andere Datei gleichen Namens im Ziel liegt\n",
+		"Copied files per day": "Kopierte Dateien pro Tag",
+		"Copied files":         "Kopierte Dateien",
+		"Corrupted copies":     "Beschädigte Kopien",
+		"Could not read %d bytes of %s in the block at %d, they are filled with zeros\n": "%d Bytes von %s im Block bei %d konnten nicht gelesen werden, sie werden mit Nullen gefüllt\n",
+		"Counting the files of %s\n": "Zähle die Dateien von %s\n",
+		"Deleted %s\n":               "Gelöscht %s\n",
+		"Destination: %s\n":          "Ziel: %s\n",
+		"Destinations":               "Ziele",
+		"Differs: %s --> %s (same size, different content)\n": "Unterschiedlich: %s --> %s (gleiche Größe, anderer Inhalt)\n",
+		"Duplicates":                "Duplikate",
+		"Duration":                  "Dauer",
+		"Error":                     "Fehler",
+		"Errored files":             "Fehlerhafte Dateien",
+		"Errors":                    "Fehler",
+		"Excluded names: %s\n":      "Ausgeschlossene Namen: %s\n",
+		"Exported %d files to %s\n": "%d Dateien nach %s exportiert\n",
+		"File":                      "Datei",
+		"Found %d files in the catalog and %d other files at the destination\n":      "%d Dateien im Katalog und %d weitere Dateien im Ziel gefunden\n",
+		"Found %d files with %s to sort\n":                                           "%d Dateien mit %s zum Sortieren gefunden\n",
+		"Found %d infected files which were not copied\n":                            "%d infizierte Dateien gefunden, die nicht kopiert wurden\n",
+		"Found %d partial copies left by earlier runs in the staging directory %s\n": "%d unvollständige Kopien früherer Läufe im Staging-Verzeichnis %s gefunden\n",
+		"Found %d runs\n": "%d Läufe gefunden\n",
+		"Gave up on %d files which did not respond within the file timeout\n":                            "%d Dateien aufgegeben, die nicht innerhalb der Zeitgrenze antworteten\n",
+		"Gave up on the copy of %s after %v\n":                                                           "Kopie von %s nach %v aufgegeben\n",
+		"Imported %d of %d files. The others are already in the catalog with the same or a newer copy\n": "%d von %d Dateien importiert. Die anderen sind mit der gleichen oder einer neueren Kopie bereits im Katalog\n",
+		"In a profile of the config file:\n    layout: '%s'\n":                                           "In einem Profil der Konfigurationsdatei:\n    layout: '%s'\n",
+		"Indexed %d files. Already in the catalog %d, Errored %d\n":                                      "%d Dateien indiziert. Bereits im Katalog %d, Fehler %d\n",
+		"Indexed %s\n":   "Indiziert %s\n",
+		"Infected files": "Infizierte Dateien",
+		"Invalid dates":  "Ungültige Daten",
+		"Keep the [d]estination, replace it with the [s]ource, keep [b]oth, decide [l]ater or [q]uit? ": "Ziel behalten [d], durch die Quelle ersetzen [s], beide behalten [b], später entscheiden [l] oder beenden [q]? ",
+		"Kept %s and left out %s\n":                                        "%s behalten und %s ausgelassen\n",
+		"Kept %s, a file is at its source %s again\n":                      "Behalten %s, an seiner Quelle %s liegt wieder eine Datei\n",
+		"Kept %s, it changed since it was copied\n":                        "Behalten %s, es hat sich seit dem Kopieren geändert\n",
+		"Kept %s, it replaced an earlier file which can not be restored\n": "Behalten %s, es hat eine frühere Datei ersetzt, die nicht wiederhergestellt werden kann\n",
+		"Looked at %d files in %s\n":                                       "%d Dateien in %s angesehen\n",
+		"Moved %d files out of the source. Bytes moved %d\n":               "%d Dateien aus der Quelle verschoben. Bytes verschoben %d\n",
+		"Moved %s --> %s\n":                                                "Verschoben %s --> %s\n",
+		"Moved files":                                                      "Verschobene Dateien",
+		"No dates were found in the folders of %d%% of the files, like %s. The layout could not be detected\n": "In den Ordnern von %d%% der Dateien wurde kein Datum gefunden, wie %s. Die Struktur konnte nicht erkannt werden\n",
+		"No duplicates":        "Keine Duplikate",
+		"No errors":            "Keine Fehler",
+		"No files were copied": "Es wurden keine Dateien kopiert",
+		"Overwrote %d different files of the same name at the destination\n":       "%d andere Dateien gleichen Namens im Ziel überschrieben\n",
+		"Pausing after the current file. Send SIGUSR2 to resume (kill -USR2 %d)\n": "Pause nach der aktuellen Datei. Zum Fortsetzen SIGUSR2 senden (kill -USR2 %d)\n",
+		"Planned %d files with an invalid date into the unsorted folder\n":         "%d Dateien mit ungültigem Datum in den unsortierten Ordner geplant\n",
+		"Planned %s %s --> %s\n":                                 "Geplant %s %s --> %s\n",
+		"Post-processed %d files, %d failed\n":                   "%d Dateien nachbearbeitet, %d fehlgeschlagen\n",
+		"Post-processed %s\n":                                    "Nachbearbeitet %s\n",
+		"Quarantined %s --> %s, it is infected with %s\n":        "In Quarantäne %s --> %s, es ist infiziert mit %s\n",
+		"Re-routed: %s from %s to %s\n":                          "Umgeleitet: %s von %s nach %s\n",
+		"Read the hashes of %d files from the MHL manifest %s\n": "Die Hashes von %d Dateien aus dem MHL-Manifest %s gelesen\n",
+		"Removed %d copies and restored %d sources. Kept %d, Already gone %d, Errored %d\n": "%d Kopien entfernt und %d Quellen wiederhergestellt. Behalten %d, Bereits weg %d, Fehler %d\n",
+		"Removed %s\n": "Entfernt %s\n",
+		"Removed the moved file %s from the source, the same file is already at %s\n": "Die verschobene Datei %s wurde aus der Quelle entfernt, dieselbe Datei liegt bereits unter %s\n",
+		"Removed the partial copy %s left by an earlier run\n":                        "Die unvollständige Kopie %s eines früheren Laufs wurde entfernt\n",
+		"Removed: %s --> %s\n": "Entfernt: %s --> %s\n",
+		"Renamed %d files since a different file of the same name was at the destination\n":                                       "%d Dateien umbenannt, da eine andere Datei gleichen Namens im Ziel lag\n",
+		"Resolve the conflicts later with 'filesorter resolve %s'\n":                                                              "Die Konflikte später mit 'filesorter resolve %s' auflösen\n",
+		"Resolved %d conflicts. Kept both %d, replaced %d destination files, kept %d. %d were gone or the same and %d are left\n": "%d Konflikte aufgelöst. Beide behalten %d, %d Zieldateien ersetzt, behalten %d. %d waren weg oder gleich und %d bleiben übrig\n",
+		"Resumed files": "Fortgesetzte Dateien",
+		"Resuming the run started at %s which was interrupted after copying %d files\n": "Setze den um %s gestarteten Lauf fort, der nach %d kopierten Dateien unterbrochen wurde\n",
+		"Resuming\n":                    "Wird fortgesetzt\n",
+		"Run %d: %s of %s started %s\n": "Lauf %d: %s von %s gestartet %s\n",
+		"Salvaged %d files with unreadable parts, which are filled with zeros in the copies:\n": "%d Dateien mit unlesbaren Teilen gerettet, die in den Kopien mit Nullen gefüllt sind:\n",
+		"Salvaged %s --> %s, %d unreadable bytes were filled with zeros\n":                      "Gerettet %s --> %s, %d unlesbare Bytes wurden mit Nullen gefüllt\n",
+		"Salvaged files":           "Gerettete Dateien",
+		"Sampled %d of %d files\n": "%d von %d Dateien als Stichprobe genommen\n",
+		"Scanned %d files of %d bytes. Hashed %d, Read from the catalog %d, Errored %d\n":        "%d Dateien mit %d Bytes durchsucht. Gehasht %d, Aus dem Katalog gelesen %d, Fehler %d\n",
+		"Skipped %d files since a different file of the same name was at the destination\n":      "%d Dateien übersprungen, da eine andere Datei gleichen Namens im Ziel lag\n",
+		"Skipped %d unchanged of %d directories\n":                                               "%d unveränderte von %d Verzeichnissen übersprungen\n",
+		"Skipped %s --> %s, a different file of the same name is already there\n":                "Übersprungen %s --> %s, dort liegt bereits eine andere Datei gleichen Namens\n",
+		"Skipped %s, it is infected with %s\n":                                                   "Übersprungen %s, es ist infiziert mit %s\n",
+		"Skipped files":                                                                          "Übersprungene Dateien",
+		"Sorted %d files with an invalid date into the unsorted folder, to be sorted by hand:\n": "%d Dateien mit ungültigem Datum in den unsortierten Ordner sortiert, um sie von Hand zu sortieren:\n",
+		"Sorting %d of the %d files left in the watch queue %s\n":                                "Sortiere %d der %d Dateien, die in der Warteschlange %s übrig sind\n",
+		"Source":       "Quelle",
+		"Source: %s\n": "Quelle: %s\n",
+		"Started":      "Gestartet",
+		"Status":       "Status",
+		"Stopping the run. Press Ctrl-C again to quit right away\n":   "Der Lauf wird beendet. Erneut Strg-C drücken, um sofort abzubrechen\n",
+		"Suggested options: %s\n":                                     "Vorgeschlagene Optionen: %s\n",
+		"Suggested options: -scheme ebook\n":                          "Vorgeschlagene Optionen: -scheme ebook\n",
+		"Suggested options: -scheme music\n":                          "Vorgeschlagene Optionen: -scheme music\n",
+		"Summary":                                                     "Zusammenfassung",
+		"Timed out files":                                             "Zeitüberschreitungen",
+		"Types: %s. Excluded: %s. Without an extension: %s":           "Typen: %s. Ausgeschlossen: %s. Ohne Endung: %s",
+		"Uploaded %s --> %s over a different file of the same name\n": "Hochgeladen %s --> %s über eine andere Datei gleichen Namens\n",
+		"Uploaded %s --> %s\n":                                        "Hochgeladen %s --> %s\n",
+		"Verified %d copies by reading them back\n":                   "%d Kopien durch Zurücklesen geprüft\n",
+		"Watching %s for new files. Files are sorted once they did not change for %v\n":                                                             "Überwache %s auf neue Dateien. Dateien werden sortiert, wenn sie sich %v lang nicht geändert haben\n",
+		"a run before runs were recorded":                                                                                                           "ein Lauf, bevor Läufe erfasst wurden",
+		"does not match the %s of %s":                                                                                                               "passt nicht zum %s von %s",
+		"filesorter report of %s":                                                                                                                   "filesorter-Bericht vom %s",
+		"run %d (%s of %s started %s)":                                                                                                              "Lauf %d (%s von %s gestartet %s)",
+		"Pruned %d files (%d bytes) older than %s. Kept %d, Without a date %d, Errored %d\n":                                                        "%d Dateien (%d Bytes) älter als %s entfernt. Behalten %d, Ohne Datum %d, Fehler %d\n",
+		"Would prune %d files (%d bytes) older than %s. Would keep %d, Without a date %d, Errored %d\n":                                             "Würde %d Dateien (%d Bytes) älter als %s entfernen. Würde behalten %d, Ohne Datum %d, Fehler %d\n",
+		"An error occurred while trying to access the destination %s: %v\n":                                                                         "Fehler beim Versuch, auf das Ziel %s zuzugreifen: %v\n",
+		"An error occurred while trying to apply %s --> %s: %v\n":                                                                                   "Fehler beim Versuch, %s --> %s anzuwenden: %v\n",
+		"An error occurred while trying to compare the file %s with %s":                                                                             "Fehler beim Versuch, die Datei %s mit %s zu vergleichen",
+		"An error occurred while trying to compare the moved file %s with %s":                                                                       "Fehler beim Versuch, die verschobene Datei %s mit %s zu vergleichen",
+		"An error occurred while trying to connect to clamd at %s: %v\n":                                                                            "Fehler beim Versuch, sich mit clamd unter %s zu verbinden: %v\n",
+		"An error occurred while trying to copy the file %s to %s in parts":                                                                         "Fehler beim Versuch, die Datei %s in Teilen nach %s zu kopieren",
+		"An error occurred while trying to copy the file %s to %s: %v\n":                                                                            "Fehler beim Versuch, die Datei %s nach %s zu kopieren: %v\n",
+		"An error occurred while trying to count the files of the source %s: %v\n":                                                                  "Fehler beim Versuch, die Dateien der Quelle %s zu zählen: %v\n",
+		"An error occurred while trying to create directories for the file %s":                                                                      "Fehler beim Versuch, Verzeichnisse für die Datei %s zu erstellen",
+		"An error occurred while trying to create the file %s: %v\n":                                                                                "Fehler beim Versuch, die Datei %s zu erstellen: %v\n",
+		"An error occurred while trying to export the catalog %s: %v\n":                                                                             "Fehler beim Versuch, den Katalog %s zu exportieren: %v\n",
+		"An error occurred while trying to find another name for the file %s":                                                                       "Fehler beim Versuch, einen anderen Namen für die Datei %s zu finden",
+		"An error occurred while trying to get the destination path of the file %s":                                                                 "Fehler beim Versuch, den Zielpfad der Datei %s zu ermitteln",
+		"An error occurred while trying to hash the file %s":                                                                                        "Fehler beim Versuch, den Hash der Datei %s zu berechnen",
+		"An error occurred while trying to hash the file %s: %v\n":                                                                                  "Fehler beim Versuch, den Hash der Datei %s zu berechnen: %v\n",
+		"An error occurred while trying to import %s: %v\n":                                                                                         "Fehler beim Versuch, %s zu importieren: %v\n",
+		"An error occurred while trying to index the file %s: %v\n":                                                                                 "Fehler beim Versuch, die Datei %s zu indizieren: %v\n",
+		"An error occurred while trying to listen on the control socket %s: %v\n":                                                                   "Fehler beim Versuch, am Steuersocket %s zu lauschen: %v\n",
+		"An error occurred while trying to look up the file %s in the catalog":                                                                      "Fehler beim Versuch, die Datei %s im Katalog nachzuschlagen",
+		"An error occurred while trying to open the file %s: %v\n":                                                                                  "Fehler beim Versuch, die Datei %s zu öffnen: %v\n",
+		"An error occurred while trying to open the manifest %s: %v\n":                                                                              "Fehler beim Versuch, das Manifest %s zu öffnen: %v\n",
+		"An error occurred while trying to post-process the file %s with %s: %v\n":                                                                  "Fehler beim Versuch, die Datei %s mit %s nachzubearbeiten: %v\n",
+		"An error occurred while trying to protect the copied file %s":                                                                              "Fehler beim Versuch, die kopierte Datei %s zu schützen",
+		"An error occurred while trying to prune the file %s: %v\n":                                                                                 "Fehler beim Versuch, die Datei %s zu entfernen: %v\n",
+		"An error occurred while trying to quarantine the infected file %s":                                                                         "Fehler beim Versuch, die infizierte Datei %s in Quarantäne zu verschieben",
+		"An error occurred while trying to query the catalog %s: %v\n":                                                                              "Fehler beim Versuch, den Katalog %s abzufragen: %v\n",
+		"An error occurred while trying to read %s: %v\n":                                                                                           "Fehler beim Versuch, %s zu lesen: %v\n",
+		"An error occurred while trying to read the %s date of the file %s: %v\n":                                                                   "Fehler beim Versuch, das Datum %s der Datei %s zu lesen: %v\n",
+		"An error occurred while trying to read the MHL manifest %s: %v\n":                                                                          "Fehler beim Versuch, das MHL-Manifest %s zu lesen: %v\n",
+		"An error occurred while trying to read the S3 credentials of the profile %s: %v\n":                                                         "Fehler beim Versuch, die S3-Zugangsdaten des Profils %s zu lesen: %v\n",
+		"An error occurred while trying to read the catalog %s: %v\n":                                                                               "Fehler beim Versuch, den Katalog %s zu lesen: %v\n",
+		"An error occurred while trying to read the config file %s: %v\n":                                                                           "Fehler beim Versuch, die Konfigurationsdatei %s zu lesen: %v\n",
+		"An error occurred while trying to read the conflicts %s: %v\n":                                                                             "Fehler beim Versuch, die Konflikte %s zu lesen: %v\n",
+		"An error occurred while trying to read the destination folder of the file %s":                                                              "Fehler beim Versuch, den Zielordner der Datei %s zu lesen",
+		"An error occurred while trying to read the error report %s: %v\n":                                                                          "Fehler beim Versuch, den Fehlerbericht %s zu lesen: %v\n",
+		"An error occurred while trying to read the file %s: %v\n":                                                                                  "Fehler beim Versuch, die Datei %s zu lesen: %v\n",
+		"An error occurred while trying to read the ignore file %s: %v\n":                                                                           "Fehler beim Versuch, die Ignorierdatei %s zu lesen: %v\n",
+		"An error occurred while trying to read the manifest %s: %v\n":                                                                              "Fehler beim Versuch, das Manifest %s zu lesen: %v\n",
+		"An error occurred while trying to read the parts of the file %s":                                                                           "Fehler beim Versuch, die Teile der Datei %s zu lesen",
+		"An error occurred while trying to read the plan %s: %v\n":                                                                                  "Fehler beim Versuch, den Plan %s zu lesen: %v\n",
+		"An error occurred while trying to read the staging directory %s: %v\n":                                                                     "Fehler beim Versuch, das Staging-Verzeichnis %s zu lesen: %v\n",
+		"An error occurred while trying to read the watch queue %s: %v\n":                                                                           "Fehler beim Versuch, die Warteschlange %s zu lesen: %v\n",
+		"An error occurred while trying to record the alias %s in the catalog":                                                                      "Fehler beim Versuch, den Alias %s im Katalog zu erfassen",
+		"An error occurred while trying to record the file %s in the catalog":                                                                       "Fehler beim Versuch, die Datei %s im Katalog zu erfassen",
+		"An error occurred while trying to record the file %s in the manifest":                                                                      "Fehler beim Versuch, die Datei %s im Manifest zu erfassen",
+		"An error occurred while trying to record the moved file %s in the manifest":                                                                "Fehler beim Versuch, die verschobene Datei %s im Manifest zu erfassen",
+		"An error occurred while trying to record the run in the catalog %s: %v\n":                                                                  "Fehler beim Versuch, den Lauf im Katalog %s zu erfassen: %v\n",
+		"An error occurred while trying to record the scanned directories in the catalog: %v\n":                                                     "Fehler beim Versuch, die durchsuchten Verzeichnisse im Katalog zu erfassen: %v\n",
+		"An error occurred while trying to remove the moved file %s from the source":                                                                "Fehler beim Versuch, die verschobene Datei %s aus der Quelle zu entfernen",
+		"An error occurred while trying to remove the partial copy %s: %v\n":                                                                        "Fehler beim Versuch, die unvollständige Kopie %s zu entfernen: %v\n",
+		"An error occurred while trying to resolve the conflict of %s and %s: %v\n":                                                                 "Fehler beim Versuch, den Konflikt von %s und %s aufzulösen: %v\n",
+		"An error occurred while trying to resolve the destination %s: %v\n":                                                                        "Fehler beim Versuch, das Ziel %s aufzulösen: %v\n",
+		"An error occurred while trying to resolve the path %s: %v\n":                                                                               "Fehler beim Versuch, den Pfad %s aufzulösen: %v\n",
+		"An error occurred while trying to resolve the quarantine directory %s: %v\n":                                                               "Fehler beim Versuch, das Quarantäneverzeichnis %s aufzulösen: %v\n",
+		"An error occurred while trying to resolve the source %s: %v\n":                                                                             "Fehler beim Versuch, die Quelle %s aufzulösen: %v\n",
+		"An error occurred while trying to resolve the staging directory %s: %v\n":                                                                  "Fehler beim Versuch, das Staging-Verzeichnis %s aufzulösen: %v\n",
+		"An error occurred while trying to resolve the tier %s: %v\n":                                                                               "Fehler beim Versuch, die Stufe %s aufzulösen: %v\n",
+		"An error occurred while trying to scan the file %s for viruses":                                                                            "Fehler beim Versuch, die Datei %s auf Viren zu prüfen",
+		"An error occurred while trying to search the catalog %s: %v\n":                                                                             "Fehler beim Versuch, den Katalog %s zu durchsuchen: %v\n",
+		"An error occurred while trying to search the catalog for copies of %s":                                                                     "Fehler beim Versuch, den Katalog nach Kopien von %s zu durchsuchen",
+		"An error occurred while trying to set the access time of the copied file %s":                                                               "Fehler beim Versuch, die Zugriffszeit der kopierten Datei %s zu setzen",
+		"An error occurred while trying to set the attributes of the copied file %s":                                                                "Fehler beim Versuch, die Attribute der kopierten Datei %s zu setzen",
+		"An error occurred while trying to stat the file %s":                                                                                        "Fehler beim Versuch, die Datei %s abzufragen",
+		"An error occurred while trying to stat the source path %s":                                                                                 "Fehler beim Versuch, den Quellpfad %s abzufragen",
+		"An error occurred while trying to undo the %s of %s to %s: %v\n":                                                                           "Fehler beim Versuch, den Vorgang %s von %s nach %s rückgängig zu machen: %v\n",
+		"An error occurred while trying to upload the file %s to %s":                                                                                "Fehler beim Versuch, die Datei %s nach %s hochzuladen",
+		"An error occurred while trying to verify the copy %s of the moved file %s. It is left in the source\n":                                     "Fehler beim Versuch, die Kopie %s der verschobenen Datei %s zu prüfen. Sie bleibt in der Quelle\n",
+		"An error occurred while trying to verify the copy %s":                                                                                      "Fehler beim Versuch, die Kopie %s zu prüfen",
+		"An error occurred while trying to walk the source %s: %v\n":                                                                                "Fehler beim Versuch, die Quelle %s zu durchlaufen: %v\n",
+		"An error occurred while trying to watch the directory %s: %v\n":                                                                            "Fehler beim Versuch, das Verzeichnis %s zu überwachen: %v\n",
+		"An error occurred while trying to watch the source %s: %v\n":                                                                               "Fehler beim Versuch, die Quelle %s zu überwachen: %v\n",
+		"An error occurred while trying to write the conflicts %s: %v\n":                                                                            "Fehler beim Versuch, die Konflikte %s zu schreiben: %v\n",
+		"An error occurred while trying to write the error report %s: %v\n":                                                                         "Fehler beim Versuch, den Fehlerbericht %s zu schreiben: %v\n",
+		"An error occurred while trying to write the html report %s: %v\n":                                                                          "Fehler beim Versuch, den HTML-Bericht %s zu schreiben: %v\n",
+		"An error occurred while trying to write the plan %s: %v\n":                                                                                 "Fehler beim Versuch, den Plan %s zu schreiben: %v\n",
+		"An error occurred while trying to write the watch queue %s: %v\n":                                                                          "Fehler beim Versuch, die Warteschlange %s zu schreiben: %v\n",
+		"An error occurred while trying to email the alert to %s: %v\n":                                                                             "Fehler beim Versuch, die Warnung per E-Mail an %s zu senden: %v\n",
+		"An error occurred while trying to post the alert to %s: %v\n":                                                                              "Fehler beim Versuch, die Warnung an %s zu senden: %v\n",
+		"The -alert-email option needs the SMTP server passed with -smtp\n":                                                                         "Die Option -alert-email braucht den mit -smtp angegebenen SMTP-Server\n",
+		"The -compress-transit option needs an sftp:// destination. The uploads to S3 can not be compressed without compressing the stored files\n": "Die Option -compress-transit braucht ein sftp://-Ziel. Die Uploads nach S3 können nicht komprimiert werden, ohne die gespeicherten Dateien zu komprimieren\n",
+		"The -conflicts option needs -on-conflict skip\n":                                                                                           "Die Option -conflicts braucht -on-conflict skip\n",
+		"The -diff option is only supported by plan\n":                                                                                              "Die Option -diff wird nur von plan unterstützt\n",
+		"The -dry-run option is only supported by sort. plan and diff do not touch the destination anyway\n":                                        "Die Option -dry-run wird nur von sort unterstützt. plan und diff verändern das Ziel ohnehin nicht\n",
+		"The -dry-run option needs -rule\n":                                                                                                         "Die Option -dry-run braucht -rule\n",
+		"The -duplicate-names alias option needs the catalog passed with -catalog\n":                                                                "Die Option -duplicate-names alias braucht den mit -catalog angegebenen Katalog\n",
+		"The -incremental option needs -catalog and is not supported by plan, diff, -order, -sample and -dry-run\n":                                 "Die Option -incremental braucht -catalog und wird von plan, diff, -order, -sample und -dry-run nicht unterstützt\n",
+		"The -min-date and -max-future options are not supported with -unsorted none\n":                                                             "Die Optionen -min-date und -max-future werden mit -unsorted none nicht unterstützt\n",
+		"The -min-size %s is larger than the -max-size %s\n":                                                                                        "Die -min-size %s ist größer als die -max-size %s\n",
+		"The -move option is not supported by plan, diff and -dry-run\n":                                                                            "Die Option -move wird von plan, diff und -dry-run nicht unterstützt\n",
+		"The -move option removes the files from the source and cannot be used with -assert-readonly-source\n":                                      "Die Option -move entfernt die Dateien aus der Quelle und kann nicht mit -assert-readonly-source verwendet werden\n",
+		"The -output json option is not supported by plan, diff and -dry-run\n":                                                                     "Die Option -output json wird von plan, diff und -dry-run nicht unterstützt\n",
+		"The -post-process option is not supported by plan, diff and -dry-run\n":                                                                    "Die Option -post-process wird von plan, diff und -dry-run nicht unterstützt\n",
+		"The -preserve-dir-owner option is not supported on this platform\n":                                                                        "Die Option -preserve-dir-owner wird auf dieser Plattform nicht unterstützt\n",
+		"The -quarantine option needs -clamd\n":                                                                                                     "Die Option -quarantine braucht -clamd\n",
+		"The -resume option needs -catalog and is not supported by diff\n":                                                                          "Die Option -resume braucht -catalog und wird von diff nicht unterstützt\n",
+		"The -split-large option is not supported by plan, diff, -protect, -immutable, -verify, -windows-attributes, -catalog, -manifest and -on-conflict rename or hash-suffix\n": "Die Option -split-large wird von plan, diff, -protect, -immutable, -verify, -windows-attributes, -catalog, -manifest und -on-conflict rename oder hash-suffix nicht unterstützt\n",
+		"The -unsorted folder %s has to be a folder inside the destination\n":                                                                                                      "Der -unsorted-Ordner %s muss ein Ordner im Ziel sein\n",
+		"The -volume option needs -catalog and a single -destination and is not supported by plan and diff\n":                                                                      "Die Option -volume braucht -catalog und ein einziges -destination und wird von plan und diff nicht unterstützt\n",
+		"The -volume option needs the size of the volume passed with -volume-size and the other way round\n":                                                                       "Die Option -volume braucht die mit -volume-size angegebene Größe des Datenträgers und umgekehrt\n",
+		"The -watch option is not supported by plan, diff, -dry-run and -retry-from\n":                                                                                             "Die Option -watch wird von plan, diff, -dry-run und -retry-from nicht unterstützt\n",
+		"The -watch-queue option needs -watch\n":                                                                                                                                   "Die Option -watch-queue braucht -watch\n",
+		"The -windows-attributes option is only supported on Windows\n":                                                                                                            "Die Option -windows-attributes wird nur unter Windows unterstützt\n",
+		"The S3 endpoint %s is not a valid URL\n":                                                                                                                                  "Der S3-Endpunkt %s ist keine gültige URL\n",
+		"The access times of the source files may be updated since this platform cannot read files without updating them\n":                                                        "Die Zugriffszeiten der Quelldateien können sich ändern, da diese Plattform Dateien nicht lesen kann, ohne sie zu aktualisieren\n",
+		"The age %s is not valid. Use one of the units y, m, w or d\n":                                                                                                             "Das Alter %s ist nicht gültig. Verwenden Sie eine der Einheiten y, m, w oder d\n",
+		"The age %s is not valid\n":                                                             "Das Alter %s ist nicht gültig\n",
+		"The bandwidth %s is not valid\n":                                                       "Die Bandbreite %s ist nicht gültig\n",
+		"The bandwidth window %s is not valid. Use the form 08:00-23:00=10MB\n":                 "Das Bandbreitenfenster %s ist nicht gültig. Verwenden Sie die Form 08:00-23:00=10MB\n",
+		"The compare mode %s is not supported. Use size or hash\n":                              "Der Vergleichsmodus %s wird nicht unterstützt. Verwenden Sie size oder hash\n",
+		"The conflict policy %s is not supported. Use overwrite, skip, rename or hash-suffix\n": "Die Konfliktregel %s wird nicht unterstützt. Verwenden Sie overwrite, skip, rename oder hash-suffix\n",
+		"The copied file %s does not match the %s of %s\n":                                      "Die kopierte Datei %s passt nicht zum %s von %s\n",
+		"The copy %s does not match the moved file %s. It is left in the source\n":              "Die Kopie %s passt nicht zur verschobenen Datei %s. Sie bleibt in der Quelle\n",
+		"The copy %s does not match the source %s and is removed\n":                             "Die Kopie %s passt nicht zur Quelle %s und wird entfernt\n",
+		"The date %s of -after is not before the date %s of -before\n":                          "Das Datum %s von -after liegt nicht vor dem Datum %s von -before\n",
+		"The date %s of -after is not valid. Use a date like 2023-01-01\n":                      "Das Datum %s von -after ist nicht gültig. Verwenden Sie ein Datum wie 2023-01-01\n",
+		"The date %s of -before is not valid. Use a date like 2023-01-01\n":                     "Das Datum %s von -before ist nicht gültig. Verwenden Sie ein Datum wie 2023-01-01\n",
+		"The date %s of -min-date is not valid. Use a date like 1990-01-01\n":                   "Das Datum %s von -min-date ist nicht gültig. Verwenden Sie ein Datum wie 1990-01-01\n",
+		"The date source %s is not supported\n":                                                 "Die Datumsquelle %s wird nicht unterstützt\n",
+		"The destination %s has %d of %d inodes free\n":                                         "Das Ziel %s hat %d von %d Inodes frei\n",
+		"The destination %s has no bucket\n":                                                    "Das Ziel %s hat keinen Bucket\n",
+		"The destination %s has only %d inodes free, fewer than the %d kept free. Free up inodes, like by archiving many small files into one, or sort onto a file system with more of them\n": "Das Ziel %s hat nur %d Inodes frei, weniger als die %d freizuhaltenden. Geben Sie Inodes frei, etwa indem Sie viele kleine Dateien in eine archivieren, oder sortieren Sie auf ein Dateisystem mit mehr davon\n",
+		"The destination %s has only %d inodes free. Free up inodes and run again to copy the remaining files\n":                                                                               "Das Ziel %s hat nur %d Inodes frei. Geben Sie Inodes frei und starten Sie erneut, um die restlichen Dateien zu kopieren\n",
+		"The destination %s is inside the source and is left out of the walk\n":                                                                                                                "Das Ziel %s liegt in der Quelle und wird beim Durchlaufen ausgelassen\n",
+		"The destination %s is inside the source which -assert-readonly-source does not allow\n":                                                                                               "Das Ziel %s liegt in der Quelle, was -assert-readonly-source nicht erlaubt\n",
+		"The destination %s is not a valid sftp:// url\n":                                                                                                                                      "Das Ziel %s ist keine gültige sftp://-URL\n",
+		"The destination %s is the same as the source %s now\n":                                                                                                                                "Das Ziel %s ist jetzt dasselbe wie die Quelle %s\n",
+		"The destination %s is the volume %s and not %s\n":                                                                                                                                     "Das Ziel %s ist der Datenträger %s und nicht %s\n",
+		"The directory mode %s is not valid. Use an octal mode like 0750\n":                                                                                                                    "Der Verzeichnismodus %s ist nicht gültig. Verwenden Sie einen oktalen Modus wie 0750\n",
+		"The duplicate names policy %s is not supported. Use copy or alias\n":                                                                                                                  "Die Regel für doppelte Namen %s wird nicht unterstützt. Verwenden Sie copy oder alias\n",
+		"The duration %v of -max-future is not valid\n":                                                                                                                                        "Die Dauer %v von -max-future ist nicht gültig\n",
+		"The exclude pattern %s is not valid\n":                                                                                                                                                "Das Ausschlussmuster %s ist nicht gültig\n",
+		"The file %s is not a regular file\n":                                                                                                                                                  "Die Datei %s ist keine reguläre Datei\n",
+		"The file timeout %v is not valid\n":                                                                                                                                                   "Die Zeitgrenze %v für Dateien ist nicht gültig\n",
+		"The folders have text besides the date, like the name of an event, which a layout can not produce. The files would be sorted into the folders of the date without it\n": "Die Ordner enthalten neben dem Datum Text, etwa den Namen eines Ereignisses, den eine Struktur nicht erzeugen kann. Die Dateien würden in die Ordner des Datums ohne ihn sortiert\n",
+		"The folders of %d%% of the files have no dates and most files are ebooks, like %s\n":                                                                                    "Die Ordner von %d%% der Dateien haben kein Datum und die meisten Dateien sind E-Books, wie %s\n",
+		"The folders of %d%% of the files have no dates and most files are music, like %s\n":                                                                                     "Die Ordner von %d%% der Dateien haben kein Datum und die meisten Dateien sind Musik, wie %s\n",
+		"The format %s is not supported\n":                                                           "Das Format %s wird nicht unterstützt\n",
+		"The host and the user of the sftp destination %s must not start with -\n":                   "Host und Benutzer des sftp-Ziels %s dürfen nicht mit - beginnen\n",
+		"The language %s is not supported. Use en, de or es\n":                                       "Die Sprache %s wird nicht unterstützt. Verwenden Sie en, de oder es\n",
+		"The layout %s is not a valid template: %v\n":                                                "Die Struktur %s ist keine gültige Vorlage: %v\n",
+		"The layout does not reproduce the path of %s exactly, check it with -dry-run first\n":       "Die Struktur gibt den Pfad von %s nicht genau wieder, prüfen Sie sie zuerst mit -dry-run\n",
+		"The limit of the run was reached. Run again to copy the remaining files\n":                  "Die Grenze des Laufs wurde erreicht. Starten Sie erneut, um die restlichen Dateien zu kopieren\n",
+		"The manifest %s is not valid: %v\n":                                                         "Das Manifest %s ist nicht gültig: %v\n",
+		"The manifest of the backup %s could not be read. Encrypted backups are not supported: %v\n": "Das Manifest der Sicherung %s konnte nicht gelesen werden. Verschlüsselte Sicherungen werden nicht unterstützt: %v\n",
+		"The maximum number of files per directory %d is not valid\n":                                "Die Höchstzahl von %d Dateien pro Verzeichnis ist nicht gültig\n",
+		"The moved file %s is left in the source since it was copied in parts\n":                     "Die verschobene Datei %s bleibt in der Quelle, da sie in Teilen kopiert wurde\n",
+		"The moved file %s is left in the source since parts of it could not be read\n":              "Die verschobene Datei %s bleibt in der Quelle, da Teile davon nicht gelesen werden konnten\n",
+		"The number of post-processing jobs %d is not valid\n":                                       "Die Anzahl von %d Nachbearbeitungsaufträgen ist nicht gültig\n",
+		"The number of uploads in flight %d is not valid\n":                                          "Die Anzahl von %d gleichzeitigen Uploads ist nicht gültig\n",
+		"The number of workers %d is not valid\n":                                                    "Die Anzahl von %d Workern ist nicht gültig\n",
+		"The option %s of the profile %s is not supported\n":                                         "Die Option %s des Profils %s wird nicht unterstützt\n",
+		"The option %s of the profile %s is not valid: %v\n":                                         "Die Option %s des Profils %s ist nicht gültig: %v\n",
+		"The order %s is not supported\n":                                                            "Die Reihenfolge %s wird nicht unterstützt\n",
+		"The output format %s is not supported. Use text or json\n":                                  "Das Ausgabeformat %s wird nicht unterstützt. Verwenden Sie text oder json\n",
+		"The path %s of %s is inside the source which -assert-readonly-source does not allow\n":      "Der Pfad %s von %s liegt in der Quelle, was -assert-readonly-source nicht erlaubt\n",
+		"The pattern %s is not valid: %v\n":                                                          "Das Muster %s ist nicht gültig: %v\n",
+		"The pattern %s of the ignore file %s is not valid and is skipped\n":                         "Das Muster %s der Ignorierdatei %s ist nicht gültig und wird übersprungen\n",
+		"The policy %s for files without an extension is not supported. Use include or skip\n":       "Die Regel %s für Dateien ohne Endung wird nicht unterstützt. Verwenden Sie include oder skip\n",
+		"The post-processing command %s is not valid\n":                                              "Der Nachbearbeitungsbefehl %s ist nicht gültig\n",
+		"The profile %s is not in the config file %s\n":                                              "Das Profil %s steht nicht in der Konfigurationsdatei %s\n",
+		"The rate of %v files per second is not valid\n":                                             "Die Rate von %v Dateien pro Sekunde ist nicht gültig\n",
+		"The report of the run was written to %s\n":                                                  "Der Bericht des Laufs wurde nach %s geschrieben\n",
+		"The rule %s is not supported\n":                                                             "Die Regel %s wird nicht unterstützt\n",
+		"The run was cancelled":                                                                      "Der Lauf wurde abgebrochen",
+		"The s3:// and sftp:// destinations are not supported by plan, diff, -dry-run, -staging, -resume-partial, -delta, -protect, -immutable, -windows-attributes, -max-files-per-dir, -catalog, -manifest, -post-process, -verify and -on-conflict rename or hash-suffix\n": "Die Ziele s3:// und sftp:// werden von plan, diff, -dry-run, -staging, -resume-partial, -delta, -protect, -immutable, -windows-attributes, -max-files-per-dir, -catalog, -manifest, -post-process, -verify und -on-conflict rename oder hash-suffix nicht unterstützt\n",
+		"The settle time %v of -watch is not valid\n":                                                                                        "Die Wartezeit %v von -watch ist nicht gültig\n",
+		"The size %s is not valid\n":                                                                                                         "Die Größe %s ist nicht gültig\n",
+		"The source %s and the destination %s are the same file. Skipping it\n":                                                              "Die Quelle %s und das Ziel %s sind dieselbe Datei. Wird übersprungen\n",
+		"The source %s is gone\n":                                                                                                            "Die Quelle %s ist nicht mehr da\n",
+		"The source %s is not an iTunes/Finder backup: %v\n":                                                                                 "Die Quelle %s ist keine iTunes/Finder-Sicherung: %v\n",
+		"The tier %s can not be a remote destination. Use -destination\n":                                                                    "Die Stufe %s kann kein entferntes Ziel sein. Verwenden Sie -destination\n",
+		"The value of %s is not valid: %v\n":                                                                                                 "Der Wert von %s ist nicht gültig: %v\n",
+		"The volume %s has %d of %d bytes used\n":                                                                                            "Der Datenträger %s hat %d von %d Bytes belegt\n",
+		"The volume %s is full. Run again with the next volume to copy the remaining files\n":                                                "Der Datenträger %s ist voll. Starten Sie erneut mit dem nächsten Datenträger, um die restlichen Dateien zu kopieren\n",
+		"There are no files in %s to detect the layout from\n":                                                                               "In %s gibt es keine Dateien, aus denen die Struktur erkannt werden kann\n",
+		"This is the default layout of filesorter and needs no options\n":                                                                    "Dies ist die Standardstruktur von filesorter und braucht keine Optionen\n",
+		"Usage: filesorter apply [-catalog <catalog path>] [-error-report <report path>] <plan path>\n":                                      "Aufruf: filesorter apply [-catalog <Katalogpfad>] [-error-report <Berichtspfad>] <Planpfad>\n",
+		"Usage: filesorter detect-layout -destination <destination path> [-sample <files>]\n":                                                "Aufruf: filesorter detect-layout -destination <Zielpfad> [-sample <Dateien>]\n",
+		"Usage: filesorter estimate-dedup -source <source path> [-catalog <catalog path>] [-top <groups>]\n":                                 "Aufruf: filesorter estimate-dedup -source <Quellpfad> [-catalog <Katalogpfad>] [-top <Gruppen>]\n",
+		"Usage: filesorter export -catalog <catalog path> [-out <file>] [-format json|csv]\n":                                                "Aufruf: filesorter export -catalog <Katalogpfad> [-out <Datei>] [-format json|csv]\n",
+		"Usage: filesorter find -catalog <catalog path> [-destination <destination path>] <name, glob or sha256>\n":                          "Aufruf: filesorter find -catalog <Katalogpfad> [-destination <Zielpfad>] <Name, Glob oder sha256>\n",
+		"Usage: filesorter history -catalog <catalog path> [-limit <runs>]\n":                                                                "Aufruf: filesorter history -catalog <Katalogpfad> [-limit <Läufe>]\n",
+		"Usage: filesorter import -catalog <catalog path> [-format json|csv] <exported file>\n":                                              "Aufruf: filesorter import -catalog <Katalogpfad> [-format json|csv] <exportierte Datei>\n",
+		"Usage: filesorter index -catalog <catalog path> -destination <destination path>\n":                                                  "Aufruf: filesorter index -catalog <Katalogpfad> -destination <Zielpfad>\n",
+		"Usage: filesorter prune -destination <destination path> -older-than <age> [-catalog <catalog path>] [-trash <path>] [-dry-run]\n":   "Aufruf: filesorter prune -destination <Zielpfad> -older-than <Alter> [-catalog <Katalogpfad>] [-trash <Pfad>] [-dry-run]\n",
+		"Usage: filesorter query -catalog <catalog path> [-ext <ext>] [-year <year>] [-min-size <size>] [-max-size <size>] [-name <text>]\n": "Aufruf: filesorter query -catalog <Katalogpfad> [-ext <Endung>] [-year <Jahr>] [-min-size <Größe>] [-max-size <Größe>] [-name <Text>]\n",
+		"Usage: filesorter resolve [-rule <rule>] [-catalog <catalog path>] [-dry-run] <conflicts path>\n":                                   "Aufruf: filesorter resolve [-rule <Regel>] [-catalog <Katalogpfad>] [-dry-run] <Konfliktpfad>\n",
+		"Usage: filesorter undo -manifest <manifest path> [-catalog <catalog path>] [-dry-run]\n":                                            "Aufruf: filesorter undo -manifest <Manifestpfad> [-catalog <Katalogpfad>] [-dry-run]\n",
+		"Would conflict %s --> %s with %s sorted there before and overwrite it\n":                                                            "Würde %s --> %s mit dem zuvor dorthin sortierten %s in Konflikt bringen und es überschreiben\n",
+		"Would conflict %s --> %s with %s sorted there before and skip it\n":                                                                 "Würde %s --> %s mit dem zuvor dorthin sortierten %s in Konflikt bringen und es überspringen\n",
+		"Would copy %s --> %s\n":                        "Würde kopieren %s --> %s\n",
+		"Would keep %s and leave out %s\n":              "Würde %s behalten und %s auslassen\n",
+		"Would move %s --> %s\n":                        "Würde verschieben %s --> %s\n",
+		"Would overwrite %s --> %s (%d and %d bytes)\n": "Würde überschreiben %s --> %s (%d und %d Bytes)\n",
+		"Would prune %s\n":                              "Würde entfernen %s\n",
+		"Would remove %d copies and restore %d sources. Would keep %d, Already gone %d, Errored %d\n": "Würde %d Kopien entfernen und %d Quellen wiederherstellen. Würde behalten %d, Bereits weg %d, Fehler %d\n",
+		"Would remove %s\n": "Würde entfernen %s\n",
+		"Would remove the partial copy %s left by an earlier run\n":                    "Würde die unvollständige Kopie %s eines früheren Laufs entfernen\n",
+		"Would rename %s --> %s, %s is sorted under the same name before\n":            "Würde umbenennen %s --> %s, %s wird zuvor unter demselben Namen sortiert\n",
+		"Would rename %s --> %s, a different file of the same name is already there\n": "Würde umbenennen %s --> %s, dort liegt bereits eine andere Datei gleichen Namens\n",
+		"Would resume %s --> %s from %d bytes\n":                                       "Würde fortsetzen %s --> %s ab %d Bytes\n",
+		"Would skip %s --> %s, %s of the same size is sorted there before\n":           "Würde überspringen %s --> %s, %s mit derselben Größe wird zuvor dorthin sortiert\n",
+		"Would skip %s --> %s, %s with the same content is sorted there before\n":      "Würde überspringen %s --> %s, %s mit demselben Inhalt wird zuvor dorthin sortiert\n",
+		"Would skip %s --> %s, a different file of the same name is already there\n":   "Würde überspringen %s --> %s, dort liegt bereits eine andere Datei gleichen Namens\n",
+		"Would skip %s --> %s, a file of the same size is already there\n":             "Würde überspringen %s --> %s, dort liegt bereits eine Datei derselben Größe\n",
+		"Would skip %s --> %s, a file with the same content is already there\n":        "Würde überspringen %s --> %s, dort liegt bereits eine Datei mit demselben Inhalt\n",
+		"Would skip %s, it is older than all the tiers\n":                              "Würde überspringen %s, sie ist älter als alle Stufen\n",
+		"Would skip %s, its date %s is outside of -after and -before\n":                "Würde überspringen %s, ihr Datum %s liegt außerhalb von -after und -before\n",
+		"Would skip %s, its size of %s is outside of -min-size and -max-size\n":        "Würde überspringen %s, ihre Größe von %s liegt außerhalb von -min-size und -max-size\n",
+		"Would skip %s, its type is not sorted\n":                                      "Würde überspringen %s, ihr Typ wird nicht sortiert\n",
+		"Would sort %s into %s, its date %s is not valid\n":                            "Würde %s in %s sortieren, ihr Datum %s ist nicht gültig\n",
+		"Would update %s --> %s\n":                                                     "Würde aktualisieren %s --> %s\n",
+	},
+	language.Spanish: {
+		"Usage: filesorter [sort] <source path> <destination path> [file types]\n": "Uso: filesorter [sort] <ruta de origen> <ruta de destino> [tipos de archivo]\n",
+		"The path %s does not exist.":                "La ruta %s no existe.",
+		"The path %s is not a directory.":            "La ruta %s no es un directorio.",
+		"The preset %s is not supported\n":           "El preajuste %s no está soportado\n",
+		"The scheme %s is not supported\n":           "El esquema %s no está soportado\n",
+		"Completed !\n":                              "¡Completado!\n",
+		"Copied %s --> %s\n":                         "Copiado %s --> %s\n",
+		"Resumed %s --> %s from %d bytes\n":          "Reanudado %s --> %s desde %d bytes\n",
+		"Updated %s --> %s writing %d of %d bytes\n": "Actualizado %s --> %s escribiendo %d de %d bytes\n",
+		"The partial file %s does not match the source and will be copied again\n":                   "El archivo parcial %s no coincide con el origen y se copiará de nuevo\n",
+		"An error occurred while trying to copy the file %s to %s":                                   "Ocurrió un error al intentar copiar el archivo %s a %s",
+		"An error occurred while trying to open the catalog %s: %v\n":                                "Ocurrió un error al intentar abrir el catálogo %s: %v\n",
+		"Copied %d files from %d directories. Skipped %d, Errored %d, Resumed %d, Bytes copied %d\n": "Copiados %d archivos de %d directorios. Omitidos %d, Con error %d, Reanudados %d, Bytes copiados %d\n",
+		"  %s: Copied %d, Skipped %d, Errored %d, Resumed %d, Bytes copied %d\n":                     "  %s: Copiados %d, Omitidos %d, Con error %d, Reanudados %d, Bytes copiados %d\n",
+		"Planned %d copies from %d directories. Skipped %d, Errored %d\n":                            "Planificadas %d copias de %d directorios. Omitidos %d, Con error %d\n",
+		"Only in source: %s --> %s\n":                                                                "Solo en el origen: %s --> %s\n",
+		"Differs: %s --> %s (%d and %d bytes)\n":                                                     "Difiere: %s --> %s (%d y %d bytes)\n",
+		"Only in destination: %s\n":                                                                  "Solo en el destino: %s\n",
+		"Only in source %d, Only in destination %d, Differ %d, Same %d. Skipped %d, Errored %d\n":    "Solo en el origen %d, Solo en el destino %d, Difieren %d, Iguales %d. Omitidos %d, Con error %d\n",
+		"Missing %s\n":              "Falta %s\n",
+		"Corrupted %s\n":            "Dañado %s\n",
+		"Repaired %s --> %s\n":      "Reparado %s --> %s\n",
+		"Could not repair %s: %v\n": "No se pudo reparar %s: %v\n",
+		"Verified %d of %d files. Missing %d, Corrupted %d, Repaired %d\n":                                                             "Verificados %d de %d archivos. Faltan %d, Dañados %d, Reparados %d\n",
+		"The following files could not be repaired:\n":                                                                                 "Los siguientes archivos no se pudieron reparar:\n",
+		"Usage: filesorter verify -catalog <catalog path> [-sample <count or percentage>] [-repair -destination <destination path>]\n": "Uso: filesorter verify -catalog <ruta del catálogo> [-sample <cantidad o porcentaje>] [-repair -destination <ruta de destino>]\n",
+		"The sample %s is not a valid percentage\n":                                                                                    "La muestra %s no es un porcentaje válido\n",
+		"The sample %s is not a valid count\n":                                                                                         "La muestra %s no es una cantidad válida\n",
+		"the source is not known since the file was added by index\n":                                                                  "el origen no se conoce ya que el archivo se añadió con index\n",
+		"the file is not in the destination %s\n":                                                                                      "el archivo no está en el destino %s\n",
+		"the source %s is no longer available\n":                                                                                       "el origen %s ya no está disponible\n",
+		"the source %s has changed since it was copied\n":                                                                              "el origen %s ha cambiado desde que se copió\n",
+		"missing":                            "falta",
+		"unreadable: %v":                     "ilegible: %v",
+		"corrupted: sha256 %s instead of %s": "dañado: sha256 %s en lugar de %s",
+		", could not be repaired: %v":        ", no se pudo reparar: %v",
+		", repaired":                         ", reparado",
+		"%d corrupted and %d missing files found by verify": "%d archivos dañados y %d que faltan encontrados por verify",
+		"Found %d files\n":        "Encontrados %d archivos\n",
+		"The run was cancelled\n": "La ejecución fue cancelada\n",
+		"       filesorter diff <source path> <destination path> [file types]\n":                  "       filesorter diff <ruta de origen> <ruta de destino> [tipos de archivo]\n",
+		"       filesorter plan -out <plan path> <source path> <destination path> [file types]\n": "       filesorter plan -out <ruta del plan> <ruta de origen> <ruta de destino> [tipos de archivo]\n",
+		"  %d%% of the files are sorted differently, like %s\n":                                   "  El %d%% de los archivos está ordenado de otra forma, como %s\n",
+		"  Copied %s, Skipped %s, Errored %s, Resumed %d, Bytes copied %d in %s\n":                "  Copiados %s, Omitidos %s, Con error %s, Reanudados %d, Bytes copiados %d en %s\n",
+		"  Did not finish\n":                     "  No terminó\n",
+		"  copied: %s by %s\n":                   "  copiado: %s por %s\n",
+		"  date used: %s\n":                      "  fecha usada: %s\n",
+		"  destination: %d bytes, modified %s\n": "  destino: %d bytes, modificado %s\n",
+		"  not in the catalog\n":                 "  no está en el catálogo\n",
+		"  size: %d bytes, sha256: %s\n":         "  tamaño: %d bytes, sha256: %s\n",
+		"  source %s: %d bytes, modified %s\n":   "  origen %s: %d bytes, modificado %s\n",
+		"  source: %s\n":                         "  origen: %s\n",
+		"  volume: %s\n":                         "  volumen: %s\n",
+		"%d bytes unreadable":                    "%d bytes ilegibles",
+		"%d copies did not match their source":   "%d copias no coincidían con su origen",
+		"%d copies of %d bytes:\n":               "%d copias de %d bytes:\n",
+		"%d files are duplicates in %d groups. Deduplicating them would save %d bytes (%.1f%%)\n": "%d archivos son duplicados en %d grupos. Deduplicarlos ahorraría %d bytes (%.1f%%)\n",
+		"%d infected files were found in %s":                                                      "Se encontraron %d archivos infectados en %s",
+		"%d%% of the files are sorted like %s\n":                                                  "El %d%% de los archivos está ordenado como %s\n",
+		"%s --> %s (%d bytes, modified %s)\n":                                                     "%s --> %s (%d bytes, modificado %s)\n",
+		"Added: %s --> %s\n":                                                                      "Añadido: %s --> %s\n",
+		"Already at":                                                                              "Ya está en",
+		"Already copied %s to %s, recorded %s as an alias\n":                                      "%s ya se copió a %s, %s se registró como alias\n",
+		"Bytes copied":                                                                            "Bytes copiados",
+		"Checked %d copies against the MHL manifests of the source\n":                             "Comprobadas %d copias con los manifiestos MHL del origen\n",
+		"Cloned %d and copied %d in the kernel of the %d copied files, the others were written\n": "Clonados %d y copiados %d en el kernel de los %d archivos copiados, los demás se escribieron\n",
+		"Compared with the plan %s: %d added, %d removed, %d re-routed\n":                         "Comparado con el plan %s: %d añadidos, %d eliminados, %d redirigidos\n",
+		"Conflicts": "Conflictos",
+		"Copied %s --> %s in %d parts over a different file of the same name\n":                     "Copiado %s --> %s en %d partes sobre otro archivo del mismo nombre\n",
+		"Copied %s --> %s in %d parts\n":                                                            "Copiado %s --> %s en %d partes\n",
+		"Copied %s --> %s over a different file of the same name\n":                                 "Copiado %s --> %s sobre otro archivo del mismo nombre\n",
+		"Copied %s --> %s, renamed since a different file of the same name is at the destination\n": "Copiado %s --> %s, renombrado porque otro archivo del mismo nombre está en el destino\n",
+		"Copied files per day": "Archivos copiados por día",
+		"Copied files":         "Archivos copiados",
+		"Corrupted copies":     "Copias dañadas",
+		"Could not read %d bytes of %s in the block at %d, they are filled with zeros\n": "No se pudieron leer %d bytes de %s en el bloque en %d, se rellenan con ceros\n",
+		"Counting the files of %s\n": "Contando los archivos de %s\n",
+		"Deleted %s\n":               "Borrado %s\n",
+		"Destination: %s\n":          "Destino: %s\n",
+		"Destinations":               "Destinos",
+		"Differs: %s --> %s (same size, different content)\n": "Difiere: %s --> %s (mismo tamaño, distinto contenido)\n",
+		"Duplicates":                "Duplicados",
+		"Duration":                  "Duración",
+		"Error":                     "Error",
+		"Errored files":             "Archivos con error",
+		"Errors":                    "Errores",
+		"Excluded names: %s\n":      "Nombres excluidos: %s\n",
+		"Exported %d files to %s\n": "Exportados %d archivos a %s\n",
+		"File":                      "Archivo",
+		"Found %d files in the catalog and %d other files at the destination\n":      "Encontrados %d archivos en el catálogo y %d archivos más en el destino\n",
+		"Found %d files with %s to sort\n":                                           "Encontrados %d archivos con %s para ordenar\n",
+		"Found %d infected files which were not copied\n":                            "Encontrados %d archivos infectados que no se copiaron\n",
+		"Found %d partial copies left by earlier runs in the staging directory %s\n": "Encontradas %d copias parciales de ejecuciones anteriores en el directorio de preparación %s\n",
+		"Found %d runs\n": "Encontradas %d ejecuciones\n",
+		"Gave up on %d files which did not respond within the file timeout\n":                            "Se abandonaron %d archivos que no respondieron dentro del tiempo límite\n",
+		"Gave up on the copy of %s after %v\n":                                                           "Se abandonó la copia de %s después de %v\n",
+		"Imported %d of %d files. The others are already in the catalog with the same or a newer copy\n": "Importados %d de %d archivos. Los demás ya están en el catálogo con la misma copia o una más reciente\n",
+		"In a profile of the config file:\n    layout: '%s'\n":                                           "En un perfil del archivo de configuración:\n    layout: '%s'\n",
+		"Indexed %d files. Already in the catalog %d, Errored %d\n":                                      "Indexados %d archivos. Ya en el catálogo %d, Con error %d\n",
+		"Indexed %s\n":   "Indexado %s\n",
+		"Infected files": "Archivos infectados",
+		"Invalid dates":  "Fechas no válidas",
+		"Keep the [d]estination, replace it with the [s]ource, keep [b]oth, decide [l]ater or [q]uit? ": "¿Mantener el destino [d], reemplazarlo por el origen [s], mantener ambos [b], decidir más tarde [l] o salir [q]? ",
+		"Kept %s and left out %s\n":                                        "Mantenido %s y omitido %s\n",
+		"Kept %s, a file is at its source %s again\n":                      "Mantenido %s, vuelve a haber un archivo en su origen %s\n",
+		"Kept %s, it changed since it was copied\n":                        "Mantenido %s, ha cambiado desde que se copió\n",
+		"Kept %s, it replaced an earlier file which can not be restored\n": "Mantenido %s, reemplazó un archivo anterior que no se puede restaurar\n",
+		"Looked at %d files in %s\n":                                       "Revisados %d archivos en %s\n",
+		"Moved %d files out of the source. Bytes moved %d\n":               "Movidos %d archivos fuera del origen. Bytes movidos %d\n",
+		"Moved %s --> %s\n":                                                "Movido %s --> %s\n",
+		"Moved files":                                                      "Archivos movidos",
+		"No dates were found in the folders of %d%% of the files, like %s. The layout could not be detected\n": "No se encontraron fechas en las carpetas del %d%% de los archivos, como %s. No se pudo detectar la estructura\n",
+		"No duplicates":        "Sin duplicados",
+		"No errors":            "Sin errores",
+		"No files were copied": "No se copió ningún archivo",
+		"Overwrote %d different files of the same name at the destination\n":       "Sobrescritos %d archivos distintos del mismo nombre en el destino\n",
+		"Pausing after the current file. Send SIGUSR2 to resume (kill -USR2 %d)\n": "Pausa tras el archivo actual. Envíe SIGUSR2 para reanudar (kill -USR2 %d)\n",
+		"Planned %d files with an invalid date into the unsorted folder\n":         "Planificados %d archivos con una fecha no válida en la carpeta sin ordenar\n",
+		"Planned %s %s --> %s\n":                                 "Planificado %s %s --> %s\n",
+		"Post-processed %d files, %d failed\n":                   "Posprocesados %d archivos, %d fallaron\n",
+		"Post-processed %s\n":                                    "Posprocesado %s\n",
+		"Quarantined %s --> %s, it is infected with %s\n":        "En cuarentena %s --> %s, está infectado con %s\n",
+		"Re-routed: %s from %s to %s\n":                          "Redirigido: %s de %s a %s\n",
+		"Read the hashes of %d files from the MHL manifest %s\n": "Leídos los hashes de %d archivos del manifiesto MHL %s\n",
+		"Removed %d copies and restored %d sources. Kept %d, Already gone %d, Errored %d\n": "Eliminadas %d copias y restaurados %d orígenes. Mantenidos %d, Ya no estaban %d, Con error %d\n",
+		"Removed %s\n": "Eliminado %s\n",
+		"Removed the moved file %s from the source, the same file is already at %s\n": "Se eliminó el archivo movido %s del origen, el mismo archivo ya está en %s\n",
+		"Removed the partial copy %s left by an earlier run\n":                        "Se eliminó la copia parcial %s de una ejecución anterior\n",
+		"Removed: %s --> %s\n": "Eliminado: %s --> %s\n",
+		"Renamed %d files since a different file of the same name was at the destination\n":                                       "Renombrados %d archivos porque otro archivo del mismo nombre estaba en el destino\n",
+		"Resolve the conflicts later with 'filesorter resolve %s'\n":                                                              "Resuelva los conflictos más tarde con 'filesorter resolve %s'\n",
+		"Resolved %d conflicts. Kept both %d, replaced %d destination files, kept %d. %d were gone or the same and %d are left\n": "Resueltos %d conflictos. Ambos mantenidos %d, reemplazados %d archivos de destino, mantenidos %d. %d ya no estaban o eran iguales y quedan %d\n",
+		"Resumed files": "Archivos reanudados",
+		"Resuming the run started at %s which was interrupted after copying %d files\n": "Reanudando la ejecución iniciada a las %s que se interrumpió tras copiar %d archivos\n",
+		"Resuming\n":                    "Reanudando\n",
+		"Run %d: %s of %s started %s\n": "Ejecución %d: %s de %s iniciada %s\n",
+		"Salvaged %d files with unreadable parts, which are filled with zeros in the copies:\n": "Rescatados %d archivos con partes ilegibles, que se rellenan con ceros en las copias:\n",
+		"Salvaged %s --> %s, %d unreadable bytes were filled with zeros\n":                      "Rescatado %s --> %s, %d bytes ilegibles se rellenaron con ceros\n",
+		"Salvaged files":           "Archivos rescatados",
+		"Sampled %d of %d files\n": "Muestreados %d de %d archivos\n",
+		"Scanned %d files of %d bytes. Hashed %d, Read from the catalog %d, Errored %d\n":        "Examinados %d archivos de %d bytes. Con hash calculado %d, Leídos del catálogo %d, Con error %d\n",
+		"Skipped %d files since a different file of the same name was at the destination\n":      "Omitidos %d archivos porque otro archivo del mismo nombre estaba en el destino\n",
+		"Skipped %d unchanged of %d directories\n":                                               "Omitidos %d directorios sin cambios de %d\n",
+		"Skipped %s --> %s, a different file of the same name is already there\n":                "Omitido %s --> %s, ya hay otro archivo del mismo nombre\n",
+		"Skipped %s, it is infected with %s\n":                                                   "Omitido %s, está infectado con %s\n",
+		"Skipped files":                                                                          "Archivos omitidos",
+		"Sorted %d files with an invalid date into the unsorted folder, to be sorted by hand:\n": "Ordenados %d archivos con una fecha no válida en la carpeta sin ordenar, para ordenarlos a mano:\n",
+		"Sorting %d of the %d files left in the watch queue %s\n":                                "Ordenando %d de los %d archivos que quedan en la cola %s\n",
+		"Source":       "Origen",
+		"Source: %s\n": "Origen: %s\n",
+		"Started":      "Iniciado",
+		"Status":       "Estado",
+		"Stopping the run. Press Ctrl-C again to quit right away\n":   "Deteniendo la ejecución. Pulse Ctrl-C otra vez para salir de inmediato\n",
+		"Suggested options: %s\n":                                     "Opciones sugeridas: %s\n",
+		"Suggested options: -scheme ebook\n":                          "Opciones sugeridas: -scheme ebook\n",
+		"Suggested options: -scheme music\n":                          "Opciones sugeridas: -scheme music\n",
+		"Summary":                                                     "Resumen",
+		"Timed out files":                                             "Archivos que agotaron el tiempo",
+		"Types: %s. Excluded: %s. Without an extension: %s":           "Tipos: %s. Excluidos: %s. Sin extensión: %s",
+		"Uploaded %s --> %s over a different file of the same name\n": "Subido %s --> %s sobre otro archivo del mismo nombre\n",
+		"Uploaded %s --> %s\n":                                        "Subido %s --> %s\n",
+		"Verified %d copies by reading them back\n":                   "Verificadas %d copias leyéndolas de nuevo\n",
+		"Watching %s for new files. Files are sorted once they did not change for %v\n":                                                             "Vigilando %s en busca de archivos nuevos. Los archivos se ordenan cuando no cambian durante %v\n",
+		"a run before runs were recorded":                                                                                                           "una ejecución anterior al registro de ejecuciones",
+		"does not match the %s of %s":                                                                                                               "no coincide con el %s de %s",
+		"filesorter report of %s":                                                                                                                   "informe de filesorter del %s",
+		"run %d (%s of %s started %s)":                                                                                                              "ejecución %d (%s de %s iniciada %s)",
+		"Pruned %d files (%d bytes) older than %s. Kept %d, Without a date %d, Errored %d\n":                                                        "Eliminados %d archivos (%d bytes) anteriores a %s. Mantenidos %d, Sin fecha %d, Con error %d\n",
+		"Would prune %d files (%d bytes) older than %s. Would keep %d, Without a date %d, Errored %d\n":                                             "Se eliminarían %d archivos (%d bytes) anteriores a %s. Se mantendrían %d, Sin fecha %d, Con error %d\n",
+		"An error occurred while trying to access the destination %s: %v\n":                                                                         "Ocurrió un error al intentar acceder al destino %s: %v\n",
+		"An error occurred while trying to apply %s --> %s: %v\n":                                                                                   "Ocurrió un error al intentar aplicar %s --> %s: %v\n",
+		"An error occurred while trying to compare the file %s with %s":                                                                             "Ocurrió un error al intentar comparar el archivo %s con %s",
+		"An error occurred while trying to compare the moved file %s with %s":                                                                       "Ocurrió un error al intentar comparar el archivo movido %s con %s",
+		"An error occurred while trying to connect to clamd at %s: %v\n":                                                                            "Ocurrió un error al intentar conectar con clamd en %s: %v\n",
+		"An error occurred while trying to copy the file %s to %s in parts":                                                                         "Ocurrió un error al intentar copiar el archivo %s a %s en partes",
+		"An error occurred while trying to copy the file %s to %s: %v\n":                                                                            "Ocurrió un error al intentar copiar el archivo %s a %s: %v\n",
+		"An error occurred while trying to count the files of the source %s: %v\n":                                                                  "Ocurrió un error al intentar contar los archivos del origen %s: %v\n",
+		"An error occurred while trying to create directories for the file %s":                                                                      "Ocurrió un error al intentar crear directorios para el archivo %s",
+		"An error occurred while trying to create the file %s: %v\n":                                                                                "Ocurrió un error al intentar crear el archivo %s: %v\n",
+		"An error occurred while trying to export the catalog %s: %v\n":                                                                             "Ocurrió un error al intentar exportar el catálogo %s: %v\n",
+		"An error occurred while trying to find another name for the file %s":                                                                       "Ocurrió un error al intentar encontrar otro nombre para el archivo %s",
+		"An error occurred while trying to get the destination path of the file %s":                                                                 "Ocurrió un error al intentar obtener la ruta de destino del archivo %s",
+		"An error occurred while trying to hash the file %s":                                                                                        "Ocurrió un error al intentar calcular el hash del archivo %s",
+		"An error occurred while trying to hash the file %s: %v\n":                                                                                  "Ocurrió un error al intentar calcular el hash del archivo %s: %v\n",
+		"An error occurred while trying to import %s: %v\n":                                                                                         "Ocurrió un error al intentar importar %s: %v\n",
+		"An error occurred while trying to index the file %s: %v\n":                                                                                 "Ocurrió un error al intentar indexar el archivo %s: %v\n",
+		"An error occurred while trying to listen on the control socket %s: %v\n":                                                                   "Ocurrió un error al intentar escuchar en el socket de control %s: %v\n",
+		"An error occurred while trying to look up the file %s in the catalog":                                                                      "Ocurrió un error al intentar buscar el archivo %s en el catálogo",
+		"An error occurred while trying to open the file %s: %v\n":                                                                                  "Ocurrió un error al intentar abrir el archivo %s: %v\n",
+		"An error occurred while trying to open the manifest %s: %v\n":                                                                              "Ocurrió un error al intentar abrir el manifiesto %s: %v\n",
+		"An error occurred while trying to post-process the file %s with %s: %v\n":                                                                  "Ocurrió un error al intentar posprocesar el archivo %s con %s: %v\n",
+		"An error occurred while trying to protect the copied file %s":                                                                              "Ocurrió un error al intentar proteger el archivo copiado %s",
+		"An error occurred while trying to prune the file %s: %v\n":                                                                                 "Ocurrió un error al intentar eliminar el archivo %s: %v\n",
+		"An error occurred while trying to quarantine the infected file %s":                                                                         "Ocurrió un error al intentar poner en cuarentena el archivo infectado %s",
+		"An error occurred while trying to query the catalog %s: %v\n":                                                                              "Ocurrió un error al intentar consultar el catálogo %s: %v\n",
+		"An error occurred while trying to read %s: %v\n":                                                                                           "Ocurrió un error al intentar leer %s: %v\n",
+		"An error occurred while trying to read the %s date of the file %s: %v\n":                                                                   "Ocurrió un error al intentar leer la fecha %s del archivo %s: %v\n",
+		"An error occurred while trying to read the MHL manifest %s: %v\n":                                                                          "Ocurrió un error al intentar leer el manifiesto MHL %s: %v\n",
+		"An error occurred while trying to read the S3 credentials of the profile %s: %v\n":                                                         "Ocurrió un error al intentar leer las credenciales de S3 del perfil %s: %v\n",
+		"An error occurred while trying to read the catalog %s: %v\n":                                                                               "Ocurrió un error al intentar leer el catálogo %s: %v\n",
+		"An error occurred while trying to read the config file %s: %v\n":                                                                           "Ocurrió un error al intentar leer el archivo de configuración %s: %v\n",
+		"An error occurred while trying to read the conflicts %s: %v\n":                                                                             "Ocurrió un error al intentar leer los conflictos %s: %v\n",
+		"An error occurred while trying to read the destination folder of the file %s":                                                              "Ocurrió un error al intentar leer la carpeta de destino del archivo %s",
+		"An error occurred while trying to read the error report %s: %v\n":                                                                          "Ocurrió un error al intentar leer el informe de errores %s: %v\n",
+		"An error occurred while trying to read the file %s: %v\n":                                                                                  "Ocurrió un error al intentar leer el archivo %s: %v\n",
+		"An error occurred while trying to read the ignore file %s: %v\n":                                                                           "Ocurrió un error al intentar leer el archivo de exclusiones %s: %v\n",
+		"An error occurred while trying to read the manifest %s: %v\n":                                                                              "Ocurrió un error al intentar leer el manifiesto %s: %v\n",
+		"An error occurred while trying to read the parts of the file %s":                                                                           "Ocurrió un error al intentar leer las partes del archivo %s",
+		"An error occurred while trying to read the plan %s: %v\n":                                                                                  "Ocurrió un error al intentar leer el plan %s: %v\n",
+		"An error occurred while trying to read the staging directory %s: %v\n":                                                                     "Ocurrió un error al intentar leer el directorio de preparación %s: %v\n",
+		"An error occurred while trying to read the watch queue %s: %v\n":                                                                           "Ocurrió un error al intentar leer la cola %s: %v\n",
+		"An error occurred while trying to record the alias %s in the catalog":                                                                      "Ocurrió un error al intentar registrar el alias %s en el catálogo",
+		"An error occurred while trying to record the file %s in the catalog":                                                                       "Ocurrió un error al intentar registrar el archivo %s en el catálogo",
+		"An error occurred while trying to record the file %s in the manifest":                                                                      "Ocurrió un error al intentar registrar el archivo %s en el manifiesto",
+		"An error occurred while trying to record the moved file %s in the manifest":                                                                "Ocurrió un error al intentar registrar el archivo movido %s en el manifiesto",
+		"An error occurred while trying to record the run in the catalog %s: %v\n":                                                                  "Ocurrió un error al intentar registrar la ejecución en el catálogo %s: %v\n",
+		"An error occurred while trying to record the scanned directories in the catalog: %v\n":                                                     "Ocurrió un error al intentar registrar los directorios examinados en el catálogo: %v\n",
+		"An error occurred while trying to remove the moved file %s from the source":                                                                "Ocurrió un error al intentar eliminar el archivo movido %s del origen",
+		"An error occurred while trying to remove the partial copy %s: %v\n":                                                                        "Ocurrió un error al intentar eliminar la copia parcial %s: %v\n",
+		"An error occurred while trying to resolve the conflict of %s and %s: %v\n":                                                                 "Ocurrió un error al intentar resolver el conflicto de %s y %s: %v\n",
+		"An error occurred while trying to resolve the destination %s: %v\n":                                                                        "Ocurrió un error al intentar resolver el destino %s: %v\n",
+		"An error occurred while trying to resolve the path %s: %v\n":                                                                               "Ocurrió un error al intentar resolver la ruta %s: %v\n",
+		"An error occurred while trying to resolve the quarantine directory %s: %v\n":                                                               "Ocurrió un error al intentar resolver el directorio de cuarentena %s: %v\n",
+		"An error occurred while trying to resolve the source %s: %v\n":                                                                             "Ocurrió un error al intentar resolver el origen %s: %v\n",
+		"An error occurred while trying to resolve the staging directory %s: %v\n":                                                                  "Ocurrió un error al intentar resolver el directorio de preparación %s: %v\n",
+		"An error occurred while trying to resolve the tier %s: %v\n":                                                                               "Ocurrió un error al intentar resolver el nivel %s: %v\n",
+		"An error occurred while trying to scan the file %s for viruses":                                                                            "Ocurrió un error al intentar analizar el archivo %s en busca de virus",
+		"An error occurred while trying to search the catalog %s: %v\n":                                                                             "Ocurrió un error al intentar buscar en el catálogo %s: %v\n",
+		"An error occurred while trying to search the catalog for copies of %s":                                                                     "Ocurrió un error al intentar buscar copias de %s en el catálogo",
+		"An error occurred while trying to set the access time of the copied file %s":                                                               "Ocurrió un error al intentar establecer la hora de acceso del archivo copiado %s",
+		"An error occurred while trying to set the attributes of the copied file %s":                                                                "Ocurrió un error al intentar establecer los atributos del archivo copiado %s",
+		"An error occurred while trying to stat the file %s":                                                                                        "Ocurrió un error al intentar consultar el archivo %s",
+		"An error occurred while trying to stat the source path %s":                                                                                 "Ocurrió un error al intentar consultar la ruta de origen %s",
+		"An error occurred while trying to undo the %s of %s to %s: %v\n":                                                                           "Ocurrió un error al intentar deshacer la operación %s de %s a %s: %v\n",
+		"An error occurred while trying to upload the file %s to %s":                                                                                "Ocurrió un error al intentar subir el archivo %s a %s",
+		"An error occurred while trying to verify the copy %s of the moved file %s. It is left in the source\n":                                     "Ocurrió un error al intentar verificar la copia %s del archivo movido %s. Se deja en el origen\n",
+		"An error occurred while trying to verify the copy %s":                                                                                      "Ocurrió un error al intentar verificar la copia %s",
+		"An error occurred while trying to walk the source %s: %v\n":                                                                                "Ocurrió un error al intentar recorrer el origen %s: %v\n",
+		"An error occurred while trying to watch the directory %s: %v\n":                                                                            "Ocurrió un error al intentar vigilar el directorio %s: %v\n",
+		"An error occurred while trying to watch the source %s: %v\n":                                                                               "Ocurrió un error al intentar vigilar el origen %s: %v\n",
+		"An error occurred while trying to write the conflicts %s: %v\n":                                                                            "Ocurrió un error al intentar escribir los conflictos %s: %v\n",
+		"An error occurred while trying to write the error report %s: %v\n":                                                                         "Ocurrió un error al intentar escribir el informe de errores %s: %v\n",
+		"An error occurred while trying to write the html report %s: %v\n":                                                                          "Ocurrió un error al intentar escribir el informe html %s: %v\n",
+		"An error occurred while trying to write the plan %s: %v\n":                                                                                 "Ocurrió un error al intentar escribir el plan %s: %v\n",
+		"An error occurred while trying to write the watch queue %s: %v\n":                                                                          "Ocurrió un error al intentar escribir la cola %s: %v\n",
+		"An error occurred while trying to email the alert to %s: %v\n":                                                                             "Ocurrió un error al intentar enviar la alerta por correo a %s: %v\n",
+		"An error occurred while trying to post the alert to %s: %v\n":                                                                              "Ocurrió un error al intentar enviar la alerta a %s: %v\n",
+		"The -alert-email option needs the SMTP server passed with -smtp\n":                                                                         "La opción -alert-email necesita el servidor SMTP indicado con -smtp\n",
+		"The -compress-transit option needs an sftp:// destination. The uploads to S3 can not be compressed without compressing the stored files\n": "La opción -compress-transit necesita un destino sftp://. Las subidas a S3 no se pueden comprimir sin comprimir los archivos guardados\n",
+		"The -conflicts option needs -on-conflict skip\n":                                                                                           "La opción -conflicts necesita -on-conflict skip\n",
+		"The -diff option is only supported by plan\n":                                                                                              "La opción -diff solo está soportada por plan\n",
+		"The -dry-run option is only supported by sort. plan and diff do not touch the destination anyway\n":                                        "La opción -dry-run solo está soportada por sort. plan y diff no modifican el destino de todos modos\n",
+		"The -dry-run option needs -rule\n":                                                                                                         "La opción -dry-run necesita -rule\n",
+		"The -duplicate-names alias option needs the catalog passed with -catalog\n":                                                                "La opción -duplicate-names alias necesita el catálogo indicado con -catalog\n",
+		"The -incremental option needs -catalog and is not supported by plan, diff, -order, -sample and -dry-run\n":                                 "La opción -incremental necesita -catalog y no está soportada por plan, diff, -order, -sample y -dry-run\n",
+		"The -min-date and -max-future options are not supported with -unsorted none\n":                                                             "Las opciones -min-date y -max-future no están soportadas con -unsorted none\n",
+		"The -min-size %s is larger than the -max-size %s\n":                                                                                        "El -min-size %s es mayor que el -max-size %s\n",
+		"The -move option is not supported by plan, diff and -dry-run\n":                                                                            "La opción -move no está soportada por plan, diff y -dry-run\n",
+		"The -move option removes the files from the source and cannot be used with -assert-readonly-source\n":                                      "La opción -move elimina los archivos del origen y no se puede usar con -assert-readonly-source\n",
+		"The -output json option is not supported by plan, diff and -dry-run\n":                                                                     "La opción -output json no está soportada por plan, diff y -dry-run\n",
+		"The -post-process option is not supported by plan, diff and -dry-run\n":                                                                    "La opción -post-process no está soportada por plan, diff y -dry-run\n",
+		"The -preserve-dir-owner option is not supported on this platform\n":                                                                        "La opción -preserve-dir-owner no está soportada en esta plataforma\n",
+		"The -quarantine option needs -clamd\n":                                                                                                     "La opción -quarantine necesita -clamd\n",
+		"The -resume option needs -catalog and is not supported by diff\n":                                                                          "La opción -resume necesita -catalog y no está soportada por diff\n",
+		"The -split-large option is not supported by plan, diff, -protect, -immutable, -verify, -windows-attributes, -catalog, -manifest and -on-conflict rename or hash-suffix\n": "La opción -split-large no está soportada por plan, diff, -protect, -immutable, -verify, -windows-attributes, -catalog, -manifest y -on-conflict rename o hash-suffix\n",
+		"The -unsorted folder %s has to be a folder inside the destination\n":                                                                                                      "La carpeta -unsorted %s tiene que ser una carpeta dentro del destino\n",
+		"The -volume option needs -catalog and a single -destination and is not supported by plan and diff\n":                                                                      "La opción -volume necesita -catalog y un único -destination y no está soportada por plan y diff\n",
+		"The -volume option needs the size of the volume passed with -volume-size and the other way round\n":                                                                       "La opción -volume necesita el tamaño del volumen indicado con -volume-size y viceversa\n",
+		"The -watch option is not supported by plan, diff, -dry-run and -retry-from\n":                                                                                             "La opción -watch no está soportada por plan, diff, -dry-run y -retry-from\n",
+		"The -watch-queue option needs -watch\n":                                                                                                                                   "La opción -watch-queue necesita -watch\n",
+		"The -windows-attributes option is only supported on Windows\n":                                                                                                            "La opción -windows-attributes solo está soportada en Windows\n",
+		"The S3 endpoint %s is not a valid URL\n":                                                                                                                                  "El endpoint de S3 %s no es una URL válida\n",
+		"The access times of the source files may be updated since this platform cannot read files without updating them\n":                                                        "Las horas de acceso de los archivos de origen pueden actualizarse porque esta plataforma no puede leer archivos sin actualizarlas\n",
+		"The age %s is not valid. Use one of the units y, m, w or d\n":                                                                                                             "La antigüedad %s no es válida. Use una de las unidades y, m, w o d\n",
+		"The age %s is not valid\n":                                                             "La antigüedad %s no es válida\n",
+		"The bandwidth %s is not valid\n":                                                       "El ancho de banda %s no es válido\n",
+		"The bandwidth window %s is not valid. Use the form 08:00-23:00=10MB\n":                 "La franja de ancho de banda %s no es válida. Use la forma 08:00-23:00=10MB\n",
+		"The compare mode %s is not supported. Use size or hash\n":                              "El modo de comparación %s no está soportado. Use size o hash\n",
+		"The conflict policy %s is not supported. Use overwrite, skip, rename or hash-suffix\n": "La política de conflictos %s no está soportada. Use overwrite, skip, rename o hash-suffix\n",
+		"The copied file %s does not match the %s of %s\n":                                      "El archivo copiado %s no coincide con el %s de %s\n",
+		"The copy %s does not match the moved file %s. It is left in the source\n":              "La copia %s no coincide con el archivo movido %s. Se deja en el origen\n",
+		"The copy %s does not match the source %s and is removed\n":                             "La copia %s no coincide con el origen %s y se elimina\n",
+		"The date %s of -after is not before the date %s of -before\n":                          "La fecha %s de -after no es anterior a la fecha %s de -before\n",
+		"The date %s of -after is not valid. Use a date like 2023-01-01\n":                      "La fecha %s de -after no es válida. Use una fecha como 2023-01-01\n",
+		"The date %s of -before is not valid. Use a date like 2023-01-01\n":                     "La fecha %s de -before no es válida. Use una fecha como 2023-01-01\n",
+		"The date %s of -min-date is not valid. Use a date like 1990-01-01\n":                   "La fecha %s de -min-date no es válida. Use una fecha como 1990-01-01\n",
+		"The date source %s is not supported\n":                                                 "La fuente de fecha %s no está soportada\n",
+		"The destination %s has %d of %d inodes free\n":                                         "El destino %s tiene %d de %d inodos libres\n",
+		"The destination %s has no bucket\n":                                                    "El destino %s no tiene bucket\n",
+		"The destination %s has only %d inodes free, fewer than the %d kept free. Free up inodes, like by archiving many small files into one, or sort onto a file system with more of them\n": "El destino %s solo tiene %d inodos libres, menos que los %d que se reservan. Libere inodos, por ejemplo archivando muchos archivos pequeños en uno, u ordene en un sistema de archivos con más\n",
+		"The destination %s has only %d inodes free. Free up inodes and run again to copy the remaining files\n":                                                                               "El destino %s solo tiene %d inodos libres. Libere inodos y ejecute de nuevo para copiar los archivos restantes\n",
+		"The destination %s is inside the source and is left out of the walk\n":                                                                                                                "El destino %s está dentro del origen y se omite al recorrerlo\n",
+		"The destination %s is inside the source which -assert-readonly-source does not allow\n":                                                                                               "El destino %s está dentro del origen, lo que -assert-readonly-source no permite\n",
+		"The destination %s is not a valid sftp:// url\n":                                                                                                                                      "El destino %s no es una url sftp:// válida\n",
+		"The destination %s is the same as the source %s now\n":                                                                                                                                "El destino %s ahora es el mismo que el origen %s\n",
+		"The destination %s is the volume %s and not %s\n":                                                                                                                                     "El destino %s es el volumen %s y no %s\n",
+		"The directory mode %s is not valid. Use an octal mode like 0750\n":                                                                                                                    "El modo de directorio %s no es válido. Use un modo octal como 0750\n",
+		"The duplicate names policy %s is not supported. Use copy or alias\n":                                                                                                                  "La política de nombres duplicados %s no está soportada. Use copy o alias\n",
+		"The duration %v of -max-future is not valid\n":                                                                                                                                        "La duración %v de -max-future no es válida\n",
+		"The exclude pattern %s is not valid\n":                                                                                                                                                "El patrón de exclusión %s no es válido\n",
+		"The file %s is not a regular file\n":                                                                                                                                                  "El archivo %s no es un archivo normal\n",
+		"The file timeout %v is not valid\n":                                                                                                                                                   "El tiempo límite de archivo %v no es válido\n",
+		"The folders have text besides the date, like the name of an event, which a layout can not produce. The files would be sorted into the folders of the date without it\n": "Las carpetas tienen texto además de la fecha, como el nombre de un evento, que una estructura no puede generar. Los archivos se ordenarían en las carpetas de la fecha sin él\n",
+		"The folders of %d%% of the files have no dates and most files are ebooks, like %s\n":                                                                                    "Las carpetas del %d%% de los archivos no tienen fechas y la mayoría de los archivos son libros electrónicos, como %s\n",
+		"The folders of %d%% of the files have no dates and most files are music, like %s\n":                                                                                     "Las carpetas del %d%% de los archivos no tienen fechas y la mayoría de los archivos son música, como %s\n",
+		"The format %s is not supported\n":                                                           "El formato %s no está soportado\n",
+		"The host and the user of the sftp destination %s must not start with -\n":                   "El host y el usuario del destino sftp %s no deben empezar por -\n",
+		"The language %s is not supported. Use en, de or es\n":                                       "El idioma %s no está soportado. Use en, de o es\n",
+		"The layout %s is not a valid template: %v\n":                                                "La estructura %s no es una plantilla válida: %v\n",
+		"The layout does not reproduce the path of %s exactly, check it with -dry-run first\n":       "La estructura no reproduce exactamente la ruta de %s, compruébela primero con -dry-run\n",
+		"The limit of the run was reached. Run again to copy the remaining files\n":                  "Se alcanzó el límite de la ejecución. Ejecute de nuevo para copiar los archivos restantes\n",
+		"The manifest %s is not valid: %v\n":                                                         "El manifiesto %s no es válido: %v\n",
+		"The manifest of the backup %s could not be read. Encrypted backups are not supported: %v\n": "No se pudo leer el manifiesto de la copia de seguridad %s. Las copias de seguridad cifradas no están soportadas: %v\n",
+		"The maximum number of files per directory %d is not valid\n":                                "El número máximo de %d archivos por directorio no es válido\n",
+		"The moved file %s is left in the source since it was copied in parts\n":                     "El archivo movido %s se deja en el origen porque se copió en partes\n",
+		"The moved file %s is left in the source since parts of it could not be read\n":              "El archivo movido %s se deja en el origen porque no se pudieron leer partes de él\n",
+		"The number of post-processing jobs %d is not valid\n":                                       "El número de %d trabajos de posprocesado no es válido\n",
+		"The number of uploads in flight %d is not valid\n":                                          "El número de %d subidas simultáneas no es válido\n",
+		"The number of workers %d is not valid\n":                                                    "El número de %d trabajadores no es válido\n",
+		"The option %s of the profile %s is not supported\n":                                         "La opción %s del perfil %s no está soportada\n",
+		"The option %s of the profile %s is not valid: %v\n":                                         "La opción %s del perfil %s no es válida: %v\n",
+		"The order %s is not supported\n":                                                            "El orden %s no está soportado\n",
+		"The output format %s is not supported. Use text or json\n":                                  "El formato de salida %s no está soportado. Use text o json\n",
+		"The path %s of %s is inside the source which -assert-readonly-source does not allow\n":      "La ruta %s de %s está dentro del origen, lo que -assert-readonly-source no permite\n",
+		"The pattern %s is not valid: %v\n":                                                          "El patrón %s no es válido: %v\n",
+		"The pattern %s of the ignore file %s is not valid and is skipped\n":                         "El patrón %s del archivo de exclusiones %s no es válido y se omite\n",
+		"The policy %s for files without an extension is not supported. Use include or skip\n":       "La política %s para archivos sin extensión no está soportada. Use include o skip\n",
+		"The post-processing command %s is not valid\n":                                              "El comando de posprocesado %s no es válido\n",
+		"The profile %s is not in the config file %s\n":                                              "El perfil %s no está en el archivo de configuración %s\n",
+		"The rate of %v files per second is not valid\n":                                             "La tasa de %v archivos por segundo no es válida\n",
+		"The report of the run was written to %s\n":                                                  "El informe de la ejecución se escribió en %s\n",
+		"The rule %s is not supported\n":                                                             "La regla %s no está soportada\n",
+		"The run was cancelled":                                                                      "La ejecución fue cancelada",
+		"The s3:// and sftp:// destinations are not supported by plan, diff, -dry-run, -staging, -resume-partial, -delta, -protect, -immutable, -windows-attributes, -max-files-per-dir, -catalog, -manifest, -post-process, -verify and -on-conflict rename or hash-suffix\n": "Los destinos s3:// y sftp:// no están soportados por plan, diff, -dry-run, -staging, -resume-partial, -delta, -protect, -immutable, -windows-attributes, -max-files-per-dir, -catalog, -manifest, -post-process, -verify y -on-conflict rename o hash-suffix\n",
+		"The settle time %v of -watch is not valid\n":                                                                                        "El tiempo de espera %v de -watch no es válido\n",
+		"The size %s is not valid\n":                                                                                                         "El tamaño %s no es válido\n",
+		"The source %s and the destination %s are the same file. Skipping it\n":                                                              "El origen %s y el destino %s son el mismo archivo. Se omite\n",
+		"The source %s is gone\n":                                                                                                            "El origen %s ya no existe\n",
+		"The source %s is not an iTunes/Finder backup: %v\n":                                                                                 "El origen %s no es una copia de seguridad de iTunes/Finder: %v\n",
+		"The tier %s can not be a remote destination. Use -destination\n":                                                                    "El nivel %s no puede ser un destino remoto. Use -destination\n",
+		"The value of %s is not valid: %v\n":                                                                                                 "El valor de %s no es válido: %v\n",
+		"The volume %s has %d of %d bytes used\n":                                                                                            "El volumen %s tiene %d de %d bytes usados\n",
+		"The volume %s is full. Run again with the next volume to copy the remaining files\n":                                                "El volumen %s está lleno. Ejecute de nuevo con el siguiente volumen para copiar los archivos restantes\n",
+		"There are no files in %s to detect the layout from\n":                                                                               "No hay archivos en %s para detectar la estructura\n",
+		"This is the default layout of filesorter and needs no options\n":                                                                    "Esta es la estructura predeterminada de filesorter y no necesita opciones\n",
+		"Usage: filesorter apply [-catalog <catalog path>] [-error-report <report path>] <plan path>\n":                                      "Uso: filesorter apply [-catalog <ruta del catálogo>] [-error-report <ruta del informe>] <ruta del plan>\n",
+		"Usage: filesorter detect-layout -destination <destination path> [-sample <files>]\n":                                                "Uso: filesorter detect-layout -destination <ruta de destino> [-sample <archivos>]\n",
+		"Usage: filesorter estimate-dedup -source <source path> [-catalog <catalog path>] [-top <groups>]\n":                                 "Uso: filesorter estimate-dedup -source <ruta de origen> [-catalog <ruta del catálogo>] [-top <grupos>]\n",
+		"Usage: filesorter export -catalog <catalog path> [-out <file>] [-format json|csv]\n":                                                "Uso: filesorter export -catalog <ruta del catálogo> [-out <archivo>] [-format json|csv]\n",
+		"Usage: filesorter find -catalog <catalog path> [-destination <destination path>] <name, glob or sha256>\n":                          "Uso: filesorter find -catalog <ruta del catálogo> [-destination <ruta de destino>] <nombre, glob o sha256>\n",
+		"Usage: filesorter history -catalog <catalog path> [-limit <runs>]\n":                                                                "Uso: filesorter history -catalog <ruta del catálogo> [-limit <ejecuciones>]\n",
+		"Usage: filesorter import -catalog <catalog path> [-format json|csv] <exported file>\n":                                              "Uso: filesorter import -catalog <ruta del catálogo> [-format json|csv] <archivo exportado>\n",
+		"Usage: filesorter index -catalog <catalog path> -destination <destination path>\n":                                                  "Uso: filesorter index -catalog <ruta del catálogo> -destination <ruta de destino>\n",
+		"Usage: filesorter prune -destination <destination path> -older-than <age> [-catalog <catalog path>] [-trash <path>] [-dry-run]\n":   "Uso: filesorter prune -destination <ruta de destino> -older-than <antigüedad> [-catalog <ruta del catálogo>] [-trash <ruta>] [-dry-run]\n",
+		"Usage: filesorter query -catalog <catalog path> [-ext <ext>] [-year <year>] [-min-size <size>] [-max-size <size>] [-name <text>]\n": "Uso: filesorter query -catalog <ruta del catálogo> [-ext <extensión>] [-year <año>] [-min-size <tamaño>] [-max-size <tamaño>] [-name <texto>]\n",
+		"Usage: filesorter resolve [-rule <rule>] [-catalog <catalog path>] [-dry-run] <conflicts path>\n":                                   "Uso: filesorter resolve [-rule <regla>] [-catalog <ruta del catálogo>] [-dry-run] <ruta de conflictos>\n",
+		"Usage: filesorter undo -manifest <manifest path> [-catalog <catalog path>] [-dry-run]\n":                                            "Uso: filesorter undo -manifest <ruta del manifiesto> [-catalog <ruta del catálogo>] [-dry-run]\n",
+		"Would conflict %s --> %s with %s sorted there before and overwrite it\n":                                                            "Entraría en conflicto %s --> %s con %s ordenado allí antes y lo sobrescribiría\n",
+		"Would conflict %s --> %s with %s sorted there before and skip it\n":                                                                 "Entraría en conflicto %s --> %s con %s ordenado allí antes y lo omitiría\n",
+		"Would copy %s --> %s\n":                        "Se copiaría %s --> %s\n",
+		"Would keep %s and leave out %s\n":              "Se mantendría %s y se omitiría %s\n",
+		"Would move %s --> %s\n":                        "Se movería %s --> %s\n",
+		"Would overwrite %s --> %s (%d and %d bytes)\n": "Se sobrescribiría %s --> %s (%d y %d bytes)\n",
+		"Would prune %s\n":                              "Se eliminaría %s\n",
+		"Would remove %d copies and restore %d sources. Would keep %d, Already gone %d, Errored %d\n": "Se eliminarían %d copias y se restaurarían %d orígenes. Se mantendrían %d, Ya no estaban %d, Con error %d\n",
+		"Would remove %s\n": "Se eliminaría %s\n",
+		"Would remove the partial copy %s left by an earlier run\n":                    "Se eliminaría la copia parcial %s de una ejecución anterior\n",
+		"Would rename %s --> %s, %s is sorted under the same name before\n":            "Se renombraría %s --> %s, %s se ordena antes con el mismo nombre\n",
+		"Would rename %s --> %s, a different file of the same name is already there\n": "Se renombraría %s --> %s, ya hay otro archivo del mismo nombre\n",
+		"Would resume %s --> %s from %d bytes\n":                                       "Se reanudaría %s --> %s desde %d bytes\n",
+		"Would skip %s --> %s, %s of the same size is sorted there before\n":           "Se omitiría %s --> %s, %s del mismo tamaño se ordena allí antes\n",
+		"Would skip %s --> %s, %s with the same content is sorted there before\n":      "Se omitiría %s --> %s, %s con el mismo contenido se ordena allí antes\n",
+		"Would skip %s --> %s, a different file of the same name is already there\n":   "Se omitiría %s --> %s, ya hay otro archivo del mismo nombre\n",
+		"Would skip %s --> %s, a file of the same size is already there\n":             "Se omitiría %s --> %s, ya hay un archivo del mismo tamaño\n",
+		"Would skip %s --> %s, a file with the same content is already there\n":        "Se omitiría %s --> %s, ya hay un archivo con el mismo contenido\n",
+		"Would skip %s, it is older than all the tiers\n":                              "Se omitiría %s, es más antiguo que todos los niveles\n",
+		"Would skip %s, its date %s is outside of -after and -before\n":                "Se omitiría %s, su fecha %s está fuera de -after y -before\n",
+		"Would skip %s, its size of %s is outside of -min-size and -max-size\n":        "Se omitiría %s, su tamaño de %s está fuera de -min-size y -max-size\n",
+		"Would skip %s, its type is not sorted\n":                                      "Se omitiría %s, su tipo no se ordena\n",
+		"Would sort %s into %s, its date %s is not valid\n":                            "Se ordenaría %s en %s, su fecha %s no es válida\n",
+		"Would update %s --> %s\n":                                                     "Se actualizaría %s --> %s\n",
+	},
+}
+
+func init() {
+	for tag, messages := range translations {
+		for key, translation := range messages {
+			message.SetString(tag, key, translation)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package sorter
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// typeCategories are the names which can be passed to -types in place of the extensions they stand
+// for, so that the less common ones like heic or dng are not forgotten.
+var typeCategories = map[string][]string{
+	"images": {"jpg", "jpeg", "jpe", "png", "gif", "bmp", "tif", "tiff", "webp", "heic", "heif", "avif",
+		// raw formats of the common camera makers
+		"dng", "cr2", "cr3", "crw", "nef", "nrw", "arw", "srf", "sr2", "orf", "rw2", "raf", "pef", "srw", "x3f", "3fr", "iiq"},
+	"videos":    {"mp4", "m4v", "mov", "avi", "mkv", "webm", "wmv", "flv", "3gp", "3g2", "mts", "m2ts", "mpg", "mpeg", "vob"},
+	"audio":     {"mp3", "m4a", "aac", "flac", "alac", "wav", "aiff", "aif", "ogg", "oga", "opus", "wma", "ape"},
+	"documents": {"pdf", "doc", "docx", "odt", "rtf", "txt", "md", "xls", "xlsx", "ods", "csv", "ppt", "pptx", "odp", "epub", "pages", "numbers", "key"},
+}
+
+// parseTypes parses a list of extensions and categories separated by a ':' like images:psd into the
+// set of extensions it stands for. The extensions are matched ignoring case and with or without the
+// leading dot, so JPG and .jpg are the same as jpg.
+func parseTypes(list string) map[string]struct{} {
+	types := make(map[string]struct{})
+	for _, v := range strings.Split(list, ":") {
+		v = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(v), "."))
+		if strings.Compare(v, "") == 0 {
+			continue
+		}
+		if extensions, ok := typeCategories[v]; ok {
+			for _, ext := range extensions {
+				types[ext] = struct{}{}
+			}
+			continue
+		}
+		types[v] = struct{}{}
+	}
+	return types
+}
+
+// fileType returns the extension of the file name in lower case without the dot. It is empty for
+// the files without an extension.
+func fileType(name string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+}
+
+// noExtensionPolicies are the -no-extension values. By default the files without an extension are
+// skipped when -types is passed and sorted otherwise.
+var noExtensionPolicies = map[string]bool{"": true, "include": true, "skip": true}
+
+// matchesTypes reports whether the file name passes the -types and -exclude-types filters.
+func matchesTypes(name string, opts *sortOptions) bool {
+	if strings.Compare(fileType(name), "") == 0 && strings.Compare(opts.noExtension, "") != 0 {
+		return opts.noExtension == "include"
+	}
+	if len(opts.filterTypes) > 0 {
+		if _, ok := opts.filterTypes[fileType(name)]; !ok {
+			return false
+		}
+	}
+	_, excluded := opts.excludeTypes[fileType(name)]
+	return !excluded
+}
+
+// describeTypeFilter returns the normalized type filters for the output of the dry runs.
+func describeTypeFilter(opts *sortOptions) string {
+	included := "all"
+	if len(opts.filterTypes) > 0 {
+		included = strings.Join(sortedTypes(opts.filterTypes), ", ")
+	}
+	excluded := "none"
+	if len(opts.excludeTypes) > 0 {
+		excluded = strings.Join(sortedTypes(opts.excludeTypes), ", ")
+	}
+	noExtension := opts.noExtension
+	if strings.Compare(noExtension, "") == 0 {
+		noExtension = "include"
+		if len(opts.filterTypes) > 0 {
+			noExtension = "skip"
+		}
+	}
+	return opts.printer.Sprintf("Types: %s. Excluded: %s. Without an extension: %s", included, excluded, noExtension)
+}
+
+func sortedTypes(types map[string]struct{}) []string {
+	var sorted []string
+	for ext := range types {
+		sorted = append(sorted, ext)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
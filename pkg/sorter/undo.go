@@ -0,0 +1,316 @@
+package sorter
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// undoManifest appends the copies and moves of a run to a file of json lines as they happen, so
+// that 'filesorter undo' can reverse them even after a run which crashed.
+type undoManifest struct {
+	path    string
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// manifestOp is a line of the manifest. op is copy, move for a source renamed into the destination
+// or remove-source for a source removed by -move once it was copied. root is the destination
+// the file was sorted into, below which the emptied folders are removed again.
+type manifestOp struct {
+	Op          string    `json:"op"`
+	Source      string    `json:"source"`
+	Destination string    `json:"destination"`
+	Root        string    `json:"root,omitempty"`
+	Hash        string    `json:"hash,omitempty"`
+	Replaced    bool      `json:"replaced,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+func openUndoManifest(path string) (*undoManifest, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &undoManifest{path: path, file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// record appends the operation. The paths are made absolute so that undo works from anywhere.
+func (m *undoManifest) record(op manifestOp) error {
+	var err error
+	if op.Source, err = filepath.Abs(op.Source); err != nil {
+		return err
+	}
+	if op.Destination, err = filepath.Abs(op.Destination); err != nil {
+		return err
+	}
+	op.Time = time.Now()
+	return m.encoder.Encode(op)
+}
+
+func (m *undoManifest) Close() error {
+	return m.file.Close()
+}
+
+// readUndoManifest reads the operations of a manifest in the order they were recorded. A last line
+// cut short by a crash is left out.
+func readUndoManifest(path string) ([]manifestOp, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var ops []manifestOp
+	for i, line := range lines {
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		var op manifestOp
+		if err := json.Unmarshal([]byte(line), &op); err != nil {
+			if i == len(lines)-1 {
+				break
+			}
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+type undoCount struct {
+	removed  int
+	restored int
+	kept     int
+	gone     int
+	errored  int
+}
+
+// runUndo reverses the operations recorded in a manifest by -manifest, the last one first. The
+// copies are removed, the moved files are moved back and the sources removed by -move are copied
+// back. A copy which changed since or which replaced an earlier file is kept.
+func runUndo(args []string) int {
+
+	flags := flag.NewFlagSet("undo", flag.ExitOnError)
+	manifestPath := flags.String("manifest", "", "The manifest written by the run with -manifest")
+	catalogPath := flags.String("catalog", "", `Optional. The catalog in which the run recorded the copies. Their entries
+	are removed`)
+	dryRun := flags.Bool("dry-run", false, "Optional. Only print what would be undone")
+	lang := AddLanguageFlag(flags)
+	parseFlags(flags, args)
+
+	if err := SetLanguage(*lang); err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	if strings.Compare(*manifestPath, "") == 0 || flags.NArg() != 0 {
+		printer.Printf("Usage: filesorter undo -manifest <manifest path> [-catalog <catalog path>] [-dry-run]\n")
+		flags.PrintDefaults()
+		return 1
+	}
+
+	ops, err := readUndoManifest(*manifestPath)
+	if err != nil {
+		printer.Printf("An error occurred while trying to read the manifest %s: %v\n", *manifestPath, err)
+		return 1
+	}
+
+	var cat *catalog
+	if strings.Compare(*catalogPath, "") != 0 && !*dryRun {
+		cat, err = openCatalog(*catalogPath)
+		if err != nil {
+			printer.Printf("An error occurred while trying to open the catalog %s: %v\n", *catalogPath, err)
+			return 1
+		}
+		defer cat.Close()
+	}
+
+	var counts undoCount
+	// the folders from which files were removed are removed too if they end up empty
+	emptied := make(map[string]string)
+	for i := len(ops) - 1; i >= 0; i-- {
+		op := ops[i]
+		if err := undoOp(op, cat, *dryRun, emptied, &counts); err != nil {
+			printer.Printf("An error occurred while trying to undo the %s of %s to %s: %v\n", op.Op, op.Source, op.Destination, err)
+			counts.errored++
+		}
+	}
+	if !*dryRun {
+		removeEmptiedDirs(emptied)
+	}
+
+	printer.Printf("Completed !\n")
+	if *dryRun {
+		printer.Printf("Would remove %d copies and restore %d sources. Would keep %d, Already gone %d, Errored %d\n",
+			counts.removed, counts.restored, counts.kept, counts.gone, counts.errored)
+	} else {
+		printer.Printf("Removed %d copies and restored %d sources. Kept %d, Already gone %d, Errored %d\n",
+			counts.removed, counts.restored, counts.kept, counts.gone, counts.errored)
+	}
+	if counts.errored > 0 {
+		return 1
+	}
+	return 0
+}
+
+// undoOp reverses one operation. The folders it removed a file from are added to emptied along
+// with the destination they belong to.
+func undoOp(op manifestOp, cat *catalog, dryRun bool, emptied map[string]string, counts *undoCount) error {
+
+	_, err := os.Stat(op.Destination)
+	if os.IsNotExist(err) {
+		// a source removed by -move can not be restored without its copy
+		if strings.Compare(op.Op, "remove-source") == 0 {
+			if _, err := os.Stat(op.Source); err != nil {
+				return fmt.Errorf("the copy is gone so the source can not be restored")
+			}
+		}
+		counts.gone++
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch op.Op {
+	case "copy":
+		if op.Replaced {
+			printer.Printf("Kept %s, it replaced an earlier file which can not be restored\n", op.Destination)
+			counts.kept++
+			return nil
+		}
+		changed, err := changedSince(op)
+		if err != nil {
+			return err
+		}
+		if changed {
+			printer.Printf("Kept %s, it changed since it was copied\n", op.Destination)
+			counts.kept++
+			return nil
+		}
+		if dryRun {
+			printer.Printf("Would remove %s\n", op.Destination)
+			counts.removed++
+			return nil
+		}
+		if err := os.Remove(op.Destination); err != nil {
+			return err
+		}
+		printer.Printf("Removed %s\n", op.Destination)
+		counts.removed++
+
+	case "move":
+		if _, err := os.Stat(op.Source); err == nil {
+			printer.Printf("Kept %s, a file is at its source %s again\n", op.Destination, op.Source)
+			counts.kept++
+			return nil
+		}
+		if dryRun {
+			printer.Printf("Would move %s --> %s\n", op.Destination, op.Source)
+			counts.restored++
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(op.Source), 0755); err != nil {
+			return err
+		}
+		if err := moveFile(op.Destination, op.Source); err != nil {
+			return err
+		}
+		printer.Printf("Moved %s --> %s\n", op.Destination, op.Source)
+		counts.restored++
+
+	case "remove-source":
+		// the copies themselves are removed by their own entries which come before in the manifest
+		if _, err := os.Stat(op.Source); err == nil {
+			counts.gone++
+			return nil
+		}
+		changed, err := changedSince(op)
+		if err != nil {
+			return err
+		}
+		if changed {
+			return fmt.Errorf("the copy changed since so the source is not restored from it")
+		}
+		if dryRun {
+			printer.Printf("Would copy %s --> %s\n", op.Destination, op.Source)
+			counts.restored++
+			return nil
+		}
+		if err := restoreSource(op); err != nil {
+			return err
+		}
+		printer.Printf("Copied %s --> %s\n", op.Destination, op.Source)
+		counts.restored++
+		return nil
+
+	default:
+		return fmt.Errorf("the operation %s is not supported", op.Op)
+	}
+
+	if cat != nil {
+		if err := cat.remove(op.Destination); err != nil {
+			return err
+		}
+	}
+	if strings.Compare(op.Root, "") != 0 {
+		emptied[filepath.Dir(op.Destination)] = op.Root
+	}
+	return nil
+}
+
+// changedSince reports whether the file at the destination is not the one the run wrote.
+func changedSince(op manifestOp) (bool, error) {
+	if strings.Compare(op.Hash, "") == 0 {
+		return false, nil
+	}
+	hash, err := defaultIO.hashFile(op.Destination)
+	if err != nil {
+		return false, err
+	}
+	return hash != op.Hash, nil
+}
+
+// restoreSource copies a file moved by -move back to its source.
+func restoreSource(op manifestOp) error {
+	fileInfo, err := os.Stat(op.Destination)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(op.Source), 0755); err != nil {
+		return err
+	}
+	if _, _, err := defaultIO.copyFile(op.Destination, op.Source); err != nil {
+		return err
+	}
+	return os.Chtimes(op.Source, fileInfo.ModTime(), fileInfo.ModTime())
+}
+
+// removeEmptiedDirs removes the folders which files were removed from and the ones above them
+// up to their destination, as long as they are empty. Removing fails for the ones which are not.
+func removeEmptiedDirs(emptied map[string]string) {
+	for dir, root := range emptied {
+		root = filepath.Clean(root)
+		for dir = filepath.Clean(dir); strings.HasPrefix(dir, root+string(filepath.Separator)); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package sorter
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultUnsortedFolder is the folder of the destinations the files with an invalid date go to.
+const defaultUnsortedFolder = "_unsorted"
+
+// defaultMaxFuture is how far in the future a date may be by default, to allow for the clocks and
+// time zones of the cameras being a bit off.
+const defaultMaxFuture = 24 * time.Hour
+
+// unsortedDates sends the files whose date is clearly wrong, like the start of the unix epoch
+// from a camera with a reset clock or a date in the future, into a folder of their own instead of
+// a misleading one like 1970/January/1. They are listed at the end of the run to be sorted by hand.
+type unsortedDates struct {
+	folder string
+	// earliest is the first valid date and maxFuture how far after the current time a date may be.
+	// the latest date moves along with the time for -watch
+	earliest  time.Time
+	maxFuture time.Duration
+}
+
+// newUnsortedDates returns nil when the folder is none, in which case the files are sorted by
+// whatever date they have. The earliest valid date is 1980 and the latest a day from now unless
+// minDate and maxFuture are passed.
+func newUnsortedDates(folder string, minDate string, maxFuture time.Duration, printer *outputPrinter) (*unsortedDates, error) {
+	if strings.Compare(folder, "") == 0 {
+		folder = defaultUnsortedFolder
+	}
+	if strings.Compare(folder, "none") == 0 {
+		if strings.Compare(minDate, "") != 0 || maxFuture != 0 {
+			return nil, printer.errorf("The -min-date and -max-future options are not supported with -unsorted none\n")
+		}
+		return nil, nil
+	}
+	if err := checkRelativePath(folder); err != nil {
+		return nil, printer.errorf("The -unsorted folder %s has to be a folder inside the destination\n", folder)
+	}
+
+	// no digital file is older than the earliest date of FAT file systems
+	u := &unsortedDates{
+		folder:    filepath.Clean(folder),
+		earliest:  time.Date(1980, time.January, 1, 0, 0, 0, 0, time.Local),
+		maxFuture: defaultMaxFuture,
+	}
+	if strings.Compare(minDate, "") != 0 {
+		var err error
+		if u.earliest, err = parseISODate(minDate); err != nil {
+			return nil, printer.errorf("The date %s of -min-date is not valid. Use a date like 1990-01-01\n", minDate)
+		}
+	}
+	if maxFuture < 0 {
+		return nil, printer.errorf("The duration %v of -max-future is not valid\n", maxFuture)
+	}
+	if maxFuture > 0 {
+		u.maxFuture = maxFuture
+	}
+	return u, nil
+}
+
+func (u *unsortedDates) valid(date time.Time) bool {
+	return !date.Before(u.earliest) && !date.After(time.Now().Add(u.maxFuture))
+}
+
+func (u *unsortedDates) path(name string) string {
+	return filepath.Join(u.folder, name)
+}
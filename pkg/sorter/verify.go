@@ -0,0 +1,274 @@
+package sorter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type verifyCount struct {
+	checkedFiles   int
+	missingFiles   int
+	corruptedFiles int
+	repairedFiles  int
+	unrepairable   []string
+	// problems are the missing and corrupted files which are alerted
+	problems []alertFile
+	// unrepairedCorruption is the number of corrupted files which were not repaired
+	unrepairedCorruption int
+}
+
+func runVerify(args []string) int {
+
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	catalogPath := flags.String("catalog", "", "The catalog database written by previous sort runs.")
+	sample := flags.String("sample", "", `Optional. Verify only a random subset of the catalog. Either a count
+	like 500 or a percentage like 5%`)
+	repair := flags.Bool("repair", false, `Optional. Copy the missing and corrupted files again from their source
+	if the source is still available and unchanged. Needs -destination`)
+	destPath := flags.String("destination", "", `Optional. The destination the files of the catalog were sorted into.
+	Only its files are repaired, like sort would write them into it`)
+	dirMode := flags.String("dir-mode", "", `Optional. The octal mode like 0750 or 2775 of the directories created
+	by -repair, like for sort`)
+	preserveDirOwner := flags.Bool("preserve-dir-owner", false, `Optional. Give the directories created by -repair
+	the owner and group of the directory they are created in, like for sort`)
+	protect := flags.Bool("protect", false, `Optional. Make the repaired files read-only once their content is
+	verified, like for sort`)
+	immutable := flags.Bool("immutable", false, `Optional. Like -protect and also set the immutable flag on the
+	repaired files, like for sort`)
+	alerts := AddAlertFlags(flags)
+	lang := AddLanguageFlag(flags)
+	parseFlags(flags, args)
+
+	if err := SetLanguage(*lang); err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	if strings.Compare(*catalogPath, "") == 0 || *repair && strings.Compare(*destPath, "") == 0 {
+		printer.Printf("Usage: filesorter verify -catalog <catalog path> [-sample <count or percentage>] [-repair -destination <destination path>]\n")
+		flags.PrintDefaults()
+		return 1
+	}
+	if err := alerts.validate(printer); err != nil {
+		printer.Println(err)
+		return 1
+	}
+	mode, err := parseDirMode(*dirMode, printer)
+	if err != nil {
+		printer.Println(err)
+		return 1
+	}
+	repairs := &repairer{
+		destination: *destPath,
+		dirs:        newCreatedDirs(mode, *preserveDirOwner),
+		protect:     *protect || *immutable,
+		immutable:   *immutable,
+	}
+
+	cat, err := openCatalog(*catalogPath)
+	if err != nil {
+		printer.Printf("An error occurred while trying to open the catalog %s: %v\n", *catalogPath, err)
+		return 1
+	}
+	defer cat.Close()
+
+	total, err := cat.count()
+	if err != nil {
+		printer.Printf("An error occurred while trying to read the catalog %s: %v\n", *catalogPath, err)
+		return 1
+	}
+
+	limit, err := sampleSize(*sample, total)
+	if err != nil {
+		printer.Println(err)
+		return 1
+	}
+
+	entries, err := cat.randomEntries(limit)
+	if err != nil {
+		printer.Printf("An error occurred while trying to read the catalog %s: %v\n", *catalogPath, err)
+		return 1
+	}
+
+	var counts verifyCount
+	for _, entry := range entries {
+		intact, corrupted := verifyEntry(entry, &counts)
+		if intact {
+			continue
+		}
+		problem := &counts.problems[len(counts.problems)-1]
+		if !*repair {
+			if corrupted {
+				counts.unrepairedCorruption++
+			}
+			continue
+		}
+		if err := repairs.repair(entry); err != nil {
+			printer.Printf("Could not repair %s: %v\n", entry.destPath, err)
+			counts.unrepairable = append(counts.unrepairable, entry.destPath)
+			problem.Detail += printer.Sprintf(", could not be repaired: %v", err)
+			if corrupted {
+				counts.unrepairedCorruption++
+			}
+			continue
+		}
+		printer.Printf("Repaired %s --> %s\n", entry.sourcePath, entry.destPath)
+		problem.Detail += printer.Sprintf(", repaired")
+		counts.repairedFiles++
+	}
+
+	printVerifyReport(&counts, total)
+
+	// repaired files are alerted too since the corruption may be a sign of a failing disk, and so
+	// are the missing ones which the archive lost
+	if counts.corruptedFiles+counts.missingFiles > 0 {
+		sendAlert(alerts, "corruption", printer.Sprintf("%d corrupted and %d missing files found by verify", counts.corruptedFiles, counts.missingFiles), counts.problems, printer)
+	}
+
+	if counts.unrepairedCorruption > 0 {
+		return ExitCorruption
+	}
+	if counts.missingFiles+counts.corruptedFiles > counts.repairedFiles {
+		return 1
+	}
+	return 0
+}
+
+// verifyEntry checks the destination file of the entry against its recorded hash and returns
+// whether the file is intact, and else whether it is corrupted rather than missing.
+func verifyEntry(entry catalogEntry, counts *verifyCount) (intact bool, corrupted bool) {
+	counts.checkedFiles++
+
+	hash, err := defaultIO.hashFile(entry.destPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			printer.Printf("Missing %s\n", entry.destPath)
+			counts.missingFiles++
+			counts.problems = append(counts.problems, alertFile{Path: entry.destPath, Detail: printer.Sprintf("missing")})
+			return false, false
+		}
+		printer.Printf("An error occurred while trying to read the file %s: %v\n", entry.destPath, err)
+		counts.corruptedFiles++
+		counts.problems = append(counts.problems, alertFile{Path: entry.destPath, Detail: printer.Sprintf("unreadable: %v", err)})
+		return false, true
+	}
+
+	if hash != entry.hash {
+		printer.Printf("Corrupted %s\n", entry.destPath)
+		counts.corruptedFiles++
+		counts.problems = append(counts.problems, alertFile{
+			Path:   entry.destPath,
+			Detail: printer.Sprintf("corrupted: sha256 %s instead of %s", hash, entry.hash),
+		})
+		return false, true
+	}
+	return true, false
+}
+
+// repairer copies the missing and corrupted files again the way sort writes them, so that a repaired
+// file gets the same directories and protection as the other files of the destination.
+type repairer struct {
+	destination string
+	dirs        *createdDirs
+	protect     bool
+	immutable   bool
+}
+
+// repair copies the source of the entry to its destination again. The copy is staged and only
+// replaces what is left of the file once its content matches the hash recorded when the source was
+// first copied, so that a failed repair keeps the file as it was.
+func (r *repairer) repair(entry catalogEntry) error {
+
+	if strings.Compare(entry.sourcePath, "") == 0 {
+		return printer.errorf("the source is not known since the file was added by index\n")
+	}
+
+	relativePath, err := filepath.Rel(r.destination, entry.destPath)
+	if err != nil || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) || relativePath == ".." {
+		return printer.errorf("the file is not in the destination %s\n", r.destination)
+	}
+	if err := r.dirs.mkdirAll(r.destination, filepath.Dir(entry.destPath)); err != nil {
+		return err
+	}
+
+	sourceFile, err := defaultIO.openSource(entry.sourcePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return printer.errorf("the source %s is no longer available\n", entry.sourcePath)
+		}
+		return err
+	}
+	defer sourceFile.Close()
+
+	destFile, err := defaultIO.stageDestination(sourceFile, entry.destPath)
+	if err != nil {
+		return err
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(destFile, hash), sourceFile)
+	if err == nil && hex.EncodeToString(hash.Sum(nil)) != entry.hash {
+		err = printer.errorf("the source %s has changed since it was copied\n", entry.sourcePath)
+	}
+	// a protected copy is made writable and mutable again so that the repair can replace it
+	if err == nil {
+		err = unprotectFile(entry.destPath)
+	}
+	if err = defaultIO.unstage(destFile, entry.destPath, entry.modTime, err); err != nil {
+		return err
+	}
+
+	if r.protect {
+		return defaultIO.protectFile(entry.destPath, entry.hash, r.immutable)
+	}
+	return nil
+}
+
+// sampleSize converts the -sample value to the number of entries to be verified.
+// An empty value means all the entries which is returned as -1.
+func sampleSize(sample string, total int) (int, error) {
+	if strings.Compare(sample, "") == 0 {
+		return -1, nil
+	}
+
+	if strings.HasSuffix(sample, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(sample, "%"), 64)
+		if err != nil || percent <= 0 || percent > 100 {
+			return 0, printer.errorf("The sample %s is not a valid percentage\n", sample)
+		}
+		// round up so that a small percentage of a small catalog still verifies something
+		size := int(float64(total) * percent / 100)
+		if float64(size) < float64(total)*percent/100 {
+			size++
+		}
+		return size, nil
+	}
+
+	size, err := strconv.Atoi(sample)
+	if err != nil || size <= 0 {
+		return 0, printer.errorf("The sample %s is not a valid count\n", sample)
+	}
+	return size, nil
+}
+
+func printVerifyReport(counts *verifyCount, total int) {
+	printer.Printf("Completed !\n")
+	printer.Printf("Verified %d of %d files. Missing %d, Corrupted %d, Repaired %d\n",
+		counts.checkedFiles,
+		total,
+		counts.missingFiles,
+		counts.corruptedFiles,
+		counts.repairedFiles)
+
+	if len(counts.unrepairable) > 0 {
+		printer.Printf("The following files could not be repaired:\n")
+		for _, path := range counts.unrepairable {
+			printer.Println(path)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package sorter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// A repair replaces the copy only with a staged copy which matches the catalog, so that a source
+// which changed leaves the corrupted copy as it was.
+func TestRepair(t *testing.T) {
+	source := t.TempDir()
+	writeFiles(t, source, map[string]string{"IMG_0001.jpg": "photo", "IMG_0002.jpg": "edited photo"})
+	sum := sha256.Sum256([]byte("photo"))
+	hash := hex.EncodeToString(sum[:])
+	modTime := time.Date(2023, time.July, 15, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name      string
+		source    string
+		corrupted bool
+		protected bool
+		outside   bool
+		repaired  bool
+	}{
+		{"corrupted", "IMG_0001.jpg", true, false, false, true},
+		{"protected", "IMG_0001.jpg", true, true, false, true},
+		{"missing", "IMG_0001.jpg", false, false, false, true},
+		{"changed source", "IMG_0002.jpg", true, false, false, false},
+		{"outside of the destination", "IMG_0001.jpg", false, false, true, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			destination := t.TempDir()
+			destPath := filepath.Join(destination, "2023", "July", "15", "IMG_0001.jpg")
+			if test.outside {
+				destPath = filepath.Join(t.TempDir(), "IMG_0001.jpg")
+			}
+			if test.corrupted {
+				writeFiles(t, destination, map[string]string{"2023/July/15/IMG_0001.jpg": "phoXo"})
+				if test.protected {
+					if err := os.Chmod(destPath, 0444); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			repairs := &repairer{destination: destination, dirs: newCreatedDirs(0750, false), protect: true}
+			entry := catalogEntry{sourcePath: filepath.Join(source, test.source), destPath: destPath, modTime: modTime, hash: hash}
+			err := repairs.repair(entry)
+			if (err == nil) != test.repaired {
+				t.Fatalf("the repair returned %v, want repaired %v", err, test.repaired)
+			}
+
+			content, readErr := os.ReadFile(destPath)
+			switch {
+			case test.repaired:
+				if string(content) != "photo" {
+					t.Errorf("the repaired file contains %q", content)
+				}
+				fileInfo, err := os.Stat(destPath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if fileInfo.Mode().Perm()&0222 != 0 || !fileInfo.ModTime().Equal(modTime) {
+					t.Errorf("the repaired file has the mode %v and the modified time %v", fileInfo.Mode(), fileInfo.ModTime())
+				}
+				dirInfo, err := os.Stat(filepath.Dir(destPath))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !test.corrupted && dirInfo.Mode().Perm() != 0750 {
+					t.Errorf("the directory was created with the mode %v, want 0750", dirInfo.Mode().Perm())
+				}
+			case test.corrupted:
+				if string(content) != "phoXo" {
+					t.Errorf("the failed repair left %q, want the corrupted file", content)
+				}
+			default:
+				if !os.IsNotExist(readErr) {
+					t.Errorf("the failed repair wrote the file outside of the destination")
+				}
+			}
+			if _, err := os.Stat(destPath + tempSuffix); !os.IsNotExist(err) {
+				t.Errorf("the repair left the staged copy behind")
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package sorter
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// videoTypes are the videos in the ISO base media format of QuickTime and MP4 whose movie header
+// holds the time they were recorded.
+var videoTypes = map[string]struct{}{
+	".mp4": {}, ".m4v": {}, ".mov": {}, ".qt": {}, ".3gp": {}, ".3g2": {},
+}
+
+// quickTimeEpoch is the start of the times in QuickTime and MP4 files, in seconds before 1970.
+const quickTimeEpoch = 2082844800
+
+// videoDate reads the creation time from the movie header mvhd in the moov box of mp4 and mov
+// files. Unlike the modified time it survives the copies and the uploads of the video. Cameras
+// which do not know the date write 0, which is taken as no date.
+func (r *runIO) videoDate(path string) (time.Time, bool, error) {
+	if _, ok := videoTypes[strings.ToLower(filepath.Ext(path))]; !ok {
+		return time.Time{}, false, nil
+	}
+
+	file, err := r.openSource(path)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	defer file.Close()
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	// the moov box is often at the end, after the media data
+	moovStart, moovEnd, err := findBox(file, 0, fileInfo.Size(), "moov")
+	if err != nil || moovStart < 0 {
+		return time.Time{}, false, err
+	}
+	mvhdStart, mvhdEnd, err := findBox(file, moovStart, moovEnd, "mvhd")
+	if err != nil || mvhdStart < 0 {
+		return time.Time{}, false, err
+	}
+
+	// a version and flags come first, then the creation time in 32 bits in version 0 and 64 in 1
+	header := make([]byte, 12)
+	if mvhdEnd-mvhdStart < int64(len(header)) {
+		return time.Time{}, false, errInvalidBox
+	}
+	if _, err := file.ReadAt(header, mvhdStart); err != nil {
+		return time.Time{}, false, err
+	}
+	var created uint64
+	switch header[0] {
+	case 0:
+		created = uint64(binary.BigEndian.Uint32(header[4:8]))
+	case 1:
+		created = binary.BigEndian.Uint64(header[4:12])
+	default:
+		return time.Time{}, false, nil
+	}
+	if created <= quickTimeEpoch {
+		return time.Time{}, false, nil
+	}
+	return time.Unix(int64(created-quickTimeEpoch), 0), true, nil
+}
@@ -0,0 +1,86 @@
+package sorter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// errVolumeFull stops the walk once the next file does not fit on the volume. The run onto the
+// next volume continues with the files which were not copied.
+var errVolumeFull = errors.New("the volume is full")
+
+// volumeLabelFile is written to the root of a volume so that a volume is not mistaken for another.
+const volumeLabelFile = ".filesorter-volume"
+
+// volumeSpan fills a destination of a fixed size, like a Blu-ray or a disk, and leaves the rest of
+// the source to the next volumes. The catalog records the volume of every copied file.
+type volumeSpan struct {
+	label string
+	size  int64
+	// used is the size of the files copied onto the volume by this and the earlier runs
+	used int64
+	full bool
+}
+
+func newVolumeSpan(label string, size string, printer *outputPrinter) (*volumeSpan, error) {
+	maxBytes, err := parseSize(size, printer)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Compare(label, "") == 0 || maxBytes <= 0 {
+		return nil, printer.errorf("The -volume option needs the size of the volume passed with -volume-size and the other way round\n")
+	}
+	return &volumeSpan{label: label, size: maxBytes}, nil
+}
+
+// open reads how much of the volume is used from the catalog and checks the label at the root of
+// the destination. A destination without a label is labelled unless only looking.
+func (v *volumeSpan) open(destPath string, cat *catalog, write bool, printer *outputPrinter) error {
+	labelPath := filepath.Join(destPath, volumeLabelFile)
+	content, err := os.ReadFile(labelPath)
+	if err == nil {
+		if label := strings.TrimSpace(string(content)); label != v.label {
+			return printer.errorf("The destination %s is the volume %s and not %s\n", destPath, label, v.label)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	} else if write {
+		if err := os.WriteFile(labelPath, []byte(v.label+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+
+	cat.volume = v.label
+	v.used, err = cat.volumeBytes(v.label)
+	return err
+}
+
+// copiedElsewhere reports whether the source file was copied onto another volume by an earlier run.
+func (v *volumeSpan) copiedElsewhere(path string, sourceFileStat os.FileInfo, cat *catalog) (bool, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+	volume, ok, err := cat.sourceVolume(absPath, sourceFileStat.Size(), sourceFileStat.ModTime())
+	if err != nil || !ok {
+		return false, err
+	}
+	return volume != v.label, nil
+}
+
+// take counts a source file of the size onto the volume. A file larger than a whole volume errors
+// so that it does not stop every run.
+func (v *volumeSpan) take(size int64) error {
+	if size > v.size {
+		return fmt.Errorf("the file of %d bytes is larger than a volume of %d bytes", size, v.size)
+	}
+	if v.used+size > v.size {
+		v.full = true
+		return errVolumeFull
+	}
+	v.used += size
+	return nil
+}
@@ -0,0 +1,183 @@
+package sorter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/karrick/godirwalk"
+)
+
+// defaultWatchSettle is how long a file has to stay unchanged before it is sorted by -watch, so
+// that the files which are still being written are not copied halfway.
+const defaultWatchSettle = 5 * time.Second
+
+// watchedFile is a file created or changed in the source which waits to settle.
+type watchedFile struct {
+	lastChange time.Time
+	size       int64
+}
+
+// watchSource sorts the files created or changed in the source until the run is cancelled. The
+// directories are watched one by one since the notifications are not recursive, and the ones
+// created later are added as they appear along with the files already in them. The pending files
+// are kept in the queue when one is passed.
+func watchSource(sourcePath string, settle time.Duration, queue *watchQueue, opts *sortOptions, counts *processedCount) error {
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	pending := make(map[string]*watchedFile)
+	if err := watchDir(watcher, sourcePath, nil, opts); err != nil {
+		return err
+	}
+	// changed is set when the pending files changed since the queue was written
+	changed := false
+	if queue != nil {
+		if err := queue.load(pending, opts); err != nil {
+			return opts.printer.errorf("An error occurred while trying to read the watch queue %s: %v\n", queue.path, err)
+		}
+		changed = true
+	}
+	opts.printer.Printf("Watching %s for new files. Files are sorted once they did not change for %v\n", sourcePath, settle)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			// the rules are read again when an ignore file changed
+			if strings.Compare(filepath.Base(event.Name), sourceIgnoreFile) == 0 {
+				opts.ignores.reset()
+			}
+			if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+				if _, ok := pending[event.Name]; ok {
+					delete(pending, event.Name)
+					changed = true
+				}
+				continue
+			}
+			if event.Op&(fsnotify.Create|fsnotify.Write) == 0 {
+				continue
+			}
+			fileInfo, err := os.Lstat(event.Name)
+			if err != nil {
+				continue
+			}
+			if fileInfo.IsDir() {
+				if event.Op&fsnotify.Create != 0 {
+					changed = true
+					if err := watchDir(watcher, event.Name, pending, opts); err != nil {
+						opts.printer.Printf("An error occurred while trying to watch the directory %s: %v\n", event.Name, err)
+					}
+				}
+				continue
+			}
+			if _, ok := pending[event.Name]; !ok {
+				changed = true
+			}
+			pending[event.Name] = &watchedFile{lastChange: time.Now(), size: fileInfo.Size()}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			opts.printer.Printf("An error occurred while trying to watch the source %s: %v\n", sourcePath, err)
+
+		case now := <-ticker.C:
+			if queue != nil && changed {
+				if err := queue.save(pending); err != nil {
+					opts.printer.Printf("An error occurred while trying to write the watch queue %s: %v\n", queue.path, err)
+				}
+				changed = false
+			}
+			if opts.control.wait() {
+				return nil
+			}
+			if opts.copyPool != nil {
+				opts.copyPool.collect(opts, counts)
+			}
+			before := len(pending)
+			err := sortSettledFiles(pending, now, settle, opts, counts)
+			changed = changed || len(pending) != before
+			if stopsRun(err) {
+				if queue != nil {
+					queue.save(pending)
+				}
+				return nil
+			}
+		}
+	}
+}
+
+// watchDir watches the directory and the directories below it which are not excluded. When pending
+// is passed the files already in them are queued, for a directory which was moved into the source.
+func watchDir(watcher *fsnotify.Watcher, dir string, pending map[string]*watchedFile, opts *sortOptions) error {
+	return godirwalk.Walk(dir, &godirwalk.Options{
+		Unsorted: false,
+		Callback: func(path string, dirent *godirwalk.Dirent) error {
+			if !dirent.IsDir() {
+				if pending != nil {
+					pending[path] = &watchedFile{lastChange: time.Now(), size: -1}
+				}
+				return nil
+			}
+			if isExcludedDir(path, dirent.Name(), opts) {
+				return filepath.SkipDir
+			}
+			return watcher.Add(path)
+		},
+		ErrorCallback: func(_ string, err error) godirwalk.ErrorAction {
+			return godirwalk.SkipNode
+		},
+	})
+}
+
+// sortSettledFiles sorts the pending files which did not change for the settle time. A file whose
+// size changed without a notification, like on some network shares, waits once more.
+// The destinations are listed again for every batch, since folders and files may have been removed
+// or added there since the last one.
+func sortSettledFiles(pending map[string]*watchedFile, now time.Time, settle time.Duration, opts *sortOptions, counts *processedCount) error {
+	listed := false
+	for path, file := range pending {
+		if now.Sub(file.lastChange) < settle {
+			continue
+		}
+		fileInfo, err := os.Stat(path)
+		if err != nil {
+			delete(pending, path)
+			continue
+		}
+		if fileInfo.Size() != file.size {
+			file.lastChange, file.size = now, fileInfo.Size()
+			continue
+		}
+		delete(pending, path)
+
+		if !listed {
+			// the copies still in progress are not in the new listings yet
+			if opts.copyPool != nil {
+				opts.copyPool.wait(opts, counts)
+			}
+			opts.destEntries.reset()
+			opts.createdDirs.reset()
+			listed = true
+		}
+		dirent, err := godirwalk.NewDirent(path)
+		if err != nil {
+			continue
+		}
+		if err := processFile(path, dirent, fileInfo, opts, counts); stopsRun(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package sorter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// watchQueue keeps the files -watch waits to sort in a json file, so that the ones noticed just
+// before a crash or a reboot are sorted once it runs again.
+type watchQueue struct {
+	path string
+}
+
+type queuedWatchFile struct {
+	Path    string    `json:"path"`
+	Noticed time.Time `json:"noticed"`
+}
+
+// load adds the files of the queue to the pending ones. The files which are gone and the ones the
+// catalog records as copied unchanged, like by the walk before the watch, are left out. The rest
+// are sorted at the next tick if they did not change meanwhile.
+func (q *watchQueue) load(pending map[string]*watchedFile, opts *sortOptions) error {
+	content, err := os.ReadFile(q.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var queued []queuedWatchFile
+	if err := json.Unmarshal(content, &queued); err != nil {
+		return err
+	}
+
+	replayed := 0
+	for _, file := range queued {
+		fileInfo, err := os.Stat(file.Path)
+		if err != nil || fileInfo.IsDir() {
+			continue
+		}
+		if opts.catalog != nil {
+			absPath, err := filepath.Abs(file.Path)
+			if err != nil {
+				return err
+			}
+			_, copied, err := opts.catalog.sourceHash(absPath, fileInfo.Size(), fileInfo.ModTime())
+			if err != nil {
+				return err
+			}
+			if copied {
+				continue
+			}
+		}
+		pending[file.Path] = &watchedFile{size: fileInfo.Size()}
+		replayed++
+	}
+	if len(queued) > 0 {
+		opts.printer.Printf("Sorting %d of the %d files left in the watch queue %s\n", replayed, len(queued), q.path)
+	}
+	return nil
+}
+
+// save writes the pending files. The queue is replaced with a rename so that a
+// crash while writing it does not lose the earlier one.
+func (q *watchQueue) save(pending map[string]*watchedFile) error {
+	queued := []queuedWatchFile{}
+	for path, file := range pending {
+		queued = append(queued, queuedWatchFile{Path: path, Noticed: file.lastChange})
+	}
+	content, err := json.MarshalIndent(queued, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp := q.path + tempSuffix
+	if err := os.WriteFile(temp, append(content, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp, q.path)
+}
@@ -0,0 +1,115 @@
+package sorter
+
+import (
+	"sync"
+	"time"
+)
+
+// copyPool writes the copies of several files at the same time, for destinations like a NAS where
+// a single copy does not use the available bandwidth. Only the reads and writes of the files run in
+// the workers. The walk and the bookkeeping of the finished copies stay in the goroutine of the run
+// so that the counts, the catalog and the created directories need no locking.
+type copyPool struct {
+	workers int
+	// timeout is the -file-timeout after which a worker gives up on a copy
+	timeout time.Duration
+	jobs    chan *copyJob
+	done    chan *copyJob
+	wg      sync.WaitGroup
+	pending int
+	// inFlight are the destination paths of the copies being written
+	inFlight map[string]struct{}
+}
+
+func newCopyPool(workers int, timeout time.Duration) *copyPool {
+	return &copyPool{workers: workers, timeout: timeout, inFlight: make(map[string]struct{})}
+}
+
+func (p *copyPool) start() {
+	p.jobs = make(chan *copyJob)
+	p.done = make(chan *copyJob, p.workers)
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for job := range p.jobs {
+				job.copyWithin(p.timeout)
+				p.done <- job
+			}
+		}()
+	}
+}
+
+// submit hands the file to a worker. The copies which finished meanwhile are recorded while it
+// waits for a free worker.
+func (p *copyPool) submit(job *copyJob, opts *sortOptions, counts *processedCount) {
+	for _, c := range job.copies {
+		p.inFlight[c.destFilePath] = struct{}{}
+	}
+	for {
+		select {
+		case p.jobs <- job:
+			p.pending++
+			return
+		case finished := <-p.done:
+			p.finish(finished, opts, counts)
+		}
+	}
+}
+
+// waitFor returns once the destination path is not being copied to.
+func (p *copyPool) waitFor(destFilePath string, opts *sortOptions, counts *processedCount) {
+	for {
+		if _, ok := p.inFlight[destFilePath]; !ok {
+			return
+		}
+		p.finish(<-p.done, opts, counts)
+	}
+}
+
+// wait records all the copies in progress once they finished.
+func (p *copyPool) wait(opts *sortOptions, counts *processedCount) {
+	for p.pending > 0 {
+		p.finish(<-p.done, opts, counts)
+	}
+}
+
+// drain waits for all the copies and stops the workers.
+func (p *copyPool) drain(opts *sortOptions, counts *processedCount) {
+	p.wait(opts, counts)
+	close(p.jobs)
+	p.wg.Wait()
+}
+
+// finish records the written copies like processFile does for the files copied in place.
+func (p *copyPool) finish(job *copyJob, opts *sortOptions, counts *processedCount) {
+	p.pending--
+	for _, c := range job.copies {
+		delete(p.inFlight, c.destFilePath)
+	}
+	var eventsBefore int
+	if opts.output != nil {
+		eventsBefore = opts.output.events
+	}
+	err := job.finish(opts, counts)
+	if err != nil && !stopsRun(err) {
+		counts.erroredFiles++
+		counts.errors = append(counts.errors, newFileError(job.path, err))
+		if opts.output != nil {
+			opts.output.fileResult(job.path, eventsBefore, counts.skippedFiles, err, counts)
+		}
+	}
+}
+
+// collect records the copies which finished meanwhile without waiting for the others, for a run
+// which sits idle between the files like -watch.
+func (p *copyPool) collect(opts *sortOptions, counts *processedCount) {
+	for {
+		select {
+		case job := <-p.done:
+			p.finish(job, opts, counts)
+		default:
+			return
+		}
+	}
+}
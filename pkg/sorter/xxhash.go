@@ -0,0 +1,127 @@
+package sorter
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// the primes of the xxHash64 algorithm
+const (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+// xxh64 computes the xxHash64 with the seed 0, the hash most camera and offload tools write into
+// their MHL manifests since it is much faster than md5.
+type xxh64 struct {
+	v1, v2, v3, v4 uint64
+	total          uint64
+	mem            [32]byte
+	n              int
+}
+
+func newXXH64() *xxh64 {
+	x := &xxh64{}
+	x.Reset()
+	return x
+}
+
+func (x *xxh64) Reset() {
+	// the seed is added to the primes with wrap around, which constants do not allow
+	prime1 := xxPrime1
+	x.v1 = prime1 + xxPrime2
+	x.v2 = xxPrime2
+	x.v3 = 0
+	x.v4 = -prime1
+	x.total = 0
+	x.n = 0
+}
+
+func (x *xxh64) Size() int      { return 8 }
+func (x *xxh64) BlockSize() int { return 32 }
+
+func (x *xxh64) Write(p []byte) (int, error) {
+	written := len(p)
+	x.total += uint64(len(p))
+
+	// fill up the stripe left over from the previous write first
+	if x.n > 0 {
+		copied := copy(x.mem[x.n:], p)
+		x.n += copied
+		p = p[copied:]
+		if x.n < 32 {
+			return written, nil
+		}
+		x.stripe(x.mem[:])
+		x.n = 0
+	}
+	for len(p) >= 32 {
+		x.stripe(p[:32])
+		p = p[32:]
+	}
+	x.n = copy(x.mem[:], p)
+	return written, nil
+}
+
+func (x *xxh64) stripe(b []byte) {
+	x.v1 = xxRound(x.v1, binary.LittleEndian.Uint64(b[0:]))
+	x.v2 = xxRound(x.v2, binary.LittleEndian.Uint64(b[8:]))
+	x.v3 = xxRound(x.v3, binary.LittleEndian.Uint64(b[16:]))
+	x.v4 = xxRound(x.v4, binary.LittleEndian.Uint64(b[24:]))
+}
+
+func (x *xxh64) Sum(b []byte) []byte {
+	var sum [8]byte
+	binary.BigEndian.PutUint64(sum[:], x.Sum64())
+	return append(b, sum[:]...)
+}
+
+func (x *xxh64) Sum64() uint64 {
+	var h uint64
+	if x.total >= 32 {
+		h = bits.RotateLeft64(x.v1, 1) + bits.RotateLeft64(x.v2, 7) + bits.RotateLeft64(x.v3, 12) + bits.RotateLeft64(x.v4, 18)
+		h = xxMergeRound(h, x.v1)
+		h = xxMergeRound(h, x.v2)
+		h = xxMergeRound(h, x.v3)
+		h = xxMergeRound(h, x.v4)
+	} else {
+		h = xxPrime5
+	}
+	h += x.total
+
+	p := x.mem[:x.n]
+	for ; len(p) >= 8; p = p[8:] {
+		h ^= xxRound(0, binary.LittleEndian.Uint64(p))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+	}
+	if len(p) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(p)) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		p = p[4:]
+	}
+	for _, c := range p {
+		h ^= uint64(c) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	h ^= h >> 32
+	return h
+}
+
+func xxRound(acc, input uint64) uint64 {
+	acc += input * xxPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxPrime1
+}
+
+func xxMergeRound(acc, val uint64) uint64 {
+	acc ^= xxRound(0, val)
+	return acc*xxPrime1 + xxPrime4
+}